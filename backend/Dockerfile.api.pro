@@ -13,7 +13,8 @@ ARG TARGETOS TARGETARCH VERSION
 RUN --mount=type=cache,target=/root/.cache/go-build \
     --mount=type=cache,target=/go/pkg/mod \
     GOOS=$TARGETOS GOARCH=$TARGETARCH go build -ldflags "-s -w -extldflags '-static' -X github.com/chaitin/panda-wiki/telemetry.Version=${VERSION}" -o /build/panda-wiki-api pro/cmd/api_pro/main.go pro/cmd/api_pro/wire_gen.go \
-    && GOOS=$TARGETOS GOARCH=$TARGETARCH go build -ldflags "-s -w -extldflags '-static' -X github.com/chaitin/panda-wiki/telemetry.Version=${VERSION}" -o /build/panda-wiki-migrate cmd/migrate/main.go cmd/migrate/wire_gen.go
+    && GOOS=$TARGETOS GOARCH=$TARGETARCH go build -ldflags "-s -w -extldflags '-static' -X github.com/chaitin/panda-wiki/telemetry.Version=${VERSION}" -o /build/panda-wiki-migrate cmd/migrate/main.go cmd/migrate/wire_gen.go \
+    && GOOS=$TARGETOS GOARCH=$TARGETARCH go build -ldflags "-s -w -extldflags '-static' -X github.com/chaitin/panda-wiki/telemetry.Version=${VERSION}" -o /build/panda-wiki-export cmd/export/main.go cmd/export/wire_gen.go
 
 FROM alpine:3.21 AS api
 
@@ -27,6 +28,7 @@ WORKDIR /app
 
 COPY --from=builder /build/panda-wiki-api /app/panda-wiki-api
 COPY --from=builder /build/panda-wiki-migrate /app/panda-wiki-migrate
+COPY --from=builder /build/panda-wiki-export /app/panda-wiki-export
 COPY --from=builder /src/store/pg/migration /app/migration
 
 CMD ["sh", "-c", "/app/panda-wiki-migrate && /app/panda-wiki-api"]
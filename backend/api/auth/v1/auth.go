@@ -4,19 +4,22 @@ import (
 	"time"
 
 	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
 )
 
 type AuthGetReq struct {
 	KBID       string            `json:"kb_id,omitempty"  query:"kb_id"`
-	SourceType consts.SourceType `query:"source_type"  json:"source_type" validate:"required,oneof=github"`
+	SourceType consts.SourceType `query:"source_type"  json:"source_type" validate:"required,oneof=github saml ldap"`
 }
 
 type AuthGetResp struct {
-	ClientID     string            `json:"client_id"`
-	ClientSecret string            `json:"client_secret"`
-	Proxy        string            `json:"proxy"`
-	SourceType   consts.SourceType `json:"source_type"`
-	Auths        []AuthItem        `json:"auths"`
+	ClientID     string                  `json:"client_id"`
+	ClientSecret string                  `json:"client_secret"`
+	Proxy        string                  `json:"proxy"`
+	SAML         *domain.SAMLAuthSetting `json:"saml,omitempty"`
+	LDAP         *domain.LDAPAuthSetting `json:"ldap,omitempty"`
+	SourceType   consts.SourceType       `json:"source_type"`
+	Auths        []AuthItem              `json:"auths"`
 }
 
 type AuthItem struct {
@@ -30,15 +33,27 @@ type AuthItem struct {
 }
 
 type AuthSetReq struct {
-	KBID         string            `json:"kb_id,omitempty"`
-	SourceType   consts.SourceType `query:"source_type"  json:"source_type" validate:"required,oneof=github"`
-	ClientID     string            `json:"client_id"`
-	ClientSecret string            `json:"client_secret"`
-	Proxy        string            `json:"proxy"`
+	KBID         string                  `json:"kb_id,omitempty"`
+	SourceType   consts.SourceType       `query:"source_type"  json:"source_type" validate:"required,oneof=github saml ldap"`
+	ClientID     string                  `json:"client_id"`
+	ClientSecret string                  `json:"client_secret"`
+	Proxy        string                  `json:"proxy"`
+	SAML         *domain.SAMLAuthSetting `json:"saml,omitempty"`
+	LDAP         *domain.LDAPAuthSetting `json:"ldap,omitempty"`
 }
 
 type AuthSetResp struct{}
 
+type LDAPGroupSyncReq struct {
+	KBID string `json:"kb_id" validate:"required"`
+}
+
+type LDAPGroupSyncResp struct {
+	Groups  int `json:"groups"`  // 同步的组数量
+	Created int `json:"created"` // 新建的用户组数量
+	Deleted int `json:"deleted"` // 删除的用户组数量
+}
+
 type AuthDeleteReq struct {
 	ID   int64  `query:"id" json:"id"`
 	KbID string `query:"kb_id" json:"kb_id"`
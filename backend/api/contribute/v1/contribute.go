@@ -0,0 +1,76 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+type ContributeListReq struct {
+	KbId   string                  `query:"kb_id" json:"kb_id" validate:"required"`
+	Status consts.ContributeStatus `query:"status" json:"status" validate:"omitempty,oneof=pending approved rejected"`
+	domain.Pager
+}
+
+type ContributeListItem struct {
+	ID          string                  `json:"id"`
+	Type        consts.ContributeType   `json:"type"`
+	Status      consts.ContributeStatus `json:"status"`
+	NodeID      string                  `json:"node_id"`
+	NodeName    string                  `json:"node_name"` // 当前文档名称，新增类型审核通过前为空
+	Name        string                  `json:"name"`
+	AuthID      *int64                  `json:"auth_id"`
+	AuthName    string                  `json:"auth_name"`
+	RemoteIP    string                  `json:"remote_ip"`
+	Reason      string                  `json:"reason"`
+	AuditUserID string                  `json:"audit_user_id"`
+	AuditTime   *time.Time              `json:"audit_time"`
+	CreatedAt   time.Time               `json:"created_at"`
+}
+
+type ContributeListResp = domain.PaginatedResult[[]*ContributeListItem]
+
+type ContributeDetailReq struct {
+	KbId string `query:"kb_id" json:"kb_id" validate:"required"`
+	ID   string `query:"id" json:"id" validate:"required"`
+}
+
+type ContributeDetailResp struct {
+	domain.Contribute
+	// OriginalName and OriginalContent are the current node, empty for add contributions
+	OriginalName    string `json:"original_name"`
+	OriginalContent string `json:"original_content"`
+}
+
+type ContributeDiffReq struct {
+	KbId string `query:"kb_id" json:"kb_id" validate:"required"`
+	ID   string `query:"id" json:"id" validate:"required"`
+}
+
+type ContributeDiffResp struct {
+	OldName string             `json:"old_name"`
+	NewName string             `json:"new_name"`
+	Rows    []*domain.DiffRow  `json:"rows"`
+	Lines   []*domain.DiffLine `json:"lines"`
+	Stats   domain.DiffStats   `json:"stats"`
+	HTML    string             `json:"html"`
+}
+
+type ContributeApproveReq struct {
+	KbId string `json:"kb_id" validate:"required"`
+	ID   string `json:"id" validate:"required"`
+	// ParentID is the folder the new document is created in for add contributions, empty means root
+	ParentID string `json:"parent_id"`
+	Reason   string `json:"reason" validate:"max=500"`
+}
+
+type ContributeApproveResp struct {
+	NodeID string `json:"node_id"`
+}
+
+type ContributeRejectReq struct {
+	KbId   string `json:"kb_id" validate:"required"`
+	ID     string `json:"id" validate:"required"`
+	Reason string `json:"reason" validate:"max=500"`
+}
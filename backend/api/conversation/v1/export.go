@@ -0,0 +1,14 @@
+package v1
+
+import (
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+type ConversationExportReq struct {
+	KbID    string                          `json:"kb_id" query:"kb_id" validate:"required"`
+	Format  domain.ConversationExportFormat `json:"format" query:"format" validate:"omitempty,oneof=csv jsonl"` // 默认 csv
+	AppID   string                          `json:"app_id" query:"app_id"`
+	StartAt int64                           `json:"start_at" query:"start_at" validate:"omitempty,min=0"`  // unix 秒，包含
+	EndAt   int64                           `json:"end_at" query:"end_at" validate:"omitempty,min=0"`      // unix 秒，不包含
+	Score   *domain.ScoreType               `json:"score" query:"score" validate:"omitempty,oneof=-1 0 1"` // 1 赞，-1 踩，0 未反馈
+}
@@ -0,0 +1,53 @@
+package v1
+
+import (
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+type QuestionGapAnalyzeReq struct {
+	KbID string `json:"kb_id" validate:"required"`
+	Days int    `json:"days" validate:"omitempty,min=1,max=365"` // 分析最近多少天的对话，默认 30
+}
+
+type QuestionGapAnalyzeResp struct {
+	ID string `json:"id"`
+}
+
+type QuestionGapReportListReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+	domain.Pager
+}
+
+type QuestionGapReportListResp = domain.PaginatedResult[[]*domain.QuestionGapReport]
+
+// QuestionGapReportReq gets the latest succeeded report when id is empty
+type QuestionGapReportReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+	ID   string `json:"id" query:"id"`
+}
+
+type QuestionGapReportResp struct {
+	Report   *domain.QuestionGapReport    `json:"report"`
+	Clusters []*domain.QuestionGapCluster `json:"clusters"`
+}
+
+type QuestionGapClusterDetailReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+	ID   string `json:"id" query:"id" validate:"required"`
+}
+
+type QuestionGapClusterDetailResp struct {
+	domain.QuestionGapCluster
+	Items []*domain.QuestionGapItem `json:"items"`
+}
+
+type QuestionGapDraftReq struct {
+	KbID     string `json:"kb_id" validate:"required"`
+	ID       string `json:"id" validate:"required"`
+	ParentID string `json:"parent_id"`
+	Name     string `json:"name"` // 为空时使用聚类主题
+}
+
+type QuestionGapDraftResp struct {
+	NodeID string `json:"node_id"`
+}
@@ -0,0 +1,63 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+type SubscriptionCreateReq struct {
+	KbID          string               `json:"kb_id" validate:"required"`
+	Name          string               `json:"name"`
+	CrawlerSource consts.CrawlerSource `json:"crawler_source" validate:"required,oneof=url rss sitemap notion feishu"`
+	Key           string               `json:"key"`
+	FeishuSetting FeishuSetting        `json:"feishu_setting"`
+	ParentID      string               `json:"parent_id"`
+	Schedule      string               `json:"schedule" validate:"required"` // cron 表达式，如 "0 3 * * *"
+	AutoPublish   bool                 `json:"auto_publish"`
+}
+
+type SubscriptionCreateResp struct {
+	ID string `json:"id"`
+}
+
+type SubscriptionListReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+}
+
+type SubscriptionListItem struct {
+	ID             string                  `json:"id"`
+	Name           string                  `json:"name"`
+	CrawlerSource  consts.CrawlerSource    `json:"crawler_source"`
+	Key            string                  `json:"key"`
+	ParentID       string                  `json:"parent_id"`
+	Schedule       string                  `json:"schedule"`
+	AutoPublish    bool                    `json:"auto_publish"`
+	Enabled        bool                    `json:"enabled"`
+	NextSyncAt     *time.Time              `json:"next_sync_at"`
+	LastSyncAt     *time.Time              `json:"last_sync_at"`
+	LastSyncStatus domain.ImportSyncStatus `json:"last_sync_status"`
+	LastSyncError  string                  `json:"last_sync_error"`
+	LastSyncResult domain.ImportSyncResult `json:"last_sync_result"`
+	CreatedAt      time.Time               `json:"created_at"`
+}
+
+type SubscriptionUpdateReq struct {
+	KbID        string  `json:"kb_id" validate:"required"`
+	ID          string  `json:"id" validate:"required"`
+	Name        *string `json:"name"`
+	Schedule    *string `json:"schedule"`
+	AutoPublish *bool   `json:"auto_publish"`
+	Enabled     *bool   `json:"enabled"`
+}
+
+type SubscriptionDeleteReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+	ID   string `json:"id" query:"id" validate:"required"`
+}
+
+type SubscriptionSyncReq struct {
+	KbID string `json:"kb_id" validate:"required"`
+	ID   string `json:"id" validate:"required"`
+}
@@ -0,0 +1,111 @@
+package v1
+
+import (
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+type EvalCaseItem struct {
+	Question        string   `json:"question" validate:"required"`
+	ExpectedNodeIDs []string `json:"expected_node_ids"`
+	ReferenceAnswer string   `json:"reference_answer"`
+}
+
+type EvalSetCreateReq struct {
+	KbID        string          `json:"kb_id" validate:"required"`
+	Name        string          `json:"name" validate:"required"`
+	Description string          `json:"description"`
+	Cases       []*EvalCaseItem `json:"cases" validate:"required,min=1,dive"`
+}
+
+type EvalSetCreateResp struct {
+	ID string `json:"id"`
+}
+
+type EvalSetListReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+}
+
+type EvalSetListItem struct {
+	domain.EvalSet
+	CaseCount int64 `json:"case_count"`
+}
+
+type EvalSetDetailReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+	ID   string `json:"id" query:"id" validate:"required"`
+}
+
+type EvalSetDetailResp struct {
+	domain.EvalSet
+	Cases []*domain.EvalCase `json:"cases"`
+}
+
+// EvalSetCasesAddReq appends cases to an existing set
+type EvalSetCasesAddReq struct {
+	KbID  string          `json:"kb_id" validate:"required"`
+	ID    string          `json:"id" validate:"required"`
+	Cases []*EvalCaseItem `json:"cases" validate:"required,min=1,dive"`
+}
+
+type EvalCaseDeleteReq struct {
+	KbID   string `json:"kb_id" query:"kb_id" validate:"required"`
+	CaseID string `json:"case_id" query:"case_id" validate:"required"`
+}
+
+type EvalSetDeleteReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+	ID   string `json:"id" query:"id" validate:"required"`
+}
+
+type EvalRunCreateReq struct {
+	KbID     string `json:"kb_id" validate:"required"`
+	SetID    string `json:"set_id" validate:"required"`
+	Name     string `json:"name"`
+	Prompt   string `json:"prompt"`                                   // 为空时使用知识库当前的提示词
+	TopK     int    `json:"top_k" validate:"omitempty,min=1,max=100"` // 计算 recall@k 的 k，默认 10
+	GroupIDs []int  `json:"group_ids"`                                // 以这些用户组的权限检索
+	Judge    bool   `json:"judge"`                                    // 是否使用模型对比参考答案评分
+}
+
+type EvalRunCreateResp struct {
+	ID string `json:"id"`
+}
+
+type EvalRunListReq struct {
+	KbID  string `json:"kb_id" query:"kb_id" validate:"required"`
+	SetID string `json:"set_id" query:"set_id"`
+	domain.Pager
+}
+
+type EvalRunListResp = domain.PaginatedResult[[]*domain.EvalRun]
+
+type EvalRunDetailReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+	ID   string `json:"id" query:"id" validate:"required"`
+}
+
+type EvalRunDetailResp struct {
+	domain.EvalRun
+	Results []*domain.EvalResult `json:"results"`
+}
+
+type EvalRunCompareReq struct {
+	KbID      string `json:"kb_id" query:"kb_id" validate:"required"`
+	BaseRunID string `json:"base_run_id" query:"base_run_id" validate:"required"`
+	RunID     string `json:"run_id" query:"run_id" validate:"required"`
+}
+
+type EvalRunCompareItem struct {
+	CaseID   string             `json:"case_id"`
+	Question string             `json:"question"`
+	Base     *domain.EvalResult `json:"base"`
+	Current  *domain.EvalResult `json:"current"`
+}
+
+// EvalRunCompareResp lists both runs and their results per case,
+// a case only evaluated in one of the runs has a nil result on the other side
+type EvalRunCompareResp struct {
+	Base    *domain.EvalRun       `json:"base"`
+	Current *domain.EvalRun       `json:"current"`
+	Cases   []*EvalRunCompareItem `json:"cases"`
+}
@@ -1,7 +1,10 @@
 package v1
 
 import (
+	"time"
+
 	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
 )
 
 type KBUserListReq struct {
@@ -40,3 +43,71 @@ type KBUserDeleteReq struct {
 
 type KBUserDeleteResp struct {
 }
+
+type KBRetrievalSettingsReq struct {
+	KBId string `json:"kb_id" query:"kb_id" validate:"required"`
+}
+
+type KBRetrievalSettingsResp struct {
+	domain.RetrievalSettings
+}
+
+type KBRetrievalSettingsUpdateReq struct {
+	KBId string `json:"kb_id" validate:"required"`
+	domain.RetrievalSettings
+}
+
+type KBRetrievalSettingsUpdateResp struct {
+}
+
+type KBExportReq struct {
+	KBId string `json:"kb_id" validate:"required"`
+}
+
+type KBImportReq struct {
+	KBId string `json:"kb_id" form:"kb_id" validate:"required"`
+}
+
+type KBImportResp struct {
+	Nodes      int    `json:"nodes"`
+	AuthGroups int    `json:"auth_groups"`
+	Apps       int    `json:"apps"`
+	Assets     int    `json:"assets"`
+	ReleaseID  string `json:"release_id"`
+}
+
+type KBReleaseScheduleCreateReq struct {
+	KBId        string    `json:"kb_id" validate:"required"`
+	NodeIDs     []string  `json:"node_ids"` // 定时发布的文档
+	Tag         string    `json:"tag" validate:"required"`
+	Message     string    `json:"message" validate:"required"`
+	ScheduledAt time.Time `json:"scheduled_at" validate:"required"`
+}
+
+type KBReleaseScheduleCreateResp struct {
+	ID string `json:"id"`
+}
+
+type KBReleaseScheduleListReq struct {
+	KBId   string                         `json:"kb_id" query:"kb_id" validate:"required"`
+	Status domain.KBReleaseScheduleStatus `json:"status" query:"status"`
+}
+
+type KBReleaseScheduleListItem struct {
+	domain.KBReleaseSchedule
+	CreatorAccount string `json:"creator_account"`
+}
+
+type KBReleaseScheduleUpdateReq struct {
+	KBId        string     `json:"kb_id" validate:"required"`
+	ID          string     `json:"id" validate:"required"`
+	NodeIDs     []string   `json:"node_ids"`
+	Tag         *string    `json:"tag"`
+	Message     *string    `json:"message"`
+	ScheduledAt *time.Time `json:"scheduled_at"`
+}
+
+type KBReleaseScheduleCancelReq struct {
+	KBId string `json:"kb_id" validate:"required"`
+	ID   string `json:"id" validate:"required"`
+}
@@ -0,0 +1,39 @@
+package v1
+
+import (
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+type TokenBudgetSetReq struct {
+	KbID       string                   `json:"kb_id" validate:"required"`
+	AppType    domain.AppType           `json:"app_type"` // 0 表示知识库全部应用
+	Period     domain.TokenBudgetPeriod `json:"period" validate:"required,oneof=daily monthly"`
+	TokenLimit int64                    `json:"token_limit" validate:"required,min=1"`
+}
+
+type TokenBudgetListReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+}
+
+type TokenBudgetListItem struct {
+	domain.TokenBudget
+	UsedTokens int64 `json:"used_tokens"` // 当前周期已使用
+}
+
+type TokenBudgetDeleteReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+	ID   string `json:"id" query:"id" validate:"required"`
+}
+
+type TokenUsageReq struct {
+	KbID    string `json:"kb_id" query:"kb_id"`                                  // 为空时统计全部知识库
+	StartAt int64  `json:"start_at" query:"start_at" validate:"omitempty,min=0"` // unix 秒，默认 30 天前
+	EndAt   int64  `json:"end_at" query:"end_at" validate:"omitempty,min=0"`     // unix 秒，默认现在
+}
+
+type TokenUsageResp struct {
+	PromptTokens     int64                    `json:"prompt_tokens"`
+	CompletionTokens int64                    `json:"completion_tokens"`
+	TotalTokens      int64                    `json:"total_tokens"`
+	Stats            []*domain.TokenUsageStat `json:"stats"` // 按知识库、应用和天统计
+}
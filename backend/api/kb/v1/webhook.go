@@ -0,0 +1,94 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+type WebhookCreateReq struct {
+	KbID    string                    `json:"kb_id" validate:"required"`
+	Name    string                    `json:"name"`
+	URL     string                    `json:"url" validate:"required,url"`
+	Secret  string                    `json:"secret"` // 为空时自动生成
+	Events  []domain.WebhookEventType `json:"events" validate:"required,min=1"`
+	Enabled bool                      `json:"enabled"`
+}
+
+type WebhookCreateResp struct {
+	ID     string `json:"id"`
+	Secret string `json:"secret"` // 只在创建时返回
+}
+
+type WebhookListReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+}
+
+type WebhookListItem struct {
+	ID        string                    `json:"id"`
+	Name      string                    `json:"name"`
+	URL       string                    `json:"url"`
+	Events    []domain.WebhookEventType `json:"events"`
+	Enabled   bool                      `json:"enabled"`
+	CreatedAt time.Time                 `json:"created_at"`
+	UpdatedAt time.Time                 `json:"updated_at"`
+}
+
+type WebhookUpdateReq struct {
+	KbID    string                    `json:"kb_id" validate:"required"`
+	ID      string                    `json:"id" validate:"required"`
+	Name    *string                   `json:"name"`
+	URL     *string                   `json:"url" validate:"omitempty,url"`
+	Secret  *string                   `json:"secret"`
+	Events  []domain.WebhookEventType `json:"events"`
+	Enabled *bool                     `json:"enabled"`
+}
+
+type WebhookDeleteReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+	ID   string `json:"id" query:"id" validate:"required"`
+}
+
+type WebhookTestReq struct {
+	KbID string `json:"kb_id" validate:"required"`
+	ID   string `json:"id" validate:"required"`
+}
+
+type WebhookTestResp struct {
+	DeliveryID     string                       `json:"delivery_id"`
+	Status         domain.WebhookDeliveryStatus `json:"status"`
+	ResponseStatus int                          `json:"response_status"`
+	Error          string                       `json:"error"`
+}
+
+type WebhookDeliveryListReq struct {
+	KbID      string `json:"kb_id" query:"kb_id" validate:"required"`
+	WebhookID string `json:"webhook_id" query:"webhook_id" validate:"required"`
+	domain.Pager
+}
+
+// WebhookDeliveryListItem leaves out the payload and the response body, which are only returned by the detail
+type WebhookDeliveryListItem struct {
+	ID             string                       `json:"id"`
+	WebhookID      string                       `json:"webhook_id"`
+	Event          domain.WebhookEventType      `json:"event"`
+	Status         domain.WebhookDeliveryStatus `json:"status"`
+	Attempts       int                          `json:"attempts"`
+	ResponseStatus int                          `json:"response_status"`
+	Error          string                       `json:"error"`
+	NextRetryAt    *time.Time                   `json:"next_retry_at"`
+	CreatedAt      time.Time                    `json:"created_at"`
+	UpdatedAt      time.Time                    `json:"updated_at"`
+}
+
+type WebhookDeliveryListResp = domain.PaginatedResult[[]*WebhookDeliveryListItem]
+
+type WebhookDeliveryDetailReq struct {
+	KbID string `json:"kb_id" query:"kb_id" validate:"required"`
+	ID   string `json:"id" query:"id" validate:"required"`
+}
+
+type WebhookDeliveryRetryReq struct {
+	KbID string `json:"kb_id" validate:"required"`
+	ID   string `json:"id" validate:"required"`
+}
@@ -65,3 +65,57 @@ type NodeRestudyReq struct {
 
 type NodeRestudyResp struct {
 }
+
+type NodeHistoryListReq struct {
+	KbId string `query:"kb_id" json:"kb_id" validate:"required"`
+	ID   string `query:"id" json:"id" validate:"required"`
+	domain.Pager
+}
+
+type NodeHistoryListItem struct {
+	ID               string          `json:"id"`
+	NodeID           string          `json:"node_id"`
+	Name             string          `json:"name"`
+	Meta             domain.NodeMeta `json:"meta" gorm:"type:jsonb"`
+	PublisherId      string          `json:"publisher_id"`
+	PublisherAccount string          `json:"publisher_account"`
+	EditorId         string          `json:"editor_id"`
+	EditorAccount    string          `json:"editor_account"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+type NodeHistoryListResp = domain.PaginatedResult[[]*NodeHistoryListItem]
+
+type NodeHistoryDiffReq struct {
+	KbId string `query:"kb_id" json:"kb_id" validate:"required"`
+	ID   string `query:"id" json:"id" validate:"required"`
+	// FromReleaseID is the node release to compare from, empty means the release before ToReleaseID
+	FromReleaseID string `query:"from_release_id" json:"from_release_id"`
+	// ToReleaseID is the node release to compare to, empty means the current draft
+	ToReleaseID string `query:"to_release_id" json:"to_release_id"`
+}
+
+type NodeHistoryVersion struct {
+	ReleaseID string          `json:"release_id"` // empty for the current draft
+	Name      string          `json:"name"`
+	Meta      domain.NodeMeta `json:"meta"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type NodeHistoryDiffResp struct {
+	From    *NodeHistoryVersion `json:"from"` // nil if the node has no earlier release
+	To      *NodeHistoryVersion `json:"to"`
+	Lines   []*domain.DiffLine  `json:"lines"`
+	Stats   domain.DiffStats    `json:"stats"`
+	HTML    string              `json:"html"`
+	Unified string              `json:"unified"`
+}
+
+type NodeHistoryRestoreReq struct {
+	KbId      string `json:"kb_id" validate:"required"`
+	ID        string `json:"id" validate:"required"`
+	ReleaseID string `json:"release_id" validate:"required"`
+}
+
+type NodeHistoryRestoreResp struct {
+}
@@ -34,6 +34,15 @@ type GitHubCallbackReq struct {
 type GitHubCallbackResp struct {
 }
 
+type AuthSAMLReq struct {
+	KbID        string `json:"kb_id"`
+	RedirectUrl string `json:"redirect_url"`
+}
+
+type AuthSAMLResp struct {
+	Url string `json:"url"`
+}
+
 type AuthLoginUserPasswordReq struct {
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
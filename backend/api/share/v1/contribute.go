@@ -0,0 +1,16 @@
+package v1
+
+import "github.com/chaitin/panda-wiki/consts"
+
+type ContributeSubmitReq struct {
+	Type         consts.ContributeType `json:"type" validate:"required,oneof=add edit"`
+	NodeID       string                `json:"node_id"` // 修改的文档，type 为 edit 时必填
+	Name         string                `json:"name" validate:"required,max=255"`
+	Content      string                `json:"content" validate:"required"`
+	ContentType  string                `json:"content_type" validate:"omitempty,oneof=html md"`
+	CaptchaToken string                `json:"captcha_token" validate:"required"`
+}
+
+type ContributeSubmitResp struct {
+	ID string `json:"id"`
+}
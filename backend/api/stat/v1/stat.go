@@ -28,6 +28,8 @@ type StatCountResp struct {
 	SessionCount      int64 `json:"session_count"`
 	PageVisitCount    int64 `json:"page_visit_count"`
 	ConversationCount int64 `json:"conversation_count"`
+	// chat requests rejected by the rate limit
+	ChatRateLimitedCount int64 `json:"chat_rate_limited_count"`
 }
 
 type StatRefererHostsReq struct {
@@ -45,6 +45,20 @@ type LoginResp struct {
 	Token string `json:"token"`
 }
 
+type OIDCLoginConfigResp struct {
+	Enabled              bool `json:"enabled"`
+	DisablePasswordLogin bool `json:"disable_password_login"`
+}
+
+type OIDCAuthorizeResp struct {
+	Url string `json:"url"`
+}
+
+type OIDCCallbackReq struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
 type UserListResp struct {
 	Users []UserListItemResp `json:"users"`
 }
@@ -73,14 +73,17 @@ func createApp() (*App, error) {
 	ragRepository := mq2.NewRAGRepository(mqProducer)
 	userRepository := pg2.NewUserRepository(db, logger)
 	kbRepo := cache2.NewKBRepo(cacheCache)
-	knowledgeBaseUsecase, err := usecase.NewKnowledgeBaseUsecase(knowledgeBaseRepository, nodeRepository, ragRepository, userRepository, ragService, kbRepo, logger, configConfig)
+	webhookRepository := mq2.NewWebhookRepository(mqProducer)
+	knowledgeBaseUsecase, err := usecase.NewKnowledgeBaseUsecase(knowledgeBaseRepository, nodeRepository, ragRepository, userRepository, ragService, kbRepo, logger, configConfig, webhookRepository)
 	if err != nil {
 		return nil, err
 	}
-	shareAuthMiddleware := middleware.NewShareAuthMiddleware(logger, knowledgeBaseUsecase)
+	authRepo := pg2.NewAuthRepo(db, logger, cacheCache)
+	shareAuthMiddleware := middleware.NewShareAuthMiddleware(logger, knowledgeBaseUsecase, authRepo)
 	captchaCaptcha := captcha.NewCaptcha()
 	baseHandler := handler.NewBaseHandler(echo, logger, configConfig, authMiddleware, shareAuthMiddleware, captchaCaptcha)
-	userUsecase, err := usecase.NewUserUsecase(userRepository, logger, configConfig)
+	systemSettingRepo := pg2.NewSystemSettingRepo(db, logger)
+	userUsecase, err := usecase.NewUserUsecase(userRepository, knowledgeBaseRepository, systemSettingRepo, cacheCache, logger, configConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +92,6 @@ func createApp() (*App, error) {
 	if err != nil {
 		return nil, err
 	}
-	authRepo := pg2.NewAuthRepo(db, logger, cacheCache)
 	authUsecase, err := usecase.NewAuthUsecase(authRepo, logger, knowledgeBaseRepository, cacheCache)
 	if err != nil {
 		return nil, err
@@ -99,26 +101,32 @@ func createApp() (*App, error) {
 	modelRepository := pg2.NewModelRepository(db, logger)
 	promptRepo := pg2.NewPromptRepo(db, logger)
 	llmUsecase := usecase.NewLLMUsecase(configConfig, ragService, conversationRepository, knowledgeBaseRepository, nodeRepository, modelRepository, promptRepo, logger)
-	knowledgeBaseHandler := v1.NewKnowledgeBaseHandler(baseHandler, echo, knowledgeBaseUsecase, llmUsecase, authMiddleware, logger)
-	systemSettingRepo := pg2.NewSystemSettingRepo(db, logger)
 	modelUsecase := usecase.NewModelUsecase(modelRepository, nodeRepository, ragRepository, ragService, logger, configConfig, knowledgeBaseRepository, systemSettingRepo)
-	nodeUsecase := usecase.NewNodeUsecase(nodeRepository, appRepository, ragRepository, userRepository, knowledgeBaseRepository, llmUsecase, ragService, logger, minioClient, modelRepository, authRepo, modelUsecase)
-	nodeHandler := v1.NewNodeHandler(baseHandler, echo, nodeUsecase, authMiddleware, logger)
+	nodeUsecase := usecase.NewNodeUsecase(nodeRepository, appRepository, ragRepository, userRepository, knowledgeBaseRepository, llmUsecase, ragService, logger, minioClient, modelRepository, authRepo, modelUsecase, webhookRepository)
 	geoRepo := cache2.NewGeoCache(cacheCache, db, logger)
 	ipdbIPDB, err := ipdb.NewIPDB(configConfig, logger)
 	if err != nil {
 		return nil, err
 	}
 	ipAddressRepo := ipdb2.NewIPAddressRepo(ipdbIPDB, logger)
-	conversationUsecase := usecase.NewConversationUsecase(conversationRepository, nodeRepository, geoRepo, logger, ipAddressRepo, authRepo)
+	conversationUsecase := usecase.NewConversationUsecase(conversationRepository, nodeRepository, geoRepo, logger, ipAddressRepo, authRepo, webhookRepository)
 	blockWordRepo := pg2.NewBlockWordRepo(db, logger)
-	chatUsecase, err := usecase.NewChatUsecase(llmUsecase, knowledgeBaseRepository, conversationUsecase, modelUsecase, appRepository, blockWordRepo, authRepo, logger)
+	tokenBudgetRepository := pg2.NewTokenBudgetRepository(db, logger)
+	tokenBudgetUsecase := usecase.NewTokenBudgetUsecase(tokenBudgetRepository, logger)
+	chatUsecase, err := usecase.NewChatUsecase(llmUsecase, knowledgeBaseRepository, conversationUsecase, modelUsecase, tokenBudgetUsecase, appRepository, blockWordRepo, authRepo, logger)
 	if err != nil {
 		return nil, err
 	}
 	appUsecase := usecase.NewAppUsecase(appRepository, authRepo, nodeRepository, knowledgeBaseRepository, nodeUsecase, logger, configConfig, chatUsecase, cacheCache)
-	appHandler := v1.NewAppHandler(echo, baseHandler, logger, authMiddleware, appUsecase, modelUsecase, conversationUsecase, configConfig)
 	fileUsecase := usecase.NewFileUsecase(logger, minioClient, configConfig, systemSettingRepo)
+	kbArchiveUsecase := usecase.NewKBArchiveUsecase(nodeRepository, appRepository, authRepo, knowledgeBaseUsecase, appUsecase, fileUsecase, minioClient, logger)
+	sitemapUsecase := usecase.NewSitemapUsecase(nodeRepository, knowledgeBaseRepository, logger)
+	staticExportUsecase := usecase.NewStaticExportUsecase(nodeRepository, knowledgeBaseRepository, sitemapUsecase, minioClient, logger)
+	kbReleaseScheduleRepository := pg2.NewKBReleaseScheduleRepository(db, logger)
+	kbReleaseScheduleUsecase := usecase.NewKBReleaseScheduleUsecase(kbReleaseScheduleRepository, userRepository, knowledgeBaseUsecase, logger)
+	knowledgeBaseHandler := v1.NewKnowledgeBaseHandler(baseHandler, echo, knowledgeBaseUsecase, llmUsecase, kbArchiveUsecase, staticExportUsecase, kbReleaseScheduleUsecase, authMiddleware, logger)
+	nodeHandler := v1.NewNodeHandler(baseHandler, echo, nodeUsecase, authMiddleware, logger)
+	appHandler := v1.NewAppHandler(echo, baseHandler, logger, authMiddleware, appUsecase, modelUsecase, conversationUsecase, configConfig)
 	fileHandler := v1.NewFileHandler(echo, baseHandler, logger, authMiddleware, minioClient, configConfig, fileUsecase)
 	modelHandler := v1.NewModelHandler(echo, baseHandler, logger, authMiddleware, modelUsecase, llmUsecase)
 	conversationHandler := v1.NewConversationHandler(echo, baseHandler, logger, authMiddleware, conversationUsecase)
@@ -130,16 +138,32 @@ func createApp() (*App, error) {
 	if err != nil {
 		return nil, err
 	}
-	crawlerHandler := v1.NewCrawlerHandler(echo, baseHandler, authMiddleware, logger, configConfig, crawlerUsecase, fileUsecase)
+	importSubscriptionRepository := pg2.NewImportSubscriptionRepository(db, logger)
+	importSubscriptionUsecase := usecase.NewImportSubscriptionUsecase(importSubscriptionRepository, nodeRepository, crawlerUsecase, knowledgeBaseUsecase, logger)
+	crawlerHandler := v1.NewCrawlerHandler(echo, baseHandler, authMiddleware, logger, configConfig, crawlerUsecase, fileUsecase, importSubscriptionUsecase)
 	creationUsecase := usecase.NewCreationUsecase(logger, llmUsecase, modelUsecase)
 	creationHandler := v1.NewCreationHandler(echo, baseHandler, logger, creationUsecase)
 	statRepository := pg2.NewStatRepository(db, cacheCache)
 	statUseCase := usecase.NewStatUseCase(statRepository, nodeRepository, conversationRepository, appRepository, ipAddressRepo, geoRepo, authRepo, knowledgeBaseRepository, logger)
 	statHandler := v1.NewStatHandler(baseHandler, echo, statUseCase, logger, authMiddleware)
 	commentRepository := pg2.NewCommentRepository(db, logger)
-	commentUsecase := usecase.NewCommentUsecase(commentRepository, logger, nodeRepository, ipAddressRepo, authRepo)
+	commentUsecase := usecase.NewCommentUsecase(commentRepository, logger, nodeRepository, ipAddressRepo, authRepo, webhookRepository)
 	commentHandler := v1.NewCommentHandler(echo, baseHandler, logger, authMiddleware, commentUsecase)
 	authV1Handler := v1.NewAuthV1Handler(echo, baseHandler, logger, authUsecase)
+	webhookRepository2 := pg2.NewWebhookRepository(db, logger)
+	webhookUsecase := usecase.NewWebhookUsecase(webhookRepository2, webhookRepository, configConfig, logger)
+	webhookHandler := v1.NewWebhookHandler(echo, baseHandler, logger, authMiddleware, webhookUsecase)
+	contributeRepository := pg2.NewContributeRepository(db, logger)
+	contributeUsecase := usecase.NewContributeUsecase(contributeRepository, nodeRepository, authRepo, webhookRepository, nodeUsecase, logger)
+	contributeHandler := v1.NewContributeHandler(echo, baseHandler, logger, authMiddleware, contributeUsecase)
+	evalRepository := pg2.NewEvalRepository(db, logger)
+	evalUsecase := usecase.NewEvalUsecase(evalRepository, llmUsecase, modelUsecase, logger)
+	evalHandler := v1.NewEvalHandler(echo, baseHandler, logger, authMiddleware, evalUsecase)
+	questionGapRepository := pg2.NewQuestionGapRepository(db, logger)
+	questionGapUsecase := usecase.NewQuestionGapUsecase(questionGapRepository, knowledgeBaseRepository, nodeRepository, modelUsecase, nodeUsecase, logger)
+	questionGapHandler := v1.NewQuestionGapHandler(echo, baseHandler, logger, authMiddleware, questionGapUsecase)
+	tokenBudgetHandler := v1.NewTokenBudgetHandler(echo, baseHandler, logger, authMiddleware, tokenBudgetUsecase)
+	scimHandler := v1.NewSCIMHandler(echo, baseHandler, logger, authMiddleware, authUsecase)
 	apiHandlers := &v1.APIHandlers{
 		UserHandler:          userHandler,
 		KnowledgeBaseHandler: knowledgeBaseHandler,
@@ -153,11 +177,17 @@ func createApp() (*App, error) {
 		StatHandler:          statHandler,
 		CommentHandler:       commentHandler,
 		AuthV1Handler:        authV1Handler,
+		WebhookHandler:       webhookHandler,
+		ContributeHandler:    contributeHandler,
+		EvalHandler:          evalHandler,
+		QuestionGapHandler:   questionGapHandler,
+		TokenBudgetHandler:   tokenBudgetHandler,
+		SCIMHandler:          scimHandler,
 	}
 	shareNodeHandler := share.NewShareNodeHandler(baseHandler, echo, nodeUsecase, logger)
 	shareAppHandler := share.NewShareAppHandler(echo, baseHandler, logger, appUsecase)
-	shareChatHandler := share.NewShareChatHandler(echo, baseHandler, logger, appUsecase, chatUsecase, authUsecase, conversationUsecase, modelUsecase)
-	sitemapUsecase := usecase.NewSitemapUsecase(nodeRepository, knowledgeBaseRepository, logger)
+	chatRateLimitUsecase := usecase.NewChatRateLimitUsecase(knowledgeBaseUsecase, statRepository, cacheCache, logger)
+	shareChatHandler := share.NewShareChatHandler(echo, baseHandler, logger, appUsecase, chatUsecase, authUsecase, conversationUsecase, modelUsecase, chatRateLimitUsecase)
 	shareSitemapHandler := share.NewShareSitemapHandler(echo, baseHandler, sitemapUsecase, appUsecase, logger)
 	shareStatHandler := share.NewShareStatHandler(baseHandler, echo, statUseCase, logger)
 	shareCommentHandler := share.NewShareCommentHandler(echo, baseHandler, logger, commentUsecase, appUsecase)
@@ -171,6 +201,12 @@ func createApp() (*App, error) {
 	shareCaptchaHandler := share.NewShareCaptchaHandler(baseHandler, echo, logger)
 	openapiV1Handler := share.NewOpenapiV1Handler(echo, baseHandler, logger, authUsecase, appUsecase)
 	shareCommonHandler := share.NewShareCommonHandler(echo, baseHandler, logger, fileUsecase)
+	shareContributeHandler := share.NewShareContributeHandler(echo, baseHandler, logger, contributeUsecase, appUsecase)
+	openAIAPIUsecase := usecase.NewOpenAIAPIUsecase(knowledgeBaseRepository, modelUsecase, logger)
+	shareOpenAIHandler := share.NewShareOpenAIHandler(echo, baseHandler, logger, appUsecase, openAIAPIUsecase, chatRateLimitUsecase, tokenBudgetUsecase)
+	mcpRepository := pg2.NewMCPRepository(db, logger)
+	mcpUsecase := usecase.NewMCPUsecase(llmUsecase, nodeUsecase, knowledgeBaseRepository, authRepo, mcpRepository, logger)
+	shareMCPHandler := share.NewShareMCPHandler(echo, baseHandler, logger, appUsecase, mcpUsecase)
 	shareHandler := &share.ShareHandler{
 		ShareNodeHandler:         shareNodeHandler,
 		ShareAppHandler:          shareAppHandler,
@@ -184,8 +220,10 @@ func createApp() (*App, error) {
 		ShareCaptchaHandler:      shareCaptchaHandler,
 		OpenapiV1Handler:         openapiV1Handler,
 		ShareCommonHandler:       shareCommonHandler,
+		ShareContributeHandler:   shareContributeHandler,
+		ShareOpenAIHandler:       shareOpenAIHandler,
+		ShareMCPHandler:          shareMCPHandler,
 	}
-	mcpRepository := pg2.NewMCPRepository(db, logger)
 	client, err := telemetry.NewClient(logger, knowledgeBaseRepository, modelUsecase, userUsecase, nodeRepository, conversationRepository, mcpRepository, configConfig)
 	if err != nil {
 		return nil, err
@@ -83,8 +83,36 @@ func createApp() (*App, error) {
 	if err != nil {
 		return nil, err
 	}
-	nodeUsecase := usecase.NewNodeUsecase(nodeRepository, appRepository, ragRepository, userRepository, knowledgeBaseRepository, llmUsecase, ragService, logger, minioClient, modelRepository, authRepo, modelUsecase)
-	cronHandler, err := mq3.NewStatCronHandler(logger, statRepository, statUseCase, nodeUsecase)
+	webhookRepository := mq2.NewWebhookRepository(mqProducer)
+	nodeUsecase := usecase.NewNodeUsecase(nodeRepository, appRepository, ragRepository, userRepository, knowledgeBaseRepository, llmUsecase, ragService, logger, minioClient, modelRepository, authRepo, modelUsecase, webhookRepository)
+	importSubscriptionRepository := pg2.NewImportSubscriptionRepository(db, logger)
+	crawlerUsecase, err := usecase.NewCrawlerUsecase(logger, mqConsumer, cacheCache)
+	if err != nil {
+		return nil, err
+	}
+	kbRepo := cache2.NewKBRepo(cacheCache)
+	knowledgeBaseUsecase, err := usecase.NewKnowledgeBaseUsecase(knowledgeBaseRepository, nodeRepository, ragRepository, userRepository, ragService, kbRepo, logger, configConfig, webhookRepository)
+	if err != nil {
+		return nil, err
+	}
+	importSubscriptionUsecase := usecase.NewImportSubscriptionUsecase(importSubscriptionRepository, nodeRepository, crawlerUsecase, knowledgeBaseUsecase, logger)
+	webhookRepository2 := pg2.NewWebhookRepository(db, logger)
+	webhookUsecase := usecase.NewWebhookUsecase(webhookRepository2, webhookRepository, configConfig, logger)
+	kbReleaseScheduleRepository := pg2.NewKBReleaseScheduleRepository(db, logger)
+	kbReleaseScheduleUsecase := usecase.NewKBReleaseScheduleUsecase(kbReleaseScheduleRepository, userRepository, knowledgeBaseUsecase, logger)
+	questionGapRepository := pg2.NewQuestionGapRepository(db, logger)
+	questionGapUsecase := usecase.NewQuestionGapUsecase(questionGapRepository, knowledgeBaseRepository, nodeRepository, modelUsecase, nodeUsecase, logger)
+	authUsecase, err := usecase.NewAuthUsecase(authRepo, logger, knowledgeBaseRepository, cacheCache)
+	if err != nil {
+		return nil, err
+	}
+	evalRepository := pg2.NewEvalRepository(db, logger)
+	evalUsecase := usecase.NewEvalUsecase(evalRepository, llmUsecase, modelUsecase, logger)
+	cronHandler, err := mq3.NewStatCronHandler(logger, statRepository, statUseCase, nodeUsecase, importSubscriptionUsecase, webhookUsecase, kbReleaseScheduleUsecase, questionGapUsecase, authUsecase, evalUsecase)
+	if err != nil {
+		return nil, err
+	}
+	webhookMQHandler, err := mq3.NewWebhookMQHandler(mqConsumer, logger, webhookUsecase)
 	if err != nil {
 		return nil, err
 	}
@@ -92,6 +120,7 @@ func createApp() (*App, error) {
 		RAGMQHandler:        ragmqHandler,
 		RagDocUpdateHandler: ragDocUpdateHandler,
 		StatCronHandler:     cronHandler,
+		WebhookMQHandler:    webhookMQHandler,
 	}
 	app := &App{
 		MQConsumer:      mqConsumer,
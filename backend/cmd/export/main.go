@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// export renders the latest release of a knowledge base as a static site zip,
+// e.g. `export -kb <kb_id> -o site.zip`
+func main() {
+	kbID := flag.String("kb", "", "knowledge base id")
+	output := flag.String("o", "", "output zip file, default <kb_id>-static.zip")
+	flag.Parse()
+	if *kbID == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *output == "" {
+		*output = fmt.Sprintf("%s-static.zip", *kbID)
+	}
+
+	app, err := createApp()
+	if err != nil {
+		panic(err)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		panic(err)
+	}
+	if err := app.StaticExportUsecase.Export(context.Background(), *kbID, f); err != nil {
+		f.Close()
+		os.Remove(*output)
+		panic(err)
+	}
+	if err := f.Close(); err != nil {
+		panic(err)
+	}
+	fmt.Printf("static site of %s exported to %s\n", *kbID, *output)
+}
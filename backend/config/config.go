@@ -4,23 +4,25 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Log           LogConfig    `mapstructure:"log"`
-	HTTP          HTTPConfig   `mapstructure:"http"`
-	AdminPassword string       `mapstructure:"admin_password"`
-	PG            PGConfig     `mapstructure:"pg"`
-	MQ            MQConfig     `mapstructure:"mq"`
-	RAG           RAGConfig    `mapstructure:"rag"`
-	Redis         RedisConfig  `mapstructure:"redis"`
-	Auth          AuthConfig   `mapstructure:"auth"`
-	S3            S3Config     `mapstructure:"s3"`
-	Sentry        SentryConfig `mapstructure:"sentry"`
-	CaddyAPI      string       `mapstructure:"caddy_api"`
-	SubnetPrefix  string       `mapstructure:"subnet_prefix"`
+	Log           LogConfig     `mapstructure:"log"`
+	HTTP          HTTPConfig    `mapstructure:"http"`
+	AdminPassword string        `mapstructure:"admin_password"`
+	PG            PGConfig      `mapstructure:"pg"`
+	MQ            MQConfig      `mapstructure:"mq"`
+	RAG           RAGConfig     `mapstructure:"rag"`
+	Redis         RedisConfig   `mapstructure:"redis"`
+	Auth          AuthConfig    `mapstructure:"auth"`
+	S3            S3Config      `mapstructure:"s3"`
+	Sentry        SentryConfig  `mapstructure:"sentry"`
+	Webhook       WebhookConfig `mapstructure:"webhook"`
+	CaddyAPI      string        `mapstructure:"caddy_api"`
+	SubnetPrefix  string        `mapstructure:"subnet_prefix"`
 }
 
 type LogConfig struct {
@@ -29,6 +31,8 @@ type LogConfig struct {
 
 type HTTPConfig struct {
 	Port int `mapstructure:"port"`
+	// TrustedProxies 可信反向代理的 CIDR 或 IP，配置后只使用这些代理设置的 X-Forwarded-For
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 type PGConfig struct {
@@ -47,8 +51,9 @@ type NATSConfig struct {
 }
 
 type RAGConfig struct {
-	Provider string      `mapstructure:"provider"`
-	CTRAG    CTRAGConfig `mapstructure:"ct_rag"`
+	Provider string         `mapstructure:"provider"`
+	CTRAG    CTRAGConfig    `mapstructure:"ct_rag"`
+	PGVector PGVectorConfig `mapstructure:"pgvector"`
 }
 
 type CTRAGConfig struct {
@@ -56,6 +61,12 @@ type CTRAGConfig struct {
 	APIKey  string `mapstructure:"api_key"`
 }
 
+type PGVectorConfig struct {
+	DSN       string `mapstructure:"dsn"`        // empty means using pg.dsn
+	ChunkSize int    `mapstructure:"chunk_size"` // max characters per chunk
+	TopK      int    `mapstructure:"top_k"`
+}
+
 type RedisConfig struct {
 	Addr     string `mapstructure:"addr"`
 	Password string `mapstructure:"password"`
@@ -81,6 +92,11 @@ type SentryConfig struct {
 	DSN     string `mapstructure:"dsn"`
 }
 
+type WebhookConfig struct {
+	// AllowedNetworks lists CIDRs, IPs or hostnames that webhooks may deliver to even if they are internal addresses
+	AllowedNetworks []string `mapstructure:"allowed_networks"`
+}
+
 func NewConfig() (*Config, error) {
 	// set default config
 	SUBNET_PREFIX := os.Getenv("SUBNET_PREFIX")
@@ -112,6 +128,10 @@ func NewConfig() (*Config, error) {
 				BaseURL: fmt.Sprintf("http://%s.18:5050", SUBNET_PREFIX),
 				APIKey:  "sk-1234567890",
 			},
+			PGVector: PGVectorConfig{
+				ChunkSize: 1000,
+				TopK:      10,
+			},
 		},
 		Redis: RedisConfig{
 			Addr:     "panda-wiki-redis:6379",
@@ -181,6 +201,10 @@ func overrideWithEnv(c *Config) {
 	if env := os.Getenv("SUBNET_PREFIX"); env != "" {
 		c.SubnetPrefix = env
 	}
+	// http
+	if env := os.Getenv("HTTP_TRUSTED_PROXIES"); env != "" {
+		c.HTTP.TrustedProxies = strings.Split(env, ",")
+	}
 	// pg
 	if env := os.Getenv("PG_DSN"); env != "" {
 		c.PG.DSN = env
@@ -193,6 +217,12 @@ func overrideWithEnv(c *Config) {
 	if env := os.Getenv("RAG_CT_RAG_BASE_URL"); env != "" {
 		c.RAG.CTRAG.BaseURL = env
 	}
+	if env := os.Getenv("RAG_PROVIDER"); env != "" {
+		c.RAG.Provider = env
+	}
+	if env := os.Getenv("RAG_PGVECTOR_DSN"); env != "" {
+		c.RAG.PGVector.DSN = env
+	}
 	// redis
 	if env := os.Getenv("REDIS_ADDR"); env != "" {
 		c.Redis.Addr = env
@@ -201,6 +231,10 @@ func overrideWithEnv(c *Config) {
 	if env := os.Getenv("S3_ENDPOINT"); env != "" {
 		c.S3.Endpoint = env
 	}
+	// webhook
+	if env := os.Getenv("WEBHOOK_ALLOWED_NETWORKS"); env != "" {
+		c.Webhook.AllowedNetworks = strings.Split(env, ",")
+	}
 	// sentry
 	if env := os.Getenv("SENTRY_ENABLED"); env != "" {
 		c.Sentry.Enabled = env == "true"
@@ -3,7 +3,7 @@ package consts
 type SourceType string
 
 var (
-	BotSourceTypes = []SourceType{SourceTypeWidget, SourceTypeDingtalkBot, SourceTypeFeishuBot, SourceTypeLarkBot, SourceTypeWechatBot, SourceTypeWechatServiceBot, SourceTypeDiscordBot, SourceTypeWechatOfficialAccount}
+	BotSourceTypes = []SourceType{SourceTypeWidget, SourceTypeDingtalkBot, SourceTypeFeishuBot, SourceTypeLarkBot, SourceTypeWechatBot, SourceTypeWechatServiceBot, SourceTypeDiscordBot, SourceTypeWechatOfficialAccount, SourceTypeSlackBot, SourceTypeTeamsBot, SourceTypeTelegramBot}
 )
 
 const (
@@ -14,6 +14,8 @@ const (
 	SourceTypeGitHub                SourceType = "github"
 	SourceTypeCAS                   SourceType = "cas"
 	SourceTypeLDAP                  SourceType = "ldap"
+	SourceTypeSAML                  SourceType = "saml"
+	SourceTypeSCIM                  SourceType = "scim"
 	SourceTypeUserPassword          SourceType = "user_password"
 	SourceTypeWidget                SourceType = "widget"
 	SourceTypeDingtalkBot           SourceType = "dingtalk_bot"
@@ -26,6 +28,9 @@ const (
 	SourceTypeWechatOfficialAccount SourceType = "wechat_official_account"
 	SourceTypeOpenAIAPI             SourceType = "openai_api"
 	SourceTypeMcpServer             SourceType = "mcp_server"
+	SourceTypeSlackBot              SourceType = "slack_bot"
+	SourceTypeTeamsBot              SourceType = "teams_bot"
+	SourceTypeTelegramBot           SourceType = "telegram_bot"
 )
 
 func (s SourceType) Name() string {
@@ -52,6 +57,12 @@ func (s SourceType) Name() string {
 		return "微信公众号"
 	case SourceTypeMcpServer:
 		return "MCP 服务器"
+	case SourceTypeSlackBot:
+		return "Slack 机器人"
+	case SourceTypeTeamsBot:
+		return "Teams 机器人"
+	case SourceTypeTelegramBot:
+		return "Telegram 机器人"
 	default:
 		return ""
 	}
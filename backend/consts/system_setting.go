@@ -5,4 +5,5 @@ type SystemSettingKey string
 const (
 	SystemSettingModelMode SystemSettingKey = "model_setting_mode"
 	SystemSettingUpload    SystemSettingKey = "upload"
+	SystemSettingAdminOIDC SystemSettingKey = "admin_oidc"
 )
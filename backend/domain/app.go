@@ -25,6 +25,9 @@ const (
 	AppTypeWecomAIBot
 	AppTypeLarkBot
 	AppTypeMcpServer
+	AppTypeSlackBot
+	AppTypeTeamsBot
+	AppTypeTelegramBot
 )
 
 var AppTypes = []AppType{
@@ -40,6 +43,9 @@ var AppTypes = []AppType{
 	AppTypeWecomAIBot,
 	AppTypeLarkBot,
 	AppTypeMcpServer,
+	AppTypeSlackBot,
+	AppTypeTeamsBot,
+	AppTypeTelegramBot,
 }
 
 func (t AppType) ToSourceType() consts.SourceType {
@@ -66,6 +72,12 @@ func (t AppType) ToSourceType() consts.SourceType {
 		return consts.SourceTypeOpenAIAPI
 	case AppTypeLarkBot:
 		return consts.SourceTypeLarkBot
+	case AppTypeSlackBot:
+		return consts.SourceTypeSlackBot
+	case AppTypeTeamsBot:
+		return consts.SourceTypeTeamsBot
+	case AppTypeTelegramBot:
+		return consts.SourceTypeTelegramBot
 	default:
 		return ""
 	}
@@ -110,6 +122,12 @@ type AppSettings struct {
 	FeishuBotAppSecret string `json:"feishu_bot_app_secret,omitempty"`
 	// LarkBot
 	LarkBotSettings LarkBotSettings `json:"lark_bot_settings,omitempty"`
+	// SlackBot
+	SlackBotSettings SlackBotSettings `json:"slack_bot_settings,omitempty"`
+	// TeamsBot
+	TeamsBotSettings TeamsBotSettings `json:"teams_bot_settings,omitempty"`
+	// TelegramBot
+	TelegramBotSettings TelegramBotSettings `json:"telegram_bot_settings,omitempty"`
 	// WechatAppBot 企业微信机器人
 	WeChatAppIsEnabled       *bool                    `json:"wechat_app_is_enabled,omitempty"`
 	WeChatAppToken           string                   `json:"wechat_app_token,omitempty"`
@@ -169,6 +187,8 @@ type AppSettings struct {
 	ContributeSettings  ContributeSettings      `json:"contribute_settings"`
 	HomePageSetting     consts.HomePageSetting  `json:"home_page_setting"`
 	ConversationSetting ConversationSetting     `json:"conversation_setting"`
+	// chat model used first by the app, empty to use the chat models by priority
+	ChatModelID string `json:"chat_model_id,omitempty"`
 	// MCP Server Settings
 	MCPServerSettings MCPServerSettings `json:"mcp_server_settings,omitempty"`
 	StatsSetting      StatsSetting      `json:"stats_setting"`
@@ -214,6 +234,35 @@ type LarkBotSettings struct {
 	EncryptKey  string `json:"encrypt_key"`
 }
 
+type SlackBotSettings struct {
+	IsEnabled *bool  `json:"is_enabled"`
+	BotToken  string `json:"bot_token"`
+	// AppToken enables Socket Mode, events are received by the Events API callback if it is empty
+	AppToken      string `json:"app_token"`
+	SigningSecret string `json:"signing_secret"`
+}
+
+// TeamsBotSettings is the Azure Bot registration of the Microsoft Teams or other Bot Framework channels bot
+type TeamsBotSettings struct {
+	IsEnabled   *bool  `json:"is_enabled"`
+	AppID       string `json:"app_id"`
+	AppPassword string `json:"app_password"`
+	// TenantID is required by single tenant bots
+	TenantID string `json:"tenant_id"`
+}
+
+const (
+	TelegramBotModePolling = "polling"
+	TelegramBotModeWebhook = "webhook"
+)
+
+type TelegramBotSettings struct {
+	IsEnabled *bool  `json:"is_enabled"`
+	BotToken  string `json:"bot_token"`
+	// Mode is polling or webhook, the webhook requires the base url of the knowledge base accessible by Telegram
+	Mode string `json:"mode"`
+}
+
 type BannerConfig struct {
 	Title            string   `json:"title"`
 	TitleColor       string   `json:"title_color"`
@@ -511,6 +560,12 @@ type AppSettingsResp struct {
 	FeishuBotAppSecret string `json:"feishu_bot_app_secret,omitempty"`
 	// LarkBot
 	LarkBotSettings LarkBotSettings `json:"lark_bot_settings,omitempty"`
+	// SlackBot
+	SlackBotSettings SlackBotSettings `json:"slack_bot_settings,omitempty"`
+	// TeamsBot
+	TeamsBotSettings TeamsBotSettings `json:"teams_bot_settings,omitempty"`
+	// TelegramBot
+	TelegramBotSettings TelegramBotSettings `json:"telegram_bot_settings,omitempty"`
 	// WechatAppBot
 	WeChatAppIsEnabled       *bool                    `json:"wechat_app_is_enabled,omitempty"`
 	WeChatAppToken           string                   `json:"wechat_app_token,omitempty"`
@@ -572,6 +627,7 @@ type AppSettingsResp struct {
 	WebAppLandingTheme   WebAppLandingTheme        `json:"web_app_landing_theme"`
 	HomePageSetting      consts.HomePageSetting    `json:"home_page_setting"`
 	ConversationSetting  ConversationSetting       `json:"conversation_setting"`
+	ChatModelID          string                    `json:"chat_model_id,omitempty"`
 	// MCP Server Settings
 	MCPServerSettings MCPServerSettings `json:"mcp_server_settings,omitempty"`
 	StatsSetting      StatsSetting      `json:"stats_setting"`
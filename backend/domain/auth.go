@@ -28,6 +28,8 @@ type Auth struct {
 	CreatedAt     time.Time         `gorm:"column:created_at;not null;default:now()" json:"created_at"`       // Timestamp when the record was created
 	UpdatedAt     time.Time         `gorm:"column:updated_at;not null;default:now()" json:"updated_at"`       // Timestamp when the record was last updated
 	UserInfo      AuthUserInfo      `json:"user_info" gorm:"type:jsonb"`
+	Disabled      bool              `gorm:"column:disabled;not null;default:false" json:"disabled"`    // 被禁用的用户不能登录，由 SCIM 同步
+	ExternalID    string            `gorm:"column:external_id;not null;default:''" json:"external_id"` // SCIM 客户端中的用户 ID
 }
 
 func (Auth) TableName() string {
@@ -86,6 +88,39 @@ type AuthSetting struct {
 	ClientID     string `json:"client_id,omitempty"`
 	ClientSecret string `json:"client_secret,omitempty"`
 	Proxy        string `json:"proxy,omitempty"`
+	// SAML
+	SAML *SAMLAuthSetting `json:"saml,omitempty"`
+	// LDAP
+	LDAP *LDAPAuthSetting `json:"ldap,omitempty"`
+}
+
+// SAMLAuthSetting SAML 2.0 单点登录配置，SP元数据地址和断言消费地址由知识库的访问地址生成
+type SAMLAuthSetting struct {
+	IDPMetadataURL string `json:"idp_metadata_url,omitempty"` // IdP元数据地址
+	IDPMetadataXML string `json:"idp_metadata_xml,omitempty"` // IdP元数据，设置后不再请求元数据地址
+	EntityID       string `json:"entity_id,omitempty"`        // SP实体ID，默认为SP元数据地址
+	Certificate    string `json:"certificate,omitempty"`      // SP证书(PEM)，可选
+	PrivateKey     string `json:"private_key,omitempty"`      // SP私钥(PEM)，可选
+	NameIDFormat   string `json:"name_id_format,omitempty"`   // NameID格式，NameID作为用户的唯一标识，需要是持久的
+	// 断言属性映射，为空时按常见的属性名查找
+	UsernameAttribute  string `json:"username_attribute,omitempty"`
+	EmailAttribute     string `json:"email_attribute,omitempty"`
+	AvatarUrlAttribute string `json:"avatar_url_attribute,omitempty"`
+}
+
+// LDAPAuthSetting LDAP 组同步配置，将组同步为用户组，组成员按用户ID属性对应知识库企业认证用户的唯一标识
+type LDAPAuthSetting struct {
+	ServerURL       string   `json:"server_url,omitempty"`        // LDAP服务器URL，如 ldap://openldap.company.com:389
+	BindDN          string   `json:"bind_dn,omitempty"`           // 绑定DN
+	BindPassword    string   `json:"bind_password,omitempty"`     // 绑定密码
+	UserBaseDN      string   `json:"user_base_dn,omitempty"`      // 用户基础DN
+	UserIDAttr      string   `json:"user_id_attr,omitempty"`      // 用户ID属性，默认 uid
+	UserNameAttr    string   `json:"user_name_attr,omitempty"`    // 用户名属性，默认 cn
+	UserEmailAttr   string   `json:"user_email_attr,omitempty"`   // 用户邮箱属性，默认 mail
+	GroupDNs        []string `json:"group_dns,omitempty"`         // 需要同步的组DN，包含嵌套的子组
+	GroupNameAttr   string   `json:"group_name_attr,omitempty"`   // 组名属性，默认 cn
+	GroupMemberAttr string   `json:"group_member_attr,omitempty"` // 组成员属性，默认 member
+	SyncEnabled     bool     `json:"sync_enabled"`                // 是否定时同步
 }
 
 type AuthInfo struct {
@@ -41,6 +41,9 @@ type ConversationMessage struct {
 	CompletionTokens int           `json:"completion_tokens" gorm:"default:0"`
 	TotalTokens      int           `json:"total_tokens" gorm:"default:0"`
 
+	// rag, nil for messages not answered with retrieval
+	RetrievedNodeCount *int `json:"retrieved_node_count,omitempty"`
+
 	// stats
 	RemoteIP  string    `json:"remote_ip"`
 	CreatedAt time.Time `json:"created_at"`
@@ -158,3 +161,46 @@ type ShareConversationMessage struct {
 	ImagePaths pq.StringArray  `json:"image_paths"`
 	CreatedAt  time.Time       `json:"created_at"`
 }
+
+type ConversationExportFormat string
+
+const (
+	ConversationExportFormatCSV   ConversationExportFormat = "csv"
+	ConversationExportFormatJSONL ConversationExportFormat = "jsonl"
+)
+
+// ConversationExportRow is an answer with its question, one line of the exported file
+type ConversationExportRow struct {
+	ID             string  `json:"id"` // the assistant message
+	ConversationID string  `json:"conversation_id"`
+	Subject        string  `json:"subject"`
+	AppID          string  `json:"app_id"`
+	AppName        string  `json:"app_name"`
+	AppType        AppType `json:"app_type"`
+
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+
+	// model
+	Provider         ModelProvider `json:"provider"`
+	Model            string        `json:"model"`
+	PromptTokens     int           `json:"prompt_tokens"`
+	CompletionTokens int           `json:"completion_tokens"`
+	TotalTokens      int           `json:"total_tokens"`
+
+	// feedbackInfo
+	Info FeedBackInfo `json:"feedback_info" gorm:"column:info;type:jsonb"`
+
+	References []*ConversationExportReference `json:"references" gorm:"-"`
+
+	// stats
+	RemoteIP  string     `json:"remote_ip"`
+	IPAddress *IPAddress `json:"ip_address" gorm:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type ConversationExportReference struct {
+	NodeID string `json:"node_id"`
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+}
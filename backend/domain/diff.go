@@ -0,0 +1,35 @@
+package domain
+
+type DiffOp string
+
+const (
+	DiffOpEqual  DiffOp = "equal"
+	DiffOpInsert DiffOp = "insert"
+	DiffOpDelete DiffOp = "delete"
+)
+
+// DiffSegment is a run of words sharing the same diff op inside a changed line
+type DiffSegment struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+type DiffLine struct {
+	Op      DiffOp `json:"op"`
+	OldLine int    `json:"old_line,omitempty"` // 1-based line number in old text, 0 for inserted lines
+	NewLine int    `json:"new_line,omitempty"` // 1-based line number in new text, 0 for deleted lines
+	Text    string `json:"text"`
+	// Segments holds word level changes when a deleted line is paired with an inserted line
+	Segments []DiffSegment `json:"segments,omitempty"`
+}
+
+type DiffStats struct {
+	Added   int `json:"added"`
+	Deleted int `json:"deleted"`
+}
+
+// DiffRow is one row of a side-by-side diff, Old or New is nil when the line only exists on one side
+type DiffRow struct {
+	Old *DiffLine `json:"old"`
+	New *DiffLine `json:"new"`
+}
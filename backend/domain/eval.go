@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type EvalRunStatus string
+
+const (
+	EvalRunStatusPending   EvalRunStatus = "pending"
+	EvalRunStatusRunning   EvalRunStatus = "running"
+	EvalRunStatusSucceeded EvalRunStatus = "succeeded"
+	EvalRunStatusFailed    EvalRunStatus = "failed"
+)
+
+// EvalSet is a set of questions used to measure the answer quality of a knowledge base
+// table: eval_sets
+type EvalSet struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	KBID        string    `json:"kb_id" gorm:"index"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (EvalSet) TableName() string {
+	return "eval_sets"
+}
+
+// EvalCase is a question with the nodes expected to be retrieved and an optional reference answer
+// table: eval_cases
+type EvalCase struct {
+	ID              string         `json:"id" gorm:"primaryKey"`
+	SetID           string         `json:"set_id" gorm:"index"`
+	KBID            string         `json:"kb_id"`
+	Question        string         `json:"question"`
+	ExpectedNodeIDs pq.StringArray `json:"expected_node_ids" gorm:"type:text[]"`
+	ReferenceAnswer string         `json:"reference_answer"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+func (EvalCase) TableName() string {
+	return "eval_cases"
+}
+
+// EvalRun runs every case of a set through retrieval and chat once,
+// metrics are averaged over the cases they apply to and are nil when none applies
+// table: eval_runs
+type EvalRun struct {
+	ID               string        `json:"id" gorm:"primaryKey"`
+	KBID             string        `json:"kb_id"`
+	SetID            string        `json:"set_id"`
+	Name             string        `json:"name"`
+	Prompt           string        `json:"prompt"` // system prompt override, empty means the prompt of the kb
+	TopK             int           `json:"top_k"`
+	Provider         ModelProvider `json:"provider"`
+	Model            string        `json:"model"`
+	Status           EvalRunStatus `json:"status"`
+	Total            int           `json:"total"`
+	Finished         int           `json:"finished"`
+	RecallAtK        *float64      `json:"recall_at_k"`
+	CitationAccuracy *float64      `json:"citation_accuracy"`
+	AnswerScore      *float64      `json:"answer_score"` // judged by llm, 0-10
+	PromptTokens     int64         `json:"prompt_tokens"`
+	CompletionTokens int64         `json:"completion_tokens"`
+	Error            string        `json:"error"`
+	CreatorID        string        `json:"creator_id"`
+	FinishedAt       *time.Time    `json:"finished_at"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
+}
+
+func (EvalRun) TableName() string {
+	return "eval_runs"
+}
+
+// EvalResult is the outcome of one case in a run
+// table: eval_results
+type EvalResult struct {
+	ID               string         `json:"id" gorm:"primaryKey"`
+	RunID            string         `json:"run_id" gorm:"index"`
+	CaseID           string         `json:"case_id"`
+	Question         string         `json:"question"`
+	Answer           string         `json:"answer"`
+	RetrievedNodeIDs pq.StringArray `json:"retrieved_node_ids" gorm:"type:text[]"`
+	CitedNodeIDs     pq.StringArray `json:"cited_node_ids" gorm:"type:text[]"`
+	RecallAtK        *float64       `json:"recall_at_k"`
+	CitationAccuracy *float64       `json:"citation_accuracy"`
+	AnswerScore      *float64       `json:"answer_score"`
+	JudgeReason      string         `json:"judge_reason"`
+	PromptTokens     int64          `json:"prompt_tokens"`
+	CompletionTokens int64          `json:"completion_tokens"`
+	Error            string         `json:"error"`
+	CreatedAt        time.Time      `json:"created_at"`
+}
+
+func (EvalResult) TableName() string {
+	return "eval_results"
+}
+
+var EvalJudgePrompt = `
+你是一个严格的问答质量评审员。请对比参考答案，评估 AI 助手对用户问题的回答质量。
+
+评分标准（0-10 分）：
+- 10 分：与参考答案的关键信息完全一致，且没有错误或无关内容
+- 6-9 分：覆盖了大部分关键信息，存在少量遗漏或冗余
+- 1-5 分：只覆盖了少量关键信息，或包含明显错误
+- 0 分：答非所问、拒绝回答或与参考答案矛盾
+
+只输出如下 JSON，不要输出其他内容：
+{"score": <0-10 的整数>, "reason": "<简要的评分理由>"}
+`
+
+var EvalJudgeUserFormatter = `
+<question>
+{{.Question}}
+</question>
+
+<reference_answer>
+{{.ReferenceAnswer}}
+</reference_answer>
+
+<answer>
+{{.Answer}}
+</answer>
+`
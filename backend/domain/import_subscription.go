@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chaitin/panda-wiki/consts"
+)
+
+type ImportSyncStatus string
+
+const (
+	ImportSyncStatusPending   ImportSyncStatus = "pending"
+	ImportSyncStatusRunning   ImportSyncStatus = "running"
+	ImportSyncStatusSucceeded ImportSyncStatus = "succeeded"
+	ImportSyncStatusFailed    ImportSyncStatus = "failed"
+)
+
+// ImportSyncTimeout a sync still running after the timeout was interrupted, e.g. the instance running it exited
+const ImportSyncTimeout = 2 * time.Hour
+
+// ImportSubscriptionSources are the crawler sources that can be fetched again without user upload
+var ImportSubscriptionSources = []consts.CrawlerSource{
+	consts.CrawlerSourceUrl,
+	consts.CrawlerSourceRSS,
+	consts.CrawlerSourceSitemap,
+	consts.CrawlerSourceNotion,
+	consts.CrawlerSourceFeishu,
+}
+
+// ImportSubscription remembers an imported source and re-syncs it into nodes on schedule
+// table: import_subscriptions
+type ImportSubscription struct {
+	ID            string                    `json:"id" gorm:"primaryKey"`
+	KBID          string                    `json:"kb_id" gorm:"index"`
+	Name          string                    `json:"name"`
+	CrawlerSource consts.CrawlerSource      `json:"crawler_source"`
+	SourceKey     string                    `json:"source_key"` // url or integration key of the source
+	Setting       ImportSubscriptionSetting `json:"setting" gorm:"type:jsonb"`
+	ParentID      string                    `json:"parent_id"`    // imported nodes are created under this folder
+	Schedule      string                    `json:"schedule"`     // standard cron spec, e.g. "0 3 * * *"
+	AutoPublish   bool                      `json:"auto_publish"` // publish changed nodes after sync, otherwise keep them as drafts
+	Enabled       bool                      `json:"enabled"`
+	CreatorID     string                    `json:"creator_id"`
+
+	NextSyncAt     *time.Time       `json:"next_sync_at"`
+	LastSyncAt     *time.Time       `json:"last_sync_at"`
+	LastSyncStatus ImportSyncStatus `json:"last_sync_status"`
+	LastSyncError  string           `json:"last_sync_error"`
+	LastSyncResult ImportSyncResult `json:"last_sync_result" gorm:"type:jsonb"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (ImportSubscription) TableName() string {
+	return "import_subscriptions"
+}
+
+type ImportSubscriptionSetting struct {
+	FeishuAppID           string `json:"feishu_app_id,omitempty"`
+	FeishuAppSecret       string `json:"feishu_app_secret,omitempty"`
+	FeishuUserAccessToken string `json:"feishu_user_access_token,omitempty"`
+	FeishuSpaceID         string `json:"feishu_space_id,omitempty"`
+}
+
+func (s *ImportSubscriptionSetting) Scan(value any) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("invalid ImportSubscriptionSetting type:", value))
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+func (s ImportSubscriptionSetting) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// ImportSyncResult reports the documents touched by one sync
+type ImportSyncResult struct {
+	Added   []ImportSyncDoc `json:"added"`
+	Changed []ImportSyncDoc `json:"changed"`
+	Removed []ImportSyncDoc `json:"removed"`
+	Failed  []ImportSyncDoc `json:"failed"`
+	// Conflicted documents changed in the source whose nodes have been edited locally since the last sync,
+	// they are not updated to keep the local edits
+	Conflicted []ImportSyncDoc `json:"conflicted"`
+}
+
+type ImportSyncDoc struct {
+	RemoteID string `json:"remote_id"`
+	Title    string `json:"title"`
+	NodeID   string `json:"node_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (r *ImportSyncResult) Scan(value any) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("invalid ImportSyncResult type:", value))
+	}
+	return json.Unmarshal(bytes, r)
+}
+
+func (r ImportSyncResult) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// ImportSubscriptionDoc maps a remote document to the node it was imported into
+// table: import_subscription_docs
+type ImportSubscriptionDoc struct {
+	SubscriptionID string    `json:"subscription_id" gorm:"primaryKey"`
+	RemoteID       string    `json:"remote_id" gorm:"primaryKey"`
+	NodeID         string    `json:"node_id"`
+	Title          string    `json:"title"`
+	ContentHash    string    `json:"content_hash"`
+	Removed        bool      `json:"removed"` // no longer exists in the source
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (ImportSubscriptionDoc) TableName() string {
+	return "import_subscription_docs"
+}
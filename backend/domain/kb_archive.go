@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/chaitin/panda-wiki/consts"
+)
+
+// KBArchiveVersion is bumped when the archive layout changes incompatibly
+const KBArchiveVersion = 1
+
+const (
+	KBArchiveManifestFile = "manifest.json"
+	KBArchiveNodeDir      = "nodes/"
+	KBArchiveAssetDir     = "assets/"
+)
+
+// KBArchiveManifest is stored as manifest.json in the root of a knowledge base archive
+type KBArchiveManifest struct {
+	Version    int                  `json:"version"`
+	KBName     string               `json:"kb_name"`
+	ExportedAt time.Time            `json:"exported_at"`
+	Apps       []KBArchiveApp       `json:"apps"`
+	AuthGroups []KBArchiveAuthGroup `json:"auth_groups"`
+}
+
+type KBArchiveApp struct {
+	Name     string      `json:"name"`
+	Type     AppType     `json:"type"`
+	Settings AppSettings `json:"settings"`
+}
+
+// KBArchiveAuthGroup keeps the group tree, members are bound to the source instance and not exported
+type KBArchiveAuthGroup struct {
+	ID         uint              `json:"id"`
+	Name       string            `json:"name"`
+	ParentID   *uint             `json:"parent_id"`
+	Position   float64           `json:"position"`
+	SourceType consts.SourceType `json:"source_type"`
+}
+
+// KBArchiveNode is the front-matter of a node file
+type KBArchiveNode struct {
+	ID          string                         `yaml:"id"`
+	ParentID    string                         `yaml:"parent_id,omitempty"`
+	Type        NodeType                       `yaml:"type"`
+	Name        string                         `yaml:"name"`
+	Position    float64                        `yaml:"position"`
+	Published   bool                           `yaml:"published"`
+	Summary     string                         `yaml:"summary,omitempty"`
+	Emoji       string                         `yaml:"emoji,omitempty"`
+	ContentType string                         `yaml:"content_type,omitempty"`
+	Permissions NodePermissions                `yaml:"permissions"`
+	AuthGroups  map[consts.NodePermName][]uint `yaml:"auth_groups,omitempty"`
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type KBReleaseScheduleStatus string
+
+const (
+	KBReleaseScheduleStatusPending   KBReleaseScheduleStatus = "pending"
+	KBReleaseScheduleStatusRunning   KBReleaseScheduleStatus = "running"
+	KBReleaseScheduleStatusSucceeded KBReleaseScheduleStatus = "succeeded"
+	KBReleaseScheduleStatusFailed    KBReleaseScheduleStatus = "failed"
+	KBReleaseScheduleStatusCanceled  KBReleaseScheduleStatus = "canceled"
+)
+
+// KBReleaseScheduleRunTimeout a schedule still running after the timeout was interrupted,
+// e.g. the instance running it exited, and is marked failed so that it can be rescheduled
+const KBReleaseScheduleRunTimeout = 30 * time.Minute
+
+var kbReleaseScheduleStatuses = []KBReleaseScheduleStatus{
+	KBReleaseScheduleStatusPending,
+	KBReleaseScheduleStatusRunning,
+	KBReleaseScheduleStatusSucceeded,
+	KBReleaseScheduleStatusFailed,
+	KBReleaseScheduleStatusCanceled,
+}
+
+// kbReleaseScheduleTransitions the statuses each status can change to,
+// a pending schedule changes to pending again when it is rescheduled
+var kbReleaseScheduleTransitions = map[KBReleaseScheduleStatus][]KBReleaseScheduleStatus{
+	KBReleaseScheduleStatusPending: {KBReleaseScheduleStatusPending, KBReleaseScheduleStatusRunning, KBReleaseScheduleStatusCanceled},
+	KBReleaseScheduleStatusRunning: {KBReleaseScheduleStatusSucceeded, KBReleaseScheduleStatusFailed},
+	KBReleaseScheduleStatusFailed:  {KBReleaseScheduleStatusPending, KBReleaseScheduleStatusCanceled},
+}
+
+func (s KBReleaseScheduleStatus) CanTransitTo(next KBReleaseScheduleStatus) bool {
+	return slices.Contains(kbReleaseScheduleTransitions[s], next)
+}
+
+// KBReleaseScheduleStatusesTo returns the statuses that can change to next
+func KBReleaseScheduleStatusesTo(next KBReleaseScheduleStatus) []KBReleaseScheduleStatus {
+	statuses := make([]KBReleaseScheduleStatus, 0)
+	for _, status := range kbReleaseScheduleStatuses {
+		if status.CanTransitTo(next) {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// KBReleaseSchedule publishes the selected nodes and creates a kb release at the scheduled time
+// table: kb_release_schedules
+type KBReleaseSchedule struct {
+	ID          string                  `json:"id" gorm:"primaryKey"`
+	KBID        string                  `json:"kb_id" gorm:"index"`
+	NodeIDs     pq.StringArray          `json:"node_ids" gorm:"type:text[]"`
+	Tag         string                  `json:"tag"`
+	Message     string                  `json:"message"`
+	ScheduledAt time.Time               `json:"scheduled_at"`
+	CreatorID   string                  `json:"creator_id"`
+	Status      KBReleaseScheduleStatus `json:"status"`
+	ReleaseID   string                  `json:"release_id"` // the kb release created when succeeded
+	Error       string                  `json:"error"`
+	ExecutedAt  *time.Time              `json:"executed_at"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+func (KBReleaseSchedule) TableName() string {
+	return "kb_release_schedules"
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKBReleaseScheduleStatus_CanTransitTo(t *testing.T) {
+	tests := []struct {
+		from     KBReleaseScheduleStatus
+		to       KBReleaseScheduleStatus
+		expected bool
+	}{
+		{KBReleaseScheduleStatusPending, KBReleaseScheduleStatusRunning, true},
+		{KBReleaseScheduleStatusPending, KBReleaseScheduleStatusPending, true},
+		{KBReleaseScheduleStatusPending, KBReleaseScheduleStatusCanceled, true},
+		{KBReleaseScheduleStatusPending, KBReleaseScheduleStatusSucceeded, false},
+		{KBReleaseScheduleStatusRunning, KBReleaseScheduleStatusSucceeded, true},
+		{KBReleaseScheduleStatusRunning, KBReleaseScheduleStatusFailed, true},
+		{KBReleaseScheduleStatusRunning, KBReleaseScheduleStatusPending, false},
+		{KBReleaseScheduleStatusRunning, KBReleaseScheduleStatusCanceled, false},
+		{KBReleaseScheduleStatusFailed, KBReleaseScheduleStatusPending, true},
+		{KBReleaseScheduleStatusFailed, KBReleaseScheduleStatusCanceled, true},
+		{KBReleaseScheduleStatusFailed, KBReleaseScheduleStatusRunning, false},
+		{KBReleaseScheduleStatusSucceeded, KBReleaseScheduleStatusPending, false},
+		{KBReleaseScheduleStatusCanceled, KBReleaseScheduleStatusPending, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.from.CanTransitTo(tt.to))
+		})
+	}
+}
+
+func TestKBReleaseScheduleStatusesTo(t *testing.T) {
+	assert.Equal(t, []KBReleaseScheduleStatus{KBReleaseScheduleStatusPending, KBReleaseScheduleStatusFailed},
+		KBReleaseScheduleStatusesTo(KBReleaseScheduleStatusPending))
+	assert.Equal(t, []KBReleaseScheduleStatus{KBReleaseScheduleStatusPending, KBReleaseScheduleStatusFailed},
+		KBReleaseScheduleStatusesTo(KBReleaseScheduleStatusCanceled))
+	assert.Equal(t, []KBReleaseScheduleStatus{KBReleaseScheduleStatusRunning},
+		KBReleaseScheduleStatusesTo(KBReleaseScheduleStatusFailed))
+	assert.Equal(t, []KBReleaseScheduleStatus{KBReleaseScheduleStatusPending},
+		KBReleaseScheduleStatusesTo(KBReleaseScheduleStatusRunning))
+}
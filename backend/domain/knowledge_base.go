@@ -37,6 +37,16 @@ type AccessSettings struct {
 	EnterpriseAuth EnterpriseAuth    `json:"enterprise_auth"`
 	SourceType     consts.SourceType `json:"source_type"`  // 企业认证来源
 	IsForbidden    bool              `json:"is_forbidden"` // 禁止访问
+	ChatRateLimit  ChatRateLimit     `json:"chat_rate_limit"`
+}
+
+// ChatRateLimit limits the chat requests of a visitor to the share endpoints in a sliding window
+type ChatRateLimit struct {
+	Enabled           bool `json:"enabled"`
+	WindowSeconds     int  `json:"window_seconds"`     // 默认 60 秒
+	IPLimit           int  `json:"ip_limit"`           // 每个 IP 的请求数，0 表示不限制
+	UserLimit         int  `json:"user_limit"`         // 每个登录用户的请求数，0 表示不限制
+	ConversationLimit int  `json:"conversation_limit"` // 每个对话的请求数，0 表示不限制
 }
 
 type SimpleAuth struct {
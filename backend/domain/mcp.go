@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// MCPCall records a request handled by the MCP server of a knowledge base,
+// either the initialize request of a session or a tool call
+type MCPCall struct {
+	ID             int             `json:"id" gorm:"primaryKey"`
+	MCPSessionID   string          `json:"mcp_session_id" gorm:"column:mcp_session_id"`
+	KBID           string          `json:"kb_id" gorm:"column:kb_id"`
+	RemoteIP       string          `json:"remote_ip"`
+	InitializeReq  json.RawMessage `json:"initialize_req,omitempty" gorm:"type:jsonb"`
+	InitializeResp json.RawMessage `json:"initialize_resp,omitempty" gorm:"type:jsonb"`
+	ToolCallReq    json.RawMessage `json:"tool_call_req,omitempty" gorm:"type:jsonb"`
+	ToolCallResp   string          `json:"tool_call_resp"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+func (MCPCall) TableName() string {
+	return "mcp_calls"
+}
+
+// MCPDocTreeItem is a node of the document tree listed by the MCP server
+type MCPDocTreeItem struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name"`
+	Type     NodeType          `json:"type"`
+	URL      string            `json:"url,omitempty"`
+	Children []*MCPDocTreeItem `json:"children,omitempty"`
+}
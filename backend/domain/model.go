@@ -34,9 +34,11 @@ type Model struct {
 	APIHeader  string        `json:"api_header"`
 	BaseURL    string        `json:"base_url"`
 	APIVersion string        `json:"api_version"` // for azure openai
-	Type       ModelType     `json:"type" gorm:"default:chat;uniqueIndex"`
+	Type       ModelType     `json:"type" gorm:"default:chat"`
 
 	IsActive bool `json:"is_active" gorm:"default:false"`
+	// Priority orders the chat models to fall back on, smaller first. Only chat models can be more than one of a type
+	Priority int `json:"priority" gorm:"default:0"`
 
 	PromptTokens     uint64 `json:"prompt_tokens" gorm:"default:0"`
 	CompletionTokens uint64 `json:"completion_tokens" gorm:"default:0"`
@@ -76,6 +78,7 @@ type ModelListItem struct {
 	Type       ModelType     `json:"type"`
 
 	IsActive bool `json:"is_active" gorm:"default:false"`
+	Priority int  `json:"priority"`
 
 	PromptTokens     uint64     `json:"prompt_tokens"`
 	CompletionTokens uint64     `json:"completion_tokens"`
@@ -86,6 +89,7 @@ type ModelListItem struct {
 type CreateModelReq struct {
 	BaseModelInfo
 	Parameters *ModelParam `json:"parameters"`
+	Priority   int         `json:"priority"` // 对话模型的回退顺序，越小越优先
 }
 
 type UpdateModelReq struct {
@@ -93,6 +97,7 @@ type UpdateModelReq struct {
 	BaseModelInfo
 	Parameters *ModelParam `json:"parameters"`
 	IsActive   *bool       `json:"is_active"`
+	Priority   *int        `json:"priority"`
 }
 
 type CheckModelReq struct {
@@ -4,12 +4,16 @@ const (
 	VectorTaskTopic       = "apps.panda-wiki.vector.task"
 	AnydocTaskExportTopic = "anydoc.persistence.doc.task.export"
 	RagDocUpdateTopic     = "raglite.events.doc.update"
+	WebhookEventTopic     = "apps.panda-wiki.webhook.event"
+	WebhookDeliveryTopic  = "apps.panda-wiki.webhook.delivery"
 )
 
 var TopicConsumerName = map[string]string{
 	VectorTaskTopic:       "panda-wiki-vector-consumer",
 	AnydocTaskExportTopic: "anydoc-task-export-consumer",
 	RagDocUpdateTopic:     "raglite-doc-update-consumer",
+	WebhookEventTopic:     "panda-wiki-webhook-event-consumer",
+	WebhookDeliveryTopic:  "panda-wiki-webhook-delivery-consumer",
 }
 
 type NodeReleaseVectorRequest struct {
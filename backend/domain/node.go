@@ -341,3 +341,17 @@ type NodeReleaseWithPublisher struct {
 	PublisherId      string `json:"publisher_id"`
 	PublisherAccount string `json:"publisher_account"`
 }
+
+// table: node_restore_logs
+type NodeRestoreLog struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	KBID          string    `json:"kb_id"`
+	NodeID        string    `json:"node_id"`
+	NodeReleaseID string    `json:"node_release_id"`
+	UserID        string    `json:"user_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (NodeRestoreLog) TableName() string {
+	return "node_restore_logs"
+}
@@ -24,6 +24,12 @@ type OpenAICompletionsRequest struct {
 	ResponseFormat   *OpenAIResponseFormat `json:"response_format,omitempty"`
 }
 
+// HasToolsOrResponseFormat tells whether the request needs tools or a structured response, which is
+// answered by calling the tools rather than with the documents retrieved in advance
+func (r *OpenAICompletionsRequest) HasToolsOrResponseFormat() bool {
+	return len(r.Tools) > 0 || (r.ResponseFormat != nil && r.ResponseFormat.Type != OpenAIResponseFormatText)
+}
+
 type OpenAIStreamOptions struct {
 	IncludeUsage bool `json:"include_usage,omitempty"`
 }
@@ -130,6 +136,7 @@ type OpenAIFunction struct {
 }
 
 type OpenAIToolCall struct {
+	Index    *int               `json:"index,omitempty"` // for streaming
 	ID       string             `json:"id" validate:"required"`
 	Type     string             `json:"type" validate:"required"`
 	Function OpenAIFunctionCall `json:"function" validate:"required"`
@@ -140,17 +147,57 @@ type OpenAIFunctionCall struct {
 	Arguments string `json:"arguments" validate:"required"`
 }
 
+const (
+	OpenAIToolChoiceNone     = "none"
+	OpenAIToolChoiceAuto     = "auto"
+	OpenAIToolChoiceRequired = "required"
+	OpenAIToolChoiceFunction = "function"
+)
+
+// OpenAIToolChoice 支持字符串 none/auto/required 或指定函数的对象格式，字符串格式时 Type 为该字符串
 type OpenAIToolChoice struct {
 	Type     string                `json:"type,omitempty"`
 	Function *OpenAIFunctionChoice `json:"function,omitempty"`
 }
 
+// UnmarshalJSON 自定义解析，支持 string 或 object 格式
+func (tc *OpenAIToolChoice) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		tc.Type = str
+		tc.Function = nil
+		return nil
+	}
+
+	type toolChoice OpenAIToolChoice
+	var obj toolChoice
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("tool_choice must be string or object")
+	}
+	*tc = OpenAIToolChoice(obj)
+	return nil
+}
+
 type OpenAIFunctionChoice struct {
 	Name string `json:"name" validate:"required"`
 }
 
+const (
+	OpenAIResponseFormatText       = "text"
+	OpenAIResponseFormatJSONObject = "json_object"
+	OpenAIResponseFormatJSONSchema = "json_schema"
+)
+
 type OpenAIResponseFormat struct {
-	Type string `json:"type" validate:"required"`
+	Type       string                    `json:"type" validate:"required,oneof=text json_object json_schema"`
+	JSONSchema *OpenAIResponseJSONSchema `json:"json_schema,omitempty" validate:"required_if=Type json_schema"`
+}
+
+type OpenAIResponseJSONSchema struct {
+	Name        string         `json:"name" validate:"required"`
+	Description string         `json:"description,omitempty"`
+	Schema      map[string]any `json:"schema,omitempty"`
+	Strict      bool           `json:"strict,omitempty"`
 }
 
 // OpenAI API 响应结构体
@@ -192,6 +239,47 @@ type OpenAIStreamChoice struct {
 	FinishReason *string       `json:"finish_reason,omitempty"`
 }
 
+// OpenAI 模型列表结构体
+type OpenAIModel struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+type OpenAIModelList struct {
+	Object string        `json:"object"`
+	Data   []OpenAIModel `json:"data"`
+}
+
+// OpenAI Embeddings 请求结构体
+type OpenAIEmbeddingsRequest struct {
+	Model          string `json:"model" validate:"required"`
+	Input          any    `json:"input" validate:"required"` // string, []string, []int 或 [][]int
+	EncodingFormat string `json:"encoding_format,omitempty" validate:"omitempty,oneof=float base64"`
+	Dimensions     *int   `json:"dimensions,omitempty"`
+	User           string `json:"user,omitempty"`
+}
+
+// OpenAI Embeddings 响应结构体
+type OpenAIEmbeddingsResponse struct {
+	Object string                `json:"object"`
+	Data   []OpenAIEmbedding     `json:"data"`
+	Model  string                `json:"model"`
+	Usage  *OpenAIEmbeddingUsage `json:"usage,omitempty"`
+}
+
+type OpenAIEmbedding struct {
+	Object    string          `json:"object"`
+	Embedding json.RawMessage `json:"embedding" swaggertype:"array,number"` // float 数组，encoding_format 为 base64 时为字符串
+	Index     int             `json:"index"`
+}
+
+type OpenAIEmbeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
 // OpenAI 错误响应结构体
 type OpenAIErrorResponse struct {
 	Error OpenAIError `json:"error"`
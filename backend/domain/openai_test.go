@@ -184,3 +184,39 @@ func TestMessageContent_String_NoTextParts(t *testing.T) {
 	})
 	assert.Equal(t, "", mc.String())
 }
+
+func TestOpenAIToolChoice_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected OpenAIToolChoice
+		wantErr  bool
+	}{
+		{"none", `"none"`, OpenAIToolChoice{Type: OpenAIToolChoiceNone}, false},
+		{"auto", `"auto"`, OpenAIToolChoice{Type: OpenAIToolChoiceAuto}, false},
+		{"required", `"required"`, OpenAIToolChoice{Type: OpenAIToolChoiceRequired}, false},
+		{"function object", `{"type":"function","function":{"name":"get_weather"}}`,
+			OpenAIToolChoice{Type: OpenAIToolChoiceFunction, Function: &OpenAIFunctionChoice{Name: "get_weather"}}, false},
+		{"object without function", `{"type":"auto"}`, OpenAIToolChoice{Type: OpenAIToolChoiceAuto}, false},
+		{"number", `1`, OpenAIToolChoice{}, true},
+		{"array", `["auto"]`, OpenAIToolChoice{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tc OpenAIToolChoice
+			err := json.Unmarshal([]byte(tt.json), &tc)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tc)
+		})
+	}
+}
+
+func TestOpenAIToolChoice_UnmarshalJSON_ResetsFunction(t *testing.T) {
+	tc := OpenAIToolChoice{Type: OpenAIToolChoiceFunction, Function: &OpenAIFunctionChoice{Name: "get_weather"}}
+	require.NoError(t, json.Unmarshal([]byte(`"auto"`), &tc))
+	assert.Equal(t, OpenAIToolChoice{Type: OpenAIToolChoiceAuto}, tc)
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// UnansweredReplyMarker is replied by the model when the documents can not answer the question, see SystemDefaultPrompt
+const UnansweredReplyMarker = "抱歉，我当前的知识不足以回答这个问题"
+
+type QuestionGapReason string
+
+const (
+	QuestionGapReasonNoRetrieval QuestionGapReason = "no_retrieval" // no document retrieved
+	QuestionGapReasonUnanswered  QuestionGapReason = "unanswered"   // the model replied it can not answer
+	QuestionGapReasonDisliked    QuestionGapReason = "disliked"     // the visitor disliked the answer
+)
+
+type QuestionGapReportStatus string
+
+const (
+	QuestionGapReportStatusRunning   QuestionGapReportStatus = "running"
+	QuestionGapReportStatusSucceeded QuestionGapReportStatus = "succeeded"
+	QuestionGapReportStatusFailed    QuestionGapReportStatus = "failed"
+)
+
+// QuestionGapReport clusters the questions the knowledge base failed to answer since a time
+// table: question_gap_reports
+type QuestionGapReport struct {
+	ID            string                  `json:"id" gorm:"primaryKey"`
+	KBID          string                  `json:"kb_id"`
+	Since         time.Time               `json:"since"`
+	Status        QuestionGapReportStatus `json:"status"`
+	QuestionCount int                     `json:"question_count"`
+	ClusterCount  int                     `json:"cluster_count"`
+	Error         string                  `json:"error"`
+	CreatorID     string                  `json:"creator_id"` // empty for the scheduled analysis
+	FinishedAt    *time.Time              `json:"finished_at"`
+	CreatedAt     time.Time               `json:"created_at"`
+}
+
+func (QuestionGapReport) TableName() string {
+	return "question_gap_reports"
+}
+
+// QuestionGapCluster is a topic of similar questions, ranked by question count in the report
+// table: question_gap_clusters
+type QuestionGapCluster struct {
+	ID            string      `json:"id" gorm:"primaryKey"`
+	ReportID      string      `json:"report_id" gorm:"index"`
+	KBID          string      `json:"kb_id"`
+	Rank          int         `json:"rank"`
+	Topic         string      `json:"topic"` // the most representative question
+	QuestionCount int         `json:"question_count"`
+	ReasonCounts  MapStrInt64 `json:"reason_counts" gorm:"type:jsonb"`   // QuestionGapReason -> count
+	AppTypeCounts MapStrInt64 `json:"app_type_counts" gorm:"type:jsonb"` // AppType -> count
+	NodeID        string      `json:"node_id"`                           // the draft node created from the cluster
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+func (QuestionGapCluster) TableName() string {
+	return "question_gap_clusters"
+}
+
+// table: question_gap_items
+type QuestionGapItem struct {
+	ID             string         `json:"id" gorm:"primaryKey"`
+	ClusterID      string         `json:"cluster_id" gorm:"index"`
+	MessageID      string         `json:"message_id"` // the assistant message
+	ConversationID string         `json:"conversation_id"`
+	Question       string         `json:"question"`
+	AppType        AppType        `json:"app_type"`
+	Reasons        pq.StringArray `json:"reasons" gorm:"type:text[]"`
+	AskedAt        time.Time      `json:"asked_at"`
+}
+
+func (QuestionGapItem) TableName() string {
+	return "question_gap_items"
+}
+
+// QuestionGapCandidate is an assistant message with its question that may be a knowledge gap
+type QuestionGapCandidate struct {
+	MessageID          string    `json:"message_id"`
+	ConversationID     string    `json:"conversation_id"`
+	AppType            AppType   `json:"app_type"`
+	Question           string    `json:"question"`
+	Answer             string    `json:"answer"`
+	Score              ScoreType `json:"score"`
+	RetrievedNodeCount *int      `json:"retrieved_node_count"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+func (c *QuestionGapCandidate) Reasons() []string {
+	reasons := make([]string, 0)
+	if c.RetrievedNodeCount != nil && *c.RetrievedNodeCount == 0 {
+		reasons = append(reasons, string(QuestionGapReasonNoRetrieval))
+	}
+	if strings.Contains(c.Answer, UnansweredReplyMarker) {
+		reasons = append(reasons, string(QuestionGapReasonUnanswered))
+	}
+	if c.Score == DisLike {
+		reasons = append(reasons, string(QuestionGapReasonDisliked))
+	}
+	return reasons
+}
@@ -8,6 +8,7 @@ import (
 const (
 	SettingKeySystemPrompt = "system_prompt"
 	SettingBlockWords      = "block_words"
+	SettingKeyRetrieval    = "retrieval"
 	SettingCopyrightInfo   = "本网站由 PandaWiki 提供技术支持"
 )
 
@@ -27,3 +28,25 @@ type SettingRepo interface {
 	GetSetting(ctx context.Context, kbID, key string) (*Setting, error)
 	UpdateSetting(ctx context.Context, kbID, key, value string) error
 }
+
+type RetrievalMode string
+
+const (
+	RetrievalModeVector RetrievalMode = "vector" // 仅向量检索
+	RetrievalModeHybrid RetrievalMode = "hybrid" // 向量检索 + 关键词检索
+)
+
+// RetrievalSettings is stored in settings with key SettingKeyRetrieval
+type RetrievalSettings struct {
+	Mode        RetrievalMode `json:"mode" validate:"omitempty,oneof=vector hybrid"`
+	KeywordTopK int           `json:"keyword_top_k" validate:"omitempty,min=1,max=50"` // 关键词检索返回的最大文档数
+	RRFK        int           `json:"rrf_k" validate:"omitempty,min=1,max=1000"`       // reciprocal rank fusion 常数
+}
+
+func DefaultRetrievalSettings() *RetrievalSettings {
+	return &RetrievalSettings{
+		Mode:        RetrievalModeHybrid,
+		KeywordTopK: 5,
+		RRFK:        60,
+	}
+}
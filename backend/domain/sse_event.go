@@ -5,4 +5,5 @@ type SSEEvent struct {
 	Content     string               `json:"content"`
 	ChunkResult *NodeContentChunkSSE `json:"chunk_result,omitempty"`
 	Error       string               `json:"error,omitempty"`
+	ToolCalls   []OpenAIToolCall     `json:"tool_calls,omitempty"`
 }
@@ -92,6 +92,7 @@ type StatPageHour struct {
 	SessionCount             int64       `json:"session_count"`
 	PageVisitCount           int64       `json:"page_visit_count"`
 	ConversationCount        int64       `json:"conversation_count"`
+	ChatRateLimitedCount     int64       `json:"chat_rate_limited_count"` // 被限流拒绝的对话请求数
 	GeoCount                 MapStrInt64 `json:"geo_count" gorm:"type:jsonb"`
 	ConversationDistribution MapStrInt64 `json:"conversation_distribution" gorm:"type:jsonb"`
 	HotRefererHost           MapStrInt64 `json:"hot_referer_host" gorm:"type:jsonb"`
@@ -33,3 +33,28 @@ type ModelModeSetting struct {
 type UploadDeniedExtensionsSetting struct {
 	DeniedExtensions []string `json:"denied_extensions"` // 禁止上传的文件扩展名列表，不带点，如 ["jsp", "php", "exe"]
 }
+
+// AdminOIDCSetting 管理后台 OIDC 登录配置
+type AdminOIDCSetting struct {
+	Enabled      bool     `json:"enabled"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	RedirectURL  string   `json:"redirect_url"`  // 管理后台的 OIDC 回调页面地址，需要在 IdP 中注册
+	Scopes       []string `json:"scopes"`        // 默认为 openid profile email
+	AccountClaim string   `json:"account_claim"` // 作为账号的 claim，默认为 preferred_username，没有时使用 email
+	GroupsClaim  string   `json:"groups_claim"`  // IdP 组的 claim，默认为 groups
+	AdminGroups  []string `json:"admin_groups"`  // 属于这些组的用户为管理员
+	UserGroups   []string `json:"user_groups"`   // 允许登录的组，为空时允许所有用户登录
+
+	KBPermissions []AdminOIDCKBPermission `json:"kb_permissions"` // IdP 组到知识库权限的映射，每次登录时同步
+
+	LinkExistingUsers    bool `json:"link_existing_users"`    // 首次登录时关联账号与已验证邮箱相同的本地用户，保留其角色
+	DisablePasswordLogin bool `json:"disable_password_login"` // 禁用本地密码登录，启动配置的 admin 账号除外
+}
+
+type AdminOIDCKBPermission struct {
+	Group string                  `json:"group"`
+	KBID  string                  `json:"kb_id"`
+	Perm  consts.UserKBPermission `json:"perm"`
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"time"
+)
+
+type TokenBudgetPeriod string
+
+const (
+	TokenBudgetPeriodDaily   TokenBudgetPeriod = "daily"
+	TokenBudgetPeriodMonthly TokenBudgetPeriod = "monthly"
+)
+
+// Start returns the start of the period containing t
+func (p TokenBudgetPeriod) Start(t time.Time) time.Time {
+	if p == TokenBudgetPeriodMonthly {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// TokenBudget limits the tokens answered in a period for a knowledge base, or one type of its apps
+// table: token_budgets
+type TokenBudget struct {
+	ID         string            `json:"id" gorm:"primaryKey"`
+	KBID       string            `json:"kb_id"`
+	AppType    AppType           `json:"app_type"` // 0 for all apps of the knowledge base
+	Period     TokenBudgetPeriod `json:"period"`
+	TokenLimit int64             `json:"token_limit"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+func (TokenBudget) TableName() string {
+	return "token_budgets"
+}
+
+// TokenUsageStat is the tokens used by an app in a day
+type TokenUsageStat struct {
+	KBID             string    `json:"kb_id"`
+	AppID            string    `json:"app_id"`
+	AppName          string    `json:"app_name"`
+	AppType          AppType   `json:"app_type"`
+	Day              time.Time `json:"day"`
+	MessageCount     int64     `json:"message_count"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	TotalTokens      int64     `json:"total_tokens"`
+}
@@ -13,6 +13,12 @@ type User struct {
 	Role       consts.UserRole `json:"role" gorm:"default:'user'"`
 	CreatedAt  time.Time       `json:"created_at"`
 	LastAccess time.Time       `json:"last_access" gorm:"default:null"`
+	// OIDCIssuer OIDC 登录用户所属 IdP 的 issuer，与 OIDCSubject 一起唯一标识用户
+	OIDCIssuer string `json:"-" gorm:"column:oidc_issuer;default:''"`
+	// OIDCSubject OIDC 登录用户在 IdP 中的唯一标识，本地用户为空
+	OIDCSubject *string `json:"-" gorm:"column:oidc_subject;default:null"`
+	// OIDCLinked 首次 OIDC 登录时关联的已有本地用户，角色不随 IdP 的组同步
+	OIDCLinked bool `json:"-" gorm:"column:oidc_linked;default:false"`
 }
 
 // KBUsers 知识库用户关联表（多对多关系）
@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/chaitin/panda-wiki/consts"
+)
+
+type WebhookEventType string
+
+const (
+	WebhookEventNodeCreated       WebhookEventType = "node.created"
+	WebhookEventNodeUpdated       WebhookEventType = "node.updated"
+	WebhookEventNodeDeleted       WebhookEventType = "node.deleted"
+	WebhookEventKBReleased        WebhookEventType = "kb.released"
+	WebhookEventCommentCreated    WebhookEventType = "comment.created"
+	WebhookEventContributeCreated WebhookEventType = "contribute.created"
+	WebhookEventFeedbackNegative  WebhookEventType = "feedback.negative"
+
+	// WebhookEventPing is only sent when testing a webhook, it can not be subscribed
+	WebhookEventPing WebhookEventType = "ping"
+)
+
+// WebhookEventTypes are the events a webhook can subscribe to
+var WebhookEventTypes = []WebhookEventType{
+	WebhookEventNodeCreated,
+	WebhookEventNodeUpdated,
+	WebhookEventNodeDeleted,
+	WebhookEventKBReleased,
+	WebhookEventCommentCreated,
+	WebhookEventContributeCreated,
+	WebhookEventFeedbackNegative,
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending  WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusRetrying WebhookDeliveryStatus = "retrying"
+	WebhookDeliveryStatusSuccess  WebhookDeliveryStatus = "success"
+	WebhookDeliveryStatusFailed   WebhookDeliveryStatus = "failed"
+)
+
+// Webhook is a per knowledge base registration receiving event notifications
+// table: webhooks
+type Webhook struct {
+	ID        string         `json:"id" gorm:"primaryKey"`
+	KBID      string         `json:"kb_id" gorm:"index"`
+	Name      string         `json:"name"`
+	URL       string         `json:"url"`
+	Secret    string         `json:"secret"` // used to sign payloads with HMAC-SHA256
+	Events    pq.StringArray `json:"events" gorm:"type:text[]"`
+	Enabled   bool           `json:"enabled"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// WebhookDelivery records one event sent to one webhook, including retries
+// table: webhook_deliveries
+type WebhookDelivery struct {
+	ID             string                `json:"id" gorm:"primaryKey"`
+	WebhookID      string                `json:"webhook_id" gorm:"index"`
+	KBID           string                `json:"kb_id"`
+	Event          WebhookEventType      `json:"event"`
+	Payload        string                `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	ResponseStatus int                   `json:"response_status"`
+	ResponseBody   string                `json:"response_body"`
+	Error          string                `json:"error"`
+	NextRetryAt    *time.Time            `json:"next_retry_at"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookEvent is produced by the api when something happens in a knowledge base,
+// the consumer fans it out to the subscribed webhooks
+type WebhookEvent struct {
+	ID         string           `json:"id"`
+	KBID       string           `json:"kb_id"`
+	Event      WebhookEventType `json:"event"`
+	Data       any              `json:"data"`
+	OccurredAt time.Time        `json:"occurred_at"`
+}
+
+// WebhookDeliveryRequest asks the consumer to send a pending delivery
+type WebhookDeliveryRequest struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+type WebhookNodeEventData struct {
+	NodeID   string   `json:"node_id"`
+	Name     string   `json:"name,omitempty"`
+	Type     NodeType `json:"type,omitempty"`
+	ParentID string   `json:"parent_id,omitempty"`
+	UserID   string   `json:"user_id,omitempty"`
+}
+
+type WebhookNodeDeletedEventData struct {
+	NodeIDs []string `json:"node_ids"`
+}
+
+type WebhookReleaseEventData struct {
+	ReleaseID string   `json:"release_id"`
+	Tag       string   `json:"tag"`
+	Message   string   `json:"message"`
+	NodeIDs   []string `json:"node_ids"`
+	UserID    string   `json:"user_id"`
+}
+
+type WebhookCommentEventData struct {
+	CommentID string        `json:"comment_id"`
+	NodeID    string        `json:"node_id"`
+	Content   string        `json:"content"`
+	UserName  string        `json:"user_name"`
+	Status    CommentStatus `json:"status"`
+}
+
+type WebhookFeedbackEventData struct {
+	ConversationID  string       `json:"conversation_id"`
+	MessageID       string       `json:"message_id"`
+	AppID           string       `json:"app_id"`
+	Question        string       `json:"question"`
+	Answer          string       `json:"answer"`
+	Type            FeedbackType `json:"type"`
+	FeedbackContent string       `json:"feedback_content"`
+}
+
+type WebhookContributeEventData struct {
+	ContributeID string                `json:"contribute_id"`
+	Type         consts.ContributeType `json:"type"`
+	NodeID       string                `json:"node_id,omitempty"`
+	Name         string                `json:"name"`
+}
@@ -12,19 +12,35 @@ import (
 )
 
 type CronHandler struct {
-	logger      *log.Logger
-	statRepo    *pg.StatRepository
-	statUseCase *usecase.StatUseCase
-	nodeUseCase *usecase.NodeUsecase
+	logger                    *log.Logger
+	statRepo                  *pg.StatRepository
+	statUseCase               *usecase.StatUseCase
+	nodeUseCase               *usecase.NodeUsecase
+	importSubscriptionUsecase *usecase.ImportSubscriptionUsecase
+	webhookUsecase            *usecase.WebhookUsecase
+	releaseScheduleUsecase    *usecase.KBReleaseScheduleUsecase
+	questionGapUsecase        *usecase.QuestionGapUsecase
+	authUsecase               *usecase.AuthUsecase
+	evalUsecase               *usecase.EvalUsecase
 }
 
-func NewStatCronHandler(logger *log.Logger, statRepo *pg.StatRepository, statUseCase *usecase.StatUseCase, nodeUseCase *usecase.NodeUsecase) (*CronHandler, error) {
+func NewStatCronHandler(logger *log.Logger, statRepo *pg.StatRepository, statUseCase *usecase.StatUseCase, nodeUseCase *usecase.NodeUsecase, importSubscriptionUsecase *usecase.ImportSubscriptionUsecase, webhookUsecase *usecase.WebhookUsecase, releaseScheduleUsecase *usecase.KBReleaseScheduleUsecase, questionGapUsecase *usecase.QuestionGapUsecase, authUsecase *usecase.AuthUsecase, evalUsecase *usecase.EvalUsecase) (*CronHandler, error) {
 	h := &CronHandler{
-		statRepo:    statRepo,
-		statUseCase: statUseCase,
-		nodeUseCase: nodeUseCase,
-		logger:      logger.WithModule("handler.mq.cron"),
+		statRepo:                  statRepo,
+		statUseCase:               statUseCase,
+		nodeUseCase:               nodeUseCase,
+		importSubscriptionUsecase: importSubscriptionUsecase,
+		webhookUsecase:            webhookUsecase,
+		releaseScheduleUsecase:    releaseScheduleUsecase,
+		questionGapUsecase:        questionGapUsecase,
+		authUsecase:               authUsecase,
+		evalUsecase:               evalUsecase,
+		logger:                    logger.WithModule("handler.mq.cron"),
 	}
+	// 导入源同步耗时较长，上一次未结束时跳过
+	syncImportJob := cron.NewChain(cron.SkipIfStillRunning(cron.DefaultLogger)).Then(cron.FuncJob(h.SyncImportSubscriptions))
+	releaseScheduleJob := cron.NewChain(cron.SkipIfStillRunning(cron.DefaultLogger)).Then(cron.FuncJob(h.RunReleaseSchedules))
+	syncLDAPGroupsJob := cron.NewChain(cron.SkipIfStillRunning(cron.DefaultLogger)).Then(cron.FuncJob(h.SyncLDAPGroups))
 	cron := cron.New()
 
 	// 每小时 */10 分执行聚合统计数据任务
@@ -60,6 +76,49 @@ func NewStatCronHandler(logger *log.Logger, statRepo *pg.StatRepository, statUse
 	}
 	h.logger.Info("add cron job", log.String("cron_id", "sync_rag_node_status"))
 
+	// 每分钟检查需要同步的导入源订阅
+	if _, err := cron.AddJob("* * * * *", syncImportJob); err != nil {
+		h.logger.Error("failed to add cron job for syncing import subscriptions", log.Error(err))
+		return nil, err
+	}
+	h.logger.Info("add cron job", log.String("cron_id", "sync_import_subscriptions"))
+
+	// 每分钟重新投递到期的 webhook 失败记录
+	if _, err := cron.AddFunc("* * * * *", h.RetryWebhookDeliveries); err != nil {
+		h.logger.Error("failed to add cron job for retrying webhook deliveries", log.Error(err))
+		return nil, err
+	}
+	h.logger.Info("add cron job", log.String("cron_id", "retry_webhook_deliveries"))
+
+	// 每分钟执行到期的定时发布
+	if _, err := cron.AddJob("* * * * *", releaseScheduleJob); err != nil {
+		h.logger.Error("failed to add cron job for running release schedules", log.Error(err))
+		return nil, err
+	}
+	h.logger.Info("add cron job", log.String("cron_id", "run_release_schedules"))
+
+	// 每天 3:30 分析未解决问题
+	if _, err := cron.AddFunc("30 3 * * *", h.AnalyzeQuestionGaps); err != nil {
+		h.logger.Error("failed to add cron job for analyzing question gaps", log.Error(err))
+		return nil, err
+	}
+	h.logger.Info("add cron job", log.String("cron_id", "analyze_question_gaps"))
+
+	// 每小时 15 分同步 LDAP 组
+	if _, err := cron.AddJob("15 * * * *", syncLDAPGroupsJob); err != nil {
+		h.logger.Error("failed to add cron job for syncing ldap groups", log.Error(err))
+		return nil, err
+	}
+	h.logger.Info("add cron job", log.String("cron_id", "sync_ldap_groups"))
+
+	// 启动时和之后每 10 分钟将中断的评测任务标记为失败，api 服务重启时评测任务会中断
+	go h.FailInterruptedEvalRuns()
+	if _, err := cron.AddFunc("*/10 * * * *", h.FailInterruptedEvalRuns); err != nil {
+		h.logger.Error("failed to add cron job for failing interrupted eval runs", log.Error(err))
+		return nil, err
+	}
+	h.logger.Info("add cron job", log.String("cron_id", "fail_interrupted_eval_runs"))
+
 	cron.Start()
 	h.logger.Info("start cron jobs")
 	return h, nil
@@ -113,3 +172,39 @@ func (h *CronHandler) SyncRagNodeStatus() {
 	}
 	h.logger.Info("sync rag node status successful")
 }
+
+func (h *CronHandler) SyncImportSubscriptions() {
+	if err := h.importSubscriptionUsecase.SyncDueSubscriptions(context.Background()); err != nil {
+		h.logger.Error("sync import subscriptions failed", log.Error(err))
+	}
+}
+
+func (h *CronHandler) RetryWebhookDeliveries() {
+	if err := h.webhookUsecase.RetryDueDeliveries(context.Background()); err != nil {
+		h.logger.Error("retry webhook deliveries failed", log.Error(err))
+	}
+}
+
+func (h *CronHandler) RunReleaseSchedules() {
+	if err := h.releaseScheduleUsecase.RunDueSchedules(context.Background()); err != nil {
+		h.logger.Error("run release schedules failed", log.Error(err))
+	}
+}
+
+func (h *CronHandler) AnalyzeQuestionGaps() {
+	if err := h.questionGapUsecase.AnalyzeAll(context.Background()); err != nil {
+		h.logger.Error("analyze question gaps failed", log.Error(err))
+	}
+}
+
+func (h *CronHandler) FailInterruptedEvalRuns() {
+	if err := h.evalUsecase.FailInterruptedRuns(context.Background()); err != nil {
+		h.logger.Error("fail interrupted eval runs failed", log.Error(err))
+	}
+}
+
+func (h *CronHandler) SyncLDAPGroups() {
+	if err := h.authUsecase.SyncAllLDAPGroups(context.Background()); err != nil {
+		h.logger.Error("sync ldap groups failed", log.Error(err))
+	}
+}
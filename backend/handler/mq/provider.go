@@ -15,6 +15,7 @@ type MQHandlers struct {
 	RAGMQHandler        *RAGMQHandler
 	RagDocUpdateHandler *RagDocUpdateHandler
 	StatCronHandler     *CronHandler
+	WebhookMQHandler    *WebhookMQHandler
 }
 
 var ProviderSet = wire.NewSet(
@@ -28,10 +29,18 @@ var ProviderSet = wire.NewSet(
 	usecase.NewStatUseCase,
 	usecase.NewNodeUsecase,
 	usecase.NewModelUsecase,
+	usecase.NewCrawlerUsecase,
+	usecase.NewKnowledgeBaseUsecase,
+	usecase.NewImportSubscriptionUsecase,
+	usecase.NewWebhookUsecase,
+	usecase.NewKBReleaseScheduleUsecase,
+	usecase.NewQuestionGapUsecase,
+	usecase.NewEvalUsecase,
 
 	NewRAGMQHandler,
 	NewRagDocUpdateHandler,
 	NewStatCronHandler,
+	NewWebhookMQHandler,
 
 	wire.Struct(new(MQHandlers), "*"),
 )
@@ -0,0 +1,59 @@
+package mq
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/mq"
+	"github.com/chaitin/panda-wiki/mq/types"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+type WebhookMQHandler struct {
+	consumer       mq.MQConsumer
+	logger         *log.Logger
+	webhookUsecase *usecase.WebhookUsecase
+}
+
+func NewWebhookMQHandler(consumer mq.MQConsumer, logger *log.Logger, webhookUsecase *usecase.WebhookUsecase) (*WebhookMQHandler, error) {
+	h := &WebhookMQHandler{
+		consumer:       consumer,
+		logger:         logger.WithModule("mq.webhook"),
+		webhookUsecase: webhookUsecase,
+	}
+	if err := consumer.RegisterHandler(domain.WebhookEventTopic, h.HandleWebhookEvent); err != nil {
+		return nil, err
+	}
+	if err := consumer.RegisterHandler(domain.WebhookDeliveryTopic, h.HandleWebhookDelivery); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *WebhookMQHandler) HandleWebhookEvent(ctx context.Context, msg types.Message) error {
+	var event domain.WebhookEvent
+	if err := json.Unmarshal(msg.GetData(), &event); err != nil {
+		h.logger.Error("unmarshal webhook event failed", log.Error(err))
+		return nil
+	}
+	if err := h.webhookUsecase.HandleEvent(ctx, &event); err != nil {
+		h.logger.Error("handle webhook event failed", log.String("kb_id", event.KBID), log.Any("event", event.Event), log.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (h *WebhookMQHandler) HandleWebhookDelivery(ctx context.Context, msg types.Message) error {
+	var request domain.WebhookDeliveryRequest
+	if err := json.Unmarshal(msg.GetData(), &request); err != nil {
+		h.logger.Error("unmarshal webhook delivery request failed", log.Error(err))
+		return nil
+	}
+	if err := h.webhookUsecase.Deliver(ctx, request.DeliveryID); err != nil {
+		h.logger.Error("deliver webhook failed", log.String("delivery_id", request.DeliveryID), log.Error(err))
+		return err
+	}
+	return nil
+}
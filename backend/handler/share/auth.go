@@ -40,13 +40,14 @@ func NewShareAuthHandler(
 		userUsecase: userUsecase,
 	}
 
-	shareAuthMiddleware := middleware.NewShareAuthMiddleware(logger, kbUsecase)
+	shareAuthMiddleware := middleware.NewShareAuthMiddleware(logger, kbUsecase, authUsecase.AuthRepo)
 
 	share := e.Group("share/v1/auth", shareAuthMiddleware.CheckForbidden)
 	share.GET("/get", h.AuthGet)
 	share.POST("/login/simple", h.AuthLoginSimple)
 	share.POST("/login/user_password", h.AuthLoginUserPassword)
 	share.POST("/github", h.AuthGitHub)
+	share.POST("/saml", h.AuthSAML)
 	return h
 }
 
@@ -187,6 +188,47 @@ func (h *ShareAuthHandler) AuthGitHub(c echo.Context) error {
 	})
 }
 
+// AuthSAML SAML登录
+//
+//	@Tags			ShareAuth
+//	@Summary		SAML登录
+//	@Description	获取跳转到 SAML IdP 登录的地址
+//	@ID				v1-AuthSAML
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-KB-ID	header		string			true	"kb id"
+//	@Param			param	body		v1.AuthSAMLReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.AuthSAMLResp}
+//	@Router			/share/v1/auth/saml [post]
+func (h *ShareAuthHandler) AuthSAML(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req v1.AuthSAMLReq
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	kbID := c.Request().Header.Get("X-KB-ID")
+	if kbID == "" {
+		return h.NewResponseWithError(c, "kb_id is required", nil)
+	}
+	req.KbID = kbID
+
+	valid, err := h.authUsecase.ValidateRedirectUrl(ctx, req.KbID, req.RedirectUrl)
+	if err != nil || !valid {
+		return h.NewResponseWithError(c, "invalid redirect url", err)
+	}
+
+	url, err := h.authUsecase.GenerateSAMLAuthUrl(ctx, req)
+	if err != nil {
+		return h.NewResponseWithError(c, "GenerateSAMLAuthUrl failed", err)
+	}
+
+	return h.NewResponseWithData(c, v1.AuthSAMLResp{
+		Url: url,
+	})
+}
+
 // AuthLoginUserPassword 用户名密码登录
 //
 //	@Tags			share_auth
@@ -3,7 +3,9 @@ package share
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,6 +25,7 @@ type ShareChatHandler struct {
 	authUsecase         *usecase.AuthUsecase
 	conversationUsecase *usecase.ConversationUsecase
 	modelUsecase        *usecase.ModelUsecase
+	chatRateLimit       *usecase.ChatRateLimitUsecase
 }
 
 func NewShareChatHandler(
@@ -34,6 +37,7 @@ func NewShareChatHandler(
 	authUsecase *usecase.AuthUsecase,
 	conversationUsecase *usecase.ConversationUsecase,
 	modelUsecase *usecase.ModelUsecase,
+	chatRateLimit *usecase.ChatRateLimitUsecase,
 ) *ShareChatHandler {
 	h := &ShareChatHandler{
 		BaseHandler:         baseHandler,
@@ -43,6 +47,7 @@ func NewShareChatHandler(
 		authUsecase:         authUsecase,
 		conversationUsecase: conversationUsecase,
 		modelUsecase:        modelUsecase,
+		chatRateLimit:       chatRateLimit,
 	}
 
 	share := e.Group("share/v1/chat",
@@ -110,11 +115,6 @@ func (h *ShareChatHandler) ChatMessage(c echo.Context) error {
 
 	req.RemoteIP = c.RealIP()
 
-	c.Response().Header().Set("Content-Type", "text/event-stream")
-	c.Response().Header().Set("Cache-Control", "no-cache")
-	c.Response().Header().Set("Connection", "keep-alive")
-	c.Response().Header().Set("Transfer-Encoding", "chunked")
-
 	// get user info --> no enterprise is nil
 	userID := c.Get("user_id")
 	h.logger.Debug("userid:", userID)
@@ -123,6 +123,15 @@ func (h *ShareChatHandler) ChatMessage(c echo.Context) error {
 		req.Info.UserInfo.AuthUserID = userIDValue
 	}
 
+	if allowed, retryAfter := h.chatRateLimit.Check(ctx, req.KBID, req.RemoteIP, req.Info.UserInfo.AuthUserID, req.ConversationID); !allowed {
+		return h.sendRateLimitedMsg(c, retryAfter)
+	}
+
+	c.Response().Header().Set("Content-Type", "text/event-stream")
+	c.Response().Header().Set("Cache-Control", "no-cache")
+	c.Response().Header().Set("Connection", "keep-alive")
+	c.Response().Header().Set("Transfer-Encoding", "chunked")
+
 	eventCh, err := h.chatUsecase.Chat(ctx, &req)
 	if err != nil {
 		return h.sendErrMsg(c, err.Error())
@@ -185,6 +194,10 @@ func (h *ShareChatHandler) ChatWidget(c echo.Context) error {
 
 	req.RemoteIP = c.RealIP()
 
+	if allowed, retryAfter := h.chatRateLimit.Check(c.Request().Context(), req.KBID, req.RemoteIP, 0, req.ConversationID); !allowed {
+		return h.sendRateLimitedMsg(c, retryAfter)
+	}
+
 	c.Response().Header().Set("Content-Type", "text/event-stream")
 	c.Response().Header().Set("Cache-Control", "no-cache")
 	c.Response().Header().Set("Connection", "keep-alive")
@@ -210,6 +223,19 @@ func (h *ShareChatHandler) sendErrMsg(c echo.Context, errMsg string) error {
 	return h.writeSSEEvent(c, domain.SSEEvent{Type: "error", Content: errMsg})
 }
 
+// sendRateLimitedMsg responds 429 with the error event, so that both SSE and plain HTTP clients know to retry later
+func (h *ShareChatHandler) sendRateLimitedMsg(c echo.Context, retryAfter time.Duration) error {
+	c.Response().Header().Set("Content-Type", "text/event-stream")
+	c.Response().Header().Set("Cache-Control", "no-cache")
+	c.Response().Header().Set("Retry-After", retryAfterSeconds(retryAfter))
+	c.Response().WriteHeader(http.StatusTooManyRequests)
+	return h.sendErrMsg(c, "请求过于频繁，请稍后再试")
+}
+
+func retryAfterSeconds(retryAfter time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+}
+
 func (h *ShareChatHandler) writeSSEEvent(c echo.Context, data any) error {
 	jsonContent, err := json.Marshal(data)
 	if err != nil {
@@ -253,7 +279,7 @@ func (h *ShareChatHandler) FeedBack(c echo.Context) error {
 // ChatCompletions OpenAI API compatible chat completions
 //
 //	@Summary		ChatCompletions
-//	@Description	OpenAI API compatible chat completions endpoint
+//	@Description	OpenAI API compatible chat completions endpoint, supports tools, tool_choice and response_format, the knowledge base is searched by the built-in tool search_knowledge_base when tools or response_format are given
 //	@Tags			share_chat
 //	@Accept			json
 //	@Produce		json
@@ -261,28 +287,30 @@ func (h *ShareChatHandler) FeedBack(c echo.Context) error {
 //	@Param			request	body		domain.OpenAICompletionsRequest	true	"OpenAI API request"
 //	@Success		200		{object}	domain.OpenAICompletionsResponse
 //	@Failure		400		{object}	domain.OpenAIErrorResponse
+//	@Failure		401		{object}	domain.OpenAIErrorResponse
+//	@Failure		500		{object}	domain.OpenAIErrorResponse
 //	@Router			/share/v1/chat/completions [post]
 func (h *ShareChatHandler) ChatCompletions(c echo.Context) error {
 	var req domain.OpenAICompletionsRequest
 	if err := c.Bind(&req); err != nil {
 		h.logger.Error("parse OpenAI request failed", log.Error(err))
-		return h.sendOpenAIError(c, "parse request failed", "invalid_request_error")
+		return sendOpenAIError(c, "parse request failed", "invalid_request_error")
 	}
 
 	// get kb id from header
 	kbID := c.Request().Header.Get("X-KB-ID")
 	if kbID == "" {
-		return h.sendOpenAIError(c, "X-KB-ID header is required", "invalid_request_error")
+		return sendOpenAIError(c, "X-KB-ID header is required", "invalid_request_error")
 	}
 
 	if err := c.Validate(&req); err != nil {
 		h.logger.Error("validate OpenAI request failed", log.Error(err))
-		return h.sendOpenAIError(c, "validate request failed", "invalid_request_error")
+		return sendOpenAIError(c, "validate request failed", "invalid_request_error")
 	}
 
 	// validate messages
 	if len(req.Messages) == 0 {
-		return h.sendOpenAIError(c, "messages cannot be empty", "invalid_request_error")
+		return sendOpenAIError(c, "messages cannot be empty", "invalid_request_error")
 	}
 
 	// use last user message as message
@@ -296,28 +324,12 @@ func (h *ShareChatHandler) ChatCompletions(c echo.Context) error {
 		}
 	}
 	if lastUserMessage == "" {
-		return h.sendOpenAIError(c, "no user message found", "invalid_request_error")
+		return sendOpenAIError(c, "no user message found", "invalid_request_error")
 	}
 
 	// validate api bot settings
-	appBot, err := h.appUsecase.GetOpenAIAPIAppInfo(c.Request().Context(), kbID)
-	if err != nil {
-		return h.sendOpenAIError(c, err.Error(), "internal_error")
-	}
-	if !appBot.Settings.OpenAIAPIBotSettings.IsEnabled {
-		return h.sendOpenAIError(c, "API Bot is not enabled", "forbidden")
-	}
-
-	secretKeyHeader := c.Request().Header.Get("Authorization")
-	if secretKeyHeader == "" {
-		return h.sendOpenAIError(c, "Authorization header is required", "invalid_request_error")
-	}
-	if secretKey, found := strings.CutPrefix(secretKeyHeader, "Bearer "); !found {
-		return h.sendOpenAIError(c, "Invalid Authorization key format", "invalid_request_error")
-	} else {
-		if appBot.Settings.OpenAIAPIBotSettings.SecretKey != secretKey {
-			return h.sendOpenAIError(c, "Invalid Authorization key", "unauthorized")
-		}
+	if errMsg, errType := validateOpenAIAPIKey(c, h.appUsecase, kbID); errMsg != "" {
+		return sendOpenAIError(c, errMsg, errType)
 	}
 
 	chatReq := &domain.ChatRequest{
@@ -327,6 +339,26 @@ func (h *ShareChatHandler) ChatCompletions(c echo.Context) error {
 		RemoteIP: c.RealIP(),
 	}
 
+	if allowed, retryAfter := h.chatRateLimit.Check(c.Request().Context(), kbID, chatReq.RemoteIP, 0, ""); !allowed {
+		c.Response().Header().Set("Retry-After", retryAfterSeconds(retryAfter))
+		return sendOpenAIError(c, "Rate limit exceeded, please retry later", "rate_limit_exceeded")
+	}
+
+	var eventCh <-chan domain.SSEEvent
+	var err error
+	if req.HasToolsOrResponseFormat() {
+		// the knowledge base is searched by the built-in tool
+		eventCh, err = h.chatUsecase.ChatCompletions(c.Request().Context(), kbID, chatReq.RemoteIP, &req)
+		if err != nil {
+			return sendOpenAIError(c, err.Error(), "invalid_request_error")
+		}
+	} else {
+		eventCh, err = h.chatUsecase.Chat(c.Request().Context(), chatReq)
+		if err != nil {
+			return sendOpenAIError(c, err.Error(), "internal_error")
+		}
+	}
+
 	// set stream response header
 	if req.Stream {
 		c.Response().Header().Set("Content-Type", "text/event-stream")
@@ -335,11 +367,6 @@ func (h *ShareChatHandler) ChatCompletions(c echo.Context) error {
 		c.Response().Header().Set("Transfer-Encoding", "chunked")
 	}
 
-	eventCh, err := h.chatUsecase.Chat(c.Request().Context(), chatReq)
-	if err != nil {
-		return h.sendOpenAIError(c, err.Error(), "internal_error")
-	}
-
 	// handle stream response
 	if req.Stream {
 		return h.handleOpenAIStreamResponse(c, eventCh, req.Model)
@@ -351,11 +378,32 @@ func (h *ShareChatHandler) ChatCompletions(c echo.Context) error {
 func (h *ShareChatHandler) handleOpenAIStreamResponse(c echo.Context, eventCh <-chan domain.SSEEvent, model string) error {
 	responseID := "chatcmpl-" + generateID()
 	created := time.Now().Unix()
+	finishReason := "stop"
 
 	for event := range eventCh {
 		switch event.Type {
 		case "error":
-			return h.sendOpenAIError(c, event.Content, "internal_error")
+			return sendOpenAIError(c, event.Content, "internal_error")
+		case "tool_calls":
+			finishReason = "tool_calls"
+			streamResp := domain.OpenAIStreamResponse{
+				ID:      responseID,
+				Object:  "chat.completion.chunk",
+				Created: created,
+				Model:   model,
+				Choices: []domain.OpenAIStreamChoice{
+					{
+						Index: 0,
+						Delta: domain.OpenAIMessage{
+							Role:      "assistant",
+							ToolCalls: event.ToolCalls,
+						},
+					},
+				},
+			}
+			if err := h.writeOpenAIStreamEvent(c, streamResp); err != nil {
+				return err
+			}
 		case "data":
 			// send stream response
 			streamResp := domain.OpenAIStreamResponse{
@@ -388,7 +436,7 @@ func (h *ShareChatHandler) handleOpenAIStreamResponse(c echo.Context, eventCh <-
 					{
 						Index:        0,
 						Delta:        domain.OpenAIMessage{},
-						FinishReason: stringPtr("stop"),
+						FinishReason: stringPtr(finishReason),
 					},
 				},
 			}
@@ -403,14 +451,33 @@ func (h *ShareChatHandler) handleOpenAINonStreamResponse(c echo.Context, eventCh
 	created := time.Now().Unix()
 
 	var content string
+	var toolCalls []domain.OpenAIToolCall
 	for event := range eventCh {
 		switch event.Type {
 		case "error":
-			return h.sendOpenAIError(c, event.Content, "internal_error")
+			return sendOpenAIError(c, event.Content, "internal_error")
+		case "tool_calls":
+			// index is only for streaming
+			for _, call := range event.ToolCalls {
+				call.Index = nil
+				toolCalls = append(toolCalls, call)
+			}
 		case "data":
 			content += event.Content
 		case "done":
 			// send complete response
+			message := domain.OpenAIMessage{
+				Role:    "assistant",
+				Content: domain.NewStringContent(content),
+			}
+			finishReason := "stop"
+			if len(toolCalls) > 0 {
+				message.ToolCalls = toolCalls
+				finishReason = "tool_calls"
+				if content == "" {
+					message.Content = nil
+				}
+			}
 			resp := domain.OpenAICompletionsResponse{
 				ID:      responseID,
 				Object:  "chat.completion",
@@ -418,12 +485,9 @@ func (h *ShareChatHandler) handleOpenAINonStreamResponse(c echo.Context, eventCh
 				Model:   model,
 				Choices: []domain.OpenAIChoice{
 					{
-						Index: 0,
-						Message: domain.OpenAIMessage{
-							Role:    "assistant",
-							Content: domain.NewStringContent(content),
-						},
-						FinishReason: "stop",
+						Index:        0,
+						Message:      message,
+						FinishReason: finishReason,
 					},
 				},
 			}
@@ -433,16 +497,6 @@ func (h *ShareChatHandler) handleOpenAINonStreamResponse(c echo.Context, eventCh
 	return nil
 }
 
-func (h *ShareChatHandler) sendOpenAIError(c echo.Context, message, errorType string) error {
-	errResp := domain.OpenAIErrorResponse{
-		Error: domain.OpenAIError{
-			Message: message,
-			Type:    errorType,
-		},
-	}
-	return c.JSON(http.StatusBadRequest, errResp)
-}
-
 func (h *ShareChatHandler) writeOpenAIStreamEvent(c echo.Context, data domain.OpenAIStreamResponse) error {
 	jsonContent, err := json.Marshal(data)
 	if err != nil {
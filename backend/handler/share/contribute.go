@@ -0,0 +1,97 @@
+package share
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/share/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+type ShareContributeHandler struct {
+	*handler.BaseHandler
+	logger  *log.Logger
+	usecase *usecase.ContributeUsecase
+	app     *usecase.AppUsecase
+}
+
+func NewShareContributeHandler(
+	e *echo.Echo,
+	baseHandler *handler.BaseHandler,
+	logger *log.Logger,
+	usecase *usecase.ContributeUsecase,
+	app *usecase.AppUsecase,
+) *ShareContributeHandler {
+	h := &ShareContributeHandler{
+		BaseHandler: baseHandler,
+		logger:      logger.WithModule("handler.share.contribute"),
+		usecase:     usecase,
+		app:         app,
+	}
+
+	group := e.Group("share/v1/contribute",
+		func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				c.Response().Header().Set("Access-Control-Allow-Origin", "*")
+				c.Response().Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+				c.Response().Header().Set("Access-Control-Allow-Headers", "Content-Type, Origin, Accept")
+				if c.Request().Method == "OPTIONS" {
+					return c.NoContent(http.StatusOK)
+				}
+				return next(c)
+			}
+		}, h.ShareAuthMiddleware.Authorize)
+	group.POST("/submit", h.SubmitContribute)
+
+	return h
+}
+
+// SubmitContribute 提交贡献
+//
+//	@Summary		提交贡献
+//	@Description	提交新增或修改文档的贡献，需要在 Web 应用中开启贡献，审核通过后生效
+//	@Tags			share_contribute
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-KB-ID	header		string					true	"kb id"
+//	@Param			body	body		v1.ContributeSubmitReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.ContributeSubmitResp}
+//	@Router			/share/v1/contribute/submit [post]
+func (h *ShareContributeHandler) SubmitContribute(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	kbID := c.Request().Header.Get("X-KB-ID")
+	if kbID == "" {
+		return h.NewResponseWithError(c, "kb_id is required", nil)
+	}
+
+	var req v1.ContributeSubmitReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	appInfo, err := h.app.GetAppDetailByKBIDAndAppType(ctx, kbID, domain.AppTypeWeb)
+	if err != nil {
+		return h.NewResponseWithError(c, "app info is not found", err)
+	}
+	if !appInfo.Settings.ContributeSettings.IsEnable {
+		return h.NewResponseWithError(c, "contribution is not enabled", nil)
+	}
+	if !h.Captcha.ValidateToken(ctx, req.CaptchaToken) {
+		return h.NewResponseWithError(c, "failed to validate captcha token", nil)
+	}
+
+	id, err := h.usecase.Submit(ctx, kbID, &req, domain.GetAuthID(c), c.RealIP())
+	if err != nil {
+		h.logger.Error("submit contribution failed", log.String("kb_id", kbID), log.Error(err))
+		return h.NewResponseWithError(c, "submit contribution failed", err)
+	}
+	return h.NewResponseWithData(c, v1.ContributeSubmitResp{ID: id})
+}
@@ -0,0 +1,210 @@
+package share
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+const (
+	mcpServerName    = "PandaWiki"
+	mcpServerVersion = "1.0.0"
+
+	mcpDefaultDocsToolName = "get_docs"
+	mcpDefaultDocsToolDesc = "为解决用户的问题从知识库中检索文档"
+	mcpGetDocToolName      = "get_doc_detail"
+	mcpDocTreeToolName     = "get_doc_tree"
+)
+
+type mcpRemoteIPKey struct{}
+
+// mcpServerEntry is the MCP server of a knowledge base, rebuilt when the docs tool settings change
+type mcpServerEntry struct {
+	toolSettings domain.MCPToolSettings
+	server       *server.StreamableHTTPServer
+}
+
+// ShareMCPHandler serves the Streamable HTTP MCP server of the MCP server app at /mcp
+type ShareMCPHandler struct {
+	*handler.BaseHandler
+	logger     *log.Logger
+	appUsecase *usecase.AppUsecase
+	mcpUsecase *usecase.MCPUsecase
+
+	mu      sync.Mutex
+	servers map[string]*mcpServerEntry
+}
+
+func NewShareMCPHandler(
+	e *echo.Echo,
+	baseHandler *handler.BaseHandler,
+	logger *log.Logger,
+	appUsecase *usecase.AppUsecase,
+	mcpUsecase *usecase.MCPUsecase,
+) *ShareMCPHandler {
+	h := &ShareMCPHandler{
+		BaseHandler: baseHandler,
+		logger:      logger.WithModule("handler.share.mcp"),
+		appUsecase:  appUsecase,
+		mcpUsecase:  mcpUsecase,
+		servers:     make(map[string]*mcpServerEntry),
+	}
+
+	e.Match([]string{http.MethodGet, http.MethodPost, http.MethodDelete}, "/mcp", h.ServeMCP)
+	return h
+}
+
+// ServeMCP MCP server
+//
+//	@Summary		ServeMCP
+//	@Description	Streamable HTTP MCP server of the knowledge base, offers tools to search, read and list the documents
+//	@Tags			share_mcp
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-KB-ID	header	string	true	"Knowledge Base ID"
+//	@Router			/mcp [post]
+func (h *ShareMCPHandler) ServeMCP(c echo.Context) error {
+	kbID := c.Request().Header.Get("X-KB-ID")
+	if kbID == "" {
+		return c.String(http.StatusBadRequest, "X-KB-ID header is required")
+	}
+	appInfo, err := h.appUsecase.GetMCPServerAppInfo(c.Request().Context(), kbID)
+	if err != nil {
+		h.logger.Error("get mcp server app info failed", log.Error(err), log.String("kb_id", kbID))
+		return c.String(http.StatusInternalServerError, "get mcp server app info failed")
+	}
+	settings := appInfo.Settings.MCPServerSettings
+	if !settings.IsEnabled {
+		return c.String(http.StatusForbidden, "MCP Server is not enabled")
+	}
+	if settings.SampleAuth.Enabled {
+		token, found := strings.CutPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+		if !found || token != settings.SampleAuth.Password {
+			return c.String(http.StatusUnauthorized, "Invalid Authorization key")
+		}
+	}
+
+	ctx := context.WithValue(c.Request().Context(), mcpRemoteIPKey{}, c.RealIP())
+	h.getServer(kbID, settings.DocsToolSettings).ServeHTTP(c.Response(), c.Request().WithContext(ctx))
+	return nil
+}
+
+func (h *ShareMCPHandler) getServer(kbID string, toolSettings domain.MCPToolSettings) *server.StreamableHTTPServer {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if entry, ok := h.servers[kbID]; ok && entry.toolSettings == toolSettings {
+		return entry.server
+	}
+	entry := &mcpServerEntry{
+		toolSettings: toolSettings,
+		server:       server.NewStreamableHTTPServer(h.newMCPServer(kbID, toolSettings)),
+	}
+	h.servers[kbID] = entry
+	return entry.server
+}
+
+func (h *ShareMCPHandler) newMCPServer(kbID string, toolSettings domain.MCPToolSettings) *server.MCPServer {
+	hooks := &server.Hooks{}
+	hooks.AddAfterInitialize(func(ctx context.Context, id any, req *mcp.InitializeRequest, result *mcp.InitializeResult) {
+		call := h.newMCPCall(ctx, kbID)
+		call.InitializeReq, _ = json.Marshal(req)
+		call.InitializeResp, _ = json.Marshal(result)
+		h.mcpUsecase.RecordCall(ctx, call)
+	})
+	hooks.AddAfterCallTool(func(ctx context.Context, id any, req *mcp.CallToolRequest, result *mcp.CallToolResult) {
+		call := h.newMCPCall(ctx, kbID)
+		call.ToolCallReq, _ = json.Marshal(req)
+		if resp, err := json.Marshal(result); err == nil {
+			call.ToolCallResp = string(resp)
+		}
+		h.mcpUsecase.RecordCall(ctx, call)
+	})
+
+	s := server.NewMCPServer(mcpServerName, mcpServerVersion,
+		server.WithToolCapabilities(false),
+		server.WithHooks(hooks),
+	)
+
+	docsToolName := strings.TrimSpace(toolSettings.Name)
+	if docsToolName == "" {
+		docsToolName = mcpDefaultDocsToolName
+	}
+	docsToolDesc := strings.TrimSpace(toolSettings.Desc)
+	if docsToolDesc == "" {
+		docsToolDesc = mcpDefaultDocsToolDesc
+	}
+	s.AddTool(mcp.NewTool(docsToolName,
+		mcp.WithDescription(docsToolDesc),
+		mcp.WithString("query", mcp.Required(), mcp.Description("用于检索文档的问题或关键词")),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		query, err := req.RequireString("query")
+		if err != nil || strings.TrimSpace(query) == "" {
+			return mcp.NewToolResultError("参数错误，query 不能为空"), nil
+		}
+		result, err := h.mcpUsecase.SearchDocs(ctx, kbID, query)
+		if err != nil {
+			h.logger.Error("search docs failed", log.Error(err), log.String("kb_id", kbID))
+			return mcp.NewToolResultError("检索知识库失败"), nil
+		}
+		return mcp.NewToolResultText(result), nil
+	})
+
+	s.AddTool(mcp.NewTool(mcpGetDocToolName,
+		mcp.WithDescription("根据文档 ID 获取知识库中已发布文档的完整内容"),
+		mcp.WithString("id", mcp.Required(), mcp.Description("文档 ID")),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, err := req.RequireString("id")
+		if err != nil || id == "" {
+			return mcp.NewToolResultError("参数错误，id 不能为空"), nil
+		}
+		doc, err := h.mcpUsecase.GetDoc(ctx, kbID, id)
+		if err != nil {
+			h.logger.Warn("get doc failed", log.Error(err), log.String("kb_id", kbID), log.String("id", id))
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(doc), nil
+	})
+
+	s.AddTool(mcp.NewTool(mcpDocTreeToolName,
+		mcp.WithDescription("获取知识库的文档目录树，可指定文件夹 ID 只获取其下的文档"),
+		mcp.WithString("parent_id", mcp.Description("文件夹 ID，为空时返回整个目录树")),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		tree, err := h.mcpUsecase.GetDocTree(ctx, kbID, req.GetString("parent_id", ""))
+		if err != nil {
+			h.logger.Error("get doc tree failed", log.Error(err), log.String("kb_id", kbID))
+			return mcp.NewToolResultError("获取文档目录失败"), nil
+		}
+		treeJSON, err := json.Marshal(tree)
+		if err != nil {
+			return mcp.NewToolResultError("获取文档目录失败"), nil
+		}
+		return mcp.NewToolResultText(string(treeJSON)), nil
+	})
+
+	return s
+}
+
+func (h *ShareMCPHandler) newMCPCall(ctx context.Context, kbID string) *domain.MCPCall {
+	call := &domain.MCPCall{KBID: kbID}
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		call.MCPSessionID = session.SessionID()
+	}
+	if remoteIP, ok := ctx.Value(mcpRemoteIPKey{}).(string); ok {
+		call.RemoteIP = remoteIP
+	}
+	return call
+}
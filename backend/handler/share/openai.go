@@ -0,0 +1,210 @@
+package share
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+// ShareOpenAIHandler serves the OpenAI compatible models and embeddings endpoints of the OpenAI API app,
+// so that SDKs configured with the base url /share/v1 work besides chat completions
+type ShareOpenAIHandler struct {
+	*handler.BaseHandler
+	logger           *log.Logger
+	appUsecase       *usecase.AppUsecase
+	openAIAPIUsecase *usecase.OpenAIAPIUsecase
+	chatRateLimit    *usecase.ChatRateLimitUsecase
+	tokenBudget      *usecase.TokenBudgetUsecase
+}
+
+func NewShareOpenAIHandler(
+	e *echo.Echo,
+	baseHandler *handler.BaseHandler,
+	logger *log.Logger,
+	appUsecase *usecase.AppUsecase,
+	openAIAPIUsecase *usecase.OpenAIAPIUsecase,
+	chatRateLimit *usecase.ChatRateLimitUsecase,
+	tokenBudget *usecase.TokenBudgetUsecase,
+) *ShareOpenAIHandler {
+	h := &ShareOpenAIHandler{
+		BaseHandler:      baseHandler,
+		logger:           logger.WithModule("handler.share.openai"),
+		appUsecase:       appUsecase,
+		openAIAPIUsecase: openAIAPIUsecase,
+		chatRateLimit:    chatRateLimit,
+		tokenBudget:      tokenBudget,
+	}
+
+	share := e.Group("share/v1",
+		func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				c.Response().Header().Set("Access-Control-Allow-Origin", "*")
+				c.Response().Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+				c.Response().Header().Set("Access-Control-Allow-Headers", "Content-Type, Origin, Accept, Authorization")
+				if c.Request().Method == "OPTIONS" {
+					return c.NoContent(http.StatusOK)
+				}
+				return next(c)
+			}
+		})
+	share.GET("/models", h.ListModels, h.authorize)
+	share.GET("/models/:model", h.GetModel, h.authorize)
+	share.POST("/embeddings", h.CreateEmbeddings, h.authorize)
+	return h
+}
+
+// authorize checks the API key of the OpenAI API app as the chat completions route does
+func (h *ShareOpenAIHandler) authorize(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		kbID := c.Request().Header.Get("X-KB-ID")
+		if kbID == "" {
+			return sendOpenAIError(c, "X-KB-ID header is required", "invalid_request_error")
+		}
+		if errMsg, errType := validateOpenAIAPIKey(c, h.appUsecase, kbID); errMsg != "" {
+			return sendOpenAIError(c, errMsg, errType)
+		}
+		return next(c)
+	}
+}
+
+// ListModels OpenAI API compatible models
+//
+//	@Summary		ListModels
+//	@Description	OpenAI API compatible models endpoint, the knowledge base is listed as the only model
+//	@Tags			share_openai
+//	@Produce		json
+//	@Param			X-KB-ID	header		string	true	"Knowledge Base ID"
+//	@Success		200		{object}	domain.OpenAIModelList
+//	@Failure		400		{object}	domain.OpenAIErrorResponse
+//	@Router			/share/v1/models [get]
+func (h *ShareOpenAIHandler) ListModels(c echo.Context) error {
+	models, err := h.openAIAPIUsecase.ListModels(c.Request().Context(), c.Request().Header.Get("X-KB-ID"))
+	if err != nil {
+		h.logger.Error("list models failed", log.Error(err))
+		return sendOpenAIError(c, "list models failed", "internal_error")
+	}
+	return c.JSON(http.StatusOK, models)
+}
+
+// GetModel OpenAI API compatible model
+//
+//	@Summary		GetModel
+//	@Description	OpenAI API compatible model endpoint, the id of the knowledge base model is the knowledge base id
+//	@Tags			share_openai
+//	@Produce		json
+//	@Param			X-KB-ID	header		string	true	"Knowledge Base ID"
+//	@Param			model	path		string	true	"model id"
+//	@Success		200		{object}	domain.OpenAIModel
+//	@Failure		404		{object}	domain.OpenAIErrorResponse
+//	@Router			/share/v1/models/{model} [get]
+func (h *ShareOpenAIHandler) GetModel(c echo.Context) error {
+	model, err := h.openAIAPIUsecase.GetModel(c.Request().Context(), c.Request().Header.Get("X-KB-ID"))
+	if err != nil {
+		h.logger.Error("get model failed", log.Error(err))
+		return sendOpenAIError(c, "get model failed", "internal_error")
+	}
+	if model.ID != c.Param("model") {
+		return sendOpenAIError(c, "The model does not exist", "not_found")
+	}
+	return c.JSON(http.StatusOK, model)
+}
+
+// CreateEmbeddings OpenAI API compatible embeddings
+//
+//	@Summary		CreateEmbeddings
+//	@Description	OpenAI API compatible embeddings endpoint, the input is embedded by the embedding model of the system whatever model is requested, it shares the rate limit and token budget of chat completions
+//	@Tags			share_openai
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-KB-ID	header		string							true	"Knowledge Base ID"
+//	@Param			request	body		domain.OpenAIEmbeddingsRequest	true	"OpenAI API request"
+//	@Success		200		{object}	domain.OpenAIEmbeddingsResponse
+//	@Failure		400		{object}	domain.OpenAIErrorResponse
+//	@Failure		429		{object}	domain.OpenAIErrorResponse
+//	@Router			/share/v1/embeddings [post]
+func (h *ShareOpenAIHandler) CreateEmbeddings(c echo.Context) error {
+	var req domain.OpenAIEmbeddingsRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("parse OpenAI embeddings request failed", log.Error(err))
+		return sendOpenAIError(c, "parse request failed", "invalid_request_error")
+	}
+	if err := c.Validate(&req); err != nil {
+		h.logger.Error("validate OpenAI embeddings request failed", log.Error(err))
+		return sendOpenAIError(c, "validate request failed", "invalid_request_error")
+	}
+
+	ctx := c.Request().Context()
+	kbID := c.Request().Header.Get("X-KB-ID")
+	if allowed, retryAfter := h.chatRateLimit.Check(ctx, kbID, c.RealIP(), 0, ""); !allowed {
+		c.Response().Header().Set("Retry-After", retryAfterSeconds(retryAfter))
+		return sendOpenAIError(c, "Rate limit exceeded, please retry later", "rate_limit_exceeded")
+	}
+	// 与对话一样，预算检查失败时不拦截请求
+	if budget, err := h.tokenBudget.CheckBudget(ctx, kbID, domain.AppTypeOpenAIAPI); err != nil {
+		h.logger.Error("check token budget failed", log.Error(err))
+	} else if budget != nil {
+		return sendOpenAIError(c, fmt.Sprintf("The %s token budget is used up", budget.Period), "insufficient_quota")
+	}
+
+	resp, err := h.openAIAPIUsecase.CreateEmbeddings(ctx, &req)
+	if err != nil {
+		h.logger.Error("create embeddings failed", log.Error(err))
+		return sendOpenAIError(c, "create embeddings failed", "internal_error")
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// validateOpenAIAPIKey checks the OpenAI API app of the knowledge base is enabled and the request carries its secret key,
+// returns the error message and OpenAI error type if not
+func validateOpenAIAPIKey(c echo.Context, appUsecase *usecase.AppUsecase, kbID string) (string, string) {
+	appBot, err := appUsecase.GetOpenAIAPIAppInfo(c.Request().Context(), kbID)
+	if err != nil {
+		return err.Error(), "internal_error"
+	}
+	if !appBot.Settings.OpenAIAPIBotSettings.IsEnabled {
+		return "API Bot is not enabled", "forbidden"
+	}
+
+	secretKeyHeader := c.Request().Header.Get("Authorization")
+	if secretKeyHeader == "" {
+		return "Authorization header is required", "invalid_request_error"
+	}
+	secretKey, found := strings.CutPrefix(secretKeyHeader, "Bearer ")
+	if !found {
+		return "Invalid Authorization key format", "invalid_request_error"
+	}
+	if appBot.Settings.OpenAIAPIBotSettings.SecretKey != secretKey {
+		return "Invalid Authorization key", "unauthorized"
+	}
+	return "", ""
+}
+
+// sendOpenAIError responds the OpenAI error with the status code of its type
+func sendOpenAIError(c echo.Context, message, errorType string) error {
+	status := http.StatusBadRequest
+	switch errorType {
+	case "unauthorized":
+		status = http.StatusUnauthorized
+	case "forbidden":
+		status = http.StatusForbidden
+	case "not_found":
+		status = http.StatusNotFound
+	case "rate_limit_exceeded", "insufficient_quota":
+		status = http.StatusTooManyRequests
+	case "internal_error":
+		status = http.StatusInternalServerError
+	}
+	return c.JSON(status, domain.OpenAIErrorResponse{
+		Error: domain.OpenAIError{
+			Message: message,
+			Type:    errorType,
+		},
+	})
+}
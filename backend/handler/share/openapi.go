@@ -43,9 +43,22 @@ func NewOpenapiV1Handler(
 
 	OpenapiGroup.Any("/github/callback", h.GitHubCallback)
 
+	// saml单点登录
+	OpenapiGroup.GET("/saml/metadata/:kb_id", h.SAMLMetadata)
+	OpenapiGroup.POST("/saml/acs/:kb_id", h.SAMLCallback)
+
 	// lark机器人
 	OpenapiGroup.POST("/lark/bot/:kb_id", h.LarkBot)
 
+	// slack机器人
+	OpenapiGroup.POST("/slack/bot/:kb_id", h.SlackBot)
+
+	// teams及其他Bot Framework渠道机器人
+	OpenapiGroup.POST("/teams/bot/:kb_id", h.TeamsBot)
+
+	// telegram机器人 webhook模式
+	OpenapiGroup.POST("/telegram/bot/:kb_id", h.TelegramBot)
+
 	return h
 }
 
@@ -83,6 +96,57 @@ func (h *OpenapiV1Handler) GitHubCallback(c echo.Context) error {
 	return c.Redirect(http.StatusFound, redirectUrl)
 }
 
+// SAMLMetadata SAML SP元数据
+//
+//	@Tags			ShareOpenapi
+//	@Summary		SAML SP元数据
+//	@Description	用于在 IdP 中注册应用的 SP 元数据
+//	@ID				v1-SAMLMetadata
+//	@Produce		xml
+//	@Param			kb_id	path	string	true	"知识库ID"
+//	@Success		200		{string}	string
+//	@Router			/share/v1/openapi/saml/metadata/{kb_id} [get]
+func (h *OpenapiV1Handler) SAMLMetadata(c echo.Context) error {
+	metadata, err := h.authUseCase.GetSAMLMetadata(c.Request().Context(), c.Param("kb_id"))
+	if err != nil {
+		return h.NewResponseWithError(c, "get saml metadata failed", err)
+	}
+	return c.Blob(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
+
+// SAMLCallback SAML断言消费地址
+//
+//	@Tags			ShareOpenapi
+//	@Summary		SAML断言消费地址
+//	@Description	接收 IdP 以 HTTP-POST 方式发送的 SAMLResponse
+//	@ID				v1-SAMLCallback
+//	@Accept			x-www-form-urlencoded
+//	@Param			kb_id			path		string	true	"知识库ID"
+//	@Param			SAMLResponse	formData	string	true	"SAMLResponse"
+//	@Param			RelayState		formData	string	true	"RelayState"
+//	@Success		302
+//	@Router			/share/v1/openapi/saml/acs/{kb_id} [post]
+func (h *OpenapiV1Handler) SAMLCallback(c echo.Context) error {
+	ctx := context.WithValue(c.Request().Context(), consts.ContextKeyEdition, consts.GetLicenseEdition(c))
+
+	kbID := c.Param("kb_id")
+	if kbID == "" {
+		return h.NewResponseWithError(c, "kb_id is required", nil)
+	}
+
+	auth, redirectUrl, err := h.authUseCase.SAMLCallback(ctx, kbID, c.Request())
+	if err != nil {
+		h.logger.Error("handle saml callback failed", log.Error(err), log.String("kb_id", kbID))
+		return h.NewResponseWithError(c, "handle callback failed", err)
+	}
+
+	if err := h.authUseCase.SaveNewSession(c, auth); err != nil {
+		return h.NewResponseWithError(c, "save session failed", err)
+	}
+
+	return c.Redirect(http.StatusFound, redirectUrl)
+}
+
 // LarkBot Lark机器人请求
 //
 //	@Tags			ShareOpenapi
@@ -155,3 +219,145 @@ func (h *OpenapiV1Handler) LarkBot(c echo.Context) error {
 
 	return c.JSONBlob(eventResp.StatusCode, eventResp.Body)
 }
+
+// SlackBot Slack机器人请求
+//
+//	@Tags			ShareOpenapi
+//	@Summary		Slack机器人请求
+//	@Description	Slack机器人 Events API 回调, 使用 Socket Mode 时无需配置
+//	@ID				v1-SlackBot
+//	@Accept			json
+//	@Produce		json
+//	@Param			kb_id	path		string	true	"知识库ID"
+//	@Success		200		{object}	domain.PWResponse
+//	@Router			/share/v1/openapi/slack/bot/{kb_id} [post]
+func (h *OpenapiV1Handler) SlackBot(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	kbID := c.Param("kb_id")
+	if kbID == "" {
+		h.logger.Error("kb_id is required")
+		return h.NewResponseWithError(c, "kb_id is required", nil)
+	}
+
+	appInfo, err := h.appCase.GetAppDetailByKBIDAndAppType(ctx, kbID, domain.AppTypeSlackBot)
+	if err != nil {
+		h.logger.Error("failed to get app detail", log.Error(err), log.String("kb_id", kbID))
+		return h.NewResponseWithError(c, "failed to get app detail", err)
+	}
+
+	if appInfo.Settings.SlackBotSettings.IsEnabled == nil || !*appInfo.Settings.SlackBotSettings.IsEnabled {
+		h.logger.Error("slack bot is not enabled")
+		return h.NewResponseWithError(c, "slack bot is not enabled", nil)
+	}
+
+	client, ok := h.appCase.GetSlackBotClient(appInfo.ID)
+	if !ok {
+		h.logger.Error("slack bot is not running", log.String("kb_id", kbID))
+		return h.NewResponseWithError(c, "slack bot is not running", nil)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		h.logger.Error("failed to read request body", log.Error(err))
+		return h.NewResponseWithError(c, "failed to read request body", err)
+	}
+	defer c.Request().Body.Close()
+
+	status, resp := client.HandleEvent(c.Request().Header, body)
+	return c.Blob(status, echo.MIMETextPlainCharsetUTF8, resp)
+}
+
+// TeamsBot Teams机器人请求
+//
+//	@Tags			ShareOpenapi
+//	@Summary		Teams机器人请求
+//	@Description	Bot Framework 消息接口, 用于 Microsoft Teams 及其他 Bot Framework 渠道
+//	@ID				v1-TeamsBot
+//	@Accept			json
+//	@Produce		json
+//	@Param			kb_id	path		string	true	"知识库ID"
+//	@Success		200		{object}	domain.PWResponse
+//	@Router			/share/v1/openapi/teams/bot/{kb_id} [post]
+func (h *OpenapiV1Handler) TeamsBot(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	kbID := c.Param("kb_id")
+	if kbID == "" {
+		h.logger.Error("kb_id is required")
+		return h.NewResponseWithError(c, "kb_id is required", nil)
+	}
+
+	appInfo, err := h.appCase.GetAppDetailByKBIDAndAppType(ctx, kbID, domain.AppTypeTeamsBot)
+	if err != nil {
+		h.logger.Error("failed to get app detail", log.Error(err), log.String("kb_id", kbID))
+		return h.NewResponseWithError(c, "failed to get app detail", err)
+	}
+
+	if appInfo.Settings.TeamsBotSettings.IsEnabled == nil || !*appInfo.Settings.TeamsBotSettings.IsEnabled {
+		h.logger.Error("teams bot is not enabled")
+		return h.NewResponseWithError(c, "teams bot is not enabled", nil)
+	}
+
+	client, ok := h.appCase.GetTeamsBotClient(appInfo.ID)
+	if !ok {
+		h.logger.Error("teams bot is not running", log.String("kb_id", kbID))
+		return h.NewResponseWithError(c, "teams bot is not running", nil)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		h.logger.Error("failed to read request body", log.Error(err))
+		return h.NewResponseWithError(c, "failed to read request body", err)
+	}
+	defer c.Request().Body.Close()
+
+	return c.NoContent(client.HandleActivity(ctx, c.Request().Header.Get("Authorization"), body))
+}
+
+// TelegramBot Telegram机器人请求
+//
+//	@Tags			ShareOpenapi
+//	@Summary		Telegram机器人请求
+//	@Description	Telegram 机器人 webhook 模式的回调接口
+//	@ID				v1-TelegramBot
+//	@Accept			json
+//	@Produce		json
+//	@Param			kb_id	path		string	true	"知识库ID"
+//	@Success		200		{object}	domain.PWResponse
+//	@Router			/share/v1/openapi/telegram/bot/{kb_id} [post]
+func (h *OpenapiV1Handler) TelegramBot(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	kbID := c.Param("kb_id")
+	if kbID == "" {
+		h.logger.Error("kb_id is required")
+		return h.NewResponseWithError(c, "kb_id is required", nil)
+	}
+
+	appInfo, err := h.appCase.GetAppDetailByKBIDAndAppType(ctx, kbID, domain.AppTypeTelegramBot)
+	if err != nil {
+		h.logger.Error("failed to get app detail", log.Error(err), log.String("kb_id", kbID))
+		return h.NewResponseWithError(c, "failed to get app detail", err)
+	}
+
+	if appInfo.Settings.TelegramBotSettings.IsEnabled == nil || !*appInfo.Settings.TelegramBotSettings.IsEnabled {
+		h.logger.Error("telegram bot is not enabled")
+		return h.NewResponseWithError(c, "telegram bot is not enabled", nil)
+	}
+
+	client, ok := h.appCase.GetTelegramBotClient(appInfo.ID)
+	if !ok {
+		h.logger.Error("telegram bot is not running", log.String("kb_id", kbID))
+		return h.NewResponseWithError(c, "telegram bot is not running", nil)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		h.logger.Error("failed to read request body", log.Error(err))
+		return h.NewResponseWithError(c, "failed to read request body", err)
+	}
+	defer c.Request().Body.Close()
+
+	return c.NoContent(client.HandleWebhook(c.Request().Header, body))
+}
@@ -19,6 +19,9 @@ type ShareHandler struct {
 	ShareCaptchaHandler      *ShareCaptchaHandler
 	OpenapiV1Handler         *OpenapiV1Handler
 	ShareCommonHandler       *ShareCommonHandler
+	ShareContributeHandler   *ShareContributeHandler
+	ShareOpenAIHandler       *ShareOpenAIHandler
+	ShareMCPHandler          *ShareMCPHandler
 }
 
 var ProviderSet = wire.NewSet(
@@ -36,6 +39,9 @@ var ProviderSet = wire.NewSet(
 	NewShareCaptchaHandler,
 	NewShareCommonHandler,
 	NewOpenapiV1Handler,
+	NewShareContributeHandler,
+	NewShareOpenAIHandler,
+	NewShareMCPHandler,
 
 	wire.Struct(new(ShareHandler), "*"),
 )
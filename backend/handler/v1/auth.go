@@ -36,6 +36,7 @@ func NewAuthV1Handler(
 	AuthGroup.GET("/get", h.OpenAuthGet)
 	AuthGroup.POST("/set", h.OpenAuthSet)
 	AuthGroup.DELETE("/delete", h.OpenAuthDelete)
+	AuthGroup.POST("/ldap/sync", h.LDAPGroupSync)
 
 	return h
 }
@@ -130,3 +131,34 @@ func (h *AuthV1Handler) OpenAuthDelete(c echo.Context) error {
 
 	return h.NewResponseWithData(c, nil)
 }
+
+// LDAPGroupSync 同步LDAP组
+//
+//	@Tags			Auth
+//	@Summary		同步LDAP组
+//	@Description	立即将配置的LDAP组及其嵌套的子组同步为用户组
+//	@ID				v1-LDAPGroupSync
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.LDAPGroupSyncReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.LDAPGroupSyncResp}
+//	@Router			/api/v1/auth/ldap/sync [post]
+func (h *AuthV1Handler) LDAPGroupSync(c echo.Context) error {
+
+	var req v1.LDAPGroupSyncReq
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	resp, err := h.authUseCase.SyncLDAPGroups(c.Request().Context(), req.KBID)
+	if err != nil {
+		return h.NewResponseWithError(c, "failed to sync ldap groups", err)
+	}
+
+	return h.NewResponseWithData(c, resp)
+}
@@ -0,0 +1,185 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/contribute/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/middleware"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+type ContributeHandler struct {
+	*handler.BaseHandler
+	logger  *log.Logger
+	auth    middleware.AuthMiddleware
+	usecase *usecase.ContributeUsecase
+}
+
+func NewContributeHandler(e *echo.Echo, baseHandler *handler.BaseHandler, logger *log.Logger, auth middleware.AuthMiddleware,
+	usecase *usecase.ContributeUsecase) *ContributeHandler {
+	h := &ContributeHandler{
+		BaseHandler: baseHandler,
+		logger:      logger.WithModule("handler.v1.contribute"),
+		auth:        auth,
+		usecase:     usecase,
+	}
+
+	group := e.Group("/api/v1/contribute", h.auth.Authorize, h.auth.ValidateKBUserPerm(consts.UserKBPermissionDocManage))
+	group.GET("/list", h.ContributeList)
+	group.GET("/detail", h.ContributeDetail)
+	group.GET("/diff", h.ContributeDiff)
+	group.POST("/approve", h.ContributeApprove)
+	group.POST("/reject", h.ContributeReject)
+
+	return h
+}
+
+// ContributeList 贡献列表
+//
+//	@Summary		贡献列表
+//	@Description	贡献列表，可按审核状态筛选
+//	@Tags			contribute
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.ContributeListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.ContributeListResp}
+//	@Router			/api/v1/contribute/list [get]
+func (h *ContributeHandler) ContributeList(c echo.Context) error {
+	var req v1.ContributeListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.usecase.GetList(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get contribute list failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// ContributeDetail 贡献详情
+//
+//	@Summary		贡献详情
+//	@Description	贡献详情，修改类型同时返回文档当前的名称和内容
+//	@Tags			contribute
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.ContributeDetailReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.ContributeDetailResp}
+//	@Router			/api/v1/contribute/detail [get]
+func (h *ContributeHandler) ContributeDetail(c echo.Context) error {
+	var req v1.ContributeDetailReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.usecase.GetDetail(c.Request().Context(), req.KbId, req.ID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get contribute detail failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// ContributeDiff 贡献对比
+//
+//	@Summary		贡献对比
+//	@Description	贡献内容与文档当前内容的逐行对比，rows 为左右对照的行，新增类型与空文档对比
+//	@Tags			contribute
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.ContributeDiffReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.ContributeDiffResp}
+//	@Router			/api/v1/contribute/diff [get]
+func (h *ContributeHandler) ContributeDiff(c echo.Context) error {
+	var req v1.ContributeDiffReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.usecase.GetDiff(c.Request().Context(), req.KbId, req.ID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get contribute diff failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// ContributeApprove 通过贡献
+//
+//	@Summary		通过贡献
+//	@Description	通过贡献并应用到文档，新增类型会在 parent_id 目录下创建文档
+//	@Tags			contribute
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.ContributeApproveReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.ContributeApproveResp}
+//	@Router			/api/v1/contribute/approve [post]
+func (h *ContributeHandler) ContributeApprove(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req v1.ContributeApproveReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+
+	nodeID, err := h.usecase.Approve(ctx, &req, authInfo.UserId, domain.GetBaseEditionLimitation(ctx).MaxNode)
+	if err != nil {
+		if errors.Is(err, domain.ErrMaxNodeLimitReached) {
+			return h.NewResponseWithError(c, "已达到最大文档数量限制，请升级到更高版本", nil)
+		}
+		h.logger.Error("approve contribution failed", log.String("id", req.ID), log.Error(err))
+		return h.NewResponseWithError(c, "approve contribution failed", err)
+	}
+	return h.NewResponseWithData(c, v1.ContributeApproveResp{NodeID: nodeID})
+}
+
+// ContributeReject 拒绝贡献
+//
+//	@Summary		拒绝贡献
+//	@Description	拒绝贡献，可填写拒绝原因
+//	@Tags			contribute
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.ContributeRejectReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/contribute/reject [post]
+func (h *ContributeHandler) ContributeReject(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req v1.ContributeRejectReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+	if err := h.usecase.Reject(ctx, &req, authInfo.UserId); err != nil {
+		return h.NewResponseWithError(c, "reject contribution failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
@@ -1,6 +1,9 @@
 package v1
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/labstack/echo/v4"
 
 	v1 "github.com/chaitin/panda-wiki/api/conversation/v1"
@@ -31,6 +34,7 @@ func NewConversationHandler(echo *echo.Echo, baseHandler *handler.BaseHandler, l
 	group.GET("/detail", handler.GetConversationDetail)
 	group.GET("/message/list", handler.GetMessageFeedBackList)
 	group.GET("/message/detail", handler.GetMessageDetail)
+	group.GET("/export", handler.ExportConversation)
 
 	return handler
 }
@@ -142,3 +146,46 @@ func (h *ConversationHandler) GetMessageDetail(c echo.Context) error {
 
 	return h.NewResponseWithData(c, message)
 }
+
+// ExportConversation 导出对话
+//
+//	@Summary		导出对话
+//	@Description	以 CSV 或 JSONL 流式导出问答记录，每行一个回答，包含问题、token 用量、模型、用户反馈、引用文档、应用类型和 IP 归属地
+//	@Tags			conversation
+//	@Accept			json
+//	@Produce		text/csv,application/x-ndjson
+//	@Security		bearerAuth
+//	@Param			param	query		v1.ConversationExportReq	true	"para"
+//	@Success		200		{file}		binary
+//	@Router			/api/v1/conversation/export [get]
+func (h *ConversationHandler) ExportConversation(c echo.Context) error {
+	var req v1.ConversationExportReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	if req.Format == "" {
+		req.Format = domain.ConversationExportFormatCSV
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if req.Format == domain.ConversationExportFormatJSONL {
+		contentType = "application/x-ndjson; charset=utf-8"
+	}
+	filename := fmt.Sprintf("conversations-%s-%s.%s", req.KbID, time.Now().Format("20060102150405"), req.Format)
+	c.Response().Header().Set(echo.HeaderContentType, contentType)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	if err := h.usecase.Export(c.Request().Context(), &req, c.Response()); err != nil {
+		h.logger.Error("export conversations failed", log.String("kb_id", req.KbID), log.Error(err))
+		// the file is partially sent, the error can only be told by the broken download
+		if c.Response().Committed {
+			return nil
+		}
+		c.Response().Header().Del(echo.HeaderContentDisposition)
+		return h.NewResponseWithError(c, "export conversations failed", err)
+	}
+	return nil
+}
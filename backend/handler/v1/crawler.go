@@ -14,10 +14,11 @@ import (
 
 type CrawlerHandler struct {
 	*handler.BaseHandler
-	logger      *log.Logger
-	usecase     *usecase.CrawlerUsecase
-	config      *config.Config
-	fileUsecase *usecase.FileUsecase
+	logger              *log.Logger
+	usecase             *usecase.CrawlerUsecase
+	config              *config.Config
+	fileUsecase         *usecase.FileUsecase
+	subscriptionUsecase *usecase.ImportSubscriptionUsecase
 }
 
 func NewCrawlerHandler(echo *echo.Echo,
@@ -27,13 +28,15 @@ func NewCrawlerHandler(echo *echo.Echo,
 	config *config.Config,
 	usecase *usecase.CrawlerUsecase,
 	fileUsecase *usecase.FileUsecase,
+	subscriptionUsecase *usecase.ImportSubscriptionUsecase,
 ) *CrawlerHandler {
 	h := &CrawlerHandler{
-		BaseHandler: baseHandler,
-		logger:      logger.WithModule("handler.v1.crawler"),
-		config:      config,
-		usecase:     usecase,
-		fileUsecase: fileUsecase,
+		BaseHandler:         baseHandler,
+		logger:              logger.WithModule("handler.v1.crawler"),
+		config:              config,
+		usecase:             usecase,
+		fileUsecase:         fileUsecase,
+		subscriptionUsecase: subscriptionUsecase,
 	}
 	group := echo.Group("/api/v1/crawler", auth.Authorize)
 	group.POST("/parse", h.CrawlerParse)
@@ -41,6 +44,14 @@ func NewCrawlerHandler(echo *echo.Echo,
 	group.GET("/result", h.CrawlerResult)
 	group.POST("/results", h.CrawlerResults)
 
+	// import subscriptions, re-sync imported sources on schedule
+	subscriptionGroup := group.Group("/subscription", auth.ValidateKBUserPerm(consts.UserKBPermissionDocManage))
+	subscriptionGroup.GET("/list", h.SubscriptionList)
+	subscriptionGroup.POST("", h.SubscriptionCreate)
+	subscriptionGroup.PATCH("", h.SubscriptionUpdate)
+	subscriptionGroup.DELETE("", h.SubscriptionDelete)
+	subscriptionGroup.POST("/sync", h.SubscriptionSync)
+
 	return h
 }
 
@@ -0,0 +1,157 @@
+package v1
+
+import (
+	"slices"
+
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/crawler/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+)
+
+// SubscriptionList 导入源订阅列表
+//
+//	@Summary		导入源订阅列表
+//	@Description	导入源订阅列表，包含最近一次同步结果
+//	@Tags			crawler
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.SubscriptionListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=[]v1.SubscriptionListItem}
+//	@Router			/api/v1/crawler/subscription/list [get]
+func (h *CrawlerHandler) SubscriptionList(c echo.Context) error {
+	var req v1.SubscriptionListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.subscriptionUsecase.GetList(c.Request().Context(), req.KbID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get subscription list failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// SubscriptionCreate 创建导入源订阅
+//
+//	@Summary		创建导入源订阅
+//	@Description	创建导入源订阅，按计划重新抓取并同步到文档
+//	@Tags			crawler
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.SubscriptionCreateReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.SubscriptionCreateResp}
+//	@Router			/api/v1/crawler/subscription [post]
+func (h *CrawlerHandler) SubscriptionCreate(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req v1.SubscriptionCreateReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	if !slices.Contains(domain.ImportSubscriptionSources, req.CrawlerSource) {
+		return h.NewResponseWithError(c, "crawler source can not be subscribed", nil)
+	}
+	if req.CrawlerSource == consts.CrawlerSourceFeishu {
+		if req.FeishuSetting.AppID == "" || req.FeishuSetting.AppSecret == "" || req.FeishuSetting.UserAccessToken == "" {
+			return h.NewResponseWithError(c, "validate request param feishu failed", nil)
+		}
+	} else if req.Key == "" {
+		return h.NewResponseWithError(c, "validate request param key failed", nil)
+	}
+
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+
+	id, err := h.subscriptionUsecase.Create(ctx, &req, authInfo.UserId)
+	if err != nil {
+		h.logger.Error("create subscription failed", log.Error(err))
+		return h.NewResponseWithError(c, "create subscription failed", err)
+	}
+	return h.NewResponseWithData(c, v1.SubscriptionCreateResp{ID: id})
+}
+
+// SubscriptionUpdate 更新导入源订阅
+//
+//	@Summary		更新导入源订阅
+//	@Description	更新导入源订阅的名称、同步计划、自动发布和启用状态
+//	@Tags			crawler
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.SubscriptionUpdateReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/crawler/subscription [patch]
+func (h *CrawlerHandler) SubscriptionUpdate(c echo.Context) error {
+	var req v1.SubscriptionUpdateReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	if err := h.subscriptionUsecase.Update(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "update subscription failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
+
+// SubscriptionDelete 删除导入源订阅
+//
+//	@Summary		删除导入源订阅
+//	@Description	删除导入源订阅，已导入的文档会保留
+//	@Tags			crawler
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.SubscriptionDeleteReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/crawler/subscription [delete]
+func (h *CrawlerHandler) SubscriptionDelete(c echo.Context) error {
+	var req v1.SubscriptionDeleteReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	if err := h.subscriptionUsecase.Delete(c.Request().Context(), req.KbID, req.ID); err != nil {
+		return h.NewResponseWithError(c, "delete subscription failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
+
+// SubscriptionSync 立即同步导入源订阅
+//
+//	@Summary		立即同步导入源订阅
+//	@Description	立即同步导入源订阅，同步任务会在一分钟内开始
+//	@Tags			crawler
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.SubscriptionSyncReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/crawler/subscription/sync [post]
+func (h *CrawlerHandler) SubscriptionSync(c echo.Context) error {
+	var req v1.SubscriptionSyncReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	if err := h.subscriptionUsecase.TriggerSync(c.Request().Context(), req.KbID, req.ID); err != nil {
+		return h.NewResponseWithError(c, "sync subscription failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
@@ -0,0 +1,308 @@
+package v1
+
+import (
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/middleware"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+type EvalHandler struct {
+	*handler.BaseHandler
+	logger  *log.Logger
+	auth    middleware.AuthMiddleware
+	usecase *usecase.EvalUsecase
+}
+
+func NewEvalHandler(e *echo.Echo, baseHandler *handler.BaseHandler, logger *log.Logger, auth middleware.AuthMiddleware,
+	usecase *usecase.EvalUsecase) *EvalHandler {
+	h := &EvalHandler{
+		BaseHandler: baseHandler,
+		logger:      logger.WithModule("handler.v1.eval"),
+		auth:        auth,
+		usecase:     usecase,
+	}
+
+	group := e.Group("/api/v1/eval", h.auth.Authorize, h.auth.ValidateKBUserPerm(consts.UserKBPermissionFullControl))
+	group.GET("/set/list", h.EvalSetList)
+	group.POST("/set", h.EvalSetCreate)
+	group.GET("/set/detail", h.EvalSetDetail)
+	group.POST("/set/cases", h.EvalSetCasesAdd)
+	group.DELETE("/set", h.EvalSetDelete)
+	group.DELETE("/case", h.EvalCaseDelete)
+	group.POST("/run", h.EvalRunCreate)
+	group.GET("/run/list", h.EvalRunList)
+	group.GET("/run/detail", h.EvalRunDetail)
+	group.GET("/run/compare", h.EvalRunCompare)
+
+	return h
+}
+
+// EvalSetList 评测集列表
+//
+//	@Summary		评测集列表
+//	@Description	知识库的问答评测集列表
+//	@Tags			eval
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.EvalSetListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=[]v1.EvalSetListItem}
+//	@Router			/api/v1/eval/set/list [get]
+func (h *EvalHandler) EvalSetList(c echo.Context) error {
+	var req v1.EvalSetListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.usecase.GetSetList(c.Request().Context(), req.KbID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get eval set list failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// EvalSetCreate 上传评测集
+//
+//	@Summary		上传评测集
+//	@Description	上传一组问题，每个问题可以包含期望检索到的文档 ID 和参考答案
+//	@Tags			eval
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.EvalSetCreateReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.EvalSetCreateResp}
+//	@Router			/api/v1/eval/set [post]
+func (h *EvalHandler) EvalSetCreate(c echo.Context) error {
+	var req v1.EvalSetCreateReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	id, err := h.usecase.CreateSet(c.Request().Context(), &req)
+	if err != nil {
+		h.logger.Error("create eval set failed", log.Error(err))
+		return h.NewResponseWithError(c, "create eval set failed", err)
+	}
+	return h.NewResponseWithData(c, v1.EvalSetCreateResp{ID: id})
+}
+
+// EvalSetDetail 评测集详情
+//
+//	@Summary		评测集详情
+//	@Description	评测集及其全部问题
+//	@Tags			eval
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.EvalSetDetailReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.EvalSetDetailResp}
+//	@Router			/api/v1/eval/set/detail [get]
+func (h *EvalHandler) EvalSetDetail(c echo.Context) error {
+	var req v1.EvalSetDetailReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.usecase.GetSetDetail(c.Request().Context(), req.KbID, req.ID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get eval set detail failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// EvalSetCasesAdd 追加评测问题
+//
+//	@Summary		追加评测问题
+//	@Description	向已有评测集追加问题
+//	@Tags			eval
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.EvalSetCasesAddReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/eval/set/cases [post]
+func (h *EvalHandler) EvalSetCasesAdd(c echo.Context) error {
+	var req v1.EvalSetCasesAddReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	if err := h.usecase.AddCases(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "add eval cases failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
+
+// EvalSetDelete 删除评测集
+//
+//	@Summary		删除评测集
+//	@Description	删除评测集及其问题和评测记录
+//	@Tags			eval
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.EvalSetDeleteReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/eval/set [delete]
+func (h *EvalHandler) EvalSetDelete(c echo.Context) error {
+	var req v1.EvalSetDeleteReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	if err := h.usecase.DeleteSet(c.Request().Context(), req.KbID, req.ID); err != nil {
+		return h.NewResponseWithError(c, "delete eval set failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
+
+// EvalCaseDelete 删除评测问题
+//
+//	@Summary		删除评测问题
+//	@Description	删除评测集中的一个问题，已有的评测记录不受影响
+//	@Tags			eval
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.EvalCaseDeleteReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/eval/case [delete]
+func (h *EvalHandler) EvalCaseDelete(c echo.Context) error {
+	var req v1.EvalCaseDeleteReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	if err := h.usecase.DeleteCase(c.Request().Context(), req.KbID, req.CaseID); err != nil {
+		return h.NewResponseWithError(c, "delete eval case failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
+
+// EvalRunCreate 发起评测
+//
+//	@Summary		发起评测
+//	@Description	使用当前的对话模型在后台逐个回答评测集中的问题，不会产生对话记录，统计 recall@k、引用准确率和模型评分
+//	@Tags			eval
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.EvalRunCreateReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.EvalRunCreateResp}
+//	@Router			/api/v1/eval/run [post]
+func (h *EvalHandler) EvalRunCreate(c echo.Context) error {
+	var req v1.EvalRunCreateReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	ctx := c.Request().Context()
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+	id, err := h.usecase.CreateRun(ctx, &req, authInfo.UserId)
+	if err != nil {
+		h.logger.Error("create eval run failed", log.Error(err))
+		return h.NewResponseWithError(c, "create eval run failed", err)
+	}
+	return h.NewResponseWithData(c, v1.EvalRunCreateResp{ID: id})
+}
+
+// EvalRunList 评测记录列表
+//
+//	@Summary		评测记录列表
+//	@Description	评测记录列表，包含每次评测的模型、提示词和汇总指标
+//	@Tags			eval
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.EvalRunListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.EvalRunListResp}
+//	@Router			/api/v1/eval/run/list [get]
+func (h *EvalHandler) EvalRunList(c echo.Context) error {
+	var req v1.EvalRunListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.usecase.GetRunList(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get eval run list failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// EvalRunDetail 评测详情
+//
+//	@Summary		评测详情
+//	@Description	评测的进度、汇总指标和每个问题的回答与指标
+//	@Tags			eval
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.EvalRunDetailReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.EvalRunDetailResp}
+//	@Router			/api/v1/eval/run/detail [get]
+func (h *EvalHandler) EvalRunDetail(c echo.Context) error {
+	var req v1.EvalRunDetailReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.usecase.GetRunDetail(c.Request().Context(), req.KbID, req.ID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get eval run detail failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// EvalRunCompare 对比评测
+//
+//	@Summary		对比评测
+//	@Description	按问题对比同一评测集的两次评测结果
+//	@Tags			eval
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.EvalRunCompareReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.EvalRunCompareResp}
+//	@Router			/api/v1/eval/run/compare [get]
+func (h *EvalHandler) EvalRunCompare(c echo.Context) error {
+	var req v1.EvalRunCompareReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.usecase.CompareRuns(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "compare eval runs failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
@@ -0,0 +1,129 @@
+package v1
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+)
+
+// ExportKnowledgeBase 导出知识库
+//
+//	@Summary		导出知识库
+//	@Description	导出知识库为 zip 包，包含文档树、引用的文件、应用配置和用户组
+//	@Tags			knowledge_base
+//	@Accept			json
+//	@Produce		application/zip
+//	@Security		bearerAuth
+//	@Param			body	body		v1.KBExportReq	true	"para"
+//	@Success		200		{file}		binary
+//	@Router			/api/v1/knowledge_base/export [post]
+func (h *KnowledgeBaseHandler) ExportKnowledgeBase(c echo.Context) error {
+	var req v1.KBExportReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	// write to a temp file first so that errors can still be returned as json
+	tmpFile, err := os.CreateTemp("", "kb-export-*.zip")
+	if err != nil {
+		return h.NewResponseWithError(c, "create temp file failed", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	if err := h.archiveUsecase.Export(c.Request().Context(), req.KBId, tmpFile); err != nil {
+		h.logger.Error("export knowledge base failed", log.String("kb_id", req.KBId), log.Error(err))
+		return h.NewResponseWithError(c, "export knowledge base failed", err)
+	}
+
+	filename := fmt.Sprintf("%s-%s.zip", req.KBId, time.Now().Format("20060102150405"))
+	return c.Attachment(tmpFile.Name(), filename)
+}
+
+// ImportKnowledgeBase 导入知识库
+//
+//	@Summary		导入知识库
+//	@Description	从导出的 zip 包导入文档树、文件、应用配置和用户组，使用新的 ID 创建，已发布的文档会重新发布并建立索引
+//	@Tags			knowledge_base
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			kb_id	formData	string	true	"Knowledge Base ID"
+//	@Param			file	formData	file	true	"Archive"
+//	@Success		200		{object}	domain.PWResponse{data=v1.KBImportResp}
+//	@Router			/api/v1/knowledge_base/import [post]
+func (h *KnowledgeBaseHandler) ImportKnowledgeBase(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req v1.KBImportReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		return h.NewResponseWithError(c, "failed to get file", err)
+	}
+
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+
+	if _, err := h.usecase.GetKnowledgeBase(ctx, req.KBId); err != nil {
+		return h.NewResponseWithError(c, "get knowledge base failed", err)
+	}
+
+	resp, err := h.archiveUsecase.Import(ctx, req.KBId, file, authInfo.UserId)
+	if err != nil {
+		h.logger.Error("import knowledge base failed", log.String("kb_id", req.KBId), log.Error(err))
+		return h.NewResponseWithError(c, "import knowledge base failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// ExportKnowledgeBaseStatic 导出知识库静态站点
+//
+//	@Summary		导出知识库静态站点
+//	@Description	将最新发布版本中无需登录即可访问的文档导出为只读静态站点 zip 包，包含目录导航、图片、搜索索引和 sitemap
+//	@Tags			knowledge_base
+//	@Accept			json
+//	@Produce		application/zip
+//	@Security		bearerAuth
+//	@Param			body	body		v1.KBExportReq	true	"para"
+//	@Success		200		{file}		binary
+//	@Router			/api/v1/knowledge_base/export/static [post]
+func (h *KnowledgeBaseHandler) ExportKnowledgeBaseStatic(c echo.Context) error {
+	var req v1.KBExportReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+
+	tmpFile, err := os.CreateTemp("", "kb-static-*.zip")
+	if err != nil {
+		return h.NewResponseWithError(c, "create temp file failed", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	if err := h.staticExportUsecase.Export(c.Request().Context(), req.KBId, tmpFile); err != nil {
+		h.logger.Error("export static site failed", log.String("kb_id", req.KBId), log.Error(err))
+		return h.NewResponseWithError(c, "export static site failed", err)
+	}
+
+	filename := fmt.Sprintf("%s-static-%s.zip", req.KBId, time.Now().Format("20060102150405"))
+	return c.Attachment(tmpFile.Name(), filename)
+}
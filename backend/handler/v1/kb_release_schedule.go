@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+)
+
+// CreateKBReleaseSchedule 创建定时发布
+//
+//	@Summary		创建定时发布
+//	@Description	在指定时间发布所选文档并创建知识库版本，由后台任务每分钟检查执行
+//	@Tags			knowledge_base
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.KBReleaseScheduleCreateReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.KBReleaseScheduleCreateResp}
+//	@Router			/api/v1/knowledge_base/release/schedule [post]
+func (h *KnowledgeBaseHandler) CreateKBReleaseSchedule(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req v1.KBReleaseScheduleCreateReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+	id, err := h.scheduleUsecase.Create(ctx, &req, authInfo.UserId)
+	if err != nil {
+		h.logger.Error("create kb release schedule failed", log.String("kb_id", req.KBId), log.Error(err))
+		return h.NewResponseWithError(c, "create kb release schedule failed", err)
+	}
+	return h.NewResponseWithData(c, v1.KBReleaseScheduleCreateResp{ID: id})
+}
+
+// GetKBReleaseScheduleList 定时发布列表
+//
+//	@Summary		定时发布列表
+//	@Description	定时发布列表，包含执行状态、生成的版本和失败原因
+//	@Tags			knowledge_base
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.KBReleaseScheduleListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=[]v1.KBReleaseScheduleListItem}
+//	@Router			/api/v1/knowledge_base/release/schedule/list [get]
+func (h *KnowledgeBaseHandler) GetKBReleaseScheduleList(c echo.Context) error {
+	var req v1.KBReleaseScheduleListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.scheduleUsecase.GetList(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get kb release schedule list failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// UpdateKBReleaseSchedule 修改定时发布
+//
+//	@Summary		修改定时发布
+//	@Description	修改待执行或执行失败的定时发布，修改后重新进入待执行状态
+//	@Tags			knowledge_base
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.KBReleaseScheduleUpdateReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/knowledge_base/release/schedule [patch]
+func (h *KnowledgeBaseHandler) UpdateKBReleaseSchedule(c echo.Context) error {
+	var req v1.KBReleaseScheduleUpdateReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	if err := h.scheduleUsecase.Update(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "update kb release schedule failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
+
+// CancelKBReleaseSchedule 取消定时发布
+//
+//	@Summary		取消定时发布
+//	@Description	取消待执行或执行失败的定时发布
+//	@Tags			knowledge_base
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.KBReleaseScheduleCancelReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/knowledge_base/release/schedule/cancel [post]
+func (h *KnowledgeBaseHandler) CancelKBReleaseSchedule(c echo.Context) error {
+	var req v1.KBReleaseScheduleCancelReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	if err := h.scheduleUsecase.Cancel(c.Request().Context(), req.KBId, req.ID); err != nil {
+		return h.NewResponseWithError(c, "cancel kb release schedule failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
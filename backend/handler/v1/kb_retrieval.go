@@ -0,0 +1,62 @@
+package v1
+
+import (
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+)
+
+// GetKBRetrievalSettings
+//
+//	@Summary		GetKBRetrievalSettings
+//	@Description	Get retrieval settings of knowledge base
+//	@Tags			knowledge_base
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			kb_id	query		string	true	"Knowledge Base ID"
+//	@Success		200		{object}	domain.PWResponse{data=v1.KBRetrievalSettingsResp}
+//	@Router			/api/v1/knowledge_base/retrieval [get]
+func (h *KnowledgeBaseHandler) GetKBRetrievalSettings(c echo.Context) error {
+	var req v1.KBRetrievalSettingsReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	resp, err := h.usecase.GetRetrievalSettings(c.Request().Context(), req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get retrieval settings failed", err)
+	}
+
+	return h.NewResponseWithData(c, resp)
+}
+
+// UpdateKBRetrievalSettings
+//
+//	@Summary		UpdateKBRetrievalSettings
+//	@Description	Update retrieval settings of knowledge base
+//	@Tags			knowledge_base
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.KBRetrievalSettingsUpdateReq	true	"Update Retrieval Settings Request"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/knowledge_base/retrieval [put]
+func (h *KnowledgeBaseHandler) UpdateKBRetrievalSettings(c echo.Context) error {
+	var req v1.KBRetrievalSettingsUpdateReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "validate request failed", err)
+	}
+
+	if err := h.usecase.UpdateRetrievalSettings(c.Request().Context(), req); err != nil {
+		return h.NewResponseWithError(c, "update retrieval settings failed", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
@@ -16,10 +16,13 @@ import (
 
 type KnowledgeBaseHandler struct {
 	*handler.BaseHandler
-	usecase    *usecase.KnowledgeBaseUsecase
-	llmUsecase *usecase.LLMUsecase
-	logger     *log.Logger
-	auth       middleware.AuthMiddleware
+	usecase             *usecase.KnowledgeBaseUsecase
+	llmUsecase          *usecase.LLMUsecase
+	archiveUsecase      *usecase.KBArchiveUsecase
+	staticExportUsecase *usecase.StaticExportUsecase
+	scheduleUsecase     *usecase.KBReleaseScheduleUsecase
+	logger              *log.Logger
+	auth                middleware.AuthMiddleware
 }
 
 func NewKnowledgeBaseHandler(
@@ -27,15 +30,21 @@ func NewKnowledgeBaseHandler(
 	echo *echo.Echo,
 	usecase *usecase.KnowledgeBaseUsecase,
 	llmUsecase *usecase.LLMUsecase,
+	archiveUsecase *usecase.KBArchiveUsecase,
+	staticExportUsecase *usecase.StaticExportUsecase,
+	scheduleUsecase *usecase.KBReleaseScheduleUsecase,
 	auth middleware.AuthMiddleware,
 	logger *log.Logger,
 ) *KnowledgeBaseHandler {
 	h := &KnowledgeBaseHandler{
-		BaseHandler: baseHandler,
-		logger:      logger.WithModule("handler.v1.knowledge_base"),
-		usecase:     usecase,
-		llmUsecase:  llmUsecase,
-		auth:        auth,
+		BaseHandler:         baseHandler,
+		logger:              logger.WithModule("handler.v1.knowledge_base"),
+		usecase:             usecase,
+		llmUsecase:          llmUsecase,
+		archiveUsecase:      archiveUsecase,
+		staticExportUsecase: staticExportUsecase,
+		scheduleUsecase:     scheduleUsecase,
+		auth:                auth,
 	}
 
 	group := echo.Group("/api/v1/knowledge_base", h.auth.Authorize)
@@ -56,6 +65,22 @@ func NewKnowledgeBaseHandler(
 	releaseGroup := group.Group("/release", h.auth.ValidateKBUserPerm(consts.UserKBPermissionDocManage))
 	releaseGroup.POST("", h.CreateKBRelease)
 	releaseGroup.GET("/list", h.GetKBReleaseList)
+	// scheduled releases, executed by the consumer cron
+	releaseGroup.POST("/schedule", h.CreateKBReleaseSchedule)
+	releaseGroup.GET("/schedule/list", h.GetKBReleaseScheduleList)
+	releaseGroup.PATCH("/schedule", h.UpdateKBReleaseSchedule)
+	releaseGroup.POST("/schedule/cancel", h.CancelKBReleaseSchedule)
+
+	// retrieval settings
+	retrievalGroup := group.Group("/retrieval", h.auth.ValidateKBUserPerm(consts.UserKBPermissionFullControl))
+	retrievalGroup.GET("", h.GetKBRetrievalSettings)
+	retrievalGroup.PUT("", h.UpdateKBRetrievalSettings)
+
+	// export & import
+	group.POST("/export", h.ExportKnowledgeBase, h.auth.ValidateKBUserPerm(consts.UserKBPermissionFullControl))
+	group.POST("/export/static", h.ExportKnowledgeBaseStatic, h.auth.ValidateKBUserPerm(consts.UserKBPermissionDocManage))
+	// kb_id of a multipart request can not be checked by ValidateKBUserPerm
+	group.POST("/import", h.ImportKnowledgeBase, h.auth.ValidateUserRole(consts.UserRoleAdmin))
 
 	return h
 }
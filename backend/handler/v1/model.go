@@ -101,6 +101,7 @@ func (h *ModelHandler) CreateModel(c echo.Context) error {
 		APIVersion: req.APIVersion,
 		Type:       req.Type,
 		IsActive:   true,
+		Priority:   req.Priority,
 		Parameters: param,
 	}
 	if err := h.usecase.Create(ctx, model); err != nil {
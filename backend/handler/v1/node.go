@@ -49,6 +49,11 @@ func NewNodeHandler(
 	group.GET("/recommend_nodes", h.RecommendNodes)
 	group.POST("/restudy", h.NodeRestudy)
 
+	// node release history
+	group.GET("/history", h.NodeHistoryList)
+	group.GET("/history/diff", h.NodeHistoryDiff)
+	group.POST("/history/restore", h.NodeHistoryRestore)
+
 	// node permission
 	group.GET("/permission", h.NodePermission)
 	group.PATCH("/permission/edit", h.NodePermissionEdit)
@@ -413,3 +418,95 @@ func (h *NodeHandler) NodeRestudy(c echo.Context) error {
 
 	return h.NewResponseWithData(c, nil)
 }
+
+// NodeHistoryList 文档历史版本列表
+//
+//	@Tags			Node
+//	@Summary		文档历史版本列表
+//	@Description	文档历史版本列表
+//	@ID				v1-NodeHistoryList
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.NodeHistoryListReq	true	"para"
+//	@Success		200		{object}	domain.Response{data=v1.NodeHistoryListResp}
+//	@Router			/api/v1/node/history [get]
+func (h *NodeHandler) NodeHistoryList(c echo.Context) error {
+	var req v1.NodeHistoryListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	resp, err := h.usecase.GetNodeHistoryList(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get node history list failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// NodeHistoryDiff 文档历史版本对比
+//
+//	@Tags			Node
+//	@Summary		文档历史版本对比
+//	@Description	对比两个发布版本，to_release_id 为空时与当前草稿对比，from_release_id 为空时与上一个发布版本对比
+//	@ID				v1-NodeHistoryDiff
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.NodeHistoryDiffReq	true	"para"
+//	@Success		200		{object}	domain.Response{data=v1.NodeHistoryDiffResp}
+//	@Router			/api/v1/node/history/diff [get]
+func (h *NodeHandler) NodeHistoryDiff(c echo.Context) error {
+	var req v1.NodeHistoryDiffReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	resp, err := h.usecase.GetNodeHistoryDiff(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get node history diff failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// NodeHistoryRestore 文档恢复到历史版本
+//
+//	@Tags			Node
+//	@Summary		文档恢复到历史版本
+//	@Description	将历史发布版本的内容恢复到文档草稿
+//	@ID				v1-NodeHistoryRestore
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	body		v1.NodeHistoryRestoreReq	true	"para"
+//	@Success		200		{object}	domain.Response{data=v1.NodeHistoryRestoreResp}
+//	@Router			/api/v1/node/history/restore [post]
+func (h *NodeHandler) NodeHistoryRestore(c echo.Context) error {
+	ctx := c.Request().Context()
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+
+	var req v1.NodeHistoryRestoreReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+
+	if err := h.usecase.RestoreNodeHistory(ctx, &req, authInfo.UserId); err != nil {
+		return h.NewResponseWithError(c, "restore node history failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
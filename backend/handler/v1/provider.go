@@ -21,6 +21,12 @@ type APIHandlers struct {
 	StatHandler          *StatHandler
 	CommentHandler       *CommentHandler
 	AuthV1Handler        *AuthV1Handler
+	WebhookHandler       *WebhookHandler
+	ContributeHandler    *ContributeHandler
+	EvalHandler          *EvalHandler
+	QuestionGapHandler   *QuestionGapHandler
+	TokenBudgetHandler   *TokenBudgetHandler
+	SCIMHandler          *SCIMHandler
 }
 
 var ProviderSet = wire.NewSet(
@@ -40,6 +46,12 @@ var ProviderSet = wire.NewSet(
 	NewStatHandler,
 	NewCommentHandler,
 	NewAuthV1Handler,
+	NewWebhookHandler,
+	NewContributeHandler,
+	NewEvalHandler,
+	NewQuestionGapHandler,
+	NewTokenBudgetHandler,
+	NewSCIMHandler,
 
 	wire.Struct(new(APIHandlers), "*"),
 )
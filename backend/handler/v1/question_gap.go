@@ -0,0 +1,180 @@
+package v1
+
+import (
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/conversation/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/middleware"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+type QuestionGapHandler struct {
+	*handler.BaseHandler
+	logger  *log.Logger
+	auth    middleware.AuthMiddleware
+	usecase *usecase.QuestionGapUsecase
+}
+
+func NewQuestionGapHandler(e *echo.Echo, baseHandler *handler.BaseHandler, logger *log.Logger, auth middleware.AuthMiddleware,
+	usecase *usecase.QuestionGapUsecase) *QuestionGapHandler {
+	h := &QuestionGapHandler{
+		BaseHandler: baseHandler,
+		logger:      logger.WithModule("handler.v1.question_gap"),
+		auth:        auth,
+		usecase:     usecase,
+	}
+
+	group := e.Group("/api/v1/question_gap", h.auth.Authorize)
+	group.POST("/analyze", h.QuestionGapAnalyze, h.auth.ValidateKBUserPerm(consts.UserKBPermissionDataOperate))
+	group.GET("/report/list", h.QuestionGapReportList, h.auth.ValidateKBUserPerm(consts.UserKBPermissionDataOperate))
+	group.GET("/report", h.QuestionGapReport, h.auth.ValidateKBUserPerm(consts.UserKBPermissionDataOperate))
+	group.GET("/cluster/detail", h.QuestionGapClusterDetail, h.auth.ValidateKBUserPerm(consts.UserKBPermissionDataOperate))
+	group.POST("/cluster/draft", h.QuestionGapDraft, h.auth.ValidateKBUserPerm(consts.UserKBPermissionDocManage))
+
+	return h
+}
+
+// QuestionGapAnalyze 分析未解决问题
+//
+//	@Summary		分析未解决问题
+//	@Description	在后台使用向量模型将最近未检索到文档、AI 无法回答或被点踩的问题聚类为主题，每天也会自动分析一次
+//	@Tags			question_gap
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.QuestionGapAnalyzeReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.QuestionGapAnalyzeResp}
+//	@Router			/api/v1/question_gap/analyze [post]
+func (h *QuestionGapHandler) QuestionGapAnalyze(c echo.Context) error {
+	var req v1.QuestionGapAnalyzeReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	ctx := c.Request().Context()
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+	id, err := h.usecase.Analyze(ctx, &req, authInfo.UserId)
+	if err != nil {
+		return h.NewResponseWithError(c, "analyze question gaps failed", err)
+	}
+	return h.NewResponseWithData(c, v1.QuestionGapAnalyzeResp{ID: id})
+}
+
+// QuestionGapReportList 分析记录列表
+//
+//	@Summary		分析记录列表
+//	@Description	未解决问题的分析记录列表
+//	@Tags			question_gap
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.QuestionGapReportListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.QuestionGapReportListResp}
+//	@Router			/api/v1/question_gap/report/list [get]
+func (h *QuestionGapHandler) QuestionGapReportList(c echo.Context) error {
+	var req v1.QuestionGapReportListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.usecase.GetReportList(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get question gap report list failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// QuestionGapReport 未解决问题报告
+//
+//	@Summary		未解决问题报告
+//	@Description	按问题数量排序的主题列表，包含各原因和各应用类型的问题数，不传 id 时返回最近一次成功的分析
+//	@Tags			question_gap
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.QuestionGapReportReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.QuestionGapReportResp}
+//	@Router			/api/v1/question_gap/report [get]
+func (h *QuestionGapHandler) QuestionGapReport(c echo.Context) error {
+	var req v1.QuestionGapReportReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.usecase.GetReport(c.Request().Context(), req.KbID, req.ID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get question gap report failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// QuestionGapClusterDetail 主题详情
+//
+//	@Summary		主题详情
+//	@Description	主题下的全部问题
+//	@Tags			question_gap
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.QuestionGapClusterDetailReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.QuestionGapClusterDetailResp}
+//	@Router			/api/v1/question_gap/cluster/detail [get]
+func (h *QuestionGapHandler) QuestionGapClusterDetail(c echo.Context) error {
+	var req v1.QuestionGapClusterDetailReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.usecase.GetClusterDetail(c.Request().Context(), req.KbID, req.ID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get question gap cluster detail failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// QuestionGapDraft 从主题创建草稿文档
+//
+//	@Summary		从主题创建草稿文档
+//	@Description	创建一篇列出主题下全部问题的未发布文档，供编辑补充解答
+//	@Tags			question_gap
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.QuestionGapDraftReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.QuestionGapDraftResp}
+//	@Router			/api/v1/question_gap/cluster/draft [post]
+func (h *QuestionGapHandler) QuestionGapDraft(c echo.Context) error {
+	var req v1.QuestionGapDraftReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	ctx := c.Request().Context()
+	authInfo := domain.GetAuthInfoFromCtx(ctx)
+	if authInfo == nil {
+		return h.NewResponseWithError(c, "authInfo not found in context", nil)
+	}
+	nodeID, err := h.usecase.CreateDraft(ctx, &req, authInfo.UserId, domain.GetBaseEditionLimitation(ctx).MaxNode)
+	if err != nil {
+		h.logger.Error("create draft node from question gap cluster failed", log.Error(err))
+		return h.NewResponseWithError(c, "create draft node failed", err)
+	}
+	return h.NewResponseWithData(c, v1.QuestionGapDraftResp{NodeID: nodeID})
+}
@@ -0,0 +1,388 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/middleware"
+	"github.com/chaitin/panda-wiki/pkg/scim"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+// SCIMHandler SCIM 2.0 服务，IdP 使用知识库的 API Token 同步知识库的用户和用户组
+type SCIMHandler struct {
+	*handler.BaseHandler
+	logger      *log.Logger
+	auth        middleware.AuthMiddleware
+	authUsecase *usecase.AuthUsecase
+}
+
+func NewSCIMHandler(e *echo.Echo, baseHandler *handler.BaseHandler, logger *log.Logger, auth middleware.AuthMiddleware, authUsecase *usecase.AuthUsecase) *SCIMHandler {
+	h := &SCIMHandler{
+		BaseHandler: baseHandler,
+		logger:      logger.WithModule("handler.v1.scim"),
+		auth:        auth,
+		authUsecase: authUsecase,
+	}
+
+	group := e.Group("/scim/v2", h.auth.Authorize, h.validateToken)
+	group.GET("/ServiceProviderConfig", h.GetServiceProviderConfig)
+	group.GET("/ResourceTypes", h.GetResourceTypes)
+
+	group.GET("/Users", h.ListUsers)
+	group.POST("/Users", h.CreateUser)
+	group.GET("/Users/:id", h.GetUser)
+	group.PUT("/Users/:id", h.ReplaceUser)
+	group.PATCH("/Users/:id", h.PatchUser)
+	group.DELETE("/Users/:id", h.DeleteUser)
+
+	group.GET("/Groups", h.ListGroups)
+	group.POST("/Groups", h.CreateGroup)
+	group.GET("/Groups/:id", h.GetGroup)
+	group.PUT("/Groups/:id", h.ReplaceGroup)
+	group.PATCH("/Groups/:id", h.PatchGroup)
+	group.DELETE("/Groups/:id", h.DeleteGroup)
+
+	return h
+}
+
+// validateToken 只允许具有完全控制权限的 API Token 调用，token 所属的知识库即同步的知识库
+func (h *SCIMHandler) validateToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		authInfo := domain.GetAuthInfoFromCtx(c.Request().Context())
+		if authInfo == nil || !authInfo.IsToken || authInfo.KBId == "" {
+			return h.scimResponse(c, http.StatusUnauthorized, scim.NewError(http.StatusUnauthorized, "", "api token is required"))
+		}
+		if authInfo.Permission != consts.UserKBPermissionFullControl {
+			return h.scimResponse(c, http.StatusForbidden, scim.NewError(http.StatusForbidden, "", "api token must have full control permission"))
+		}
+		return next(c)
+	}
+}
+
+func (h *SCIMHandler) kbID(c echo.Context) string {
+	return domain.GetAuthInfoFromCtx(c.Request().Context()).KBId
+}
+
+func (h *SCIMHandler) scimResponse(c echo.Context, status int, data any) error {
+	c.Response().Header().Set(echo.HeaderContentType, scim.ContentType)
+	return c.JSON(status, data)
+}
+
+func (h *SCIMHandler) scimError(c echo.Context, err error) error {
+	var scimErr *scim.Error
+	if errors.As(err, &scimErr) {
+		return h.scimResponse(c, scimErr.StatusCode(), scimErr)
+	}
+	h.logger.Error("scim request failed", log.String("path", c.Path()), log.Error(err))
+	return h.scimResponse(c, http.StatusInternalServerError, scim.NewError(http.StatusInternalServerError, "", "internal server error"))
+}
+
+// bindSCIM SCIM 请求的 Content-Type 为 application/scim+json，不能使用 c.Bind
+func bindSCIM(c echo.Context, v any) error {
+	if err := json.NewDecoder(c.Request().Body).Decode(v); err != nil {
+		return scim.NewError(http.StatusBadRequest, scim.ErrTypeInvalidSyntax, "invalid request body: "+err.Error())
+	}
+	return nil
+}
+
+// listParams 获取 filter、startIndex 和 count 参数
+func listParams(c echo.Context) (string, int, int) {
+	startIndex, err := strconv.Atoi(c.QueryParam("startIndex"))
+	if err != nil {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(c.QueryParam("count"))
+	if err != nil {
+		count = -1
+	}
+	return c.QueryParam("filter"), startIndex, count
+}
+
+// GetServiceProviderConfig
+//
+//	@Summary		SCIM ServiceProviderConfig
+//	@Description	SCIM 服务支持的功能
+//	@Tags			scim
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Success		200	{object}	scim.ServiceProviderConfig
+//	@Router			/scim/v2/ServiceProviderConfig [get]
+func (h *SCIMHandler) GetServiceProviderConfig(c echo.Context) error {
+	return h.scimResponse(c, http.StatusOK, scim.NewServiceProviderConfig(scim.MaxResults))
+}
+
+// GetResourceTypes
+//
+//	@Summary		SCIM ResourceTypes
+//	@Description	SCIM 服务支持的资源类型
+//	@Tags			scim
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Success		200	{object}	scim.ListResponse
+//	@Router			/scim/v2/ResourceTypes [get]
+func (h *SCIMHandler) GetResourceTypes(c echo.Context) error {
+	return h.scimResponse(c, http.StatusOK, scim.NewResourceTypes())
+}
+
+// ListUsers
+//
+//	@Summary		SCIM 用户列表
+//	@Description	获取知识库企业认证的用户，filter 只支持 eq，如 userName eq "alice"
+//	@Tags			scim
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			filter		query		string	false	"filter"
+//	@Param			startIndex	query		int		false	"start index, starts from 1"
+//	@Param			count		query		int		false	"count"
+//	@Success		200			{object}	scim.ListResponse
+//	@Router			/scim/v2/Users [get]
+func (h *SCIMHandler) ListUsers(c echo.Context) error {
+	filter, startIndex, count := listParams(c)
+	resp, err := h.authUsecase.ListSCIMUsers(c.Request().Context(), h.kbID(c), filter, startIndex, count)
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.scimResponse(c, http.StatusOK, resp)
+}
+
+// CreateUser
+//
+//	@Summary		SCIM 创建用户
+//	@Description	创建企业认证的用户，userName 需要与企业认证登录时的用户唯一标识一致
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		scim.User	true	"user"
+//	@Success		201		{object}	scim.User
+//	@Router			/scim/v2/Users [post]
+func (h *SCIMHandler) CreateUser(c echo.Context) error {
+	var req scim.User
+	if err := bindSCIM(c, &req); err != nil {
+		return h.scimError(c, err)
+	}
+	ctx := context.WithValue(c.Request().Context(), consts.ContextKeyEdition, consts.GetLicenseEdition(c))
+	user, err := h.authUsecase.CreateSCIMUser(ctx, h.kbID(c), &req)
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.scimResponse(c, http.StatusCreated, user)
+}
+
+// GetUser
+//
+//	@Summary		SCIM 获取用户
+//	@Description	SCIM 获取用户
+//	@Tags			scim
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			id	path		string	true	"user id"
+//	@Success		200	{object}	scim.User
+//	@Router			/scim/v2/Users/{id} [get]
+func (h *SCIMHandler) GetUser(c echo.Context) error {
+	user, err := h.authUsecase.GetSCIMUser(c.Request().Context(), h.kbID(c), c.Param("id"))
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.scimResponse(c, http.StatusOK, user)
+}
+
+// ReplaceUser
+//
+//	@Summary		SCIM 替换用户
+//	@Description	SCIM 替换用户，active 为 false 时禁用用户
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			id		path		string		true	"user id"
+//	@Param			body	body		scim.User	true	"user"
+//	@Success		200		{object}	scim.User
+//	@Router			/scim/v2/Users/{id} [put]
+func (h *SCIMHandler) ReplaceUser(c echo.Context) error {
+	var req scim.User
+	if err := bindSCIM(c, &req); err != nil {
+		return h.scimError(c, err)
+	}
+	user, err := h.authUsecase.ReplaceSCIMUser(c.Request().Context(), h.kbID(c), c.Param("id"), &req)
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.scimResponse(c, http.StatusOK, user)
+}
+
+// PatchUser
+//
+//	@Summary		SCIM 修改用户
+//	@Description	SCIM 修改用户，active 为 false 时禁用用户
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			id		path		string				true	"user id"
+//	@Param			body	body		scim.PatchRequest	true	"patch operations"
+//	@Success		200		{object}	scim.User
+//	@Router			/scim/v2/Users/{id} [patch]
+func (h *SCIMHandler) PatchUser(c echo.Context) error {
+	var req scim.PatchRequest
+	if err := bindSCIM(c, &req); err != nil {
+		return h.scimError(c, err)
+	}
+	user, err := h.authUsecase.PatchSCIMUser(c.Request().Context(), h.kbID(c), c.Param("id"), &req)
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.scimResponse(c, http.StatusOK, user)
+}
+
+// DeleteUser
+//
+//	@Summary		SCIM 删除用户
+//	@Description	删除用户并从所有用户组中移除
+//	@Tags			scim
+//	@Security		bearerAuth
+//	@Param			id	path	string	true	"user id"
+//	@Success		204
+//	@Router			/scim/v2/Users/{id} [delete]
+func (h *SCIMHandler) DeleteUser(c echo.Context) error {
+	if err := h.authUsecase.DeleteSCIMUser(c.Request().Context(), h.kbID(c), c.Param("id")); err != nil {
+		return h.scimError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListGroups
+//
+//	@Summary		SCIM 用户组列表
+//	@Description	获取 SCIM 同步的用户组，filter 只支持 eq，如 displayName eq "dev"
+//	@Tags			scim
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			filter		query		string	false	"filter"
+//	@Param			startIndex	query		int		false	"start index, starts from 1"
+//	@Param			count		query		int		false	"count"
+//	@Success		200			{object}	scim.ListResponse
+//	@Router			/scim/v2/Groups [get]
+func (h *SCIMHandler) ListGroups(c echo.Context) error {
+	filter, startIndex, count := listParams(c)
+	resp, err := h.authUsecase.ListSCIMGroups(c.Request().Context(), h.kbID(c), filter, startIndex, count)
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.scimResponse(c, http.StatusOK, resp)
+}
+
+// CreateGroup
+//
+//	@Summary		SCIM 创建用户组
+//	@Description	SCIM 创建用户组
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		scim.Group	true	"group"
+//	@Success		201		{object}	scim.Group
+//	@Router			/scim/v2/Groups [post]
+func (h *SCIMHandler) CreateGroup(c echo.Context) error {
+	var req scim.Group
+	if err := bindSCIM(c, &req); err != nil {
+		return h.scimError(c, err)
+	}
+	group, err := h.authUsecase.CreateSCIMGroup(c.Request().Context(), h.kbID(c), &req)
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.scimResponse(c, http.StatusCreated, group)
+}
+
+// GetGroup
+//
+//	@Summary		SCIM 获取用户组
+//	@Description	SCIM 获取用户组
+//	@Tags			scim
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			id	path		string	true	"group id"
+//	@Success		200	{object}	scim.Group
+//	@Router			/scim/v2/Groups/{id} [get]
+func (h *SCIMHandler) GetGroup(c echo.Context) error {
+	group, err := h.authUsecase.GetSCIMGroup(c.Request().Context(), h.kbID(c), c.Param("id"))
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.scimResponse(c, http.StatusOK, group)
+}
+
+// ReplaceGroup
+//
+//	@Summary		SCIM 替换用户组
+//	@Description	SCIM 替换用户组的名称和成员
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			id		path		string		true	"group id"
+//	@Param			body	body		scim.Group	true	"group"
+//	@Success		200		{object}	scim.Group
+//	@Router			/scim/v2/Groups/{id} [put]
+func (h *SCIMHandler) ReplaceGroup(c echo.Context) error {
+	var req scim.Group
+	if err := bindSCIM(c, &req); err != nil {
+		return h.scimError(c, err)
+	}
+	group, err := h.authUsecase.ReplaceSCIMGroup(c.Request().Context(), h.kbID(c), c.Param("id"), &req)
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.scimResponse(c, http.StatusOK, group)
+}
+
+// PatchGroup
+//
+//	@Summary		SCIM 修改用户组
+//	@Description	SCIM 修改用户组的名称或增删成员
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			id		path		string				true	"group id"
+//	@Param			body	body		scim.PatchRequest	true	"patch operations"
+//	@Success		200		{object}	scim.Group
+//	@Router			/scim/v2/Groups/{id} [patch]
+func (h *SCIMHandler) PatchGroup(c echo.Context) error {
+	var req scim.PatchRequest
+	if err := bindSCIM(c, &req); err != nil {
+		return h.scimError(c, err)
+	}
+	group, err := h.authUsecase.PatchSCIMGroup(c.Request().Context(), h.kbID(c), c.Param("id"), &req)
+	if err != nil {
+		return h.scimError(c, err)
+	}
+	return h.scimResponse(c, http.StatusOK, group)
+}
+
+// DeleteGroup
+//
+//	@Summary		SCIM 删除用户组
+//	@Description	删除用户组及其文档权限
+//	@Tags			scim
+//	@Security		bearerAuth
+//	@Param			id	path	string	true	"group id"
+//	@Success		204
+//	@Router			/scim/v2/Groups/{id} [delete]
+func (h *SCIMHandler) DeleteGroup(c echo.Context) error {
+	if err := h.authUsecase.DeleteSCIMGroup(c.Request().Context(), h.kbID(c), c.Param("id")); err != nil {
+		return h.scimError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
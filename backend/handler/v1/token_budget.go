@@ -0,0 +1,140 @@
+package v1
+
+import (
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/middleware"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+type TokenBudgetHandler struct {
+	*handler.BaseHandler
+	logger  *log.Logger
+	auth    middleware.AuthMiddleware
+	usecase *usecase.TokenBudgetUsecase
+}
+
+func NewTokenBudgetHandler(e *echo.Echo, baseHandler *handler.BaseHandler, logger *log.Logger, auth middleware.AuthMiddleware,
+	usecase *usecase.TokenBudgetUsecase) *TokenBudgetHandler {
+	h := &TokenBudgetHandler{
+		BaseHandler: baseHandler,
+		logger:      logger.WithModule("handler.v1.token_budget"),
+		auth:        auth,
+		usecase:     usecase,
+	}
+
+	group := e.Group("/api/v1/token_budget", h.auth.Authorize)
+	group.GET("/list", h.TokenBudgetList, h.auth.ValidateKBUserPerm(consts.UserKBPermissionFullControl))
+	group.POST("", h.TokenBudgetSet, h.auth.ValidateKBUserPerm(consts.UserKBPermissionFullControl))
+	group.DELETE("", h.TokenBudgetDelete, h.auth.ValidateKBUserPerm(consts.UserKBPermissionFullControl))
+	// the usage of all knowledge bases can be queried
+	group.GET("/usage", h.TokenUsage, h.auth.ValidateUserRole(consts.UserRoleAdmin))
+
+	return h
+}
+
+// TokenBudgetList token 预算列表
+//
+//	@Summary		token 预算列表
+//	@Description	知识库的 token 预算及当前周期已使用的 token 数
+//	@Tags			token_budget
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.TokenBudgetListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=[]v1.TokenBudgetListItem}
+//	@Router			/api/v1/token_budget/list [get]
+func (h *TokenBudgetHandler) TokenBudgetList(c echo.Context) error {
+	var req v1.TokenBudgetListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.usecase.GetList(c.Request().Context(), req.KbID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get token budget list failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// TokenBudgetSet 设置 token 预算
+//
+//	@Summary		设置 token 预算
+//	@Description	设置知识库或某类应用每天或每月可使用的 token 数，同一应用类型和周期已有预算时更新额度，用完后对话会提示额度不足
+//	@Tags			token_budget
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.TokenBudgetSetReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/token_budget [post]
+func (h *TokenBudgetHandler) TokenBudgetSet(c echo.Context) error {
+	var req v1.TokenBudgetSetReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	if err := h.usecase.Set(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "set token budget failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
+
+// TokenBudgetDelete 删除 token 预算
+//
+//	@Summary		删除 token 预算
+//	@Description	删除 token 预算
+//	@Tags			token_budget
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.TokenBudgetDeleteReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/token_budget [delete]
+func (h *TokenBudgetHandler) TokenBudgetDelete(c echo.Context) error {
+	var req v1.TokenBudgetDeleteReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	if err := h.usecase.Delete(c.Request().Context(), req.KbID, req.ID); err != nil {
+		return h.NewResponseWithError(c, "delete token budget failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
+
+// TokenUsage token 使用统计
+//
+//	@Summary		token 使用统计
+//	@Description	按知识库、应用和天统计回答消耗的 token，不传 kb_id 时统计全部知识库
+//	@Tags			token_budget
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.TokenUsageReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.TokenUsageResp}
+//	@Router			/api/v1/token_budget/usage [get]
+func (h *TokenBudgetHandler) TokenUsage(c echo.Context) error {
+	var req v1.TokenUsageReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.usecase.GetUsage(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get token usage failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
@@ -2,6 +2,7 @@ package v1
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -45,6 +46,13 @@ func NewUserHandler(e *echo.Echo, baseHandler *handler.BaseHandler, logger *log.
 	group := e.Group("/api/v1/user")
 	group.POST("/login", h.Login)
 
+	// OIDC 登录
+	group.GET("/oidc/config", h.GetOIDCLoginConfig)
+	group.POST("/oidc/authorize", h.OIDCAuthorize)
+	group.POST("/oidc/callback", h.OIDCCallback)
+	group.GET("/oidc/setting", h.GetOIDCSetting, h.auth.Authorize, h.auth.ValidateUserRole(consts.UserRoleAdmin))
+	group.PUT("/oidc/setting", h.UpdateOIDCSetting, h.auth.Authorize, h.auth.ValidateUserRole(consts.UserRoleAdmin))
+
 	group.GET("", h.GetUserInfo, h.auth.Authorize)
 	group.GET("/list", h.ListUsers, h.auth.Authorize)
 	group.GET("/guest/list", h.ListGuestUsers, h.auth.Authorize, h.auth.ValidateUserRole(consts.UserRoleAdmin))
@@ -135,6 +143,9 @@ func (h *UserHandler) Login(c echo.Context) error {
 	}
 
 	token, err := h.usecase.VerifyUserAndGenerateToken(ctx, req)
+	if errors.Is(err, usecase.ErrPasswordLoginDisabled) {
+		return h.NewResponseWithError(c, "密码登录已禁用，请使用 OIDC 登录", err)
+	}
 	if err != nil {
 		h.rateLimiter.LockAttempt(ctx, ip)
 		return h.NewResponseWithError(c, "用户名或密码错误", err)
@@ -149,6 +160,111 @@ func (h *UserHandler) Login(c echo.Context) error {
 	return h.NewResponseWithData(c, v1.LoginResp{Token: token})
 }
 
+// GetOIDCLoginConfig
+//
+//	@Summary		GetOIDCLoginConfig
+//	@Description	获取登录页的 OIDC 登录配置
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	domain.PWResponse{data=v1.OIDCLoginConfigResp}
+//	@Router			/api/v1/user/oidc/config [get]
+func (h *UserHandler) GetOIDCLoginConfig(c echo.Context) error {
+	resp, err := h.usecase.GetAdminOIDCLoginConfig(c.Request().Context())
+	if err != nil {
+		return h.NewResponseWithError(c, "failed to get oidc login config", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// OIDCAuthorize
+//
+//	@Summary		OIDCAuthorize
+//	@Description	获取跳转到 OIDC IdP 登录的地址
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	domain.PWResponse{data=v1.OIDCAuthorizeResp}
+//	@Router			/api/v1/user/oidc/authorize [post]
+func (h *UserHandler) OIDCAuthorize(c echo.Context) error {
+	url, err := h.usecase.GenerateAdminOIDCAuthUrl(c.Request().Context())
+	if err != nil {
+		return h.NewResponseWithError(c, "failed to generate oidc auth url", err)
+	}
+	return h.NewResponseWithData(c, v1.OIDCAuthorizeResp{Url: url})
+}
+
+// OIDCCallback
+//
+//	@Summary		OIDCCallback
+//	@Description	使用 IdP 回调的 code 和 state 完成登录
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		v1.OIDCCallbackReq	true	"OIDCCallback Request"
+//	@Success		200		{object}	domain.PWResponse{data=v1.LoginResp}
+//	@Router			/api/v1/user/oidc/callback [post]
+func (h *UserHandler) OIDCCallback(c echo.Context) error {
+	var req v1.OIDCCallbackReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+
+	token, err := h.usecase.AdminOIDCCallback(c.Request().Context(), req, consts.GetLicenseEdition(c))
+	if errors.Is(err, usecase.ErrOIDCUserNotAllowed) {
+		return h.NewResponseWithError(c, "当前用户没有登录权限", err)
+	}
+	if err != nil {
+		return h.NewResponseWithError(c, "OIDC 登录失败", err)
+	}
+
+	return h.NewResponseWithData(c, v1.LoginResp{Token: token})
+}
+
+// GetOIDCSetting
+//
+//	@Summary		GetOIDCSetting
+//	@Description	获取管理后台 OIDC 登录设置
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	domain.PWResponse{data=domain.AdminOIDCSetting}
+//	@Router			/api/v1/user/oidc/setting [get]
+func (h *UserHandler) GetOIDCSetting(c echo.Context) error {
+	setting, err := h.usecase.GetAdminOIDCSetting(c.Request().Context())
+	if err != nil {
+		return h.NewResponseWithError(c, "failed to get oidc setting", err)
+	}
+	return h.NewResponseWithData(c, setting)
+}
+
+// UpdateOIDCSetting
+//
+//	@Summary		UpdateOIDCSetting
+//	@Description	更新管理后台 OIDC 登录设置
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		domain.AdminOIDCSetting	true	"UpdateOIDCSetting Request"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/user/oidc/setting [put]
+func (h *UserHandler) UpdateOIDCSetting(c echo.Context) error {
+	var req domain.AdminOIDCSetting
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "invalid request", err)
+	}
+
+	if err := h.usecase.UpdateAdminOIDCSetting(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "failed to update oidc setting", err)
+	}
+
+	return h.NewResponseWithData(c, nil)
+}
+
 // GetUserInfo
 //
 //	@Summary		GetUser
@@ -0,0 +1,247 @@
+package v1
+
+import (
+	"github.com/labstack/echo/v4"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/handler"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/middleware"
+	"github.com/chaitin/panda-wiki/usecase"
+)
+
+type WebhookHandler struct {
+	*handler.BaseHandler
+	logger  *log.Logger
+	auth    middleware.AuthMiddleware
+	usecase *usecase.WebhookUsecase
+}
+
+func NewWebhookHandler(e *echo.Echo, baseHandler *handler.BaseHandler, logger *log.Logger, auth middleware.AuthMiddleware,
+	usecase *usecase.WebhookUsecase) *WebhookHandler {
+	h := &WebhookHandler{
+		BaseHandler: baseHandler,
+		logger:      logger.WithModule("handler.v1.webhook"),
+		auth:        auth,
+		usecase:     usecase,
+	}
+
+	group := e.Group("/api/v1/webhook", h.auth.Authorize, h.auth.ValidateKBUserPerm(consts.UserKBPermissionFullControl))
+	group.GET("/list", h.WebhookList)
+	group.POST("", h.WebhookCreate)
+	group.PATCH("", h.WebhookUpdate)
+	group.DELETE("", h.WebhookDelete)
+	group.POST("/test", h.WebhookTest)
+	group.GET("/delivery/list", h.WebhookDeliveryList)
+	group.GET("/delivery/detail", h.WebhookDeliveryDetail)
+	group.POST("/delivery/retry", h.WebhookDeliveryRetry)
+
+	return h
+}
+
+// WebhookList webhook 列表
+//
+//	@Summary		webhook 列表
+//	@Description	知识库的 webhook 列表
+//	@Tags			webhook
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.WebhookListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=[]v1.WebhookListItem}
+//	@Router			/api/v1/webhook/list [get]
+func (h *WebhookHandler) WebhookList(c echo.Context) error {
+	var req v1.WebhookListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.usecase.GetList(c.Request().Context(), req.KbID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get webhook list failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// WebhookCreate 创建 webhook
+//
+//	@Summary		创建 webhook
+//	@Description	创建 webhook，订阅的事件发生时会以 POST 请求推送，请求体使用 secret 进行 HMAC-SHA256 签名，签名内容为 "<X-PandaWiki-Timestamp>.<body>"，结果放在 X-PandaWiki-Signature 中
+//	@Tags			webhook
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.WebhookCreateReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.WebhookCreateResp}
+//	@Router			/api/v1/webhook [post]
+func (h *WebhookHandler) WebhookCreate(c echo.Context) error {
+	var req v1.WebhookCreateReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	resp, err := h.usecase.Create(c.Request().Context(), &req)
+	if err != nil {
+		h.logger.Error("create webhook failed", log.Error(err))
+		return h.NewResponseWithError(c, "create webhook failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// WebhookUpdate 更新 webhook
+//
+//	@Summary		更新 webhook
+//	@Description	更新 webhook 的名称、地址、密钥、订阅事件和启用状态
+//	@Tags			webhook
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.WebhookUpdateReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/webhook [patch]
+func (h *WebhookHandler) WebhookUpdate(c echo.Context) error {
+	var req v1.WebhookUpdateReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	if err := h.usecase.Update(c.Request().Context(), &req); err != nil {
+		return h.NewResponseWithError(c, "update webhook failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
+
+// WebhookDelete 删除 webhook
+//
+//	@Summary		删除 webhook
+//	@Description	删除 webhook 及其推送记录
+//	@Tags			webhook
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.WebhookDeleteReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/webhook [delete]
+func (h *WebhookHandler) WebhookDelete(c echo.Context) error {
+	var req v1.WebhookDeleteReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	if err := h.usecase.Delete(c.Request().Context(), req.KbID, req.ID); err != nil {
+		return h.NewResponseWithError(c, "delete webhook failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
+
+// WebhookTest 测试 webhook
+//
+//	@Summary		测试 webhook
+//	@Description	立即向 webhook 推送一条 ping 事件并返回推送结果
+//	@Tags			webhook
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.WebhookTestReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.WebhookTestResp}
+//	@Router			/api/v1/webhook/test [post]
+func (h *WebhookHandler) WebhookTest(c echo.Context) error {
+	var req v1.WebhookTestReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	resp, err := h.usecase.Test(c.Request().Context(), req.KbID, req.ID)
+	if err != nil {
+		return h.NewResponseWithError(c, "test webhook failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// WebhookDeliveryList webhook 推送记录
+//
+//	@Summary		webhook 推送记录
+//	@Description	webhook 推送记录，包含重试次数和最近一次的响应状态，推送内容和响应内容需要查看详情
+//	@Tags			webhook
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.WebhookDeliveryListReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=v1.WebhookDeliveryListResp}
+//	@Router			/api/v1/webhook/delivery/list [get]
+func (h *WebhookHandler) WebhookDeliveryList(c echo.Context) error {
+	var req v1.WebhookDeliveryListReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.usecase.GetDeliveryList(c.Request().Context(), &req)
+	if err != nil {
+		return h.NewResponseWithError(c, "get webhook delivery list failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// WebhookDeliveryDetail webhook 推送详情
+//
+//	@Summary		webhook 推送详情
+//	@Description	webhook 推送详情，包含推送内容和最近一次的响应内容
+//	@Tags			webhook
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			param	query		v1.WebhookDeliveryDetailReq	true	"para"
+//	@Success		200		{object}	domain.PWResponse{data=domain.WebhookDelivery}
+//	@Router			/api/v1/webhook/delivery/detail [get]
+func (h *WebhookHandler) WebhookDeliveryDetail(c echo.Context) error {
+	var req v1.WebhookDeliveryDetailReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request params is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request params failed", err)
+	}
+	resp, err := h.usecase.GetDelivery(c.Request().Context(), req.KbID, req.ID)
+	if err != nil {
+		return h.NewResponseWithError(c, "get webhook delivery failed", err)
+	}
+	return h.NewResponseWithData(c, resp)
+}
+
+// WebhookDeliveryRetry 重新推送
+//
+//	@Summary		重新推送
+//	@Description	重新推送一条 webhook 推送记录，重试次数会被重置
+//	@Tags			webhook
+//	@Accept			json
+//	@Produce		json
+//	@Security		bearerAuth
+//	@Param			body	body		v1.WebhookDeliveryRetryReq	true	"para"
+//	@Success		200		{object}	domain.Response
+//	@Router			/api/v1/webhook/delivery/retry [post]
+func (h *WebhookHandler) WebhookDeliveryRetry(c echo.Context) error {
+	var req v1.WebhookDeliveryRetryReq
+	if err := c.Bind(&req); err != nil {
+		return h.NewResponseWithError(c, "request body is invalid", err)
+	}
+	if err := c.Validate(req); err != nil {
+		return h.NewResponseWithError(c, "validate request body failed", err)
+	}
+	if err := h.usecase.RetryDelivery(c.Request().Context(), req.KbID, req.ID); err != nil {
+		return h.NewResponseWithError(c, "retry webhook delivery failed", err)
+	}
+	return h.NewResponseWithData(c, nil)
+}
@@ -9,18 +9,21 @@ import (
 
 	"github.com/chaitin/panda-wiki/domain"
 	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
 	"github.com/chaitin/panda-wiki/usecase"
 )
 
 type ShareAuthMiddleware struct {
 	logger    *log.Logger
 	kbUsecase *usecase.KnowledgeBaseUsecase
+	authRepo  *pg.AuthRepo
 }
 
-func NewShareAuthMiddleware(logger *log.Logger, kbUsecase *usecase.KnowledgeBaseUsecase) *ShareAuthMiddleware {
+func NewShareAuthMiddleware(logger *log.Logger, kbUsecase *usecase.KnowledgeBaseUsecase, authRepo *pg.AuthRepo) *ShareAuthMiddleware {
 	return &ShareAuthMiddleware{
 		logger:    logger.WithModule("middleware.share_auth"),
 		kbUsecase: kbUsecase,
+		authRepo:  authRepo,
 	}
 }
 
@@ -118,6 +121,17 @@ func (h *ShareAuthMiddleware) Authorize(next echo.HandlerFunc) echo.HandlerFunc
 					Message: "Unauthorized",
 				})
 			}
+			// 用户被删除或被 SCIM 禁用后登录态失效，查询失败时不确定用户状态，不让已登录的用户掉线
+			active, err := h.authRepo.IsAuthActive(c.Request().Context(), userId)
+			if err != nil {
+				h.logger.Error("check auth active failed", log.Int("user_id", int(userId)), log.Error(err))
+			} else if !active {
+				h.logger.Warn("auth is not active", log.Int("user_id", int(userId)))
+				return c.JSON(http.StatusUnauthorized, domain.PWResponse{
+					Success: false,
+					Message: "Unauthorized",
+				})
+			}
 			c.Set("user_id", userId)
 			return next(c)
 		}
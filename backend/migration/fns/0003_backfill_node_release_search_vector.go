@@ -0,0 +1,36 @@
+package fns
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+)
+
+type MigrationBackfillSearchVector struct {
+	Name     string
+	logger   *log.Logger
+	nodeRepo *pg.NodeRepository
+}
+
+func NewMigrationBackfillSearchVector(logger *log.Logger, nodeRepo *pg.NodeRepository) *MigrationBackfillSearchVector {
+	return &MigrationBackfillSearchVector{
+		Name:     "0003_backfill_node_release_search_vector",
+		logger:   logger,
+		nodeRepo: nodeRepo,
+	}
+}
+
+func (m *MigrationBackfillSearchVector) Execute(tx *gorm.DB) error {
+	ctx := context.Background()
+	// 为已发布的文档生成关键词检索索引
+	count, err := m.nodeRepo.BackfillNodeReleaseSearchVectors(ctx)
+	if err != nil {
+		return fmt.Errorf("backfill node release search vector failed: %w", err)
+	}
+	m.logger.Info("backfill node release search vector success", log.Int("count", count))
+	return nil
+}
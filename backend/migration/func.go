@@ -5,8 +5,9 @@ import (
 )
 
 type MigrationFuncs struct {
-	NodeMigration    *fns.MigrationNodeVersion
-	BotAuthMigration *fns.MigrationCreateBotAuth
+	NodeMigration         *fns.MigrationNodeVersion
+	BotAuthMigration      *fns.MigrationCreateBotAuth
+	SearchVectorMigration *fns.MigrationBackfillSearchVector
 }
 
 func (mf *MigrationFuncs) GetMigrationFuncs() []MigrationFunc {
@@ -19,5 +20,9 @@ func (mf *MigrationFuncs) GetMigrationFuncs() []MigrationFunc {
 		Name: mf.BotAuthMigration.Name,
 		Fn:   mf.BotAuthMigration.Execute,
 	})
+	funcs = append(funcs, MigrationFunc{
+		Name: mf.SearchVectorMigration.Name,
+		Fn:   mf.SearchVectorMigration.Execute,
+	})
 	return funcs
 }
@@ -7,3 +7,36 @@ import (
 )
 
 type GetQAFun func(ctx context.Context, msg string, info domain.ConversationInfo, ConversationID string) (chan string, error)
+
+// Reference is a document the answer is based on
+type Reference struct {
+	Title string
+	URL   string
+}
+
+// QAChunk is a piece of the streaming answer, the references are sent in their own chunks before the content
+type QAChunk struct {
+	Content   string
+	Reference *Reference
+}
+
+// GetQAWithReferencesFun is like GetQAFun for the bots showing the references besides the answer
+type GetQAWithReferencesFun func(ctx context.Context, msg string, info domain.ConversationInfo, ConversationID string) (chan QAChunk, error)
+
+// FeedbackFun records the feedback of the user on the answer message
+type FeedbackFun func(ctx context.Context, messageID string, score domain.ScoreType) error
+
+type messageIDRecorderKey struct{}
+
+// WithMessageIDRecorder returns the context making GetQAFun report the id of the answer message to the recorder
+// instead of appending the feedback links to the answer, for the bots collecting the feedback by themselves.
+// The recorder is called before the answer channel is closed, only if the AI feedback is enabled.
+func WithMessageIDRecorder(ctx context.Context, recorder func(messageID string)) context.Context {
+	return context.WithValue(ctx, messageIDRecorderKey{}, recorder)
+}
+
+// MessageIDRecorder returns the recorder set by WithMessageIDRecorder, nil if there is none
+func MessageIDRecorder(ctx context.Context) func(messageID string) {
+	recorder, _ := ctx.Value(messageIDRecorderKey{}).(func(messageID string))
+	return recorder
+}
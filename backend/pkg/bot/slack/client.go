@@ -0,0 +1,277 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/pkg/bot"
+)
+
+// updateInterval limits the message edits while streaming the answer, chat.update is rate limited by Slack
+const updateInterval = time.Second
+
+var (
+	mdLinkRegex = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdBoldRegex = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+)
+
+// SlackClient is a Slack bot client, events are received by Socket Mode if the app level token is set,
+// otherwise by the Events API HTTP callbacks passed to HandleEvent
+type SlackClient struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	signingSecret string
+	logger        *log.Logger
+	client        *slack.Client
+	socket        *socketmode.Client
+	botUserID     string
+	msgMap        sync.Map
+	getQA         bot.GetQAFun
+}
+
+func NewSlackClient(ctx context.Context, cancel context.CancelFunc, botToken, appToken, signingSecret string, logger *log.Logger, getQA bot.GetQAFun) (*SlackClient, error) {
+	if botToken == "" {
+		return nil, fmt.Errorf("bot token is required")
+	}
+	if appToken == "" && signingSecret == "" {
+		return nil, fmt.Errorf("app token or signing secret is required")
+	}
+
+	c := &SlackClient{
+		ctx:           ctx,
+		cancel:        cancel,
+		signingSecret: signingSecret,
+		logger:        logger.WithModule("bot.slack"),
+		getQA:         getQA,
+	}
+	if appToken != "" {
+		c.client = slack.New(botToken, slack.OptionAppLevelToken(appToken))
+		c.socket = socketmode.New(c.client)
+	} else {
+		c.client = slack.New(botToken)
+	}
+
+	go func() {
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+				c.msgMap.Range(func(key, value any) bool {
+					// remove message if it is older than 5 minutes
+					if time.Now().Unix()-value.(int64) > 5*60 {
+						c.msgMap.Delete(key)
+					}
+					return true
+				})
+			}
+		}
+	}()
+	return c, nil
+}
+
+// Start connects to Slack by Socket Mode and blocks until the client is stopped,
+// in Events API mode it only waits for the events passed to HandleEvent
+func (c *SlackClient) Start() error {
+	authResp, err := c.client.AuthTestContext(c.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to auth slack bot: %w", err)
+	}
+	c.botUserID = authResp.UserID
+
+	if c.socket == nil {
+		c.logger.Info("slack bot client initialized (Events API mode)", log.String("bot_user_id", c.botUserID))
+		<-c.ctx.Done()
+		c.logger.Info("slack bot client stopped")
+		return nil
+	}
+
+	go c.handleSocketEvents()
+	c.logger.Info("slack bot client initialized (Socket Mode)", log.String("bot_user_id", c.botUserID))
+	if err := c.socket.RunContext(c.ctx); err != nil && c.ctx.Err() == nil {
+		return fmt.Errorf("failed to run slack socket mode: %w", err)
+	}
+	c.logger.Info("slack bot client stopped")
+	return nil
+}
+
+func (c *SlackClient) Stop() {
+	c.cancel()
+}
+
+func (c *SlackClient) handleSocketEvents() {
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case evt := <-c.socket.Events:
+			switch evt.Type {
+			case socketmode.EventTypeConnecting:
+				c.logger.Debug("connecting to slack socket mode")
+			case socketmode.EventTypeConnected:
+				c.logger.Info("connected to slack socket mode")
+			case socketmode.EventTypeConnectionError, socketmode.EventTypeInvalidAuth:
+				c.logger.Error("slack socket mode connection failed", log.Any("event", evt.Data))
+			case socketmode.EventTypeEventsAPI:
+				if evt.Request != nil {
+					c.socket.Ack(*evt.Request)
+				}
+				eventsAPIEvent, ok := evt.Data.(slackevents.EventsAPIEvent)
+				if !ok || eventsAPIEvent.Type != slackevents.CallbackEvent {
+					continue
+				}
+				c.handleInnerEvent(eventsAPIEvent.InnerEvent)
+			}
+		}
+	}
+}
+
+// HandleEvent handles the Events API HTTP callback, returns the status code and body of the response
+func (c *SlackClient) HandleEvent(header http.Header, body []byte) (int, []byte) {
+	// Socket Mode 下没有签名密钥，空密钥的签名任何人都可以伪造
+	if c.socket != nil || c.signingSecret == "" {
+		c.logger.Warn("slack events api callback is not enabled")
+		return http.StatusUnauthorized, nil
+	}
+	verifier, err := slack.NewSecretsVerifier(header, c.signingSecret)
+	if err != nil {
+		c.logger.Error("failed to create slack secrets verifier", log.Error(err))
+		return http.StatusUnauthorized, nil
+	}
+	if _, err := verifier.Write(body); err != nil {
+		return http.StatusInternalServerError, nil
+	}
+	if err := verifier.Ensure(); err != nil {
+		c.logger.Warn("invalid slack request signature", log.Error(err))
+		return http.StatusUnauthorized, nil
+	}
+
+	event, err := slackevents.ParseEvent(json.RawMessage(body), slackevents.OptionNoVerifyToken())
+	if err != nil {
+		c.logger.Error("failed to parse slack event", log.Error(err))
+		return http.StatusBadRequest, nil
+	}
+	switch event.Type {
+	case slackevents.URLVerification:
+		var challenge slackevents.ChallengeResponse
+		if err := json.Unmarshal(body, &challenge); err != nil {
+			return http.StatusBadRequest, nil
+		}
+		return http.StatusOK, []byte(challenge.Challenge)
+	case slackevents.CallbackEvent:
+		c.handleInnerEvent(event.InnerEvent)
+	}
+	return http.StatusOK, nil
+}
+
+// handleInnerEvent answers the mentions in channels and the direct messages,
+// the messages of bots and the ones already handled (Slack retries slow callbacks) are ignored
+func (c *SlackClient) handleInnerEvent(event slackevents.EventsAPIInnerEvent) {
+	switch ev := event.Data.(type) {
+	case *slackevents.AppMentionEvent:
+		if ev.BotID != "" || ev.User == c.botUserID || !c.markHandled(ev.Channel, ev.TimeStamp) {
+			return
+		}
+		threadTS := ev.ThreadTimeStamp
+		if threadTS == "" {
+			threadTS = ev.TimeStamp
+		}
+		question := strings.TrimSpace(strings.ReplaceAll(ev.Text, fmt.Sprintf("<@%s>", c.botUserID), ""))
+		go c.answer(ev.Channel, threadTS, ev.User, question, domain.MessageFromGroup)
+	case *slackevents.MessageEvent:
+		// mentions in channels are answered on app_mention
+		if ev.ChannelType != "im" || ev.SubType != "" || ev.BotID != "" || ev.User == c.botUserID {
+			return
+		}
+		if !c.markHandled(ev.Channel, ev.TimeStamp) {
+			return
+		}
+		go c.answer(ev.Channel, ev.ThreadTimeStamp, ev.User, strings.TrimSpace(ev.Text), domain.MessageFromPrivate)
+	}
+}
+
+func (c *SlackClient) markHandled(channel, ts string) bool {
+	_, loaded := c.msgMap.LoadOrStore(channel+":"+ts, time.Now().Unix())
+	return !loaded
+}
+
+// answer posts a placeholder message and edits it while the answer is streaming
+func (c *SlackClient) answer(channel, threadTS, userID, question string, from domain.MessageFrom) {
+	if question == "" {
+		return
+	}
+	c.logger.Info("received message from slack bot", log.String("channel", channel), log.String("user", userID), log.String("question", question))
+
+	options := []slack.MsgOption{slack.MsgOptionText("稍等，让我想一想...", false)}
+	if threadTS != "" {
+		options = append(options, slack.MsgOptionTS(threadTS))
+	}
+	_, ts, err := c.client.PostMessageContext(c.ctx, channel, options...)
+	if err != nil {
+		c.logger.Error("failed to post slack message", log.Error(err))
+		return
+	}
+
+	info := domain.ConversationInfo{
+		UserInfo: domain.UserInfo{
+			UserID: userID,
+			From:   from,
+		},
+	}
+	if user, err := c.client.GetUserInfoContext(c.ctx, userID); err != nil {
+		c.logger.Error("get slack user info failed", log.Error(err))
+	} else {
+		info.UserInfo.NickName = user.Name
+		info.UserInfo.RealName = user.RealName
+		info.UserInfo.Email = user.Profile.Email
+		info.UserInfo.Avatar = user.Profile.Image192
+	}
+
+	answerCh, err := c.getQA(c.ctx, question, info, "")
+	if err != nil {
+		c.logger.Error("slack client failed to get answer", log.Error(err))
+		c.update(channel, ts, "出错了，请稍后再试")
+		return
+	}
+
+	answer := strings.Builder{}
+	lastUpdate := time.Now()
+	for chunk := range answerCh {
+		answer.WriteString(chunk)
+		if time.Since(lastUpdate) < updateInterval {
+			continue
+		}
+		c.update(channel, ts, answer.String())
+		lastUpdate = time.Now()
+	}
+	if answer.Len() == 0 {
+		answer.WriteString("抱歉，没有找到相关答案")
+	}
+	c.update(channel, ts, answer.String())
+}
+
+func (c *SlackClient) update(channel, ts, text string) {
+	if _, _, _, err := c.client.UpdateMessageContext(c.ctx, channel, ts, slack.MsgOptionText(toMrkdwn(text), false)); err != nil {
+		c.logger.Error("failed to update slack message", log.Error(err))
+	}
+}
+
+// toMrkdwn converts the links and bold text of the markdown answer to Slack mrkdwn
+func toMrkdwn(text string) string {
+	text = mdLinkRegex.ReplaceAllString(text, "<$2|$1>")
+	return mdBoldRegex.ReplaceAllString(text, "*$1*")
+}
@@ -0,0 +1,63 @@
+package slack
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/chaitin/panda-wiki/config"
+	"github.com/chaitin/panda-wiki/log"
+)
+
+func TestToMrkdwn(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{"**重点** 内容", "*重点* 内容"},
+		{"[👍 满意](https://wiki.example.com/feedback?score=1)", "<https://wiki.example.com/feedback?score=1|👍 满意>"},
+	}
+	for _, tt := range tests {
+		if got := toMrkdwn(tt.text); got != tt.want {
+			t.Errorf("toMrkdwn(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestHandleEventRejectsEmptySigningSecret(t *testing.T) {
+	body := []byte(`{"type":"event_callback","event":{"type":"app_mention","user":"U1","text":"hi","channel":"C1","ts":"1.0"}}`)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	// 使用空密钥计算的签名
+	mac := hmac.New(sha256.New, []byte(""))
+	mac.Write([]byte("v0:" + ts + ":" + string(body)))
+	header := http.Header{}
+	header.Set("X-Slack-Request-Timestamp", ts)
+	header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger := log.NewLogger(&config.Config{})
+
+	socketClient, err := NewSlackClient(ctx, cancel, "xoxb-test", "xapp-test", "", logger, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := socketClient.HandleEvent(header, body); status != http.StatusUnauthorized {
+		t.Errorf("socket mode: expected status %d, got %d", http.StatusUnauthorized, status)
+	}
+
+	// 同时配置了 app token 和签名密钥时也只使用 Socket Mode
+	socketClient, err = NewSlackClient(ctx, cancel, "xoxb-test", "xapp-test", "secret", logger, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := socketClient.HandleEvent(header, body); status != http.StatusUnauthorized {
+		t.Errorf("socket mode with signing secret: expected status %d, got %d", http.StatusUnauthorized, status)
+	}
+}
@@ -0,0 +1,179 @@
+package teams
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	openIDConfigURL = "https://login.botframework.com/v1/.well-known/openidconfiguration"
+	tokenIssuer     = "https://api.botframework.com"
+	// keysRefreshInterval is how often the signing keys are refetched, Bot Framework rotates them regularly
+	keysRefreshInterval = 24 * time.Hour
+	// keysMinRefreshInterval stops tokens with unknown key ids from refetching the keys on every request
+	keysMinRefreshInterval = 5 * time.Minute
+)
+
+type signingKey struct {
+	key          *rsa.PublicKey
+	endorsements []string
+}
+
+// tokenValidator validates the JWT the Bot Framework connector signs the incoming activities with
+type tokenValidator struct {
+	appID         string
+	httpClient    *http.Client
+	openIDConfURL string
+
+	mu        sync.RWMutex
+	keys      map[string]*signingKey
+	fetchedAt time.Time
+}
+
+func newTokenValidator(appID string, httpClient *http.Client) *tokenValidator {
+	return &tokenValidator{
+		appID:         appID,
+		httpClient:    httpClient,
+		openIDConfURL: openIDConfigURL,
+	}
+}
+
+// Validate checks the bearer token of the activity is issued by Bot Framework for the bot,
+// and is endorsed for the channel and service url of the activity
+func (v *tokenValidator) Validate(ctx context.Context, authHeader string, activity *Activity) error {
+	tokenString, found := strings.CutPrefix(authHeader, "Bearer ")
+	if !found || tokenString == "" {
+		return errors.New("bearer token is required")
+	}
+
+	var endorsements []string
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := v.getKey(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		endorsements = key.endorsements
+		return key.key, nil
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithAudience(v.appID),
+		jwt.WithLeeway(5*time.Minute),
+	)
+	if err != nil {
+		return fmt.Errorf("invalid token: %w", err)
+	}
+	if !slices.Contains(endorsements, activity.ChannelID) {
+		return fmt.Errorf("signing key is not endorsed for channel %s", activity.ChannelID)
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return errors.New("invalid token claims")
+	}
+	if serviceURL, _ := claims["serviceurl"].(string); serviceURL == "" || serviceURL != activity.ServiceURL {
+		return errors.New("service url of the token does not match the activity")
+	}
+	return nil
+}
+
+func (v *tokenValidator) getKey(ctx context.Context, kid string) (*signingKey, error) {
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	sinceFetched := time.Since(v.fetchedAt)
+	v.mu.RUnlock()
+	if ok && sinceFetched < keysRefreshInterval {
+		return key, nil
+	}
+	if !ok && sinceFetched < keysMinRefreshInterval {
+		return nil, fmt.Errorf("signing key %s not found", kid)
+	}
+
+	// unknown keys may be newly rotated ones, so the keys are refetched
+	if err := v.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("signing key %s not found", kid)
+}
+
+func (v *tokenValidator) refreshKeys(ctx context.Context) error {
+	var config struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := v.getJSON(ctx, v.openIDConfURL, &config); err != nil {
+		return fmt.Errorf("get openid configuration failed: %w", err)
+	}
+	var jwks struct {
+		Keys []struct {
+			Kty          string   `json:"kty"`
+			Kid          string   `json:"kid"`
+			N            string   `json:"n"`
+			E            string   `json:"e"`
+			Endorsements []string `json:"endorsements"`
+		} `json:"keys"`
+	}
+	if err := v.getJSON(ctx, config.JWKSURI, &jwks); err != nil {
+		return fmt.Errorf("get signing keys failed: %w", err)
+	}
+
+	keys := make(map[string]*signingKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &signingKey{
+			key: &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			},
+			endorsements: k.Endorsements,
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	return nil
+}
+
+func (v *tokenValidator) getJSON(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,110 @@
+package teams
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testAppID      = "test-app-id"
+	testServiceURL = "https://smba.trafficmanager.net/teams/"
+)
+
+// newTestJWKSServer serves the openid configuration and a jwks with the key of kid "key1" endorsed for msteams,
+// and kid "key2" without endorsements
+func newTestJWKSServer(t *testing.T, key *rsa.PublicKey) *httptest.Server {
+	t.Helper()
+	jwk := func(kid string, endorsements []string) map[string]any {
+		return map[string]any{
+			"kty":          "RSA",
+			"kid":          kid,
+			"n":            base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":            base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			"endorsements": endorsements,
+		}
+	}
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	mux.HandleFunc("/openidconfiguration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"jwks_uri": server.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []any{
+			jwk("key1", []string{"msteams"}),
+			jwk("key2", nil),
+		}})
+	})
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTokenValidatorValidate(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestJWKSServer(t, &privateKey.PublicKey)
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":        tokenIssuer,
+			"aud":        testAppID,
+			"exp":        time.Now().Add(time.Hour).Unix(),
+			"nbf":        time.Now().Add(-time.Minute).Unix(),
+			"serviceurl": testServiceURL,
+		}
+	}
+	tests := []struct {
+		name    string
+		kid     string
+		claims  func(jwt.MapClaims)
+		wantErr string
+	}{
+		{name: "valid", kid: "key1"},
+		{name: "wrong issuer", kid: "key1", claims: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, wantErr: "invalid token"},
+		{name: "wrong audience", kid: "key1", claims: func(c jwt.MapClaims) { c["aud"] = "other-app-id" }, wantErr: "invalid token"},
+		{name: "expired token", kid: "key1", claims: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, wantErr: "invalid token"},
+		{name: "missing serviceurl", kid: "key1", claims: func(c jwt.MapClaims) { delete(c, "serviceurl") }, wantErr: "service url"},
+		{name: "mismatched serviceurl", kid: "key1", claims: func(c jwt.MapClaims) { c["serviceurl"] = "https://evil.example.com/" }, wantErr: "service url"},
+		{name: "unknown kid", kid: "key3", wantErr: "signing key key3 not found"},
+		{name: "key without endorsements", kid: "key2", wantErr: "not endorsed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			if tt.claims != nil {
+				tt.claims(claims)
+			}
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+			token.Header["kid"] = tt.kid
+			signed, err := token.SignedString(privateKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v := newTokenValidator(testAppID, server.Client())
+			v.openIDConfURL = server.URL + "/openidconfiguration"
+			err = v.Validate(context.Background(), "Bearer "+signed, &Activity{ChannelID: "msteams", ServiceURL: testServiceURL})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package teams
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/pkg/bot"
+)
+
+const (
+	// defaultTenant is used to get the token of multi-tenant bots
+	defaultTenant = "botframework.com"
+	tokenURL      = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
+	tokenScope    = "https://api.botframework.com/.default"
+)
+
+var mentionRegex = regexp.MustCompile(`<at>[^<]*</at>`)
+
+// TeamsClient is a Bot Framework bot client for Microsoft Teams and the other Bot Framework channels,
+// activities are received by the HTTP endpoint passing them to HandleActivity
+type TeamsClient struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	appID       string
+	appPassword string
+	tenantID    string
+	logger      *log.Logger
+	httpClient  *http.Client
+	validator   *tokenValidator
+	getQA       bot.GetQAWithReferencesFun
+
+	tokenMu     sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func NewTeamsClient(ctx context.Context, cancel context.CancelFunc, appID, appPassword, tenantID string, logger *log.Logger, getQA bot.GetQAWithReferencesFun) (*TeamsClient, error) {
+	if appID == "" || appPassword == "" {
+		return nil, fmt.Errorf("app id and app password are required")
+	}
+	if tenantID == "" {
+		tenantID = defaultTenant
+	}
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	return &TeamsClient{
+		ctx:         ctx,
+		cancel:      cancel,
+		appID:       appID,
+		appPassword: appPassword,
+		tenantID:    tenantID,
+		logger:      logger.WithModule("bot.teams"),
+		httpClient:  httpClient,
+		validator:   newTokenValidator(appID, httpClient),
+		getQA:       getQA,
+	}, nil
+}
+
+// Start waits until the client is stopped, the activities are handled by HandleActivity
+func (c *TeamsClient) Start() error {
+	c.logger.Info("teams bot client initialized (HTTP callback mode)", log.String("app_id", c.appID))
+	<-c.ctx.Done()
+	c.logger.Info("teams bot client stopped")
+	return nil
+}
+
+func (c *TeamsClient) Stop() {
+	c.cancel()
+}
+
+// HandleActivity validates the activity posted by the Bot Framework connector and answers the messages asynchronously,
+// returns the status code to respond
+func (c *TeamsClient) HandleActivity(ctx context.Context, authHeader string, body []byte) int {
+	var activity Activity
+	if err := json.Unmarshal(body, &activity); err != nil {
+		c.logger.Error("failed to unmarshal activity", log.Error(err))
+		return http.StatusBadRequest
+	}
+	if err := c.validator.Validate(ctx, authHeader, &activity); err != nil {
+		c.logger.Warn("invalid bot framework token", log.Error(err))
+		return http.StatusUnauthorized
+	}
+	if activity.Type != ActivityTypeMessage {
+		return http.StatusOK
+	}
+
+	question := strings.TrimSpace(mentionRegex.ReplaceAllString(activity.Text, ""))
+	if question == "" {
+		return http.StatusOK
+	}
+	go c.answer(&activity, question)
+	return http.StatusOK
+}
+
+func (c *TeamsClient) answer(activity *Activity, question string) {
+	c.logger.Info("received message from teams bot", log.String("channel_id", activity.ChannelID), log.String("conversation_id", activity.Conversation.ID), log.String("question", question))
+
+	if err := c.reply(activity, &Activity{Type: ActivityTypeTyping}); err != nil {
+		c.logger.Warn("failed to send typing activity", log.Error(err))
+	}
+
+	info := domain.ConversationInfo{
+		UserInfo: domain.UserInfo{
+			UserID:   activity.From.ID,
+			NickName: activity.From.Name,
+			From:     domain.MessageFromPrivate,
+		},
+	}
+	if activity.Conversation.IsGroup {
+		info.UserInfo.From = domain.MessageFromGroup
+	}
+
+	answerCh, err := c.getQA(c.ctx, question, info, "")
+	if err != nil {
+		c.logger.Error("teams client failed to get answer", log.Error(err))
+		if err := c.reply(activity, &Activity{Type: ActivityTypeMessage, Text: "出错了，请稍后再试"}); err != nil {
+			c.logger.Error("failed to reply activity", log.Error(err))
+		}
+		return
+	}
+
+	answer := strings.Builder{}
+	var references []bot.Reference
+	for chunk := range answerCh {
+		if chunk.Reference != nil {
+			references = append(references, *chunk.Reference)
+			continue
+		}
+		answer.WriteString(chunk.Content)
+	}
+	if answer.Len() == 0 {
+		answer.WriteString("抱歉，没有找到相关答案")
+	}
+
+	if err := c.reply(activity, &Activity{
+		Type: ActivityTypeMessage,
+		Attachments: []Attachment{{
+			ContentType: AdaptiveCardContentType,
+			Content:     newAnswerCard(answer.String(), references),
+		}},
+	}); err != nil {
+		c.logger.Error("failed to reply activity", log.Error(err))
+	}
+}
+
+// reply sends the activity to the conversation of the incoming one as its reply
+func (c *TeamsClient) reply(incoming *Activity, activity *Activity) error {
+	activity.From = incoming.Recipient
+	activity.Recipient = incoming.From
+	activity.Conversation = incoming.Conversation
+	activity.ReplyToID = incoming.ID
+
+	token, err := c.getToken(c.ctx)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+	replyURL := fmt.Sprintf("%s/v3/conversations/%s/activities/%s",
+		strings.TrimSuffix(incoming.ServiceURL, "/"), url.PathEscape(incoming.Conversation.ID), url.PathEscape(incoming.ID))
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodPost, replyURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("reply activity failed, status: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// getToken returns the access token of the bot for the Bot Framework connector, cached until it is about to expire
+func (c *TeamsClient) getToken(ctx context.Context) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.appID},
+		"client_secret": {c.appPassword},
+		"scope":         {tokenScope},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf(tokenURL, c.tenantID), strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("get bot token failed: %w", err)
+	}
+	defer resp.Body.Close()
+	var tokenResp struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("decode bot token failed: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", fmt.Errorf("get bot token failed: %s", tokenResp.ErrorDescription)
+	}
+	c.token = tokenResp.AccessToken
+	// refresh the token 5 minutes before it expires
+	c.tokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn)*time.Second - 5*time.Minute)
+	return c.token, nil
+}
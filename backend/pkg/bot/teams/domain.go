@@ -0,0 +1,82 @@
+package teams
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chaitin/panda-wiki/pkg/bot"
+)
+
+const (
+	ActivityTypeMessage = "message"
+	ActivityTypeTyping  = "typing"
+
+	AdaptiveCardContentType = "application/vnd.microsoft.card.adaptive"
+)
+
+// Activity is the Bot Framework activity, only the fields used by the bot are kept
+type Activity struct {
+	Type         string              `json:"type"`
+	ID           string              `json:"id,omitempty"`
+	ServiceURL   string              `json:"serviceUrl,omitempty"`
+	ChannelID    string              `json:"channelId,omitempty"`
+	From         ChannelAccount      `json:"from"`
+	Recipient    ChannelAccount      `json:"recipient"`
+	Conversation ConversationAccount `json:"conversation"`
+	Text         string              `json:"text,omitempty"`
+	ReplyToID    string              `json:"replyToId,omitempty"`
+	Attachments  []Attachment        `json:"attachments,omitempty"`
+}
+
+type ChannelAccount struct {
+	ID          string `json:"id"`
+	Name        string `json:"name,omitempty"`
+	AADObjectID string `json:"aadObjectId,omitempty"`
+}
+
+type ConversationAccount struct {
+	ID               string `json:"id"`
+	Name             string `json:"name,omitempty"`
+	IsGroup          bool   `json:"isGroup,omitempty"`
+	ConversationType string `json:"conversationType,omitempty"`
+	TenantID         string `json:"tenantId,omitempty"`
+}
+
+type Attachment struct {
+	ContentType string `json:"contentType"`
+	Content     any    `json:"content"`
+}
+
+// newAnswerCard returns the adaptive card of the answer followed by its references
+func newAnswerCard(answer string, references []bot.Reference) map[string]any {
+	body := []map[string]any{
+		{"type": "TextBlock", "text": answer, "wrap": true},
+	}
+	if len(references) > 0 {
+		refs := strings.Builder{}
+		for i, ref := range references {
+			if ref.URL != "" {
+				refs.WriteString(fmt.Sprintf("%d. [%s](%s)\n", i+1, ref.Title, ref.URL))
+			} else {
+				refs.WriteString(fmt.Sprintf("%d. %s\n", i+1, ref.Title))
+			}
+		}
+		body = append(body,
+			map[string]any{"type": "TextBlock", "text": "参考文档", "weight": "Bolder", "separator": true, "wrap": true},
+			map[string]any{"type": "TextBlock", "text": refs.String(), "wrap": true},
+		)
+	}
+	body = append(body, map[string]any{
+		"type":     "TextBlock",
+		"text":     "本回答由 PandaWiki 基于 AI 生成，仅供参考。",
+		"size":     "Small",
+		"isSubtle": true,
+		"wrap":     true,
+	})
+	return map[string]any{
+		"type":    "AdaptiveCard",
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"version": "1.4",
+		"body":    body,
+	}
+}
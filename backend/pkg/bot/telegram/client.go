@@ -0,0 +1,300 @@
+package telegram
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/pkg/bot"
+)
+
+const (
+	// updateInterval limits the message edits while streaming the answer, Telegram limits the messages a bot sends to a chat
+	updateInterval = 2 * time.Second
+	// maxMessageLength is the max length of a Telegram text message
+	maxMessageLength = 4096
+	// pollingTimeout is the timeout in seconds of the long polling getUpdates requests
+	pollingTimeout = 60
+
+	secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+	feedbackPrefix    = "fb"
+)
+
+// TelegramClient is a Telegram bot client, updates are received by long polling if the webhook url is empty,
+// otherwise by the webhook HTTP callbacks passed to HandleWebhook
+type TelegramClient struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	token       string
+	webhookURL  string
+	secretToken string
+	logger      *log.Logger
+	api         atomic.Pointer[tgbotapi.BotAPI]
+	getQA       bot.GetQAFun
+	feedback    bot.FeedbackFun
+}
+
+func NewTelegramClient(ctx context.Context, cancel context.CancelFunc, token, webhookURL string, logger *log.Logger, getQA bot.GetQAFun, feedback bot.FeedbackFun) (*TelegramClient, error) {
+	if token == "" {
+		return nil, fmt.Errorf("bot token is required")
+	}
+	// the secret token only allows [A-Za-z0-9_-], so it is derived from the bot token instead of using it directly
+	secret := sha256.Sum256([]byte("panda-wiki-telegram-webhook:" + token))
+	return &TelegramClient{
+		ctx:         ctx,
+		cancel:      cancel,
+		token:       token,
+		webhookURL:  webhookURL,
+		secretToken: hex.EncodeToString(secret[:]),
+		logger:      logger.WithModule("bot.telegram"),
+		getQA:       getQA,
+		feedback:    feedback,
+	}, nil
+}
+
+// Start registers the webhook and waits until the client is stopped in webhook mode,
+// otherwise receives the updates by long polling until the client is stopped
+func (c *TelegramClient) Start() error {
+	api, err := tgbotapi.NewBotAPI(c.token)
+	if err != nil {
+		return fmt.Errorf("create telegram bot api failed: %w", err)
+	}
+	c.api.Store(api)
+
+	if c.webhookURL != "" {
+		if _, err := api.MakeRequest("setWebhook", tgbotapi.Params{
+			"url":             c.webhookURL,
+			"secret_token":    c.secretToken,
+			"allowed_updates": `["message","callback_query"]`,
+		}); err != nil {
+			return fmt.Errorf("set telegram webhook failed: %w", err)
+		}
+		c.logger.Info("telegram bot client initialized (webhook mode)", log.String("username", api.Self.UserName))
+		<-c.ctx.Done()
+		c.logger.Info("telegram bot client stopped")
+		return nil
+	}
+
+	// getUpdates does not work while a webhook is set
+	if _, err := api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		return fmt.Errorf("delete telegram webhook failed: %w", err)
+	}
+	config := tgbotapi.NewUpdate(0)
+	config.Timeout = pollingTimeout
+	config.AllowedUpdates = []string{"message", "callback_query"}
+	updates := api.GetUpdatesChan(config)
+	c.logger.Info("telegram bot client initialized (polling mode)", log.String("username", api.Self.UserName))
+	for {
+		select {
+		case <-c.ctx.Done():
+			api.StopReceivingUpdates()
+			c.logger.Info("telegram bot client stopped")
+			return nil
+		case update := <-updates:
+			c.handleUpdate(api, update)
+		}
+	}
+}
+
+func (c *TelegramClient) Stop() {
+	c.cancel()
+}
+
+// HandleWebhook verifies the secret token of the webhook request and handles the update,
+// returns the status code to respond
+func (c *TelegramClient) HandleWebhook(header http.Header, body []byte) int {
+	if subtle.ConstantTimeCompare([]byte(header.Get(secretTokenHeader)), []byte(c.secretToken)) != 1 {
+		c.logger.Warn("invalid telegram webhook secret token")
+		return http.StatusUnauthorized
+	}
+	api := c.api.Load()
+	if api == nil {
+		// Telegram retries the update later
+		return http.StatusServiceUnavailable
+	}
+	var update tgbotapi.Update
+	if err := json.Unmarshal(body, &update); err != nil {
+		c.logger.Error("failed to unmarshal telegram update", log.Error(err))
+		return http.StatusBadRequest
+	}
+	c.handleUpdate(api, update)
+	return http.StatusOK
+}
+
+// handleUpdate answers the private messages and the messages mentioning or replying to the bot in groups,
+// and records the feedback of the callback queries
+func (c *TelegramClient) handleUpdate(api *tgbotapi.BotAPI, update tgbotapi.Update) {
+	if update.CallbackQuery != nil {
+		go c.handleCallback(api, update.CallbackQuery)
+		return
+	}
+	msg := update.Message
+	if msg == nil || msg.From == nil || msg.From.IsBot || msg.Text == "" {
+		return
+	}
+
+	if msg.Chat.IsPrivate() {
+		if msg.IsCommand() {
+			if msg.Command() == "start" {
+				c.send(api, tgbotapi.NewMessage(msg.Chat.ID, "你好，请直接发送你的问题"))
+			}
+			return
+		}
+		go c.answer(api, msg, strings.TrimSpace(msg.Text), domain.MessageFromPrivate)
+		return
+	}
+
+	mention := "@" + api.Self.UserName
+	repliedToBot := msg.ReplyToMessage != nil && msg.ReplyToMessage.From != nil && msg.ReplyToMessage.From.ID == api.Self.ID
+	if !strings.Contains(msg.Text, mention) && !repliedToBot {
+		return
+	}
+	go c.answer(api, msg, strings.TrimSpace(strings.ReplaceAll(msg.Text, mention, "")), domain.MessageFromGroup)
+}
+
+// answer replies a placeholder message and edits it while the answer is streaming,
+// the feedback buttons are attached to the last message of the answer
+func (c *TelegramClient) answer(api *tgbotapi.BotAPI, msg *tgbotapi.Message, question string, from domain.MessageFrom) {
+	if question == "" {
+		return
+	}
+	c.logger.Info("received message from telegram bot", log.Int("chat_id", int(msg.Chat.ID)), log.String("user", msg.From.UserName), log.String("question", question))
+
+	placeholder := tgbotapi.NewMessage(msg.Chat.ID, "稍等，让我想一想...")
+	placeholder.ReplyToMessageID = msg.MessageID
+	reply, err := api.Send(placeholder)
+	if err != nil {
+		c.logger.Error("failed to send telegram message", log.Error(err))
+		return
+	}
+
+	info := domain.ConversationInfo{
+		UserInfo: domain.UserInfo{
+			UserID:   strconv.FormatInt(msg.From.ID, 10),
+			NickName: msg.From.UserName,
+			RealName: strings.TrimSpace(msg.From.FirstName + " " + msg.From.LastName),
+			From:     from,
+		},
+	}
+
+	var messageID string
+	ctx := bot.WithMessageIDRecorder(c.ctx, func(id string) {
+		messageID = id
+	})
+	answerCh, err := c.getQA(ctx, question, info, "")
+	if err != nil {
+		c.logger.Error("telegram client failed to get answer", log.Error(err))
+		c.edit(api, tgbotapi.NewEditMessageText(msg.Chat.ID, reply.MessageID, "出错了，请稍后再试"))
+		return
+	}
+
+	answer := strings.Builder{}
+	sent := ""
+	lastUpdate := time.Now()
+	for chunk := range answerCh {
+		answer.WriteString(chunk)
+		if time.Since(lastUpdate) < updateInterval {
+			continue
+		}
+		// the overflow of a long answer is sent after the answer is finished
+		text := splitText(answer.String())[0]
+		if text != sent {
+			c.edit(api, tgbotapi.NewEditMessageText(msg.Chat.ID, reply.MessageID, text))
+			sent = text
+		}
+		lastUpdate = time.Now()
+	}
+	if answer.Len() == 0 {
+		answer.WriteString("抱歉，没有找到相关答案")
+	}
+
+	parts := splitText(answer.String())
+	for i, part := range parts {
+		last := i == len(parts)-1
+		if i == 0 {
+			edit := tgbotapi.NewEditMessageText(msg.Chat.ID, reply.MessageID, part)
+			if last && messageID != "" {
+				markup := feedbackMarkup(messageID)
+				edit.ReplyMarkup = &markup
+			}
+			c.edit(api, edit)
+			continue
+		}
+		next := tgbotapi.NewMessage(msg.Chat.ID, part)
+		if last && messageID != "" {
+			next.ReplyMarkup = feedbackMarkup(messageID)
+		}
+		c.send(api, next)
+	}
+}
+
+// handleCallback records the feedback of the button clicked and removes the buttons from the answer
+func (c *TelegramClient) handleCallback(api *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	text, recorded := c.recordFeedback(query.Data)
+	// the callback query is always answered, otherwise the client keeps showing the spinner
+	if _, err := api.Request(tgbotapi.NewCallback(query.ID, text)); err != nil {
+		c.logger.Error("failed to answer telegram callback query", log.Error(err))
+	}
+	if recorded && query.Message != nil {
+		c.edit(api, tgbotapi.NewEditMessageReplyMarkup(query.Message.Chat.ID, query.Message.MessageID,
+			tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}))
+	}
+}
+
+// recordFeedback records the feedback in the callback data, returns the text to answer and whether it is recorded
+func (c *TelegramClient) recordFeedback(callbackData string) (string, bool) {
+	prefix, data, _ := strings.Cut(callbackData, ":")
+	scoreStr, messageID, _ := strings.Cut(data, ":")
+	score, err := strconv.Atoi(scoreStr)
+	if prefix != feedbackPrefix || err != nil || messageID == "" {
+		c.logger.Warn("invalid telegram callback data", log.String("data", callbackData))
+		return "无效的反馈", false
+	}
+	if err := c.feedback(c.ctx, messageID, domain.ScoreType(score)); err != nil {
+		c.logger.Error("telegram client failed to record feedback", log.String("message_id", messageID), log.Error(err))
+		return "反馈失败，请稍后再试", false
+	}
+	return "感谢您的反馈", true
+}
+
+func (c *TelegramClient) send(api *tgbotapi.BotAPI, msg tgbotapi.MessageConfig) {
+	if _, err := api.Send(msg); err != nil {
+		c.logger.Error("failed to send telegram message", log.Error(err))
+	}
+}
+
+func (c *TelegramClient) edit(api *tgbotapi.BotAPI, edit tgbotapi.Chattable) {
+	if _, err := api.Request(edit); err != nil {
+		c.logger.Error("failed to edit telegram message", log.Error(err))
+	}
+}
+
+func feedbackMarkup(messageID string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("👍 满意", fmt.Sprintf("%s:%d:%s", feedbackPrefix, domain.Like, messageID)),
+		tgbotapi.NewInlineKeyboardButtonData("👎 不满意", fmt.Sprintf("%s:%d:%s", feedbackPrefix, domain.DisLike, messageID)),
+	))
+}
+
+// splitText splits the text into parts no longer than the max message length
+func splitText(text string) []string {
+	runes := []rune(text)
+	parts := make([]string, 0, len(runes)/maxMessageLength+1)
+	for len(runes) > maxMessageLength {
+		parts = append(parts, string(runes[:maxMessageLength]))
+		runes = runes[maxMessageLength:]
+	}
+	return append(parts, string(runes))
+}
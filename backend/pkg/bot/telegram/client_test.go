@@ -0,0 +1,29 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitText(t *testing.T) {
+	long := strings.Repeat("文", maxMessageLength+10)
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"", []int{0}},
+		{"short answer", []int{12}},
+		{long, []int{maxMessageLength, 10}},
+	}
+	for _, tt := range tests {
+		parts := splitText(tt.text)
+		if len(parts) != len(tt.want) {
+			t.Fatalf("splitText() returned %d parts, want %d", len(parts), len(tt.want))
+		}
+		for i, part := range parts {
+			if n := len([]rune(part)); n != tt.want[i] {
+				t.Errorf("part %d has %d runes, want %d", i, n, tt.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package ldap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/samber/lo"
+
+	"github.com/chaitin/panda-wiki/log"
+)
+
+// Group LDAP组，嵌套的子组通过 ParentDN 关联到父组
+type Group struct {
+	DN       string      `json:"dn"`
+	Name     string      `json:"name"`
+	ParentDN string      `json:"parent_dn"` // 配置的根组为空
+	Members  []*UserInfo `json:"members"`   // 组的用户成员，不包含挂在其下的子组的成员
+}
+
+// groupObjectClasses 常见的组 objectClass
+var groupObjectClasses = []string{"groupOfNames", "groupOfUniqueNames", "group", "groupOfURLs"}
+
+// entryReader 按DN读取条目，条目不存在时返回 nil
+type entryReader func(dn string) (*ldap.Entry, error)
+
+// ListGroups 从配置的组DN开始遍历组及其嵌套的子组，返回的组按遍历顺序排列，父组在子组之前
+func (c *Client) ListGroups() ([]*Group, error) {
+	if len(c.config.GroupDNs) == 0 {
+		return nil, fmt.Errorf("group DNs are required")
+	}
+
+	conn, err := ldap.DialURL(c.config.ServerURL)
+	if err != nil {
+		c.logger.Error("failed to connect to LDAP server", log.Error(err))
+		return nil, fmt.Errorf("failed to connect to LDAP server: %w", err)
+	}
+	defer conn.Close()
+
+	if err := conn.Bind(c.config.BindDN, c.config.BindPassword); err != nil {
+		c.logger.Error("failed to bind with admin credentials", log.Error(err))
+		return nil, fmt.Errorf("failed to bind with admin credentials: %w", err)
+	}
+
+	attrs := []string{
+		"objectClass",
+		c.config.GroupNameAttr,
+		c.config.GroupMemberAttr,
+		c.config.UserIDAttr,
+		c.config.UserNameAttr,
+		c.config.UserEmailAttr,
+	}
+	read := func(dn string) (*ldap.Entry, error) {
+		searchRequest := ldap.NewSearchRequest(
+			dn,
+			ldap.ScopeBaseObject,
+			ldap.NeverDerefAliases,
+			0,
+			0,
+			false,
+			"(objectClass=*)",
+			attrs,
+			nil,
+		)
+		searchResult, err := conn.Search(searchRequest)
+		if err != nil {
+			if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("read entry %s failed: %w", dn, err)
+		}
+		if len(searchResult.Entries) == 0 {
+			return nil, nil
+		}
+		return searchResult.Entries[0], nil
+	}
+
+	groups, err := c.walkGroups(read)
+	if err != nil {
+		c.logger.Error("list groups failed", log.Error(err))
+		return nil, err
+	}
+
+	c.logger.Info("groups listed", log.Int("count", len(groups)))
+	return groups, nil
+}
+
+// walkGroups 广度优先遍历组，配置的组不存在或不是组时返回错误。同一个组被多个父组包含时只挂在最先遍历到的父组下，
+// 其余父组直接包含该组的所有成员；包含自身或祖先组的循环引用会被忽略
+func (c *Client) walkGroups(read entryReader) ([]*Group, error) {
+	type queueItem struct {
+		group *Group
+		entry *ldap.Entry
+	}
+
+	groups := make([]*Group, 0)
+	groupByKey := make(map[string]*Group)
+	userByKey := make(map[string]*UserInfo) // 已读取过的成员，不存在或缺少ID属性的为 nil
+	edges := make(map[string][]string)      // 组到直接包含的子组
+	extraEdges := make(map[string][]string) // 组到直接包含但未挂在其下的子组
+	queue := make([]queueItem, 0)
+
+	addGroup := func(entry *ldap.Entry, parentDN string) {
+		group := &Group{
+			DN:       entry.DN,
+			Name:     c.getAttributeValue(entry, c.config.GroupNameAttr),
+			ParentDN: parentDN,
+			Members:  make([]*UserInfo, 0),
+		}
+		if group.Name == "" {
+			group.Name = entry.DN
+		}
+		groupByKey[dnKey(entry.DN)] = group
+		groups = append(groups, group)
+		queue = append(queue, queueItem{group: group, entry: entry})
+	}
+
+	for _, dn := range c.config.GroupDNs {
+		if _, ok := groupByKey[dnKey(dn)]; ok {
+			continue
+		}
+		entry, err := read(dn)
+		if err != nil {
+			return nil, err
+		}
+		// 根组缺失时不能继续同步，否则其下的用户组都会被当作已删除
+		if entry == nil {
+			return nil, fmt.Errorf("group %s not found", dn)
+		}
+		if !c.isGroup(entry) {
+			return nil, fmt.Errorf("entry %s is not a group", dn)
+		}
+		addGroup(entry, "")
+	}
+
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+		groupKey := dnKey(item.group.DN)
+
+		for _, memberDN := range item.entry.GetAttributeValues(c.config.GroupMemberAttr) {
+			// uniqueMember 的值可能带有 #'0101'B 形式的唯一标识后缀
+			if i := strings.LastIndex(memberDN, "#'"); i > 0 {
+				memberDN = memberDN[:i]
+			}
+			memberKey := dnKey(memberDN)
+
+			if child, ok := groupByKey[memberKey]; ok {
+				if !isAncestorOrSelf(groupByKey, child, item.group) {
+					edges[groupKey] = append(edges[groupKey], memberKey)
+					extraEdges[groupKey] = append(extraEdges[groupKey], memberKey)
+				}
+				continue
+			}
+			if user, ok := userByKey[memberKey]; ok {
+				if user != nil {
+					item.group.Members = append(item.group.Members, user)
+				}
+				continue
+			}
+
+			entry, err := read(memberDN)
+			if err != nil {
+				return nil, err
+			}
+			if entry == nil {
+				c.logger.Warn("group member not found", log.String("group", item.group.DN), log.String("dn", memberDN))
+				userByKey[memberKey] = nil
+				continue
+			}
+			if c.isGroup(entry) {
+				edges[groupKey] = append(edges[groupKey], memberKey)
+				addGroup(entry, item.group.DN)
+				continue
+			}
+
+			user := c.toUserInfo(entry)
+			if user.ID == "" {
+				c.logger.Warn("group member has no id attribute", log.String("dn", memberDN), log.String("attr", c.config.UserIDAttr))
+				userByKey[memberKey] = nil
+				continue
+			}
+			userByKey[memberKey] = user
+			item.group.Members = append(item.group.Members, user)
+		}
+	}
+
+	// 先计算所有需要补充的成员再合并，避免补充的成员影响其他组的计算
+	additions := make(map[string][]*UserInfo)
+	for groupKey, childKeys := range extraEdges {
+		for _, childKey := range childKeys {
+			additions[groupKey] = append(additions[groupKey], allMembers(groupByKey, edges, childKey, make(map[string]bool))...)
+		}
+	}
+	for groupKey, users := range additions {
+		group := groupByKey[groupKey]
+		group.Members = append(group.Members, users...)
+	}
+	for _, group := range groups {
+		group.Members = lo.UniqBy(group.Members, func(user *UserInfo) string {
+			return dnKey(user.DN)
+		})
+	}
+
+	return groups, nil
+}
+
+// allMembers 组及其所有子组的成员
+func allMembers(groupByKey map[string]*Group, edges map[string][]string, groupKey string, visited map[string]bool) []*UserInfo {
+	if visited[groupKey] {
+		return nil
+	}
+	visited[groupKey] = true
+
+	members := append([]*UserInfo{}, groupByKey[groupKey].Members...)
+	for _, childKey := range edges[groupKey] {
+		members = append(members, allMembers(groupByKey, edges, childKey, visited)...)
+	}
+	return members
+}
+
+// isAncestorOrSelf target 是否为 group 本身或其祖先组
+func isAncestorOrSelf(groupByKey map[string]*Group, target, group *Group) bool {
+	for group != nil {
+		if group == target {
+			return true
+		}
+		if group.ParentDN == "" {
+			return false
+		}
+		group = groupByKey[dnKey(group.ParentDN)]
+	}
+	return false
+}
+
+// isGroup 条目的 objectClass 为组，或者包含组成员属性
+func (c *Client) isGroup(entry *ldap.Entry) bool {
+	for _, objectClass := range entry.GetAttributeValues("objectClass") {
+		for _, groupObjectClass := range groupObjectClasses {
+			if strings.EqualFold(objectClass, groupObjectClass) {
+				return true
+			}
+		}
+	}
+	return len(entry.GetAttributeValues(c.config.GroupMemberAttr)) > 0
+}
+
+// dnKey DN 比较时不区分大小写，并忽略分隔符两侧的空格
+func dnKey(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(dn))
+	}
+	rdns := make([]string, 0, len(parsed.RDNs))
+	for _, rdn := range parsed.RDNs {
+		attrs := make([]string, 0, len(rdn.Attributes))
+		for _, attr := range rdn.Attributes {
+			attrs = append(attrs, strings.ToLower(attr.Type)+"="+strings.ToLower(attr.Value))
+		}
+		rdns = append(rdns, strings.Join(attrs, "+"))
+	}
+	return strings.Join(rdns, ",")
+}
@@ -0,0 +1,156 @@
+package ldap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/chaitin/panda-wiki/config"
+	"github.com/chaitin/panda-wiki/log"
+)
+
+func newTestClient(t *testing.T, groupDNs ...string) *Client {
+	client, err := NewClient(context.Background(), log.NewLogger(&config.Config{}), Config{
+		ServerURL:  "ldap://localhost:389",
+		BindDN:     "cn=admin,dc=example,dc=com",
+		UserBaseDN: "ou=People,dc=example,dc=com",
+		GroupDNs:   groupDNs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func newTestReader(entries ...*ldap.Entry) entryReader {
+	entryByKey := make(map[string]*ldap.Entry)
+	for _, entry := range entries {
+		entryByKey[dnKey(entry.DN)] = entry
+	}
+	return func(dn string) (*ldap.Entry, error) {
+		return entryByKey[dnKey(dn)], nil
+	}
+}
+
+func newGroupEntry(dn, name string, members ...string) *ldap.Entry {
+	return ldap.NewEntry(dn, map[string][]string{
+		"objectClass": {"top", "groupOfNames"},
+		"cn":          {name},
+		"member":      members,
+	})
+}
+
+func newUserEntry(dn, uid string) *ldap.Entry {
+	return ldap.NewEntry(dn, map[string][]string{
+		"objectClass": {"inetOrgPerson"},
+		"uid":         {uid},
+		"cn":          {uid},
+	})
+}
+
+func memberIDs(group *Group) []string {
+	ids := make([]string, 0, len(group.Members))
+	for _, member := range group.Members {
+		ids = append(ids, member.ID)
+	}
+	return ids
+}
+
+func TestWalkGroups(t *testing.T) {
+	const (
+		eng     = "cn=eng,ou=Groups,dc=example,dc=com"
+		backend = "cn=backend,ou=Groups,dc=example,dc=com"
+		ops     = "cn=ops,ou=Groups,dc=example,dc=com"
+		alice   = "uid=alice,ou=People,dc=example,dc=com"
+		bob     = "uid=bob,ou=People,dc=example,dc=com"
+		carol   = "uid=carol,ou=People,dc=example,dc=com"
+	)
+	read := newTestReader(
+		// eng 包含 backend，backend 又包含 eng，形成循环
+		newGroupEntry(eng, "eng", alice, "CN=Backend, OU=Groups, DC=example, DC=com", "uid=missing,ou=People,dc=example,dc=com"),
+		newGroupEntry(backend, "backend", bob, eng),
+		// ops 也包含 backend，但 backend 已经挂在 eng 下
+		newGroupEntry(ops, "ops", carol, backend),
+		newUserEntry(alice, "alice"),
+		newUserEntry(bob, "bob"),
+		newUserEntry(carol, "carol"),
+	)
+
+	groups, err := newTestClient(t, eng, ops).walkGroups(read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(groups))
+	}
+
+	expected := []struct {
+		name     string
+		parentDN string
+		members  []string
+	}{
+		{name: "eng", parentDN: "", members: []string{"alice"}},
+		{name: "ops", parentDN: "", members: []string{"carol", "bob"}},
+		{name: "backend", parentDN: eng, members: []string{"bob"}},
+	}
+	for i, e := range expected {
+		group := groups[i]
+		if group.Name != e.name || group.ParentDN != e.parentDN {
+			t.Errorf("group %d: expected %s under %q, got %s under %q", i, e.name, e.parentDN, group.Name, group.ParentDN)
+		}
+		ids := memberIDs(group)
+		if len(ids) != len(e.members) {
+			t.Errorf("group %s: expected members %v, got %v", e.name, e.members, ids)
+			continue
+		}
+		for j := range ids {
+			if ids[j] != e.members[j] {
+				t.Errorf("group %s: expected members %v, got %v", e.name, e.members, ids)
+				break
+			}
+		}
+	}
+}
+
+func TestWalkGroupsDuplicateRoots(t *testing.T) {
+	const (
+		group = "cn=eng,ou=Groups,dc=example,dc=com"
+		user  = "uid=alice,ou=People,dc=example,dc=com"
+	)
+	read := newTestReader(
+		newGroupEntry(group, "eng", user),
+		newUserEntry(user, "alice"),
+	)
+
+	groups, err := newTestClient(t, group, "CN=eng,ou=Groups,dc=example,dc=com").walkGroups(read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0].DN != group {
+		t.Fatalf("expected only %s, got %v", group, groups)
+	}
+}
+
+func TestWalkGroupsInvalidRoots(t *testing.T) {
+	const (
+		group = "cn=eng,ou=Groups,dc=example,dc=com"
+		user  = "uid=alice,ou=People,dc=example,dc=com"
+	)
+	read := newTestReader(
+		newGroupEntry(group, "eng", user),
+		newUserEntry(user, "alice"),
+	)
+
+	for _, root := range []string{"cn=missing,dc=example,dc=com", user} {
+		if _, err := newTestClient(t, group, root).walkGroups(read); err == nil {
+			t.Errorf("expected error for root %s", root)
+		}
+	}
+}
+
+func TestDNKey(t *testing.T) {
+	if dnKey("CN=Eng, OU=Groups,DC=example,DC=com") != dnKey("cn=eng,ou=groups,dc=example,dc=com") {
+		t.Error("expected dn keys to be equal")
+	}
+}
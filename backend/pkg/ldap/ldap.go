@@ -25,6 +25,10 @@ type Config struct {
 	UserIDAttr    string `json:"user_id_attr"`    // 用户ID属性，默认 uid
 	UserNameAttr  string `json:"user_name_attr"`  // 用户名属性，默认 cn
 	UserEmailAttr string `json:"user_email_attr"` // 用户邮箱属性，默认 mail
+
+	GroupDNs        []string `json:"group_dns"`         // 需要同步的组DN，如 cn=dev,ou=Groups,dc=company,dc=com
+	GroupNameAttr   string   `json:"group_name_attr"`   // 组名属性，默认 cn
+	GroupMemberAttr string   `json:"group_member_attr"` // 组成员属性，值为成员DN，默认 member，也可以是 uniqueMember
 }
 
 type UserInfo struct {
@@ -39,6 +43,9 @@ const (
 	defaultUserNameAttr  = "cn"
 	defaultUserEmailAttr = "mail"
 	defaultUserFilter    = "(&(objectClass=person)(uid=%s))"
+
+	defaultGroupNameAttr   = "cn"
+	defaultGroupMemberAttr = "member"
 )
 
 // NewClient 创建LDAP客户端
@@ -56,6 +63,12 @@ func NewClient(ctx context.Context, logger *log.Logger, config Config) (*Client,
 	if config.UserFilter == "" {
 		config.UserFilter = defaultUserFilter
 	}
+	if config.GroupNameAttr == "" {
+		config.GroupNameAttr = defaultGroupNameAttr
+	}
+	if config.GroupMemberAttr == "" {
+		config.GroupMemberAttr = defaultGroupMemberAttr
+	}
 
 	// 验证必需的配置
 	if config.ServerURL == "" {
@@ -158,7 +171,19 @@ func (c *Client) searchUser(conn *ldap.Conn, username string) (*UserInfo, error)
 	}
 
 	// 解析用户信息
-	entry := searchResult.Entries[0]
+	userInfo := c.toUserInfo(searchResult.Entries[0])
+
+	c.logger.Info("user found",
+		log.String("dn", userInfo.DN),
+		log.String("id", userInfo.ID),
+		log.String("username", userInfo.Username),
+		log.String("email", userInfo.Email))
+
+	return userInfo, nil
+}
+
+// toUserInfo 从用户条目中解析用户信息
+func (c *Client) toUserInfo(entry *ldap.Entry) *UserInfo {
 	userInfo := &UserInfo{
 		DN:       entry.DN,
 		ID:       c.getAttributeValue(entry, c.config.UserIDAttr),
@@ -170,14 +195,7 @@ func (c *Client) searchUser(conn *ldap.Conn, username string) (*UserInfo, error)
 	if userInfo.Username == "" {
 		userInfo.Username = userInfo.ID
 	}
-
-	c.logger.Info("user found",
-		log.String("dn", userInfo.DN),
-		log.String("id", userInfo.ID),
-		log.String("username", userInfo.Username),
-		log.String("email", userInfo.Email))
-
-	return userInfo, nil
+	return userInfo
 }
 
 // getAttributeValue 获取LDAP属性值
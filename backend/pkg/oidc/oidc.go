@@ -0,0 +1,146 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"github.com/chaitin/panda-wiki/log"
+)
+
+type Client struct {
+	logger   *log.Logger
+	ctx      context.Context
+	provider *oidc.Provider
+	verifier *oidc.IDTokenVerifier
+	oauth    *oauth2.Config
+}
+
+type Config struct {
+	Issuer       string   `json:"issuer"` // 通过 {issuer}/.well-known/openid-configuration 获取端点
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	RedirectURL  string   `json:"redirect_url"`
+	Scopes       []string `json:"scopes,omitempty"` // 默认为 openid profile email
+}
+
+// UserInfo ID Token 和 UserInfo 端点返回的 claims，ID Token 中的优先
+type UserInfo struct {
+	Issuer  string         `json:"iss"`
+	Subject string         `json:"sub"`
+	Claims  map[string]any `json:"claims"`
+}
+
+var defaultScopes = []string{oidc.ScopeOpenID, "profile", "email"}
+
+// NewClient 创建OIDC客户端，会请求 IdP 的 discovery 文档
+func NewClient(ctx context.Context, logger *log.Logger, config Config) (*Client, error) {
+	provider, err := oidc.NewProvider(ctx, config.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery failed: %w", err)
+	}
+
+	scopes := config.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultScopes
+	}
+
+	return &Client{
+		ctx:      ctx,
+		logger:   logger.WithModule("pkg.oidc"),
+		provider: provider,
+		verifier: provider.Verifier(&oidc.Config{ClientID: config.ClientID}),
+		oauth: &oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			Endpoint:     provider.Endpoint(),
+			RedirectURL:  config.RedirectURL,
+			Scopes:       scopes,
+		},
+	}, nil
+}
+
+// GetAuthorizeURL 生成授权 URL，使用 PKCE 和 nonce
+func (c *Client) GetAuthorizeURL(state, nonce, verifier string) string {
+	return c.oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+}
+
+// GetUserInfo 使用授权码换取并校验 ID Token，获取用户的 claims
+func (c *Client) GetUserInfo(code, nonce, verifier string) (*UserInfo, error) {
+	token, err := c.oauth.Exchange(c.ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchange token failed: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, fmt.Errorf("id_token is missing in the token response")
+	}
+	idToken, err := c.verifier.Verify(c.ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("verify id_token failed: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, fmt.Errorf("invalid id_token nonce")
+	}
+
+	claims := make(map[string]any)
+	// 部分 IdP 只在 UserInfo 端点返回 profile、groups 等 claims
+	if userInfo, err := c.provider.UserInfo(c.ctx, oauth2.StaticTokenSource(token)); err != nil {
+		c.logger.Warn("get oidc userinfo failed", log.Error(err))
+	} else if userInfo.Subject == idToken.Subject {
+		if err := userInfo.Claims(&claims); err != nil {
+			c.logger.Warn("parse oidc userinfo claims failed", log.Error(err))
+		}
+	}
+	idTokenClaims := make(map[string]any)
+	if err := idToken.Claims(&idTokenClaims); err != nil {
+		return nil, fmt.Errorf("parse id_token claims failed: %w", err)
+	}
+	for k, v := range idTokenClaims {
+		claims[k] = v
+	}
+
+	return &UserInfo{
+		Issuer:  idToken.Issuer,
+		Subject: idToken.Subject,
+		Claims:  claims,
+	}, nil
+}
+
+// String 获取字符串类型的 claim
+func (u *UserInfo) String(claim string) string {
+	value, _ := u.Claims[claim].(string)
+	return value
+}
+
+// Bool 获取布尔类型的 claim，部分 IdP 使用字符串 "true" 表示，如 email_verified
+func (u *UserInfo) Bool(claim string) bool {
+	switch value := u.Claims[claim].(type) {
+	case bool:
+		return value
+	case string:
+		return strings.EqualFold(value, "true")
+	}
+	return false
+}
+
+// Strings 获取字符串数组类型的 claim，如 groups，单个字符串视为只有一个元素
+func (u *UserInfo) Strings(claim string) []string {
+	switch value := u.Claims[claim].(type) {
+	case string:
+		return []string{value}
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
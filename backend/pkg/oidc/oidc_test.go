@@ -0,0 +1,58 @@
+package oidc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUserInfoStrings(t *testing.T) {
+	userInfo := &UserInfo{
+		Subject: "sub",
+		Claims: map[string]any{
+			"groups": []any{"admin", 1, "dev"},
+			"role":   "user",
+			"age":    1,
+		},
+	}
+	tests := []struct {
+		claim string
+		want  []string
+	}{
+		{"groups", []string{"admin", "dev"}},
+		{"role", []string{"user"}},
+		{"age", nil},
+		{"missing", nil},
+	}
+	for _, tt := range tests {
+		if got := userInfo.Strings(tt.claim); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Strings(%q) = %v, want %v", tt.claim, got, tt.want)
+		}
+	}
+}
+
+func TestUserInfoBool(t *testing.T) {
+	userInfo := &UserInfo{
+		Subject: "sub",
+		Claims: map[string]any{
+			"email_verified": true,
+			"phone_verified": "True",
+			"verified":       "false",
+			"count":          1,
+		},
+	}
+	tests := []struct {
+		claim string
+		want  bool
+	}{
+		{"email_verified", true},
+		{"phone_verified", true},
+		{"verified", false},
+		{"count", false},
+		{"missing", false},
+	}
+	for _, tt := range tests {
+		if got := userInfo.Bool(tt.claim); got != tt.want {
+			t.Errorf("Bool(%q) = %v, want %v", tt.claim, got, tt.want)
+		}
+	}
+}
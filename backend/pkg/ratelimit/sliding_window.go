@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript checks all the keys first and records the request in the sorted sets of the keys
+// only if every key has less than its limit requests in the window, so a rejected request is not counted
+// in any window. Returns {0, 0} if allowed, otherwise {milliseconds until a request leaves the window,
+// 1-based index of the rejecting key}
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+for i, key in ipairs(KEYS) do
+	redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+	if redis.call('ZCARD', key) >= tonumber(ARGV[3 + i]) then
+		local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+		return {math.max(tonumber(oldest[2]) + window - now, 1), i}
+	end
+end
+for _, key in ipairs(KEYS) do
+	redis.call('ZADD', key, now, ARGV[3])
+	redis.call('PEXPIRE', key, window)
+end
+return {0, 0}
+`)
+
+// SlidingWindowLimit allows at most Limit requests of Key in the window
+type SlidingWindowLimit struct {
+	Key   string
+	Limit int
+}
+
+// AllowSlidingWindow tells whether one more request is allowed by all the limits in the window
+// Returns:
+// - bool: whether the request is allowed, it is counted in all the limits only if allowed
+// - time.Duration: time to wait before retrying if not allowed
+// - int: index of the limit rejecting the request, -1 if allowed
+func (r *RateLimiter) AllowSlidingWindow(ctx context.Context, limits []SlidingWindowLimit, window time.Duration) (bool, time.Duration, int, error) {
+	if len(limits) == 0 {
+		return true, 0, -1, nil
+	}
+	keys := make([]string, 0, len(limits))
+	args := []any{time.Now().UnixMilli(), window.Milliseconds(), uuid.New().String()}
+	for _, limit := range limits {
+		keys = append(keys, fmt.Sprintf("rate_limit:%s", limit.Key))
+		args = append(args, limit.Limit)
+	}
+	result, err := slidingWindowScript.Run(ctx, r.cache, keys, args...).Int64Slice()
+	if err != nil {
+		return false, 0, -1, err
+	}
+	if len(result) != 2 {
+		return false, 0, -1, fmt.Errorf("unexpected sliding window result: %v", result)
+	}
+	if result[0] > 0 {
+		return false, time.Duration(result[0]) * time.Millisecond, int(result[1]) - 1, nil
+	}
+	return true, 0, -1, nil
+}
@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/chaitin/panda-wiki/store/cache"
+)
+
+func newTestRateLimiter(t *testing.T) (*RateLimiter, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewRateLimiter(nil, &cache.Cache{Client: client}), mr
+}
+
+func TestAllowSlidingWindow(t *testing.T) {
+	r, _ := newTestRateLimiter(t)
+	ctx := context.Background()
+	limits := []SlidingWindowLimit{{Key: "ip", Limit: 2}}
+
+	for i := 0; i < 2; i++ {
+		allowed, _, _, err := r.AllowSlidingWindow(ctx, limits, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	allowed, retryAfter, rejected, err := r.AllowSlidingWindow(ctx, limits, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allowed || rejected != 0 {
+		t.Fatalf("expected rejected by limit 0, got allowed=%v rejected=%d", allowed, rejected)
+	}
+	if retryAfter <= 0 || retryAfter > time.Minute {
+		t.Errorf("unexpected retry after %v", retryAfter)
+	}
+}
+
+func TestAllowSlidingWindowExpires(t *testing.T) {
+	r, _ := newTestRateLimiter(t)
+	ctx := context.Background()
+	limits := []SlidingWindowLimit{{Key: "ip", Limit: 1}}
+	window := 50 * time.Millisecond
+
+	if allowed, _, _, err := r.AllowSlidingWindow(ctx, limits, window); err != nil || !allowed {
+		t.Fatalf("first request should be allowed, err: %v", err)
+	}
+	if allowed, _, _, err := r.AllowSlidingWindow(ctx, limits, window); err != nil || allowed {
+		t.Fatalf("second request should be rejected, err: %v", err)
+	}
+	time.Sleep(window + 10*time.Millisecond)
+	if allowed, _, _, err := r.AllowSlidingWindow(ctx, limits, window); err != nil || !allowed {
+		t.Fatalf("request after the window should be allowed, err: %v", err)
+	}
+}
+
+func TestAllowSlidingWindowRejectedNotCounted(t *testing.T) {
+	r, mr := newTestRateLimiter(t)
+	ctx := context.Background()
+	limits := []SlidingWindowLimit{
+		{Key: "ip", Limit: 10},
+		{Key: "conversation", Limit: 1},
+	}
+
+	if allowed, _, _, err := r.AllowSlidingWindow(ctx, limits, time.Minute); err != nil || !allowed {
+		t.Fatalf("first request should be allowed, err: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		allowed, _, rejected, err := r.AllowSlidingWindow(ctx, limits, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allowed || rejected != 1 {
+			t.Fatalf("expected rejected by limit 1, got allowed=%v rejected=%d", allowed, rejected)
+		}
+	}
+
+	// 被拒绝的请求不占用其他限制的名额
+	members, err := mr.ZMembers("rate_limit:ip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 {
+		t.Errorf("expected 1 request counted by ip, got %d", len(members))
+	}
+}
+
+func TestAllowSlidingWindowNoLimits(t *testing.T) {
+	r, _ := newTestRateLimiter(t)
+	allowed, _, rejected, err := r.AllowSlidingWindow(context.Background(), nil, time.Minute)
+	if err != nil || !allowed || rejected != -1 {
+		t.Fatalf("expected allowed without limits, got allowed=%v rejected=%d err=%v", allowed, rejected, err)
+	}
+}
@@ -0,0 +1,344 @@
+package saml
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/crewjam/saml"
+	dsig "github.com/russellhaering/goxmldsig"
+
+	"github.com/chaitin/panda-wiki/log"
+)
+
+type Client struct {
+	logger  *log.Logger
+	ctx     context.Context
+	config  *Config
+	sp      *saml.ServiceProvider
+	mapping AttributeMapping
+}
+
+type Config struct {
+	EntityID       string           `json:"entity_id"`        // SP实体ID，默认为SP元数据地址
+	MetadataURL    string           `json:"metadata_url"`     // SP元数据地址
+	AcsURL         string           `json:"acs_url"`          // 断言消费地址，IdP登录后将SAMLResponse POST到该地址
+	IDPMetadataURL string           `json:"idp_metadata_url"` // IdP元数据地址
+	IDPMetadataXML string           `json:"idp_metadata_xml"` // IdP元数据，设置后不再请求元数据地址
+	IDPMetadata    *IDPMetadata     `json:"-"`                // 已解析的IdP元数据，设置后不再解析或请求元数据
+	Certificate    string           `json:"certificate"`      // SP证书(PEM)，可选，用于签名AuthnRequest和解密加密的断言
+	PrivateKey     string           `json:"private_key"`      // SP私钥(PEM)
+	NameIDFormat   string           `json:"name_id_format"`   // 请求的NameID格式，默认不指定
+	Mapping        AttributeMapping `json:"mapping"`
+}
+
+// IDPMetadata 解析后的IdP元数据
+type IDPMetadata = saml.EntityDescriptor
+
+// AttributeMapping 断言属性到用户信息的映射，为空时按常见的属性名查找
+type AttributeMapping struct {
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type UserInfo struct {
+	ID         string              `json:"id"` // NameID
+	Username   string              `json:"username"`
+	Email      string              `json:"email"`
+	AvatarURL  string              `json:"avatar_url"`
+	Attributes map[string][]string `json:"attributes"`
+}
+
+// AuthnRequest 发往IdP的认证请求
+type AuthnRequest struct {
+	ID  string
+	req *saml.AuthnRequest
+	sp  *saml.ServiceProvider
+}
+
+var (
+	defaultUsernameAttributes = []string{
+		"displayName",
+		"http://schemas.microsoft.com/identity/claims/displayname",
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/name",
+		"name",
+		"cn",
+		"uid",
+	}
+	defaultEmailAttributes = []string{
+		"email",
+		"mail",
+		"emailAddress",
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress",
+	}
+	defaultAvatarAttributes = []string{
+		"avatar",
+		"picture",
+	}
+)
+
+const (
+	metadataFetchTimeout = 30 * time.Second
+	metadataMaxSize      = 10 << 20
+)
+
+var metadataHTTPClient = &http.Client{Timeout: metadataFetchTimeout}
+
+// NewClient 创建SAML SP客户端，会获取并解析IdP元数据
+func NewClient(ctx context.Context, logger *log.Logger, config Config) (*Client, error) {
+	metadataURL, err := url.Parse(config.MetadataURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metadata URL: %w", err)
+	}
+	acsURL, err := url.Parse(config.AcsURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ACS URL: %w", err)
+	}
+
+	c := &Client{
+		ctx:     ctx,
+		logger:  logger.WithModule("pkg.saml"),
+		config:  &config,
+		mapping: config.Mapping,
+	}
+
+	idpMetadata := config.IDPMetadata
+	if idpMetadata == nil {
+		idpMetadata, err = c.getIDPMetadata()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.sp = &saml.ServiceProvider{
+		EntityID:          config.EntityID,
+		MetadataURL:       *metadataURL,
+		AcsURL:            *acsURL,
+		IDPMetadata:       idpMetadata,
+		AuthnNameIDFormat: saml.UnspecifiedNameIDFormat,
+	}
+	if config.NameIDFormat != "" {
+		c.sp.AuthnNameIDFormat = saml.NameIDFormat(config.NameIDFormat)
+	}
+
+	if config.Certificate != "" || config.PrivateKey != "" {
+		cert, key, err := parseKeyPair(config.Certificate, config.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		c.sp.Certificate = cert
+		c.sp.Key = key
+		c.sp.SignatureMethod = dsig.RSASHA256SignatureMethod
+	}
+
+	return c, nil
+}
+
+// IDPMetadata 获取已解析的IdP元数据，可以缓存下来用于创建客户端
+func (c *Client) IDPMetadata() *IDPMetadata {
+	return c.sp.IDPMetadata
+}
+
+// Metadata 获取SP元数据，用于在IdP中注册应用
+func (c *Client) Metadata() ([]byte, error) {
+	metadata, err := xml.MarshalIndent(c.sp.Metadata(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+// NewAuthnRequest 创建认证请求，请求ID需要保存下来用于校验IdP的响应
+func (c *Client) NewAuthnRequest() (*AuthnRequest, error) {
+	idpURL := c.sp.GetSSOBindingLocation(saml.HTTPRedirectBinding)
+	if idpURL == "" {
+		return nil, fmt.Errorf("IdP does not support the HTTP-Redirect binding")
+	}
+	req, err := c.sp.MakeAuthenticationRequest(idpURL, saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make authn request: %w", err)
+	}
+	return &AuthnRequest{
+		ID:  req.ID,
+		req: req,
+		sp:  c.sp,
+	}, nil
+}
+
+// RedirectURL 获取跳转到IdP登录的URL
+func (r *AuthnRequest) RedirectURL(relayState string) (string, error) {
+	redirectURL, err := r.req.Redirect(relayState, r.sp)
+	if err != nil {
+		return "", fmt.Errorf("failed to build redirect URL: %w", err)
+	}
+	return redirectURL.String(), nil
+}
+
+// ParseResponse 校验IdP POST到ACS地址的SAMLResponse并获取用户信息，
+// 响应或断言必须由IdP元数据中的证书签名，且是对requestID的响应
+func (c *Client) ParseResponse(r *http.Request, requestID string) (*UserInfo, error) {
+	assertion, err := c.sp.ParseResponse(r, []string{requestID})
+	if err != nil {
+		var invalidErr *saml.InvalidResponseError
+		if errors.As(err, &invalidErr) {
+			c.logger.Warn("invalid SAML response", log.Error(invalidErr.PrivateErr))
+			return nil, fmt.Errorf("invalid SAML response: %w", invalidErr.PrivateErr)
+		}
+		return nil, fmt.Errorf("failed to parse SAML response: %w", err)
+	}
+	return c.getUserInfo(assertion)
+}
+
+func (c *Client) getUserInfo(assertion *saml.Assertion) (*UserInfo, error) {
+	if assertion.Subject == nil || assertion.Subject.NameID == nil || assertion.Subject.NameID.Value == "" {
+		return nil, fmt.Errorf("NameID is missing in the SAML assertion")
+	}
+	nameID := assertion.Subject.NameID
+
+	userInfo := &UserInfo{
+		ID:         nameID.Value,
+		Attributes: make(map[string][]string),
+	}
+	for _, statement := range assertion.AttributeStatements {
+		for _, attr := range statement.Attributes {
+			values := make([]string, 0, len(attr.Values))
+			for _, value := range attr.Values {
+				values = append(values, value.Value)
+			}
+			userInfo.Attributes[attr.Name] = append(userInfo.Attributes[attr.Name], values...)
+			if attr.FriendlyName != "" && attr.FriendlyName != attr.Name {
+				userInfo.Attributes[attr.FriendlyName] = append(userInfo.Attributes[attr.FriendlyName], values...)
+			}
+		}
+	}
+
+	userInfo.Username = userInfo.lookup(c.mapping.Username, defaultUsernameAttributes)
+	userInfo.Email = userInfo.lookup(c.mapping.Email, defaultEmailAttributes)
+	userInfo.AvatarURL = userInfo.lookup(c.mapping.AvatarURL, defaultAvatarAttributes)
+
+	if userInfo.Email == "" && nameID.Format == string(saml.EmailAddressNameIDFormat) {
+		userInfo.Email = nameID.Value
+	}
+	// 如果没有显示名称，使用NameID
+	if userInfo.Username == "" {
+		userInfo.Username = nameID.Value
+	}
+	return userInfo, nil
+}
+
+// lookup 获取映射的属性值，未配置映射时按默认属性名依次查找
+func (u *UserInfo) lookup(name string, defaults []string) string {
+	names := defaults
+	if name != "" {
+		names = []string{name}
+	}
+	for _, n := range names {
+		if values := u.Attributes[n]; len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+func (c *Client) getIDPMetadata() (*saml.EntityDescriptor, error) {
+	data := []byte(c.config.IDPMetadataXML)
+	if len(data) == 0 {
+		if c.config.IDPMetadataURL == "" {
+			return nil, fmt.Errorf("IdP metadata URL or XML is required")
+		}
+		var err error
+		data, err = c.fetchIDPMetadata()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parseIDPMetadata(data)
+}
+
+func (c *Client) fetchIDPMetadata() ([]byte, error) {
+	ctx, cancel := context.WithTimeout(c.ctx, metadataFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.IDPMetadataURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IdP metadata URL: %w", err)
+	}
+	resp, err := metadataHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch IdP metadata: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch IdP metadata, status: %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, metadataMaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IdP metadata: %w", err)
+	}
+	if len(data) > metadataMaxSize {
+		return nil, fmt.Errorf("IdP metadata exceeds %d bytes", metadataMaxSize)
+	}
+	return data, nil
+}
+
+// parseIDPMetadata 解析IdP元数据，支持EntitiesDescriptor中包含多个实体的情况
+func parseIDPMetadata(data []byte) (*saml.EntityDescriptor, error) {
+	var entity saml.EntityDescriptor
+	if err := xml.Unmarshal(data, &entity); err == nil {
+		if len(entity.IDPSSODescriptors) == 0 {
+			return nil, fmt.Errorf("no IDPSSODescriptor found in IdP metadata")
+		}
+		return &entity, nil
+	}
+
+	var entities saml.EntitiesDescriptor
+	if err := xml.Unmarshal(data, &entities); err != nil {
+		return nil, fmt.Errorf("failed to parse IdP metadata: %w", err)
+	}
+	for i := range entities.EntityDescriptors {
+		if len(entities.EntityDescriptors[i].IDPSSODescriptors) > 0 {
+			return &entities.EntityDescriptors[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no IDPSSODescriptor found in IdP metadata")
+}
+
+func parseKeyPair(certPEM, keyPEM string) (*x509.Certificate, crypto.Signer, error) {
+	certBlock, _ := pem.Decode([]byte(certPEM))
+	if certBlock == nil {
+		return nil, nil, fmt.Errorf("invalid SP certificate")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse SP certificate: %w", err)
+	}
+
+	keyBlock, _ := pem.Decode([]byte(keyPEM))
+	if keyBlock == nil {
+		return nil, nil, fmt.Errorf("invalid SP private key")
+	}
+	var key any
+	key, err = x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		key, err = x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse SP private key: %w", err)
+		}
+	}
+	// AuthnRequest 使用 RSA-SHA256 签名
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, fmt.Errorf("SP private key must be an RSA key")
+	}
+	return cert, rsaKey, nil
+}
@@ -0,0 +1,41 @@
+package saml
+
+import (
+	"testing"
+
+	"github.com/crewjam/saml"
+)
+
+func TestGetUserInfo(t *testing.T) {
+	assertion := &saml.Assertion{
+		Subject: &saml.Subject{
+			NameID: &saml.NameID{Format: string(saml.EmailAddressNameIDFormat), Value: "alice@example.com"},
+		},
+		AttributeStatements: []saml.AttributeStatement{{
+			Attributes: []saml.Attribute{
+				{Name: "urn:oid:2.16.840.1.113730.3.1.241", FriendlyName: "displayName", Values: []saml.AttributeValue{{Value: "Alice"}}},
+				{Name: "department", Values: []saml.AttributeValue{{Value: "R&D"}}},
+			},
+		}},
+	}
+
+	tests := []struct {
+		mapping  AttributeMapping
+		username string
+		email    string
+	}{
+		{AttributeMapping{}, "Alice", "alice@example.com"},
+		{AttributeMapping{Username: "department"}, "R&D", "alice@example.com"},
+		{AttributeMapping{Username: "missing"}, "alice@example.com", "alice@example.com"},
+	}
+	for _, tt := range tests {
+		c := &Client{mapping: tt.mapping}
+		userInfo, err := c.getUserInfo(assertion)
+		if err != nil {
+			t.Fatalf("getUserInfo() error = %v", err)
+		}
+		if userInfo.ID != "alice@example.com" || userInfo.Username != tt.username || userInfo.Email != tt.email {
+			t.Errorf("getUserInfo() with mapping %+v = %+v, want username %q email %q", tt.mapping, userInfo, tt.username, tt.email)
+		}
+	}
+}
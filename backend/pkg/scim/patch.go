@@ -0,0 +1,253 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const (
+	opAdd     = "add"
+	opReplace = "replace"
+	opRemove  = "remove"
+)
+
+// ApplyPatch 将 PATCH 操作应用到用户上，不支持的属性会被忽略
+func (u *User) ApplyPatch(ops []PatchOperation) error {
+	for _, op := range ops {
+		switch strings.ToLower(op.Op) {
+		case opAdd, opReplace:
+			if op.Path == "" {
+				values, err := parseObject(op.Value)
+				if err != nil {
+					return err
+				}
+				for attr, value := range values {
+					path, err := ParsePath(attr)
+					if err != nil {
+						return err
+					}
+					if err := u.set(path, value); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			path, err := ParsePath(op.Path)
+			if err != nil {
+				return err
+			}
+			if err := u.set(path, op.Value); err != nil {
+				return err
+			}
+		case opRemove:
+			path, err := ParsePath(op.Path)
+			if err != nil {
+				return err
+			}
+			u.remove(path)
+		default:
+			return NewError(http.StatusBadRequest, ErrTypeInvalidSyntax, "unsupported patch operation: "+op.Op)
+		}
+	}
+	return nil
+}
+
+func (u *User) set(path *Path, value json.RawMessage) error {
+	var err error
+	switch path.Attribute {
+	case "active":
+		var active bool
+		active, err = parseBool(value)
+		u.Active = &active
+	case "username":
+		u.UserName, err = parseString(value)
+	case "displayname":
+		u.DisplayName, err = parseString(value)
+	case "externalid":
+		u.ExternalID, err = parseString(value)
+	case "name":
+		if u.Name == nil {
+			u.Name = &Name{}
+		}
+		switch path.SubAttribute {
+		case "":
+			err = json.Unmarshal(value, u.Name)
+		case "formatted":
+			u.Name.Formatted, err = parseString(value)
+		case "givenname":
+			u.Name.GivenName, err = parseString(value)
+		case "familyname":
+			u.Name.FamilyName, err = parseString(value)
+		}
+	case "emails":
+		u.Emails, err = setMultiValue(u.Emails, path, value)
+	case "photos":
+		u.Photos, err = setMultiValue(u.Photos, path, value)
+	}
+	if err != nil {
+		return NewError(http.StatusBadRequest, ErrTypeInvalidValue, "invalid value of "+path.Attribute)
+	}
+	return nil
+}
+
+func (u *User) remove(path *Path) {
+	switch path.Attribute {
+	case "displayname":
+		u.DisplayName = ""
+	case "externalid":
+		u.ExternalID = ""
+	case "name":
+		u.Name = nil
+	case "emails":
+		u.Emails = nil
+	case "photos":
+		u.Photos = nil
+	}
+}
+
+// setMultiValue 设置多值属性，如 emails[type eq "work"].value 只设置单个值并作为主值
+func setMultiValue(values []MultiValue, path *Path, value json.RawMessage) ([]MultiValue, error) {
+	if path.SubAttribute == "" {
+		var newValues []MultiValue
+		if err := json.Unmarshal(value, &newValues); err != nil {
+			return nil, err
+		}
+		return newValues, nil
+	}
+	if path.SubAttribute != "value" {
+		return values, nil
+	}
+	v, err := parseString(value)
+	if err != nil {
+		return nil, err
+	}
+	newValue := MultiValue{Value: v, Primary: true}
+	if path.Filter != nil && path.Filter.Attribute == "type" {
+		newValue.Type = path.Filter.Value
+	}
+	return []MultiValue{newValue}, nil
+}
+
+// ApplyPatch 将 PATCH 操作应用到组上，支持修改名称和增删成员
+func (g *Group) ApplyPatch(ops []PatchOperation) error {
+	for _, op := range ops {
+		opName := strings.ToLower(op.Op)
+		switch opName {
+		case opAdd, opReplace:
+			if op.Path == "" {
+				values, err := parseObject(op.Value)
+				if err != nil {
+					return err
+				}
+				for attr, value := range values {
+					path, err := ParsePath(attr)
+					if err != nil {
+						return err
+					}
+					if err := g.set(opName, path, value); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			path, err := ParsePath(op.Path)
+			if err != nil {
+				return err
+			}
+			if err := g.set(opName, path, op.Value); err != nil {
+				return err
+			}
+		case opRemove:
+			path, err := ParsePath(op.Path)
+			if err != nil {
+				return err
+			}
+			if err := g.remove(path, op.Value); err != nil {
+				return err
+			}
+		default:
+			return NewError(http.StatusBadRequest, ErrTypeInvalidSyntax, "unsupported patch operation: "+op.Op)
+		}
+	}
+	return nil
+}
+
+func (g *Group) set(op string, path *Path, value json.RawMessage) error {
+	var err error
+	switch path.Attribute {
+	case "displayname":
+		g.DisplayName, err = parseString(value)
+	case "externalid":
+		g.ExternalID, err = parseString(value)
+	case "members":
+		var members []Member
+		if err := json.Unmarshal(value, &members); err != nil {
+			return NewError(http.StatusBadRequest, ErrTypeInvalidValue, "invalid value of members")
+		}
+		if op == opReplace {
+			g.Members = nil
+		}
+		for _, member := range members {
+			if !g.hasMember(member.Value) {
+				g.Members = append(g.Members, member)
+			}
+		}
+	}
+	if err != nil {
+		return NewError(http.StatusBadRequest, ErrTypeInvalidValue, "invalid value of "+path.Attribute)
+	}
+	return nil
+}
+
+// remove 删除成员，支持 members[value eq "id"] 和在 value 中指定成员两种方式，都没有时删除所有成员
+func (g *Group) remove(path *Path, value json.RawMessage) error {
+	switch path.Attribute {
+	case "externalid":
+		g.ExternalID = ""
+	case "members":
+		removed := make(map[string]bool)
+		if path.Filter != nil {
+			if path.Filter.Attribute != "value" {
+				return NewError(http.StatusBadRequest, ErrTypeInvalidFilter, "unsupported members filter: "+path.Filter.Attribute)
+			}
+			removed[path.Filter.Value] = true
+		} else if len(value) > 0 && string(value) != "null" {
+			var members []Member
+			if err := json.Unmarshal(value, &members); err != nil {
+				return NewError(http.StatusBadRequest, ErrTypeInvalidValue, "invalid value of members")
+			}
+			for _, member := range members {
+				removed[member.Value] = true
+			}
+		} else {
+			g.Members = nil
+			return nil
+		}
+		members := make([]Member, 0, len(g.Members))
+		for _, member := range g.Members {
+			if !removed[member.Value] {
+				members = append(members, member)
+			}
+		}
+		g.Members = members
+	}
+	return nil
+}
+
+func (g *Group) hasMember(value string) bool {
+	for _, member := range g.Members {
+		if member.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func parseObject(raw json.RawMessage) (map[string]json.RawMessage, error) {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, NewError(http.StatusBadRequest, ErrTypeInvalidValue, "patch value must be an object when path is empty")
+	}
+	return values, nil
+}
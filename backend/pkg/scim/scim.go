@@ -0,0 +1,363 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	ContentType = "application/scim+json"
+
+	ResourceTypeUser  = "User"
+	ResourceTypeGroup = "Group"
+
+	// MaxResults 列表接口单次返回的最大数量
+	MaxResults = 1000
+)
+
+// scimType of the errors, RFC 7644 section 3.12
+const (
+	ErrTypeInvalidFilter = "invalidFilter"
+	ErrTypeInvalidPath   = "invalidPath"
+	ErrTypeInvalidValue  = "invalidValue"
+	ErrTypeInvalidSyntax = "invalidSyntax"
+	ErrTypeUniqueness    = "uniqueness"
+	ErrTypeNoTarget      = "noTarget"
+)
+
+type Meta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+}
+
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// MultiValue 多值属性，如 emails、photos
+type MultiValue struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type Member struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type User struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	ExternalID  string       `json:"externalId,omitempty"`
+	UserName    string       `json:"userName"`
+	Name        *Name        `json:"name,omitempty"`
+	DisplayName string       `json:"displayName,omitempty"`
+	Emails      []MultiValue `json:"emails,omitempty"`
+	Photos      []MultiValue `json:"photos,omitempty"`
+	Active      *bool        `json:"active,omitempty"`
+	Groups      []Member     `json:"groups,omitempty"` // 只读
+	Meta        *Meta        `json:"meta,omitempty"`
+}
+
+type Group struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	ExternalID  string   `json:"externalId,omitempty"`
+	DisplayName string   `json:"displayName"`
+	Members     []Member `json:"members"`
+	Meta        *Meta    `json:"meta,omitempty"`
+}
+
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int64    `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Error SCIM 错误响应，同时作为 error 返回给调用方
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+func NewError(status int, scimType, detail string) *Error {
+	return &Error{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("scim error %s: %s", e.Status, e.Detail)
+}
+
+func (e *Error) StatusCode() int {
+	status, err := strconv.Atoi(e.Status)
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	return status
+}
+
+func NewListResponse(total int64, startIndex int, resources []any) *ListResponse {
+	return &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+// PrimaryEmail 获取主邮箱，没有标记为主邮箱时使用第一个
+func (u *User) PrimaryEmail() string {
+	for _, email := range u.Emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+	if len(u.Emails) > 0 {
+		return u.Emails[0].Value
+	}
+	return ""
+}
+
+// PrimaryPhoto 获取主头像，没有标记为主头像时使用第一个
+func (u *User) PrimaryPhoto() string {
+	for _, photo := range u.Photos {
+		if photo.Primary {
+			return photo.Value
+		}
+	}
+	if len(u.Photos) > 0 {
+		return u.Photos[0].Value
+	}
+	return ""
+}
+
+// IsActive 未设置 active 时视为启用
+func (u *User) IsActive() bool {
+	return u.Active == nil || *u.Active
+}
+
+// Filter 过滤条件，只支持 eq 操作，如 userName eq "alice"
+type Filter struct {
+	Attribute string // 小写的属性名
+	Value     string
+}
+
+// ParseFilter 解析 filter 参数，为空时返回 nil
+func ParseFilter(filter string) (*Filter, error) {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return nil, nil
+	}
+	attr, rest, ok := strings.Cut(filter, " ")
+	if !ok {
+		return nil, NewError(http.StatusBadRequest, ErrTypeInvalidFilter, "invalid filter: "+filter)
+	}
+	op, value, ok := strings.Cut(strings.TrimSpace(rest), " ")
+	if !ok {
+		return nil, NewError(http.StatusBadRequest, ErrTypeInvalidFilter, "invalid filter: "+filter)
+	}
+	if !strings.EqualFold(op, "eq") {
+		return nil, NewError(http.StatusBadRequest, ErrTypeInvalidFilter, "unsupported filter operator: "+op)
+	}
+	var v string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(value)), &v); err != nil {
+		return nil, NewError(http.StatusBadRequest, ErrTypeInvalidFilter, "filter value must be a string: "+value)
+	}
+	return &Filter{
+		Attribute: normalizeAttr(attr),
+		Value:     v,
+	}, nil
+}
+
+// Path PATCH 操作的路径，如 members[value eq "1"]、emails[type eq "work"].value
+type Path struct {
+	Attribute    string // 小写的属性名
+	Filter       *Filter
+	SubAttribute string // 小写的子属性名
+}
+
+func ParsePath(path string) (*Path, error) {
+	p := &Path{}
+	attr := path
+	if i := strings.Index(path, "["); i >= 0 {
+		j := strings.LastIndex(path, "]")
+		if j < i {
+			return nil, NewError(http.StatusBadRequest, ErrTypeInvalidPath, "invalid path: "+path)
+		}
+		filter, err := ParseFilter(path[i+1 : j])
+		if err != nil {
+			return nil, NewError(http.StatusBadRequest, ErrTypeInvalidPath, "invalid path: "+path)
+		}
+		p.Filter = filter
+		attr = path[:i]
+		if sub := path[j+1:]; sub != "" {
+			if !strings.HasPrefix(sub, ".") {
+				return nil, NewError(http.StatusBadRequest, ErrTypeInvalidPath, "invalid path: "+path)
+			}
+			p.SubAttribute = strings.ToLower(sub[1:])
+		}
+	}
+	attr = normalizeAttr(attr)
+	if p.Filter == nil {
+		attr, p.SubAttribute, _ = strings.Cut(attr, ".")
+	}
+	p.Attribute = attr
+	if p.Attribute == "" {
+		return nil, NewError(http.StatusBadRequest, ErrTypeInvalidPath, "invalid path: "+path)
+	}
+	return p, nil
+}
+
+// normalizeAttr 属性名不区分大小写，并去掉 schema 前缀
+func normalizeAttr(attr string) string {
+	attr = strings.TrimSpace(attr)
+	for _, schema := range []string{SchemaUser, SchemaGroup} {
+		if len(attr) > len(schema) && strings.EqualFold(attr[:len(schema)+1], schema+":") {
+			attr = attr[len(schema)+1:]
+			break
+		}
+	}
+	return strings.ToLower(attr)
+}
+
+// parseBool 部分 IdP（如 Azure AD）使用字符串表示布尔值
+func parseBool(raw json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return false, NewError(http.StatusBadRequest, ErrTypeInvalidValue, "invalid boolean value: "+string(raw))
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, NewError(http.StatusBadRequest, ErrTypeInvalidValue, "invalid boolean value: "+s)
+	}
+	return b, nil
+}
+
+func parseString(raw json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return "", NewError(http.StatusBadRequest, ErrTypeInvalidValue, "invalid string value: "+string(raw))
+	}
+	return s, nil
+}
+
+type supported struct {
+	Supported bool `json:"supported"`
+}
+
+type filterSupported struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type bulkSupported struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+type authenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Primary     bool   `json:"primary"`
+}
+
+type ServiceProviderConfig struct {
+	Schemas               []string               `json:"schemas"`
+	Patch                 supported              `json:"patch"`
+	Bulk                  bulkSupported          `json:"bulk"`
+	Filter                filterSupported        `json:"filter"`
+	ChangePassword        supported              `json:"changePassword"`
+	Sort                  supported              `json:"sort"`
+	ETag                  supported              `json:"etag"`
+	AuthenticationSchemes []authenticationScheme `json:"authenticationSchemes"`
+}
+
+type ResourceType struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Endpoint    string   `json:"endpoint"`
+	Description string   `json:"description"`
+	Schema      string   `json:"schema"`
+}
+
+// NewServiceProviderConfig 支持 PATCH 和 eq 过滤，不支持批量操作、排序和 ETag
+func NewServiceProviderConfig(maxResults int) *ServiceProviderConfig {
+	return &ServiceProviderConfig{
+		Schemas: []string{SchemaServiceProviderConfig},
+		Patch:   supported{Supported: true},
+		Filter:  filterSupported{Supported: true, MaxResults: maxResults},
+		AuthenticationSchemes: []authenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "OAuth Bearer Token",
+			Description: "Authentication scheme using the API token of the knowledge base",
+			Primary:     true,
+		}},
+	}
+}
+
+func NewResourceTypes() *ListResponse {
+	return NewListResponse(2, 1, []any{
+		&ResourceType{
+			Schemas:     []string{SchemaResourceType},
+			ID:          ResourceTypeUser,
+			Name:        ResourceTypeUser,
+			Endpoint:    "/Users",
+			Description: "User Account",
+			Schema:      SchemaUser,
+		},
+		&ResourceType{
+			Schemas:     []string{SchemaResourceType},
+			ID:          ResourceTypeGroup,
+			Name:        ResourceTypeGroup,
+			Endpoint:    "/Groups",
+			Description: "Group",
+			Schema:      SchemaGroup,
+		},
+	})
+}
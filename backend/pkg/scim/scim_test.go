@@ -0,0 +1,101 @@
+package scim
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		filter  string
+		want    *Filter
+		wantErr bool
+	}{
+		{filter: "", want: nil},
+		{filter: `userName eq "alice@example.com"`, want: &Filter{Attribute: "username", Value: "alice@example.com"}},
+		{filter: `externalId EQ "a b"`, want: &Filter{Attribute: "externalid", Value: "a b"}},
+		{filter: `urn:ietf:params:scim:schemas:core:2.0:User:userName eq "bob"`, want: &Filter{Attribute: "username", Value: "bob"}},
+		{filter: `userName co "alice"`, wantErr: true},
+		{filter: `userName eq alice`, wantErr: true},
+		{filter: `userName`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFilter(tt.filter)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFilter(%q) error = %v, wantErr %v", tt.filter, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseFilter(%q) = %+v, want %+v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want *Path
+	}{
+		{path: "active", want: &Path{Attribute: "active"}},
+		{path: "name.givenName", want: &Path{Attribute: "name", SubAttribute: "givenname"}},
+		{path: `members[value eq "12"]`, want: &Path{Attribute: "members", Filter: &Filter{Attribute: "value", Value: "12"}}},
+		{path: `emails[type eq "work"].value`, want: &Path{Attribute: "emails", Filter: &Filter{Attribute: "type", Value: "work"}, SubAttribute: "value"}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePath(tt.path)
+		if err != nil {
+			t.Errorf("ParsePath(%q) error = %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePath(%q) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestUserApplyPatch(t *testing.T) {
+	user := &User{UserName: "alice", DisplayName: "Alice"}
+	var req PatchRequest
+	if err := json.Unmarshal([]byte(`{"Operations": [
+		{"op": "Replace", "path": "active", "value": "False"},
+		{"op": "replace", "value": {"displayName": "Alice Liddell", "name.givenName": "Alice"}},
+		{"op": "add", "path": "emails[type eq \"work\"].value", "value": "alice@example.com"}
+	]}`), &req); err != nil {
+		t.Fatal(err)
+	}
+	if err := user.ApplyPatch(req.Operations); err != nil {
+		t.Fatal(err)
+	}
+	if user.IsActive() {
+		t.Errorf("user should be inactive")
+	}
+	if user.DisplayName != "Alice Liddell" || user.Name == nil || user.Name.GivenName != "Alice" {
+		t.Errorf("unexpected name: %q %+v", user.DisplayName, user.Name)
+	}
+	if user.PrimaryEmail() != "alice@example.com" {
+		t.Errorf("PrimaryEmail() = %q", user.PrimaryEmail())
+	}
+}
+
+func TestGroupApplyPatch(t *testing.T) {
+	group := &Group{DisplayName: "dev", Members: []Member{{Value: "1"}, {Value: "2"}}}
+	var req PatchRequest
+	if err := json.Unmarshal([]byte(`{"Operations": [
+		{"op": "add", "path": "members", "value": [{"value": "2"}, {"value": "3"}]},
+		{"op": "remove", "path": "members[value eq \"1\"]"},
+		{"op": "remove", "path": "members", "value": [{"value": "3"}]},
+		{"op": "replace", "value": {"displayName": "developers"}}
+	]}`), &req); err != nil {
+		t.Fatal(err)
+	}
+	if err := group.ApplyPatch(req.Operations); err != nil {
+		t.Fatal(err)
+	}
+	if group.DisplayName != "developers" {
+		t.Errorf("DisplayName = %q", group.DisplayName)
+	}
+	if want := []Member{{Value: "2"}}; !reflect.DeepEqual(group.Members, want) {
+		t.Errorf("Members = %+v, want %+v", group.Members, want)
+	}
+}
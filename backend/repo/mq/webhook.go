@@ -0,0 +1,43 @@
+package mq
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/mq"
+)
+
+type WebhookRepository struct {
+	producer mq.MQProducer
+}
+
+func NewWebhookRepository(producer mq.MQProducer) *WebhookRepository {
+	return &WebhookRepository{producer: producer}
+}
+
+// AsyncTriggerEvent publishes a knowledge base event, the consumer delivers it to the subscribed webhooks
+func (r *WebhookRepository) AsyncTriggerEvent(ctx context.Context, kbID string, event domain.WebhookEventType, data any) error {
+	eventBytes, err := json.Marshal(&domain.WebhookEvent{
+		ID:         uuid.New().String(),
+		KBID:       kbID,
+		Event:      event,
+		Data:       data,
+		OccurredAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return r.producer.Produce(ctx, domain.WebhookEventTopic, kbID, eventBytes)
+}
+
+func (r *WebhookRepository) AsyncDeliver(ctx context.Context, deliveryID string) error {
+	requestBytes, err := json.Marshal(&domain.WebhookDeliveryRequest{DeliveryID: deliveryID})
+	if err != nil {
+		return err
+	}
+	return r.producer.Produce(ctx, domain.WebhookDeliveryTopic, deliveryID, requestBytes)
+}
@@ -255,6 +255,18 @@ func (r *AuthRepo) GetAuthConfig(ctx context.Context, kbID string, sourceType co
 	return &authConfig, nil
 }
 
+// ListAuthConfigsBySourceType 获取所有知识库指定来源的认证配置
+func (r *AuthRepo) ListAuthConfigsBySourceType(ctx context.Context, sourceType consts.SourceType) ([]domain.AuthConfig, error) {
+	var authConfigs []domain.AuthConfig
+	if err := r.db.WithContext(ctx).
+		Model(&domain.AuthConfig{}).
+		Where("source_type = ?", string(sourceType)).
+		Find(&authConfigs).Error; err != nil {
+		return nil, err
+	}
+	return authConfigs, nil
+}
+
 func (r *AuthRepo) GetAuths(ctx context.Context, kbID string, sourceType consts.SourceType) ([]domain.Auth, error) {
 	auths := make([]domain.Auth, 0)
 
@@ -312,6 +324,9 @@ func (r *AuthRepo) GetOrCreateAuth(ctx context.Context, auth *domain.Auth, sourc
 			}
 			return err
 		}
+		if existing.Disabled {
+			return domain.ErrAuthDisabled
+		}
 
 		updateMap := map[string]interface{}{
 			"last_login_time": time.Now(),
@@ -352,6 +367,20 @@ func (r *AuthRepo) ListAuthGroups(ctx context.Context, kbID string) ([]domain.Au
 	return groups, nil
 }
 
+// ListAuthGroupsBySourceType 获取知识库下指定来源的所有用户组
+func (r *AuthRepo) ListAuthGroupsBySourceType(ctx context.Context, kbID string, sourceType consts.SourceType) ([]domain.AuthGroup, error) {
+	var groups []domain.AuthGroup
+	if err := r.db.WithContext(ctx).
+		Model(&domain.AuthGroup{}).
+		Where("kb_id = ?", kbID).
+		Where("source_type = ?", sourceType).
+		Order("id ASC").
+		Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
 // GetAuthGroup 获取单个用户组
 func (r *AuthRepo) GetAuthGroup(ctx context.Context, id uint) (*domain.AuthGroup, error) {
 	var group domain.AuthGroup
@@ -362,6 +391,16 @@ func (r *AuthRepo) GetAuthGroup(ctx context.Context, id uint) (*domain.AuthGroup
 	return &group, nil
 }
 
+// GetAuthGroupByName 按名称获取知识库的用户组
+func (r *AuthRepo) GetAuthGroupByName(ctx context.Context, kbID, name string) (*domain.AuthGroup, error) {
+	var group domain.AuthGroup
+	err := r.db.WithContext(ctx).Model(&domain.AuthGroup{}).Where("kb_id = ? AND name = ?", kbID, name).First(&group).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
 // CreateAuthGroup 创建用户组
 func (r *AuthRepo) CreateAuthGroup(ctx context.Context, group *domain.AuthGroup) error {
 	return r.db.WithContext(ctx).Create(group).Error
@@ -418,3 +457,113 @@ func (r *AuthRepo) UpdateUserGroups(ctx context.Context, userID string, groupIDs
 		return nil
 	})
 }
+
+// IsAuthActive 用户存在且未被禁用
+func (r *AuthRepo) IsAuthActive(ctx context.Context, id uint) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Auth{}).
+		Where("id = ? AND disabled = ?", id, false).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *AuthRepo) GetAuthByUnionID(ctx context.Context, kbID string, sourceType consts.SourceType, unionID string) (*domain.Auth, error) {
+	var auth domain.Auth
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Auth{}).
+		Where("kb_id = ?", kbID).
+		Where("source_type = ?", sourceType).
+		Where("union_id = ?", unionID).
+		First(&auth).Error; err != nil {
+		return nil, err
+	}
+	return &auth, nil
+}
+
+func (r *AuthRepo) GetAuthsByIDs(ctx context.Context, kbID string, ids []uint) ([]domain.Auth, error) {
+	auths := make([]domain.Auth, 0)
+	if len(ids) == 0 {
+		return auths, nil
+	}
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Auth{}).
+		Where("kb_id = ?", kbID).
+		Where("id IN (?)", ids).
+		Find(&auths).Error; err != nil {
+		return nil, err
+	}
+	return auths, nil
+}
+
+// ListAuthsByAttr 分页获取用户，column 不为空时按 column = value 过滤，column 必须是调用方固定的字段
+func (r *AuthRepo) ListAuthsByAttr(ctx context.Context, kbID string, sourceType consts.SourceType, column, value string, offset, limit int) ([]domain.Auth, int64, error) {
+	query := r.db.WithContext(ctx).
+		Model(&domain.Auth{}).
+		Where("kb_id = ?", kbID).
+		Where("source_type = ?", sourceType)
+	if column != "" {
+		query = query.Where(column+" = ?", value)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	auths := make([]domain.Auth, 0)
+	if limit > 0 {
+		if err := query.Order("id ASC").Offset(offset).Limit(limit).Find(&auths).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+	return auths, total, nil
+}
+
+func (r *AuthRepo) UpdateAuth(ctx context.Context, kbID string, id uint, updates map[string]any) error {
+	updates["updated_at"] = time.Now()
+	return r.db.WithContext(ctx).
+		Model(&domain.Auth{}).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		Updates(updates).Error
+}
+
+// DeleteAuthWithGroups 删除用户并从所有用户组中移除
+func (r *AuthRepo) DeleteAuthWithGroups(ctx context.Context, kbID string, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("kb_id = ? AND id = ?", kbID, id).Delete(&domain.Auth{}).Error; err != nil {
+			return err
+		}
+		return tx.Exec("UPDATE auth_groups SET auth_ids = array_remove(auth_ids, ?) WHERE kb_id = ?", id, kbID).Error
+	})
+}
+
+// ListAuthGroupsByAttr 分页获取用户组，column 不为空时按 column = value 过滤，column 必须是调用方固定的字段
+func (r *AuthRepo) ListAuthGroupsByAttr(ctx context.Context, kbID string, sourceType consts.SourceType, column, value string, offset, limit int) ([]domain.AuthGroup, int64, error) {
+	query := r.db.WithContext(ctx).
+		Model(&domain.AuthGroup{}).
+		Where("kb_id = ?", kbID).
+		Where("source_type = ?", sourceType)
+	if column != "" {
+		query = query.Where(column+" = ?", value)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	groups := make([]domain.AuthGroup, 0)
+	if limit > 0 {
+		if err := query.Order("id ASC").Offset(offset).Limit(limit).Find(&groups).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+	return groups, total, nil
+}
+
+// UpdateAuthGroupFields 更新用户组的指定字段，可以更新为零值，如清空成员
+func (r *AuthRepo) UpdateAuthGroupFields(ctx context.Context, id uint, updates map[string]any) error {
+	updates["updated_at"] = time.Now()
+	return r.db.WithContext(ctx).Model(&domain.AuthGroup{}).Where("id = ?", id).Updates(updates).Error
+}
@@ -0,0 +1,86 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+type ContributeRepository struct {
+	db     *pg.DB
+	logger *log.Logger
+}
+
+func NewContributeRepository(db *pg.DB, logger *log.Logger) *ContributeRepository {
+	return &ContributeRepository{db: db, logger: logger.WithModule("repo.pg.contribute")}
+}
+
+func (r *ContributeRepository) Create(ctx context.Context, contribute *domain.Contribute) error {
+	return r.db.WithContext(ctx).Create(contribute).Error
+}
+
+func (r *ContributeRepository) GetByID(ctx context.Context, kbID, id string) (*domain.Contribute, error) {
+	var contribute domain.Contribute
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		First(&contribute).Error; err != nil {
+		return nil, err
+	}
+	return &contribute, nil
+}
+
+// GetList returns contributions without content, filtered by status if not empty
+func (r *ContributeRepository) GetList(ctx context.Context, kbID string, status consts.ContributeStatus, pager *domain.Pager) (int64, []*domain.Contribute, error) {
+	query := r.db.WithContext(ctx).
+		Model(&domain.Contribute{}).
+		Where("kb_id = ?", kbID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return 0, nil, err
+	}
+	var contributes []*domain.Contribute
+	if err := query.
+		Omit("content").
+		Order("created_at DESC").
+		Offset(pager.Offset()).
+		Limit(pager.Limit()).
+		Find(&contributes).Error; err != nil {
+		return 0, nil, err
+	}
+	return total, contributes, nil
+}
+
+// Audit moves a pending contribution to the given status,
+// returns false if it has been audited already
+func (r *ContributeRepository) Audit(ctx context.Context, kbID, id string, status consts.ContributeStatus, auditUserID, reason string) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&domain.Contribute{}).
+		Where("kb_id = ? AND id = ? AND status = ?", kbID, id, consts.ContributeStatusPending).
+		Updates(map[string]any{
+			"status":        status,
+			"audit_user_id": auditUserID,
+			"audit_time":    now,
+			"reason":        reason,
+			"updated_at":    now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *ContributeRepository) Update(ctx context.Context, id string, updateMap map[string]any) error {
+	updateMap["updated_at"] = time.Now()
+	return r.db.WithContext(ctx).
+		Model(&domain.Contribute{}).
+		Where("id = ?", id).
+		Updates(updateMap).Error
+}
@@ -3,10 +3,13 @@ package pg
 import (
 	"context"
 	"strconv"
+	"time"
 
 	"github.com/cloudwego/eino/schema"
+	"github.com/samber/lo"
 	"gorm.io/gorm"
 
+	v1 "github.com/chaitin/panda-wiki/api/conversation/v1"
 	"github.com/chaitin/panda-wiki/domain"
 	"github.com/chaitin/panda-wiki/log"
 	"github.com/chaitin/panda-wiki/store/pg"
@@ -292,3 +295,76 @@ func (r *ConversationRepository) GetConversationCountByAppType(ctx context.Conte
 	}
 	return result, nil
 }
+
+// ExportMessages calls fn with the answers matching the request batch by batch in creation order,
+// together with their questions and references, so that the whole result is never held in memory
+func (r *ConversationRepository) ExportMessages(ctx context.Context, req *v1.ConversationExportReq, batchSize int, fn func([]*domain.ConversationExportRow) error) error {
+	var lastCreatedAt time.Time
+	var lastID string
+	for {
+		query := r.db.WithContext(ctx).Table("conversation_messages as cm").
+			Joins("LEFT JOIN LATERAL (SELECT content FROM conversation_messages WHERE conversation_id = cm.conversation_id AND role = 'user' AND created_at < cm.created_at ORDER BY created_at DESC LIMIT 1) u ON true").
+			Joins("JOIN conversations ON conversations.id = cm.conversation_id").
+			Joins("LEFT JOIN apps ON cm.app_id = apps.id").
+			Where("cm.kb_id = ?", req.KbID).
+			Where("cm.role = ?", schema.Assistant)
+		if req.AppID != "" {
+			query = query.Where("cm.app_id = ?", req.AppID)
+		}
+		if req.StartAt > 0 {
+			query = query.Where("cm.created_at >= ?", time.Unix(req.StartAt, 0))
+		}
+		if req.EndAt > 0 {
+			query = query.Where("cm.created_at < ?", time.Unix(req.EndAt, 0))
+		}
+		if req.Score != nil {
+			query = query.Where("COALESCE((cm.info->>'score')::int, 0) = ?", *req.Score)
+		}
+		if lastID != "" {
+			query = query.Where("(cm.created_at, cm.id) > (?, ?)", lastCreatedAt, lastID)
+		}
+
+		var rows []*domain.ConversationExportRow
+		if err := query.
+			Select("cm.id", "cm.conversation_id", "conversations.subject", "cm.app_id", "COALESCE(apps.name, '') as app_name",
+				"COALESCE(apps.type, 0) as app_type", "COALESCE(u.content, '') as question", "cm.content as answer",
+				"cm.provider", "cm.model", "cm.prompt_tokens", "cm.completion_tokens", "cm.total_tokens",
+				"COALESCE(cm.info, '{}'::jsonb) as info", "cm.remote_ip", "cm.created_at").
+			Order("cm.created_at ASC, cm.id ASC").
+			Limit(batchSize).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		var references []*domain.ConversationReference
+		if err := r.db.WithContext(ctx).
+			Model(&domain.ConversationReference{}).
+			Where("conversation_id IN ?", lo.Map(rows, func(row *domain.ConversationExportRow, _ int) string {
+				return row.ID
+			})).
+			Find(&references).Error; err != nil {
+			return err
+		}
+		// references are saved with the message id as conversation_id
+		referenceMap := lo.GroupBy(references, func(reference *domain.ConversationReference) string {
+			return reference.ConversationID
+		})
+		for _, row := range rows {
+			row.References = lo.Map(referenceMap[row.ID], func(reference *domain.ConversationReference, _ int) *domain.ConversationExportReference {
+				return &domain.ConversationExportReference{NodeID: reference.NodeID, Name: reference.Name, URL: reference.URL}
+			})
+		}
+
+		if err := fn(rows); err != nil {
+			return err
+		}
+		if len(rows) < batchSize {
+			return nil
+		}
+		last := rows[len(rows)-1]
+		lastCreatedAt, lastID = last.CreatedAt, last.ID
+	}
+}
@@ -0,0 +1,184 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+type EvalRepository struct {
+	db     *pg.DB
+	logger *log.Logger
+}
+
+func NewEvalRepository(db *pg.DB, logger *log.Logger) *EvalRepository {
+	return &EvalRepository{db: db, logger: logger.WithModule("repo.pg.eval")}
+}
+
+func (r *EvalRepository) CreateSet(ctx context.Context, set *domain.EvalSet, cases []*domain.EvalCase) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(set).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(cases, 100).Error
+	})
+}
+
+func (r *EvalRepository) GetSet(ctx context.Context, kbID, id string) (*domain.EvalSet, error) {
+	var set domain.EvalSet
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		First(&set).Error; err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+func (r *EvalRepository) GetSetList(ctx context.Context, kbID string) ([]*v1.EvalSetListItem, error) {
+	var sets []*v1.EvalSetListItem
+	if err := r.db.WithContext(ctx).
+		Model(&domain.EvalSet{}).
+		Select("eval_sets.*, COUNT(eval_cases.id) AS case_count").
+		Joins("LEFT JOIN eval_cases ON eval_cases.set_id = eval_sets.id").
+		Where("eval_sets.kb_id = ?", kbID).
+		Group("eval_sets.id").
+		Order("eval_sets.created_at DESC").
+		Find(&sets).Error; err != nil {
+		return nil, err
+	}
+	return sets, nil
+}
+
+// DeleteSet deletes the set together with its cases, runs and results
+func (r *EvalRepository) DeleteSet(ctx context.Context, kbID, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("kb_id = ? AND id = ?", kbID, id).Delete(&domain.EvalSet{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("set_id = ?", id).Delete(&domain.EvalCase{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("run_id IN (?)", tx.Model(&domain.EvalRun{}).Select("id").Where("set_id = ?", id)).
+			Delete(&domain.EvalResult{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("set_id = ?", id).Delete(&domain.EvalRun{}).Error
+	})
+}
+
+func (r *EvalRepository) GetCases(ctx context.Context, setID string) ([]*domain.EvalCase, error) {
+	var cases []*domain.EvalCase
+	if err := r.db.WithContext(ctx).
+		Where("set_id = ?", setID).
+		Order("created_at ASC, id ASC").
+		Find(&cases).Error; err != nil {
+		return nil, err
+	}
+	return cases, nil
+}
+
+func (r *EvalRepository) AddCases(ctx context.Context, setID string, cases []*domain.EvalCase) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(cases, 100).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.EvalSet{}).Where("id = ?", setID).Update("updated_at", time.Now()).Error
+	})
+}
+
+func (r *EvalRepository) DeleteCase(ctx context.Context, kbID, caseID string) error {
+	result := r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, caseID).
+		Delete(&domain.EvalCase{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *EvalRepository) CreateRun(ctx context.Context, run *domain.EvalRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+func (r *EvalRepository) GetRun(ctx context.Context, kbID, id string) (*domain.EvalRun, error) {
+	var run domain.EvalRun
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		First(&run).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *EvalRepository) GetRunList(ctx context.Context, kbID, setID string, pager *domain.Pager) (int64, []*domain.EvalRun, error) {
+	query := r.db.WithContext(ctx).
+		Model(&domain.EvalRun{}).
+		Where("kb_id = ?", kbID)
+	if setID != "" {
+		query = query.Where("set_id = ?", setID)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return 0, nil, err
+	}
+	var runs []*domain.EvalRun
+	if err := query.
+		Order("created_at DESC").
+		Offset(pager.Offset()).
+		Limit(pager.Limit()).
+		Find(&runs).Error; err != nil {
+		return 0, nil, err
+	}
+	return total, runs, nil
+}
+
+func (r *EvalRepository) UpdateRun(ctx context.Context, id string, updateMap map[string]any) error {
+	updateMap["updated_at"] = time.Now()
+	return r.db.WithContext(ctx).
+		Model(&domain.EvalRun{}).
+		Where("id = ?", id).
+		Updates(updateMap).Error
+}
+
+// FailStaleRuns marks the unfinished runs not updated since the given time failed, returns the number of them
+func (r *EvalRepository) FailStaleRuns(ctx context.Context, before time.Time, runErr string) (int64, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&domain.EvalRun{}).
+		Where("status IN ? AND updated_at < ?", []domain.EvalRunStatus{domain.EvalRunStatusPending, domain.EvalRunStatusRunning}, before).
+		Updates(map[string]any{
+			"status":      domain.EvalRunStatusFailed,
+			"error":       runErr,
+			"finished_at": now,
+			"updated_at":  now,
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *EvalRepository) CreateResult(ctx context.Context, result *domain.EvalResult) error {
+	return r.db.WithContext(ctx).Create(result).Error
+}
+
+func (r *EvalRepository) GetResults(ctx context.Context, runID string) ([]*domain.EvalResult, error) {
+	var results []*domain.EvalResult
+	if err := r.db.WithContext(ctx).
+		Where("run_id = ?", runID).
+		Order("created_at ASC").
+		Find(&results).Error; err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,146 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+type ImportSubscriptionRepository struct {
+	db     *pg.DB
+	logger *log.Logger
+}
+
+func NewImportSubscriptionRepository(db *pg.DB, logger *log.Logger) *ImportSubscriptionRepository {
+	return &ImportSubscriptionRepository{db: db, logger: logger.WithModule("repo.pg.import_subscription")}
+}
+
+func (r *ImportSubscriptionRepository) Create(ctx context.Context, sub *domain.ImportSubscription) error {
+	return r.db.WithContext(ctx).Create(sub).Error
+}
+
+func (r *ImportSubscriptionRepository) GetByID(ctx context.Context, kbID, id string) (*domain.ImportSubscription, error) {
+	var sub domain.ImportSubscription
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		First(&sub).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *ImportSubscriptionRepository) GetList(ctx context.Context, kbID string) ([]*domain.ImportSubscription, error) {
+	var subs []*domain.ImportSubscription
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ?", kbID).
+		Order("created_at DESC").
+		Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+func (r *ImportSubscriptionRepository) Update(ctx context.Context, kbID, id string, updateMap map[string]any) error {
+	updateMap["updated_at"] = time.Now()
+	return r.db.WithContext(ctx).
+		Model(&domain.ImportSubscription{}).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		Updates(updateMap).Error
+}
+
+func (r *ImportSubscriptionRepository) Delete(ctx context.Context, kbID, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("kb_id = ? AND id = ?", kbID, id).Delete(&domain.ImportSubscription{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("subscription_id = ?", id).Delete(&domain.ImportSubscriptionDoc{}).Error
+	})
+}
+
+// GetDueList returns enabled subscriptions whose next sync time has come
+func (r *ImportSubscriptionRepository) GetDueList(ctx context.Context, now time.Time) ([]*domain.ImportSubscription, error) {
+	var subs []*domain.ImportSubscription
+	if err := r.db.WithContext(ctx).
+		Where("enabled AND next_sync_at IS NOT NULL AND next_sync_at <= ?", now).
+		Order("next_sync_at ASC").
+		Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// ClaimSync moves next_sync_at forward and marks the subscription running since now,
+// returns false if another instance has claimed it already
+func (r *ImportSubscriptionRepository) ClaimSync(ctx context.Context, sub *domain.ImportSubscription, nextSyncAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.ImportSubscription{}).
+		Where("id = ? AND next_sync_at = ?", sub.ID, sub.NextSyncAt).
+		Updates(map[string]any{
+			"next_sync_at":     nextSyncAt,
+			"last_sync_status": domain.ImportSyncStatusRunning,
+			"updated_at":       time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *ImportSubscriptionRepository) FinishSync(ctx context.Context, id string, status domain.ImportSyncStatus, syncErr string, syncResult domain.ImportSyncResult) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.ImportSubscription{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"last_sync_at":     time.Now(),
+			"last_sync_status": status,
+			"last_sync_error":  syncErr,
+			"last_sync_result": syncResult,
+			"updated_at":       time.Now(),
+		}).Error
+}
+
+func (r *ImportSubscriptionRepository) GetDocs(ctx context.Context, subscriptionID string) ([]*domain.ImportSubscriptionDoc, error) {
+	var docs []*domain.ImportSubscriptionDoc
+	if err := r.db.WithContext(ctx).
+		Where("subscription_id = ?", subscriptionID).
+		Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (r *ImportSubscriptionRepository) UpsertDoc(ctx context.Context, doc *domain.ImportSubscriptionDoc) error {
+	doc.UpdatedAt = time.Now()
+	if doc.CreatedAt.IsZero() {
+		doc.CreatedAt = doc.UpdatedAt
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "subscription_id"}, {Name: "remote_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"node_id", "title", "content_hash", "removed", "updated_at"}),
+		}).
+		Create(doc).Error
+}
+
+func (r *ImportSubscriptionRepository) MarkDocsRemoved(ctx context.Context, subscriptionID string, remoteIDs []string) error {
+	if len(remoteIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Model(&domain.ImportSubscriptionDoc{}).
+		Where("subscription_id = ? AND remote_id IN ?", subscriptionID, remoteIDs).
+		Updates(map[string]any{
+			"removed":    true,
+			"updated_at": time.Now(),
+		}).Error
+}
@@ -0,0 +1,125 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+type KBReleaseScheduleRepository struct {
+	db     *pg.DB
+	logger *log.Logger
+}
+
+func NewKBReleaseScheduleRepository(db *pg.DB, logger *log.Logger) *KBReleaseScheduleRepository {
+	return &KBReleaseScheduleRepository{db: db, logger: logger.WithModule("repo.pg.kb_release_schedule")}
+}
+
+func (r *KBReleaseScheduleRepository) Create(ctx context.Context, schedule *domain.KBReleaseSchedule) error {
+	return r.db.WithContext(ctx).Create(schedule).Error
+}
+
+func (r *KBReleaseScheduleRepository) GetByID(ctx context.Context, kbID, id string) (*domain.KBReleaseSchedule, error) {
+	var schedule domain.KBReleaseSchedule
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		First(&schedule).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *KBReleaseScheduleRepository) GetList(ctx context.Context, kbID string, status domain.KBReleaseScheduleStatus) ([]*domain.KBReleaseSchedule, error) {
+	query := r.db.WithContext(ctx).Where("kb_id = ?", kbID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var schedules []*domain.KBReleaseSchedule
+	if err := query.
+		Order("scheduled_at DESC").
+		Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// UpdateIfStatus updates the schedule only when it is still in one of the given statuses
+func (r *KBReleaseScheduleRepository) UpdateIfStatus(ctx context.Context, kbID, id string, statuses []domain.KBReleaseScheduleStatus, updateMap map[string]any) error {
+	updateMap["updated_at"] = time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&domain.KBReleaseSchedule{}).
+		Where("kb_id = ? AND id = ? AND status IN ?", kbID, id, statuses).
+		Updates(updateMap)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetDueList returns pending schedules whose time has come
+func (r *KBReleaseScheduleRepository) GetDueList(ctx context.Context, now time.Time) ([]*domain.KBReleaseSchedule, error) {
+	var schedules []*domain.KBReleaseSchedule
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND scheduled_at <= ?", domain.KBReleaseScheduleStatusPending, now).
+		Order("scheduled_at ASC").
+		Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// ClaimRun marks a pending schedule running, returns false if another instance has claimed it already
+func (r *KBReleaseScheduleRepository) ClaimRun(ctx context.Context, id string) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&domain.KBReleaseSchedule{}).
+		Where("id = ? AND status = ?", id, domain.KBReleaseScheduleStatusPending).
+		Updates(map[string]any{
+			"status":      domain.KBReleaseScheduleStatusRunning,
+			"executed_at": now,
+			"updated_at":  now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FailStaleRuns marks the schedules running since before the given time failed, returns the number of them
+func (r *KBReleaseScheduleRepository) FailStaleRuns(ctx context.Context, before time.Time, runErr string) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.KBReleaseSchedule{}).
+		Where("status = ? AND executed_at < ?", domain.KBReleaseScheduleStatusRunning, before).
+		Updates(map[string]any{
+			"status":     domain.KBReleaseScheduleStatusFailed,
+			"error":      runErr,
+			"updated_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// FinishRun records the result of a running schedule, returns false if the run is stale,
+// i.e. it has been marked failed as interrupted or changed since it was claimed
+func (r *KBReleaseScheduleRepository) FinishRun(ctx context.Context, id string, status domain.KBReleaseScheduleStatus, releaseID, runErr string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.KBReleaseSchedule{}).
+		Where("id = ? AND status = ?", id, domain.KBReleaseScheduleStatusRunning).
+		Updates(map[string]any{
+			"status":     status,
+			"release_id": releaseID,
+			"error":      runErr,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
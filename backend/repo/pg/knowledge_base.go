@@ -17,6 +17,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/samber/lo"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
 	"github.com/chaitin/panda-wiki/config"
@@ -175,7 +176,7 @@ func (r *KnowledgeBaseRepository) SyncKBAccessSettingsToCaddy(ctx context.Contex
 							{
 								"match": []map[string]any{
 									{
-										"path": []string{"/share/v1/chat/completions", "/share/v1/app/wechat/app", "/share/v1/app/wechat/service", "/sitemap.xml", "/share/v1/app/wechat/official_account", "/share/v1/app/wechat/service/answer", "/mcp"},
+										"path": []string{"/share/v1/chat/completions", "/share/v1/models", "/share/v1/models/*", "/share/v1/embeddings", "/share/v1/app/wechat/app", "/share/v1/app/wechat/service", "/sitemap.xml", "/share/v1/app/wechat/official_account", "/share/v1/app/wechat/service/answer", "/mcp"},
 									},
 								},
 								"handle": []map[string]any{
@@ -578,6 +579,55 @@ func (r *KnowledgeBaseRepository) GetKnowledgeBaseByID(ctx context.Context, kbID
 	return &kb, nil
 }
 
+// GetRetrievalSettings returns the retrieval settings of kb, missing fields are filled with defaults
+func (r *KnowledgeBaseRepository) GetRetrievalSettings(ctx context.Context, kbID string) (*domain.RetrievalSettings, error) {
+	settings := domain.DefaultRetrievalSettings()
+	var setting domain.Setting
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND key = ?", kbID, domain.SettingKeyRetrieval).
+		First(&setting).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return settings, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(setting.Value, settings); err != nil {
+		return nil, err
+	}
+	defaults := domain.DefaultRetrievalSettings()
+	if settings.Mode == "" {
+		settings.Mode = defaults.Mode
+	}
+	if settings.KeywordTopK <= 0 {
+		settings.KeywordTopK = defaults.KeywordTopK
+	}
+	if settings.RRFK <= 0 {
+		settings.RRFK = defaults.RRFK
+	}
+	return settings, nil
+}
+
+func (r *KnowledgeBaseRepository) UpdateRetrievalSettings(ctx context.Context, kbID string, settings *domain.RetrievalSettings) error {
+	value, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "kb_id"}, {Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+		}).
+		Create(&domain.Setting{
+			KBID:        kbID,
+			Key:         domain.SettingKeyRetrieval,
+			Value:       value,
+			Description: "retrieval settings",
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}).Error
+}
+
 func (r *KnowledgeBaseRepository) DeleteKnowledgeBase(ctx context.Context, kbID string) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("kb_id = ?", kbID).Delete(&domain.Node{}).Error; err != nil {
@@ -586,6 +636,13 @@ func (r *KnowledgeBaseRepository) DeleteKnowledgeBase(ctx context.Context, kbID
 		if err := tx.Where("kb_id = ?", kbID).Delete(&domain.App{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("subscription_id IN (?)", tx.Model(&domain.ImportSubscription{}).Select("id").Where("kb_id = ?", kbID)).
+			Delete(&domain.ImportSubscriptionDoc{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("kb_id = ?", kbID).Delete(&domain.ImportSubscription{}).Error; err != nil {
+			return err
+		}
 		if err := tx.Where("id = ?", kbID).Delete(&domain.KnowledgeBase{}).Error; err != nil {
 			return err
 		}
@@ -3,6 +3,7 @@ package pg
 import (
 	"context"
 
+	"github.com/chaitin/panda-wiki/domain"
 	"github.com/chaitin/panda-wiki/log"
 	"github.com/chaitin/panda-wiki/store/pg"
 )
@@ -23,3 +24,7 @@ func (r *MCPRepository) GetMCPCallCount(ctx context.Context) (int64, error) {
 	}
 	return count, nil
 }
+
+func (r *MCPRepository) CreateMCPCall(ctx context.Context, call *domain.MCPCall) error {
+	return r.db.WithContext(ctx).Create(call).Error
+}
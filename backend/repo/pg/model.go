@@ -33,7 +33,7 @@ func (r *ModelRepository) GetList(ctx context.Context) ([]*domain.ModelListItem,
 	var models []*domain.ModelListItem
 	if err := r.db.WithContext(ctx).
 		Model(&domain.Model{}).
-		Order("created_at ASC").
+		Order("priority ASC, created_at ASC").
 		Find(&models).Error; err != nil {
 		return nil, err
 	}
@@ -58,6 +58,9 @@ func (r *ModelRepository) Update(ctx context.Context, req *domain.UpdateModelReq
 	if req.IsActive != nil {
 		updateMap["is_active"] = *req.IsActive
 	}
+	if req.Priority != nil {
+		updateMap["priority"] = *req.Priority
+	}
 	return r.db.WithContext(ctx).
 		Model(&domain.Model{}).
 		Where("id = ?", req.ID).
@@ -75,11 +78,37 @@ func (r *ModelRepository) Delete(ctx context.Context, id string) error {
 	})
 }
 
+// GetChatModel returns the chat model with the highest priority, preferring the active ones
 func (r *ModelRepository) GetChatModel(ctx context.Context) (*domain.Model, error) {
 	var model domain.Model
 	if err := r.db.WithContext(ctx).
 		Model(&domain.Model{}).
 		Where("type = ?", domain.ModelTypeChat).
+		Order("is_active DESC, priority ASC, created_at ASC").
+		First(&model).Error; err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+// GetActiveChatModels returns the active chat models in priority order
+func (r *ModelRepository) GetActiveChatModels(ctx context.Context) ([]*domain.Model, error) {
+	var models []*domain.Model
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Model{}).
+		Where("type = ? AND is_active = ?", domain.ModelTypeChat, true).
+		Order("priority ASC, created_at ASC").
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+	return models, nil
+}
+
+func (r *ModelRepository) GetChatModelByID(ctx context.Context, id string) (*domain.Model, error) {
+	var model domain.Model
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Model{}).
+		Where("id = ? AND type = ?", id, domain.ModelTypeChat).
 		First(&model).Error; err != nil {
 		return nil, err
 	}
@@ -21,6 +21,7 @@ import (
 	"github.com/chaitin/panda-wiki/domain"
 	"github.com/chaitin/panda-wiki/log"
 	"github.com/chaitin/panda-wiki/store/pg"
+	"github.com/chaitin/panda-wiki/utils"
 )
 
 type NodeRepository struct {
@@ -145,6 +146,95 @@ func (r *NodeRepository) GetList(ctx context.Context, req *domain.GetNodeListReq
 	return nodes, nil
 }
 
+// GetNodesByKBID returns all nodes of a knowledge base with content
+func (r *NodeRepository) GetNodesByKBID(ctx context.Context, kbID string) ([]*domain.Node, error) {
+	var nodes []*domain.Node
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Node{}).
+		Where("kb_id = ?", kbID).
+		Order("created_at ASC").
+		Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// CreateNodes inserts nodes with their ids, positions and permissions kept as is,
+// used when importing a knowledge base archive
+func (r *NodeRepository) CreateNodes(ctx context.Context, kbID string, nodes []*domain.Node, nodeGroups []domain.NodeAuthGroup, maxNode int) error {
+	if len(nodes) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkNodeLimit(tx, kbID, len(nodes), maxNode); err != nil {
+			return err
+		}
+		return createNodes(tx, nodes, nodeGroups)
+	})
+}
+
+// CheckNodeLimit 检查知识库是否还能添加 n 个文档 (maxNode <= 0 表示无限制)
+func (r *NodeRepository) CheckNodeLimit(ctx context.Context, kbID string, n, maxNode int) error {
+	return checkNodeLimit(r.db.WithContext(ctx), kbID, n, maxNode)
+}
+
+// ImportNodes 在一个事务中创建用户组、文档及文档的用户组权限。
+// groups 中父组在子组之前，子组通过 Parent 关联父组；nodeGroups 的 AuthGroupID 为用户组在 groups 中的下标
+func (r *NodeRepository) ImportNodes(ctx context.Context, kbID string, groups []*domain.AuthGroup, nodes []*domain.Node, nodeGroups []domain.NodeAuthGroup, maxNode int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkNodeLimit(tx, kbID, len(nodes), maxNode); err != nil {
+			return err
+		}
+		for _, group := range groups {
+			if group.Parent != nil {
+				group.ParentID = &group.Parent.ID
+			}
+			if err := tx.Create(group).Error; err != nil {
+				return fmt.Errorf("create auth group %s failed: %w", group.Name, err)
+			}
+		}
+		for i := range nodeGroups {
+			idx := nodeGroups[i].AuthGroupID
+			if idx < 0 || idx >= len(groups) {
+				return fmt.Errorf("auth group index %d out of range", idx)
+			}
+			nodeGroups[i].AuthGroupID = int(groups[idx].ID)
+		}
+		if len(nodes) == 0 {
+			return nil
+		}
+		return createNodes(tx, nodes, nodeGroups)
+	})
+}
+
+func checkNodeLimit(db *gorm.DB, kbID string, n, maxNode int) error {
+	if maxNode <= 0 {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&domain.Node{}).
+		Where("kb_id = ?", kbID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count+int64(n) > int64(maxNode) {
+		return domain.ErrMaxNodeLimitReached
+	}
+	return nil
+}
+
+func createNodes(tx *gorm.DB, nodes []*domain.Node, nodeGroups []domain.NodeAuthGroup) error {
+	if err := tx.CreateInBatches(nodes, 100).Error; err != nil {
+		return err
+	}
+	if len(nodeGroups) > 0 {
+		if err := tx.Model(&domain.NodeAuthGroup{}).CreateInBatches(&nodeGroups, 100).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *NodeRepository) GetLatestNodeReleaseByNodeIDs(ctx context.Context, kbID string, ids []string) ([]*domain.NodeRelease, error) {
 	var nodeReleases []*domain.NodeRelease
 	if err := r.db.WithContext(ctx).
@@ -718,6 +808,21 @@ func (r *NodeRepository) GetNodeReleaseListByKBID(ctx context.Context, kbID stri
 	return nodes, nil
 }
 
+// GetNodeReleasesByKBReleaseID returns the node releases with content included in a kb release
+func (r *NodeRepository) GetNodeReleasesByKBReleaseID(ctx context.Context, kbID, releaseID string) ([]*domain.NodeRelease, error) {
+	var nodeReleases []*domain.NodeRelease
+	if err := r.db.WithContext(ctx).
+		Model(&domain.KBReleaseNodeRelease{}).
+		Joins("JOIN node_releases ON node_releases.id = kb_release_node_releases.node_release_id").
+		Where("kb_release_node_releases.kb_id = ?", kbID).
+		Where("kb_release_node_releases.release_id = ?", releaseID).
+		Select("node_releases.*").
+		Find(&nodeReleases).Error; err != nil {
+		return nil, err
+	}
+	return nodeReleases, nil
+}
+
 func (r *NodeRepository) GetNodeReleaseDetailByKBIDAndID(ctx context.Context, kbID, id string) (*shareV1.ShareNodeDetailResp, error) {
 	// get kb release
 	var kbRelease *domain.KBRelease
@@ -896,6 +1001,11 @@ func (r *NodeRepository) CreateNodeReleases(ctx context.Context, kbID, userId st
 		if err := tx.CreateInBatches(&nodeReleases, 100).Error; err != nil {
 			return err
 		}
+		for _, nodeRelease := range nodeReleases {
+			if err := updateNodeReleaseSearchVector(tx, nodeRelease); err != nil {
+				return err
+			}
+		}
 		return nil
 	}); err != nil {
 		return nil, err
@@ -903,6 +1013,76 @@ func (r *NodeRepository) CreateNodeReleases(ctx context.Context, kbID, userId st
 	return releaseIDs, nil
 }
 
+func updateNodeReleaseSearchVector(tx *gorm.DB, nodeRelease *domain.NodeRelease) error {
+	tokens := utils.DocumentSearchTokens(nodeRelease.Name, nodeRelease.Content)
+	return tx.Model(&domain.NodeRelease{}).
+		Where("id = ?", nodeRelease.ID).
+		Omit("updated_at").
+		Update("search_vector", gorm.Expr("array_to_tsvector(?::text[])", pq.StringArray(tokens))).Error
+}
+
+// BackfillNodeReleaseSearchVectors builds search vectors for node releases created before keyword retrieval
+func (r *NodeRepository) BackfillNodeReleaseSearchVectors(ctx context.Context) (int, error) {
+	count := 0
+	for {
+		var nodeReleases []*domain.NodeRelease
+		if err := r.db.WithContext(ctx).
+			Model(&domain.NodeRelease{}).
+			Select("id, name, content").
+			Where("search_vector IS NULL").
+			Order("id").
+			Limit(100).
+			Find(&nodeReleases).Error; err != nil {
+			return count, err
+		}
+		if len(nodeReleases) == 0 {
+			return count, nil
+		}
+		if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			for _, nodeRelease := range nodeReleases {
+				if err := updateNodeReleaseSearchVector(tx, nodeRelease); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return count, err
+		}
+		count += len(nodeReleases)
+	}
+}
+
+// SearchNodeReleaseDocIDs returns doc ids of published node releases matching the keyword tokens,
+// ordered by rank and filtered by the answerable permission of auth groups
+func (r *NodeRepository) SearchNodeReleaseDocIDs(ctx context.Context, kbID string, tokens []string, groupIDs []int, limit int) ([]string, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	lexemes := make([]string, len(tokens))
+	for i, token := range tokens {
+		token = strings.ReplaceAll(token, `\`, `\\`)
+		lexemes[i] = "'" + strings.ReplaceAll(token, "'", "''") + "'"
+	}
+	query := strings.Join(lexemes, " | ")
+
+	var docIDs []string
+	if err := r.db.WithContext(ctx).
+		Model(&domain.NodeRelease{}).
+		Joins("JOIN nodes ON nodes.id = node_releases.node_id").
+		Where("node_releases.kb_id = ?", kbID).
+		Where("node_releases.doc_id != ''").
+		Where("node_releases.search_vector @@ ?::tsquery", query).
+		Where(r.db.Where("COALESCE(nodes.permissions->>'answerable', '') IN ?", []string{"", string(consts.NodeAccessPermOpen)}).
+			Or("nodes.permissions->>'answerable' = ? AND EXISTS (SELECT 1 FROM node_auth_groups WHERE node_auth_groups.node_id = nodes.id AND node_auth_groups.perm = ? AND node_auth_groups.auth_group_id IN ?)",
+				consts.NodeAccessPermPartial, consts.NodePermNameAnswerable, groupIDs)).
+		Order(clause.Expr{SQL: "ts_rank(node_releases.search_vector, ?::tsquery) DESC", Vars: []any{query}}).
+		Limit(limit).
+		Pluck("node_releases.doc_id", &docIDs).Error; err != nil {
+		return nil, err
+	}
+	return docIDs, nil
+}
+
 func (r *NodeRepository) GetOldNodeDocIDsByNodeID(ctx context.Context, nodeReleaseID, nodeID string) ([]string, error) {
 	var docIDs []string
 	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
@@ -1191,3 +1371,101 @@ func (r *NodeRepository) GetNodeCount(ctx context.Context) (int, error) {
 	}
 	return int(count), nil
 }
+
+// GetNodeReleaseHistory get releases of a node, latest first
+func (r *NodeRepository) GetNodeReleaseHistory(ctx context.Context, kbID, nodeID string, offset, limit int) ([]*v1.NodeHistoryListItem, int64, error) {
+	query := r.db.WithContext(ctx).
+		Model(&domain.NodeRelease{}).
+		Where("node_releases.kb_id = ?", kbID).
+		Where("node_releases.node_id = ?", nodeID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]*v1.NodeHistoryListItem, 0)
+	if err := query.
+		Select("node_releases.id, node_releases.node_id, node_releases.name, node_releases.meta, node_releases.publisher_id, publisher.account as publisher_account, node_releases.editor_id, editor.account as editor_account, node_releases.updated_at").
+		Joins("left join users publisher on publisher.id = node_releases.publisher_id").
+		Joins("left join users editor on editor.id = node_releases.editor_id").
+		Order("node_releases.updated_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (r *NodeRepository) GetNodeReleaseByNodeIDAndID(ctx context.Context, kbID, nodeID, id string) (*domain.NodeRelease, error) {
+	var nodeRelease *domain.NodeRelease
+	if err := r.db.WithContext(ctx).
+		Model(&domain.NodeRelease{}).
+		Where("id = ?", id).
+		Where("kb_id = ?", kbID).
+		Where("node_id = ?", nodeID).
+		First(&nodeRelease).Error; err != nil {
+		return nil, err
+	}
+	return nodeRelease, nil
+}
+
+// GetPrevNodeRelease get the release published right before the given one, nil if it is the first
+func (r *NodeRepository) GetPrevNodeRelease(ctx context.Context, nodeRelease *domain.NodeRelease) (*domain.NodeRelease, error) {
+	var prev *domain.NodeRelease
+	if err := r.db.WithContext(ctx).
+		Model(&domain.NodeRelease{}).
+		Where("kb_id = ?", nodeRelease.KBID).
+		Where("node_id = ?", nodeRelease.NodeID).
+		Where("updated_at < ?", nodeRelease.UpdatedAt).
+		Order("updated_at DESC").
+		First(&prev).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return prev, nil
+}
+
+// RestoreNodeFromRelease overwrite the draft node with the content of a release and record the restore
+func (r *NodeRepository) RestoreNodeFromRelease(ctx context.Context, nodeRelease *domain.NodeRelease, userId string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var node domain.Node
+		if err := tx.Model(&domain.Node{}).
+			Where("id = ?", nodeRelease.NodeID).
+			Where("kb_id = ?", nodeRelease.KBID).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&node).Error; err != nil {
+			return err
+		}
+
+		// content_type can only change when it was empty before, same as UpdateNodeContent
+		meta := nodeRelease.Meta
+		if node.Meta.ContentType != "" {
+			meta.ContentType = node.Meta.ContentType
+		}
+
+		if err := tx.Model(&domain.Node{}).
+			Where("id = ?", node.ID).
+			Updates(map[string]any{
+				"name":      nodeRelease.Name,
+				"content":   nodeRelease.Content,
+				"meta":      &meta,
+				"status":    domain.NodeStatusDraft,
+				"editor_id": userId,
+				"edit_time": time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&domain.NodeRestoreLog{
+			KBID:          nodeRelease.KBID,
+			NodeID:        nodeRelease.NodeID,
+			NodeReleaseID: nodeRelease.ID,
+			UserID:        userId,
+			CreatedAt:     time.Now(),
+		}).Error
+	})
+}
@@ -46,3 +46,17 @@ func (r *NodeRepository) GetNodeAuthGroupIdsByNodeId(ctx context.Context, nodeId
 	}
 	return nil, nil
 }
+
+// GetNodeAuthGroupsByKBID 查询知识库下所有文档的用户组
+func (r *NodeRepository) GetNodeAuthGroupsByKBID(ctx context.Context, kbID string) ([]domain.NodeAuthGroup, error) {
+	nodeGroups := make([]domain.NodeAuthGroup, 0)
+	if err := r.db.WithContext(ctx).
+		Model(&domain.NodeAuthGroup{}).
+		Joins("join nodes on nodes.id = node_auth_groups.node_id").
+		Where("nodes.kb_id = ?", kbID).
+		Select("node_auth_groups.*").
+		Find(&nodeGroups).Error; err != nil {
+		return nil, err
+	}
+	return nodeGroups, nil
+}
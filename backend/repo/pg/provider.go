@@ -25,4 +25,11 @@ var ProviderSet = wire.NewSet(
 	NewAPITokenRepo,
 	NewSystemSettingRepo,
 	NewMCPRepository,
+	NewImportSubscriptionRepository,
+	NewWebhookRepository,
+	NewContributeRepository,
+	NewKBReleaseScheduleRepository,
+	NewEvalRepository,
+	NewQuestionGapRepository,
+	NewTokenBudgetRepository,
 )
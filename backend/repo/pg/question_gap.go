@@ -0,0 +1,207 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"gorm.io/gorm"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+type QuestionGapRepository struct {
+	db     *pg.DB
+	logger *log.Logger
+}
+
+func NewQuestionGapRepository(db *pg.DB, logger *log.Logger) *QuestionGapRepository {
+	return &QuestionGapRepository{db: db, logger: logger.WithModule("repo.pg.question_gap")}
+}
+
+// GetCandidates returns the latest answers since the time that retrieved nothing,
+// could not be answered or were disliked, together with their questions
+func (r *QuestionGapRepository) GetCandidates(ctx context.Context, kbID string, since time.Time, limit int) ([]*domain.QuestionGapCandidate, error) {
+	var candidates []*domain.QuestionGapCandidate
+	if err := r.db.WithContext(ctx).Table("conversation_messages as cm").
+		Joins("LEFT JOIN LATERAL (SELECT content FROM conversation_messages WHERE conversation_id = cm.conversation_id AND role = 'user' AND created_at < cm.created_at ORDER BY created_at DESC LIMIT 1) u ON true").
+		Joins("JOIN conversations ON conversations.id = cm.conversation_id").
+		Joins("JOIN apps ON cm.app_id = apps.id").
+		Where("conversations.kb_id = ?", kbID).
+		Where("cm.role = ?", schema.Assistant).
+		Where("cm.created_at >= ?", since).
+		Where("(cm.retrieved_node_count = 0 OR cm.content LIKE ? OR cm.info->>'score' = ?)",
+			"%"+domain.UnansweredReplyMarker+"%", "-1").
+		Where("COALESCE(u.content, '') != ''").
+		Select("cm.id as message_id", "cm.conversation_id", "apps.type as app_type", "u.content as question",
+			"cm.content as answer", "COALESCE((cm.info->>'score')::int, 0) as score", "cm.retrieved_node_count", "cm.created_at").
+		Order("cm.created_at DESC").
+		Limit(limit).
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+func (r *QuestionGapRepository) CreateReport(ctx context.Context, report *domain.QuestionGapReport) error {
+	return r.db.WithContext(ctx).Create(report).Error
+}
+
+func (r *QuestionGapRepository) UpdateReport(ctx context.Context, id string, updateMap map[string]any) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.QuestionGapReport{}).
+		Where("id = ?", id).
+		Updates(updateMap).Error
+}
+
+// FinishReport saves the clusters and marks the report succeeded
+func (r *QuestionGapRepository) FinishReport(ctx context.Context, id string, questionCount int, clusters []*domain.QuestionGapCluster, items []*domain.QuestionGapItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(clusters) > 0 {
+			if err := tx.CreateInBatches(clusters, 100).Error; err != nil {
+				return err
+			}
+		}
+		if len(items) > 0 {
+			if err := tx.CreateInBatches(items, 200).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&domain.QuestionGapReport{}).
+			Where("id = ?", id).
+			Updates(map[string]any{
+				"status":         domain.QuestionGapReportStatusSucceeded,
+				"question_count": questionCount,
+				"cluster_count":  len(clusters),
+				"finished_at":    time.Now(),
+			}).Error
+	})
+}
+
+func (r *QuestionGapRepository) GetReport(ctx context.Context, kbID, id string) (*domain.QuestionGapReport, error) {
+	var report domain.QuestionGapReport
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		First(&report).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetLatestReport returns the latest succeeded report
+func (r *QuestionGapRepository) GetLatestReport(ctx context.Context, kbID string) (*domain.QuestionGapReport, error) {
+	var report domain.QuestionGapReport
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND status = ?", kbID, domain.QuestionGapReportStatusSucceeded).
+		Order("created_at DESC").
+		First(&report).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *QuestionGapRepository) GetReportList(ctx context.Context, kbID string, pager *domain.Pager) (int64, []*domain.QuestionGapReport, error) {
+	query := r.db.WithContext(ctx).
+		Model(&domain.QuestionGapReport{}).
+		Where("kb_id = ?", kbID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return 0, nil, err
+	}
+	var reports []*domain.QuestionGapReport
+	if err := query.
+		Order("created_at DESC").
+		Offset(pager.Offset()).
+		Limit(pager.Limit()).
+		Find(&reports).Error; err != nil {
+		return 0, nil, err
+	}
+	return total, reports, nil
+}
+
+// DeleteReportsBefore deletes the reports created before the time with their clusters and items,
+// the latest succeeded report of each knowledge base is kept
+func (r *QuestionGapRepository) DeleteReportsBefore(ctx context.Context, before time.Time) (int, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.QuestionGapReport{}).
+			Where("created_at < ?", before).
+			Where("id NOT IN (SELECT DISTINCT ON (kb_id) id FROM question_gap_reports WHERE status = ? ORDER BY kb_id, created_at DESC)", domain.QuestionGapReportStatusSucceeded).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := tx.Where("cluster_id IN (SELECT id FROM question_gap_clusters WHERE report_id IN ?)", ids).
+			Delete(&domain.QuestionGapItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("report_id IN ?", ids).Delete(&domain.QuestionGapCluster{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(&domain.QuestionGapReport{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// HasRunningReport tells whether an analysis started after the time is still running
+func (r *QuestionGapRepository) HasRunningReport(ctx context.Context, kbID string, after time.Time) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Model(&domain.QuestionGapReport{}).
+		Where("kb_id = ? AND status = ? AND created_at > ?", kbID, domain.QuestionGapReportStatusRunning, after).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *QuestionGapRepository) GetClusters(ctx context.Context, reportID string) ([]*domain.QuestionGapCluster, error) {
+	var clusters []*domain.QuestionGapCluster
+	if err := r.db.WithContext(ctx).
+		Where("report_id = ?", reportID).
+		Order("rank ASC").
+		Find(&clusters).Error; err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
+
+func (r *QuestionGapRepository) GetCluster(ctx context.Context, kbID, id string) (*domain.QuestionGapCluster, error) {
+	var cluster domain.QuestionGapCluster
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		First(&cluster).Error; err != nil {
+		return nil, err
+	}
+	return &cluster, nil
+}
+
+func (r *QuestionGapRepository) GetItems(ctx context.Context, clusterID string) ([]*domain.QuestionGapItem, error) {
+	var items []*domain.QuestionGapItem
+	if err := r.db.WithContext(ctx).
+		Where("cluster_id = ?", clusterID).
+		Order("asked_at DESC").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// SetClusterNode records the draft node, returns false if the cluster has one already
+func (r *QuestionGapRepository) SetClusterNode(ctx context.Context, id, nodeID string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.QuestionGapCluster{}).
+		Where("id = ? AND node_id = ''", id).
+		Update("node_id", nodeID)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -2,7 +2,12 @@ package pg
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
 
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 
@@ -202,3 +207,54 @@ func (r *StatRepository) UpsertNodeStats(ctx context.Context, nodeID string, pvC
 		}).
 		Create(nodeStats).Error
 }
+
+func chatRateLimitedKey(kbID string, t time.Time) string {
+	return fmt.Sprintf("chat_rate_limited:%s:%s", kbID, t.Format("2006-01-02-15"))
+}
+
+// IncrChatRateLimited counts a chat request rejected by the rate limit in the current hour
+func (r *StatRepository) IncrChatRateLimited(ctx context.Context, kbID string) error {
+	key := chatRateLimitedKey(kbID, time.Now())
+	count, err := r.cache.Incr(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	if count == 1 {
+		return r.cache.Expire(ctx, key, 25*time.Hour).Err()
+	}
+	return nil
+}
+
+// GetChatRateLimitedCount returns the chat requests rejected in the last hours, at most 24 hours are kept
+func (r *StatRepository) GetChatRateLimitedCount(ctx context.Context, kbID string, hours int) (int64, error) {
+	now := time.Now()
+	keys := make([]string, 0, hours)
+	for i := 0; i < hours; i++ {
+		keys = append(keys, chatRateLimitedKey(kbID, now.Add(-time.Duration(i)*time.Hour)))
+	}
+	values, err := r.cache.MGet(ctx, keys...).Result()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			continue
+		}
+		count, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parse chat rate limited count failed: %w", err)
+		}
+		total += count
+	}
+	return total, nil
+}
+
+func (r *StatRepository) GetChatRateLimitedCountOneHour(ctx context.Context, kbID string) (int64, error) {
+	count, err := r.cache.Get(ctx, chatRateLimitedKey(kbID, time.Now().Add(-time.Hour))).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return count, err
+}
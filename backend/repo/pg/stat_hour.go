@@ -271,7 +271,7 @@ func (r *StatRepository) GetHotOSOneHour(ctx context.Context, kbID string) (map[
 func (r *StatRepository) GetStatPageCountByHour(ctx context.Context, kbID string, startHour int64) (*v1.StatCountResp, error) {
 	var count v1.StatCountResp
 	if err := r.db.WithContext(ctx).Model(&domain.StatPageHour{}).
-		Select("SUM(ip_count) as ip_count, SUM(session_count) as session_count, SUM(page_visit_count) as page_visit_count, SUM(conversation_count) as conversation_count").
+		Select("SUM(ip_count) as ip_count, SUM(session_count) as session_count, SUM(page_visit_count) as page_visit_count, SUM(conversation_count) as conversation_count, SUM(chat_rate_limited_count) as chat_rate_limited_count").
 		Where("kb_id = ?", kbID).
 		Where("hour >=  ? and hour < ?", utils.GetTimeHourOffset(-startHour), utils.GetTimeHourOffset(-24)).
 		Scan(&count).Error; err != nil {
@@ -0,0 +1,95 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"gorm.io/gorm/clause"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+type TokenBudgetRepository struct {
+	db     *pg.DB
+	logger *log.Logger
+}
+
+func NewTokenBudgetRepository(db *pg.DB, logger *log.Logger) *TokenBudgetRepository {
+	return &TokenBudgetRepository{db: db, logger: logger.WithModule("repo.pg.token_budget")}
+}
+
+// Upsert creates the budget or updates the limit of the existing one of the same app type and period
+func (r *TokenBudgetRepository) Upsert(ctx context.Context, budget *domain.TokenBudget) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "kb_id"}, {Name: "app_type"}, {Name: "period"}},
+			DoUpdates: clause.AssignmentColumns([]string{"token_limit", "updated_at"}),
+		}).
+		Create(budget).Error
+}
+
+func (r *TokenBudgetRepository) GetList(ctx context.Context, kbID string) ([]*domain.TokenBudget, error) {
+	var budgets []*domain.TokenBudget
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ?", kbID).
+		Order("app_type ASC, period ASC").
+		Find(&budgets).Error; err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+// GetAppBudgets returns the budgets of the knowledge base applying to the app type
+func (r *TokenBudgetRepository) GetAppBudgets(ctx context.Context, kbID string, appType domain.AppType) ([]*domain.TokenBudget, error) {
+	var budgets []*domain.TokenBudget
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND app_type IN ?", kbID, []domain.AppType{0, appType}).
+		Find(&budgets).Error; err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+func (r *TokenBudgetRepository) Delete(ctx context.Context, kbID, id string) error {
+	return r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		Delete(&domain.TokenBudget{}).Error
+}
+
+// GetUsedTokens sums the tokens answered for the knowledge base since the time, only by the apps of the type if it is not 0
+func (r *TokenBudgetRepository) GetUsedTokens(ctx context.Context, kbID string, appType domain.AppType, since time.Time) (int64, error) {
+	query := r.db.WithContext(ctx).Table("conversation_messages as cm").
+		Where("cm.kb_id = ? AND cm.role = ? AND cm.created_at >= ?", kbID, schema.Assistant, since)
+	if appType != 0 {
+		query = query.Joins("JOIN apps ON cm.app_id = apps.id").Where("apps.type = ?", appType)
+	}
+	var total int64
+	if err := query.Select("COALESCE(SUM(cm.total_tokens), 0)").Scan(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// GetUsageStats returns the tokens used by every app per day in [start, end), of all knowledge bases if kbID is empty
+func (r *TokenBudgetRepository) GetUsageStats(ctx context.Context, kbID string, start, end time.Time) ([]*domain.TokenUsageStat, error) {
+	query := r.db.WithContext(ctx).Table("conversation_messages as cm").
+		Joins("LEFT JOIN apps ON cm.app_id = apps.id").
+		Where("cm.role = ? AND cm.created_at >= ? AND cm.created_at < ?", schema.Assistant, start, end)
+	if kbID != "" {
+		query = query.Where("cm.kb_id = ?", kbID)
+	}
+	var stats []*domain.TokenUsageStat
+	if err := query.
+		Select("cm.kb_id", "cm.app_id", "COALESCE(MAX(apps.name), '') as app_name", "COALESCE(MAX(apps.type), 0) as app_type",
+			"date_trunc('day', cm.created_at) as day", "COUNT(*) as message_count",
+			"SUM(cm.prompt_tokens) as prompt_tokens", "SUM(cm.completion_tokens) as completion_tokens", "SUM(cm.total_tokens) as total_tokens").
+		Group("cm.kb_id, cm.app_id, day").
+		Order("day DESC, cm.kb_id ASC, total_tokens DESC").
+		Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -158,3 +158,50 @@ func (r *UserRepository) DeleteUser(ctx context.Context, userID string) error {
 	}
 	return nil
 }
+
+func (r *UserRepository) GetUserByAccount(ctx context.Context, account string) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).
+		Where("account = ?", account).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUserByOIDCSubject subject 只在同一个 issuer 内唯一，需要同时按 issuer 查询
+func (r *UserRepository) GetUserByOIDCSubject(ctx context.Context, issuer, subject string) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).
+		Where("oidc_issuer = ? AND oidc_subject = ?", issuer, subject).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUserOIDC 关联用户的 OIDC 标识并同步角色
+func (r *UserRepository) UpdateUserOIDC(ctx context.Context, userID string, issuer, subject string, role consts.UserRole) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]any{
+			"oidc_issuer":  issuer,
+			"oidc_subject": subject,
+			"role":         role,
+		}).Error
+}
+
+// LinkUserOIDC 将已有的本地用户关联到 OIDC 标识，保留用户原有的角色
+func (r *UserRepository) LinkUserOIDC(ctx context.Context, userID string, issuer, subject string) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]any{
+			"oidc_issuer":  issuer,
+			"oidc_subject": subject,
+			"oidc_linked":  true,
+		}).Error
+}
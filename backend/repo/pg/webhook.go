@@ -0,0 +1,159 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/store/pg"
+)
+
+type WebhookRepository struct {
+	db     *pg.DB
+	logger *log.Logger
+}
+
+func NewWebhookRepository(db *pg.DB, logger *log.Logger) *WebhookRepository {
+	return &WebhookRepository{db: db, logger: logger.WithModule("repo.pg.webhook")}
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, webhook *domain.Webhook) error {
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+func (r *WebhookRepository) GetByID(ctx context.Context, kbID, id string) (*domain.Webhook, error) {
+	var webhook domain.Webhook
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		First(&webhook).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *WebhookRepository) GetList(ctx context.Context, kbID string) ([]*domain.Webhook, error) {
+	var webhooks []*domain.Webhook
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ?", kbID).
+		Order("created_at DESC").
+		Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// GetSubscribedList returns enabled webhooks of the knowledge base subscribed to the event
+func (r *WebhookRepository) GetSubscribedList(ctx context.Context, kbID string, event domain.WebhookEventType) ([]*domain.Webhook, error) {
+	var webhooks []*domain.Webhook
+	if err := r.db.WithContext(ctx).
+		Where("kb_id = ? AND enabled AND ? = ANY(events)", kbID, string(event)).
+		Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, kbID, id string, updateMap map[string]any) error {
+	updateMap["updated_at"] = time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&domain.Webhook{}).
+		Where("kb_id = ? AND id = ?", kbID, id).
+		Updates(updateMap)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, kbID, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("kb_id = ? AND id = ?", kbID, id).Delete(&domain.Webhook{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("webhook_id = ?", id).Delete(&domain.WebhookDelivery{}).Error
+	})
+}
+
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+func (r *WebhookRepository) GetDeliveryByID(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	if err := r.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(&delivery).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// GetDeliveryList returns the deliveries without their payload and response body
+func (r *WebhookRepository) GetDeliveryList(ctx context.Context, kbID, webhookID string, pager *domain.Pager) (int64, []*v1.WebhookDeliveryListItem, error) {
+	query := r.db.WithContext(ctx).
+		Model(&domain.WebhookDelivery{}).
+		Where("kb_id = ? AND webhook_id = ?", kbID, webhookID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return 0, nil, err
+	}
+	var deliveries []*v1.WebhookDeliveryListItem
+	if err := query.
+		Select("id, webhook_id, event, status, attempts, response_status, error, next_retry_at, created_at, updated_at").
+		Order("created_at DESC").
+		Offset(pager.Offset()).
+		Limit(pager.Limit()).
+		Find(&deliveries).Error; err != nil {
+		return 0, nil, err
+	}
+	return total, deliveries, nil
+}
+
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, id string, updateMap map[string]any) error {
+	updateMap["updated_at"] = time.Now()
+	return r.db.WithContext(ctx).
+		Model(&domain.WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(updateMap).Error
+}
+
+// GetDueRetryDeliveries returns deliveries waiting for retry whose next retry time has come
+func (r *WebhookRepository) GetDueRetryDeliveries(ctx context.Context, now time.Time) ([]*domain.WebhookDelivery, error) {
+	var deliveries []*domain.WebhookDelivery
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND next_retry_at <= ?", domain.WebhookDeliveryStatusRetrying, now).
+		Order("next_retry_at ASC").
+		Limit(100).
+		Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimRetry moves a due delivery back to pending,
+// returns false if another instance has claimed it already
+func (r *WebhookRepository) ClaimRetry(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.WebhookDelivery{}).
+		Where("id = ? AND status = ?", id, domain.WebhookDeliveryStatusRetrying).
+		Updates(map[string]any{
+			"status":        domain.WebhookDeliveryStatusPending,
+			"next_retry_at": nil,
+			"updated_at":    time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
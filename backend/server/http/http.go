@@ -3,8 +3,10 @@ package http
 import (
 	"context"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/getsentry/sentry-go"
@@ -63,6 +65,9 @@ func NewEcho(
 	e.HidePort = true
 
 	e.Binder = &MyBinder{}
+	if len(config.HTTP.TrustedProxies) > 0 {
+		e.IPExtractor = newIPExtractor(logger, config.HTTP.TrustedProxies)
+	}
 
 	if os.Getenv("ENV") == "local" {
 		e.Debug = true
@@ -127,6 +132,36 @@ func NewEcho(
 	return e
 }
 
+// newIPExtractor 只信任本机和配置的反向代理设置的 X-Forwarded-For，
+// 客户端直接设置的 X-Forwarded-For 和 X-Real-IP 会被忽略，防止伪造 IP 绕过限流
+func newIPExtractor(logger *log.Logger, trustedProxies []string) echo.IPExtractor {
+	options := []echo.TrustOption{
+		echo.TrustLoopback(true),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range trustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			logger.Warn("ignore invalid trusted proxy", log.String("proxy", proxy), log.Error(err))
+			continue
+		}
+		options = append(options, echo.TrustIPRange(network))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
 type MyBinder struct {
 	echo.DefaultBinder
 }
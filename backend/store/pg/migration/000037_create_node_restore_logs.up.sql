@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS node_restore_logs (
+    id BIGSERIAL PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    node_id TEXT NOT NULL,
+    node_release_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_node_restore_logs_node_id ON node_restore_logs(node_id);
@@ -0,0 +1,4 @@
+-- Add search_vector column to node_releases table for keyword retrieval
+-- Lexemes are tokenized by the application (CJK bigrams), so it is built with array_to_tsvector
+ALTER TABLE node_releases ADD COLUMN IF NOT EXISTS search_vector tsvector;
+CREATE INDEX IF NOT EXISTS idx_node_releases_search_vector ON node_releases USING GIN (search_vector);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS import_subscription_docs;
+DROP TABLE IF EXISTS import_subscriptions;
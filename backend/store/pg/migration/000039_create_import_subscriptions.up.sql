@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS import_subscriptions (
+    id TEXT PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    crawler_source TEXT NOT NULL,
+    source_key TEXT NOT NULL,
+    setting JSONB NOT NULL DEFAULT '{}',
+    parent_id TEXT NOT NULL DEFAULT '',
+    schedule TEXT NOT NULL,
+    auto_publish BOOLEAN NOT NULL DEFAULT FALSE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    creator_id TEXT NOT NULL DEFAULT '',
+    next_sync_at timestamptz,
+    last_sync_at timestamptz,
+    last_sync_status TEXT NOT NULL DEFAULT '',
+    last_sync_error TEXT NOT NULL DEFAULT '',
+    last_sync_result JSONB NOT NULL DEFAULT '{}',
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    updated_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_subscriptions_kb_id ON import_subscriptions(kb_id);
+CREATE INDEX IF NOT EXISTS idx_import_subscriptions_next_sync_at ON import_subscriptions(next_sync_at) WHERE enabled;
+
+CREATE TABLE IF NOT EXISTS import_subscription_docs (
+    subscription_id TEXT NOT NULL,
+    remote_id TEXT NOT NULL,
+    node_id TEXT NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    content_hash TEXT NOT NULL DEFAULT '',
+    removed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    updated_at timestamptz NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (subscription_id, remote_id)
+);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS kb_release_schedules (
+    id TEXT PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    node_ids TEXT[] NOT NULL DEFAULT '{}',
+    tag TEXT NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    scheduled_at timestamptz NOT NULL,
+    creator_id TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending',
+    release_id TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    executed_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    updated_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_kb_release_schedules_kb_id ON kb_release_schedules(kb_id);
+CREATE INDEX IF NOT EXISTS idx_kb_release_schedules_scheduled_at ON kb_release_schedules(scheduled_at) WHERE status = 'pending';
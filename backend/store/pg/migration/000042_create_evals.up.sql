@@ -0,0 +1,68 @@
+CREATE TABLE IF NOT EXISTS eval_sets (
+    id TEXT PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    updated_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_eval_sets_kb_id ON eval_sets(kb_id);
+
+CREATE TABLE IF NOT EXISTS eval_cases (
+    id TEXT PRIMARY KEY,
+    set_id TEXT NOT NULL,
+    kb_id TEXT NOT NULL,
+    question TEXT NOT NULL,
+    expected_node_ids TEXT[] NOT NULL DEFAULT '{}',
+    reference_answer TEXT NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_eval_cases_set_id ON eval_cases(set_id);
+
+CREATE TABLE IF NOT EXISTS eval_runs (
+    id TEXT PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    set_id TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    prompt TEXT NOT NULL DEFAULT '',
+    top_k INT NOT NULL DEFAULT 10,
+    provider TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending',
+    total INT NOT NULL DEFAULT 0,
+    finished INT NOT NULL DEFAULT 0,
+    recall_at_k DOUBLE PRECISION,
+    citation_accuracy DOUBLE PRECISION,
+    answer_score DOUBLE PRECISION,
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    creator_id TEXT NOT NULL DEFAULT '',
+    finished_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    updated_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_eval_runs_kb_id_set_id ON eval_runs(kb_id, set_id);
+
+CREATE TABLE IF NOT EXISTS eval_results (
+    id TEXT PRIMARY KEY,
+    run_id TEXT NOT NULL,
+    case_id TEXT NOT NULL,
+    question TEXT NOT NULL,
+    answer TEXT NOT NULL DEFAULT '',
+    retrieved_node_ids TEXT[] NOT NULL DEFAULT '{}',
+    cited_node_ids TEXT[] NOT NULL DEFAULT '{}',
+    recall_at_k DOUBLE PRECISION,
+    citation_accuracy DOUBLE PRECISION,
+    answer_score DOUBLE PRECISION,
+    judge_reason TEXT NOT NULL DEFAULT '',
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_eval_results_run_id_case_id ON eval_results(run_id, case_id);
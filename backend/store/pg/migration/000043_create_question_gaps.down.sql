@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS question_gap_items;
+DROP TABLE IF EXISTS question_gap_clusters;
+DROP TABLE IF EXISTS question_gap_reports;
+
+ALTER TABLE conversation_messages DROP COLUMN IF EXISTS retrieved_node_count;
@@ -0,0 +1,44 @@
+ALTER TABLE conversation_messages ADD COLUMN IF NOT EXISTS retrieved_node_count INT;
+
+CREATE TABLE IF NOT EXISTS question_gap_reports (
+    id TEXT PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    since timestamptz NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running',
+    question_count INT NOT NULL DEFAULT 0,
+    cluster_count INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    creator_id TEXT NOT NULL DEFAULT '',
+    finished_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_question_gap_reports_kb_id ON question_gap_reports(kb_id, created_at);
+
+CREATE TABLE IF NOT EXISTS question_gap_clusters (
+    id TEXT PRIMARY KEY,
+    report_id TEXT NOT NULL,
+    kb_id TEXT NOT NULL,
+    rank INT NOT NULL,
+    topic TEXT NOT NULL,
+    question_count INT NOT NULL DEFAULT 0,
+    reason_counts JSONB NOT NULL DEFAULT '{}',
+    app_type_counts JSONB NOT NULL DEFAULT '{}',
+    node_id TEXT NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_question_gap_clusters_report_id ON question_gap_clusters(report_id);
+
+CREATE TABLE IF NOT EXISTS question_gap_items (
+    id TEXT PRIMARY KEY,
+    cluster_id TEXT NOT NULL,
+    message_id TEXT NOT NULL,
+    conversation_id TEXT NOT NULL,
+    question TEXT NOT NULL,
+    app_type SMALLINT NOT NULL DEFAULT 0,
+    reasons TEXT[] NOT NULL DEFAULT '{}',
+    asked_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_question_gap_items_cluster_id ON question_gap_items(cluster_id);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_conversation_messages_kb_id_created_at;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_conversation_messages_kb_id_created_at ON conversation_messages (kb_id, created_at, id);
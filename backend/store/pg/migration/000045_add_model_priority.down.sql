@@ -0,0 +1,8 @@
+DELETE FROM models WHERE type = 'chat' AND id NOT IN (
+    SELECT id FROM models WHERE type = 'chat' ORDER BY is_active DESC, priority ASC, created_at ASC LIMIT 1
+);
+
+ALTER TABLE models DROP COLUMN IF EXISTS priority;
+
+DROP INDEX IF EXISTS idx_models_type;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_models_type ON models (type);
@@ -0,0 +1,5 @@
+-- several chat models can be configured and tried in priority order, other types keep one model each
+DROP INDEX IF EXISTS idx_models_type;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_models_type ON models (type) WHERE type <> 'chat';
+
+ALTER TABLE models ADD COLUMN IF NOT EXISTS priority INT NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS token_budgets (
+    id TEXT PRIMARY KEY,
+    kb_id TEXT NOT NULL,
+    app_type SMALLINT NOT NULL DEFAULT 0,
+    period TEXT NOT NULL,
+    token_limit BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_uniq_token_budgets_kb_id_app_type_period ON token_budgets (kb_id, app_type, period);
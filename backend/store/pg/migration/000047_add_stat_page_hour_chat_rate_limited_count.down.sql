@@ -0,0 +1 @@
+ALTER TABLE stat_page_hours DROP COLUMN IF EXISTS chat_rate_limited_count;
@@ -0,0 +1 @@
+ALTER TABLE stat_page_hours ADD COLUMN IF NOT EXISTS chat_rate_limited_count BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+DELETE FROM system_settings WHERE key = 'admin_oidc';
+
+DROP INDEX IF EXISTS idx_uniq_users_oidc_subject;
+
+ALTER TABLE users DROP COLUMN IF EXISTS oidc_subject;
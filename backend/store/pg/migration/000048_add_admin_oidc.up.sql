@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_subject TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_uniq_users_oidc_subject ON users(oidc_subject) WHERE oidc_subject IS NOT NULL;
+
+INSERT INTO system_settings (key, value, description)
+SELECT 'admin_oidc', '{"enabled": false}'::jsonb, 'Admin console OIDC login configuration'
+WHERE NOT EXISTS (
+    SELECT 1 FROM system_settings WHERE key = 'admin_oidc'
+);
@@ -0,0 +1,2 @@
+ALTER TABLE auths DROP COLUMN IF EXISTS external_id;
+ALTER TABLE auths DROP COLUMN IF EXISTS disabled;
@@ -0,0 +1,2 @@
+ALTER TABLE auths ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE auths ADD COLUMN IF NOT EXISTS external_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_linked BOOLEAN NOT NULL DEFAULT FALSE;
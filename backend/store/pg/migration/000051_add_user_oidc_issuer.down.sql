@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_uniq_users_oidc_issuer_subject;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_uniq_users_oidc_subject ON users(oidc_subject) WHERE oidc_subject IS NOT NULL;
+
+ALTER TABLE users DROP COLUMN IF EXISTS oidc_issuer;
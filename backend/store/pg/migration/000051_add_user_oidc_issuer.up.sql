@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_issuer TEXT NOT NULL DEFAULT '';
+
+-- 已关联的用户都来自当前配置的 IdP
+UPDATE users SET oidc_issuer = COALESCE((SELECT value->>'issuer' FROM system_settings WHERE key = 'admin_oidc'), '')
+WHERE oidc_subject IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_uniq_users_oidc_subject;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_uniq_users_oidc_issuer_subject ON users(oidc_issuer, oidc_subject) WHERE oidc_subject IS NOT NULL;
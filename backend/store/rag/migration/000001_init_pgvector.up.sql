@@ -0,0 +1,47 @@
+-- the embedding column has no fixed dimension so that switching embedding models only needs a new dataset,
+-- the dimension of each dataset is kept in rag_datasets and checked when chunks are written or queried
+CREATE EXTENSION IF NOT EXISTS vector;
+
+CREATE TABLE rag_datasets (
+    id TEXT PRIMARY KEY,
+    -- dimension of the embeddings, set by the first indexed document
+    dimension INT,
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE rag_documents (
+    id TEXT PRIMARY KEY,
+    dataset_id TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT '',
+    progress_msg TEXT NOT NULL DEFAULT '',
+    group_ids INT[],
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    updated_at timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_rag_documents_dataset_id ON rag_documents(dataset_id);
+
+CREATE TABLE rag_chunks (
+    id TEXT PRIMARY KEY,
+    dataset_id TEXT NOT NULL,
+    document_id TEXT NOT NULL,
+    seq INT NOT NULL,
+    content TEXT NOT NULL,
+    embedding vector NOT NULL
+);
+CREATE INDEX idx_rag_chunks_dataset_id ON rag_chunks(dataset_id);
+CREATE INDEX idx_rag_chunks_document_id ON rag_chunks(document_id);
+
+CREATE TABLE rag_models (
+    id TEXT PRIMARY KEY,
+    type TEXT NOT NULL UNIQUE,
+    provider TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL,
+    base_url TEXT NOT NULL,
+    api_key TEXT NOT NULL DEFAULT '',
+    api_header TEXT NOT NULL DEFAULT '',
+    api_version TEXT NOT NULL DEFAULT '',
+    is_active BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at timestamptz NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,524 @@
+package rag
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	stdlog "log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"github.com/golang-migrate/migrate/v4"
+	migratePG "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/chaitin/panda-wiki/config"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/utils"
+)
+
+//go:embed migration/*.sql
+var pgVectorMigrations embed.FS
+
+// pgVectorMigrationsTable keeps the migration version apart from the main migrations when sharing the database
+const pgVectorMigrationsTable = "rag_schema_migrations"
+
+// table: rag_datasets
+type pgVectorDataset struct {
+	ID        string `gorm:"primaryKey"`
+	Dimension *int   // nil until the first document is indexed
+	CreatedAt time.Time
+}
+
+func (pgVectorDataset) TableName() string {
+	return "rag_datasets"
+}
+
+// table: rag_documents
+type pgVectorDocument struct {
+	ID          string `gorm:"primaryKey"`
+	DatasetID   string
+	Name        string
+	Status      string
+	ProgressMsg string
+	GroupIDs    pq.Int64Array  `gorm:"column:group_ids;type:int[]"` // nil means no group limitation
+	Tags        pq.StringArray `gorm:"type:text[]"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (pgVectorDocument) TableName() string {
+	return "rag_documents"
+}
+
+// table: rag_chunks
+type pgVectorChunk struct {
+	ID         string `gorm:"primaryKey"`
+	DatasetID  string
+	DocumentID string
+	Seq        int
+	Content    string
+	Embedding  string `gorm:"type:vector"`
+}
+
+func (pgVectorChunk) TableName() string {
+	return "rag_chunks"
+}
+
+// PGVectorRAG stores chunks and embeddings in postgres with the pgvector extension,
+// embedding and rerank models are called directly with OpenAI compatible apis
+type PGVectorRAG struct {
+	db        *gorm.DB
+	logger    *log.Logger
+	mdConv    *converter.Converter
+	client    *http.Client
+	chunkSize int
+	topK      int
+}
+
+func NewPGVectorRAG(config *config.Config, logger *log.Logger) (*PGVectorRAG, error) {
+	dsn := config.RAG.PGVector.DSN
+	if dsn == "" {
+		dsn = config.PG.DSN
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		TranslateError: true,
+		Logger: gormlogger.New(stdlog.New(os.Stdout, "\r\n", stdlog.LstdFlags), gormlogger.Config{
+			SlowThreshold:             200 * time.Millisecond,
+			LogLevel:                  gormlogger.Warn,
+			IgnoreRecordNotFoundError: true,
+		}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect pgvector database: %w", err)
+	}
+	if err := migratePGVector(dsn); err != nil {
+		return nil, err
+	}
+	topK := config.RAG.PGVector.TopK
+	if topK <= 0 {
+		topK = 10
+	}
+	return &PGVectorRAG{
+		db:        db,
+		logger:    logger.WithModule("store.vector.pgvector"),
+		mdConv:    NewHTML2MDConverter(),
+		client:    &http.Client{Timeout: 60 * time.Second},
+		chunkSize: config.RAG.PGVector.ChunkSize,
+		topK:      topK,
+	}, nil
+}
+
+func migratePGVector(dsn string) error {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return fmt.Errorf("open pgvector database failed: %w", err)
+	}
+	defer db.Close()
+	driver, err := migratePG.WithInstance(db, &migratePG.Config{MigrationsTable: pgVectorMigrationsTable})
+	if err != nil {
+		return fmt.Errorf("with instance failed: %w", err)
+	}
+	source, err := iofs.New(pgVectorMigrations, "migration")
+	if err != nil {
+		return fmt.Errorf("open pgvector migrations failed: %w", err)
+	}
+	m, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
+	if err != nil {
+		return fmt.Errorf("new migrate instance failed: %w", err)
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrate pgvector schema failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PGVectorRAG) CreateKnowledgeBase(ctx context.Context) (string, error) {
+	dataset := &pgVectorDataset{
+		ID:        uuid.New().String(),
+		CreatedAt: time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(dataset).Error; err != nil {
+		return "", err
+	}
+	return dataset.ID, nil
+}
+
+func (s *PGVectorRAG) DeleteKnowledgeBase(ctx context.Context, datasetID string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("dataset_id = ?", datasetID).Delete(&pgVectorChunk{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("dataset_id = ?", datasetID).Delete(&pgVectorDocument{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", datasetID).Delete(&pgVectorDataset{}).Error
+	})
+}
+
+func (s *PGVectorRAG) UpsertRecords(ctx context.Context, req *UpsertRecordsRequest) (string, error) {
+	markdown := req.Content
+	// if the content is html, convert it to markdown first
+	if utils.IsLikelyHTML(req.Content) {
+		var err error
+		markdown, err = s.mdConv.ConvertString(req.Content)
+		if err != nil {
+			return "", fmt.Errorf("convert html to markdown failed: %w", err)
+		}
+	}
+
+	docID := req.DocID
+	if docID == "" {
+		docID = uuid.New().String()
+	}
+	doc := &pgVectorDocument{
+		ID:        docID,
+		DatasetID: req.DatasetID,
+		Name:      fmt.Sprintf("%s.md", req.ID),
+		Status:    string(consts.NodeRagStatusRunning),
+		Tags:      req.Tags,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if len(req.GroupIDs) > 0 {
+		doc.GroupIDs = toInt64Array(req.GroupIDs)
+	}
+	if err := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(doc).Error; err != nil {
+		return "", fmt.Errorf("upsert document failed: %w", err)
+	}
+
+	if indexErr := s.indexDocument(ctx, doc, markdown); indexErr != nil {
+		// keep the document so that the failure shows in the node rag status
+		s.logger.Error("index document failed", log.String("doc_id", docID), log.Error(indexErr))
+		if err := s.db.WithContext(ctx).Model(&pgVectorDocument{}).
+			Where("id = ?", docID).
+			Updates(map[string]any{
+				"status":       string(consts.NodeRagStatusFailed),
+				"progress_msg": indexErr.Error(),
+				"updated_at":   time.Now(),
+			}).Error; err != nil {
+			return "", err
+		}
+	}
+	return docID, nil
+}
+
+func (s *PGVectorRAG) indexDocument(ctx context.Context, doc *pgVectorDocument, markdown string) error {
+	contents := splitMarkdownChunks(markdown, s.chunkSize)
+	chunks := make([]*pgVectorChunk, len(contents))
+	dimension := 0
+	if len(contents) > 0 {
+		model, err := s.getModel(ctx, domain.ModelTypeEmbedding)
+		if err != nil {
+			return err
+		}
+		if model == nil {
+			return errors.New("embedding model not configured")
+		}
+		vectors, err := model.embed(ctx, s.client, contents)
+		if err != nil {
+			return err
+		}
+		dimension = len(vectors[0])
+		for i, content := range contents {
+			if len(vectors[i]) != dimension {
+				return fmt.Errorf("embedding dimensions are not consistent: %d and %d", dimension, len(vectors[i]))
+			}
+			chunks[i] = &pgVectorChunk{
+				ID:         uuid.New().String(),
+				DatasetID:  doc.DatasetID,
+				DocumentID: doc.ID,
+				Seq:        i,
+				Content:    content,
+				Embedding:  vectorLiteral(vectors[i]),
+			}
+		}
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(chunks) > 0 {
+			if err := checkDatasetDimension(tx, doc.DatasetID, dimension, true); err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("document_id = ?", doc.ID).Delete(&pgVectorChunk{}).Error; err != nil {
+			return err
+		}
+		if len(chunks) > 0 {
+			if err := tx.CreateInBatches(chunks, 100).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&pgVectorDocument{}).
+			Where("id = ?", doc.ID).
+			Updates(map[string]any{
+				"status":       string(consts.NodeRagStatusSucceeded),
+				"progress_msg": "",
+				"updated_at":   time.Now(),
+			}).Error
+	})
+}
+
+// checkDatasetDimension checks the embeddings have the dimension of the dataset,
+// the dimension is set by the first embeddings written when set is true
+func checkDatasetDimension(db *gorm.DB, datasetID string, dimension int, set bool) error {
+	if set {
+		if err := db.Model(&pgVectorDataset{}).
+			Where("id = ? AND dimension IS NULL", datasetID).
+			Update("dimension", dimension).Error; err != nil {
+			return err
+		}
+	}
+	var dataset pgVectorDataset
+	if err := db.Where("id = ?", datasetID).First(&dataset).Error; err != nil {
+		return fmt.Errorf("get dataset %s failed: %w", datasetID, err)
+	}
+	if dataset.Dimension != nil && *dataset.Dimension != dimension {
+		return fmt.Errorf("embedding dimension %d does not match dimension %d of the dataset, the knowledge base needs to be rebuilt after switching the embedding model", dimension, *dataset.Dimension)
+	}
+	return nil
+}
+
+type pgVectorQueryResult struct {
+	ID         string
+	DocumentID string
+	Content    string
+	Similarity float64
+}
+
+func (s *PGVectorRAG) QueryRecords(ctx context.Context, req *QueryRecordsRequest) (string, []*domain.NodeContentChunk, error) {
+	model, err := s.getModel(ctx, domain.ModelTypeEmbedding)
+	if err != nil {
+		return "", nil, err
+	}
+	if model == nil {
+		return "", nil, errors.New("embedding model not configured")
+	}
+	vectors, err := model.embed(ctx, s.client, []string{req.Query})
+	if err != nil {
+		return "", nil, err
+	}
+	if err := checkDatasetDimension(s.db.WithContext(ctx), req.DatasetID, len(vectors[0]), false); err != nil {
+		return "", nil, err
+	}
+	queryVector := vectorLiteral(vectors[0])
+
+	// fetch more candidates for rerank and per document limitation
+	query := s.db.WithContext(ctx).
+		Table("rag_chunks").
+		Select("rag_chunks.id, rag_chunks.document_id, rag_chunks.content, 1 - (rag_chunks.embedding <=> ?::vector) AS similarity", queryVector).
+		Joins("JOIN rag_documents ON rag_documents.id = rag_chunks.document_id").
+		Where("rag_chunks.dataset_id = ?", req.DatasetID).
+		Where("rag_documents.group_ids IS NULL OR rag_documents.group_ids && ?::int[]", toInt64Array(req.GroupIDs))
+	if len(req.Tags) > 0 {
+		query = query.Where("rag_documents.tags && ?::text[]", pq.StringArray(req.Tags))
+	}
+	if req.SimilarityThreshold > 0 {
+		query = query.Where("1 - (rag_chunks.embedding <=> ?::vector) >= ?", queryVector, req.SimilarityThreshold)
+	}
+	var results []*pgVectorQueryResult
+	if err := query.
+		Order(clause.Expr{SQL: "rag_chunks.embedding <=> ?::vector", Vars: []any{queryVector}}).
+		Limit(s.topK * 4).
+		Find(&results).Error; err != nil {
+		return "", nil, err
+	}
+
+	results = s.rerank(ctx, req.Query, results)
+
+	perDoc := make(map[string]int)
+	chunks := make([]*domain.NodeContentChunk, 0, s.topK)
+	for _, r := range results {
+		if req.MaxChunksPerDoc > 0 && perDoc[r.DocumentID] >= req.MaxChunksPerDoc {
+			continue
+		}
+		perDoc[r.DocumentID]++
+		chunks = append(chunks, &domain.NodeContentChunk{
+			ID:      r.ID,
+			Content: r.Content,
+			DocID:   r.DocumentID,
+		})
+		if len(chunks) >= s.topK {
+			break
+		}
+	}
+	s.logger.Info("retrieve chunks result", log.Int("chunks count", len(chunks)), log.String("query", req.Query))
+	return req.Query, chunks, nil
+}
+
+// rerank reorders results with the active rerank model, the vector order is kept if it is unavailable
+func (s *PGVectorRAG) rerank(ctx context.Context, query string, results []*pgVectorQueryResult) []*pgVectorQueryResult {
+	if len(results) <= 1 {
+		return results
+	}
+	model, err := s.getModel(ctx, domain.ModelTypeRerank)
+	if err != nil || model == nil || !model.IsActive {
+		return results
+	}
+	documents := make([]string, len(results))
+	for i, r := range results {
+		documents[i] = r.Content
+	}
+	indexes, err := model.rerank(ctx, s.client, query, documents, len(documents))
+	if err != nil {
+		s.logger.Warn("rerank failed, fallback to vector order", log.Error(err))
+		return results
+	}
+	reranked := make([]*pgVectorQueryResult, 0, len(indexes))
+	for _, i := range indexes {
+		reranked = append(reranked, results[i])
+	}
+	return reranked
+}
+
+func (s *PGVectorRAG) DeleteRecords(ctx context.Context, datasetID string, docIDs []string) error {
+	if len(docIDs) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("dataset_id = ? AND document_id IN ?", datasetID, docIDs).Delete(&pgVectorChunk{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("dataset_id = ? AND id IN ?", datasetID, docIDs).Delete(&pgVectorDocument{}).Error
+	})
+}
+
+func (s *PGVectorRAG) UpdateDocumentGroupIDs(ctx context.Context, datasetID string, docID string, groupIds []int) error {
+	var groupIDs pq.Int64Array
+	if groupIds != nil {
+		groupIDs = toInt64Array(groupIds)
+	}
+	if err := s.db.WithContext(ctx).Model(&pgVectorDocument{}).
+		Where("dataset_id = ? AND id = ?", datasetID, docID).
+		Updates(map[string]any{
+			"group_ids":  groupIDs,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+		return fmt.Errorf("update document group IDs failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PGVectorRAG) ListDocuments(ctx context.Context, datasetID string, documentIDs []string) ([]Document, error) {
+	var docs []*pgVectorDocument
+	query := s.db.WithContext(ctx).Where("dataset_id = ?", datasetID)
+	if len(documentIDs) > 0 {
+		query = query.Where("id IN ?", documentIDs)
+	}
+	if err := query.Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	documents := make([]Document, len(docs))
+	for i, doc := range docs {
+		documents[i] = Document{
+			ID:          doc.ID,
+			Name:        doc.Name,
+			DatasetID:   doc.DatasetID,
+			Status:      doc.Status,
+			ProgressMsg: doc.ProgressMsg,
+			Tags:        doc.Tags,
+		}
+		if doc.GroupIDs != nil {
+			documents[i].MetaData.GroupIDs = make([]int, len(doc.GroupIDs))
+			for j, id := range doc.GroupIDs {
+				documents[i].MetaData.GroupIDs[j] = int(id)
+			}
+		}
+	}
+	return documents, nil
+}
+
+func (s *PGVectorRAG) getModel(ctx context.Context, modelType domain.ModelType) (*pgVectorModel, error) {
+	var model pgVectorModel
+	if err := s.db.WithContext(ctx).Where("type = ?", modelType).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (s *PGVectorRAG) GetModelList(ctx context.Context) ([]*domain.Model, error) {
+	var models []*pgVectorModel
+	if err := s.db.WithContext(ctx).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*domain.Model, len(models))
+	for i, model := range models {
+		result[i] = model.toDomain()
+	}
+	return result, nil
+}
+
+func (s *PGVectorRAG) AddModel(ctx context.Context, model *domain.Model) (string, error) {
+	m := newPGVectorModel(model)
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	if err := s.db.WithContext(ctx).Create(m).Error; err != nil {
+		return "", err
+	}
+	return m.ID, nil
+}
+
+func (s *PGVectorRAG) UpdateModel(ctx context.Context, model *domain.Model) error {
+	m := newPGVectorModel(model)
+	return s.db.WithContext(ctx).Model(&pgVectorModel{}).
+		Where("id = ?", model.ID).
+		Select("*").
+		Omit("id").
+		Updates(m).Error
+}
+
+// UpsertModel keeps one model per type, same as the default model of raglite
+func (s *PGVectorRAG) UpsertModel(ctx context.Context, model *domain.Model) error {
+	m := newPGVectorModel(model)
+	m.ID = uuid.New().String()
+	return s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "type"}},
+			DoUpdates: clause.AssignmentColumns([]string{"provider", "model", "base_url", "api_key", "api_header", "api_version", "is_active", "updated_at"}),
+		}).
+		Create(m).Error
+}
+
+func (s *PGVectorRAG) DeleteModel(ctx context.Context, model *domain.Model) error {
+	return s.db.WithContext(ctx).Where("id = ?", model.ID).Delete(&pgVectorModel{}).Error
+}
+
+func vectorLiteral(vector []float32) string {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i, v := range vector {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(strconv.FormatFloat(float64(v), 'f', -1, 32))
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+func toInt64Array(ids []int) pq.Int64Array {
+	arr := make(pq.Int64Array, len(ids))
+	for i, id := range ids {
+		arr[i] = int64(id)
+	}
+	return arr
+}
@@ -0,0 +1,121 @@
+package rag
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// splitMarkdownChunks splits markdown into chunks of at most chunkSize characters,
+// cutting at headings and blank lines and keeping fenced code blocks together when possible
+func splitMarkdownChunks(markdown string, chunkSize int) []string {
+	if chunkSize <= 0 {
+		chunkSize = 1000
+	}
+	chunks := make([]string, 0)
+	var cur strings.Builder
+	curLen := 0
+	flush := func() {
+		if text := strings.TrimSpace(cur.String()); text != "" {
+			chunks = append(chunks, text)
+		}
+		cur.Reset()
+		curLen = 0
+	}
+
+	for _, block := range splitMarkdownBlocks(markdown) {
+		blockLen := utf8.RuneCountInString(block)
+		if blockLen > chunkSize {
+			flush()
+			chunks = append(chunks, splitLongBlock(block, chunkSize)...)
+			continue
+		}
+		if curLen > 0 && (curLen+blockLen+2 > chunkSize || strings.HasPrefix(block, "#")) {
+			flush()
+		}
+		if curLen > 0 {
+			cur.WriteString("\n\n")
+			curLen += 2
+		}
+		cur.WriteString(block)
+		curLen += blockLen
+	}
+	flush()
+	return chunks
+}
+
+// splitMarkdownBlocks splits markdown by blank lines and headings, fenced code blocks are one block
+func splitMarkdownBlocks(markdown string) []string {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	blocks := make([]string, 0)
+	cur := make([]string, 0)
+	inFence := false
+	flush := func() {
+		if len(cur) > 0 {
+			if block := strings.TrimSpace(strings.Join(cur, "\n")); block != "" {
+				blocks = append(blocks, block)
+			}
+			cur = cur[:0]
+		}
+	}
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			if !inFence {
+				flush()
+			}
+			cur = append(cur, line)
+			if inFence {
+				flush()
+			}
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			cur = append(cur, line)
+			continue
+		}
+		if trimmed == "" {
+			flush()
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			flush()
+		}
+		cur = append(cur, line)
+	}
+	flush()
+	return blocks
+}
+
+// splitLongBlock splits a block by lines, lines longer than chunkSize are cut by characters
+func splitLongBlock(block string, chunkSize int) []string {
+	parts := make([]string, 0)
+	var cur strings.Builder
+	curLen := 0
+	flush := func() {
+		if text := strings.TrimSpace(cur.String()); text != "" {
+			parts = append(parts, text)
+		}
+		cur.Reset()
+		curLen = 0
+	}
+	for _, line := range strings.Split(block, "\n") {
+		runes := []rune(line)
+		for len(runes) > chunkSize {
+			flush()
+			parts = append(parts, string(runes[:chunkSize]))
+			runes = runes[chunkSize:]
+		}
+		if curLen > 0 && curLen+len(runes)+1 > chunkSize {
+			flush()
+		}
+		if curLen > 0 {
+			cur.WriteString("\n")
+			curLen++
+		}
+		cur.WriteString(string(runes))
+		curLen += len(runes)
+	}
+	flush()
+	return parts
+}
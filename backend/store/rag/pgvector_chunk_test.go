@@ -0,0 +1,25 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitMarkdownChunks(t *testing.T) {
+	markdown := "# Title\n\nintro paragraph\n\n## Section\n\n```go\nfunc main() {\n\n}\n```\n\nlast paragraph"
+	assert.Equal(t, []string{
+		"# Title\n\nintro paragraph",
+		"## Section\n\n```go\nfunc main() {\n\n}\n```\n\nlast paragraph",
+	}, splitMarkdownChunks(markdown, 1000))
+
+	long := strings.Repeat("知识库", 100)
+	chunks := splitMarkdownChunks(long, 120)
+	assert.Len(t, chunks, 3)
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, utf8.RuneCountInString(chunk), 120)
+	}
+	assert.Equal(t, long, strings.Join(chunks, ""))
+}
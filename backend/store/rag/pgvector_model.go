@@ -0,0 +1,176 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/utils"
+)
+
+const embeddingBatchSize = 16
+
+// pgVectorModel is an embedding or rerank model registered to the pgvector store
+// table: rag_models
+type pgVectorModel struct {
+	ID         string           `gorm:"primaryKey"`
+	Type       domain.ModelType `gorm:"uniqueIndex"`
+	Provider   domain.ModelProvider
+	Model      string
+	BaseURL    string
+	APIKey     string
+	APIHeader  string
+	APIVersion string
+	IsActive   bool
+	UpdatedAt  time.Time
+}
+
+func (pgVectorModel) TableName() string {
+	return "rag_models"
+}
+
+func newPGVectorModel(model *domain.Model) *pgVectorModel {
+	return &pgVectorModel{
+		ID:         model.ID,
+		Type:       model.Type,
+		Provider:   model.Provider,
+		Model:      model.Model,
+		BaseURL:    model.BaseURL,
+		APIKey:     model.APIKey,
+		APIHeader:  model.APIHeader,
+		APIVersion: model.APIVersion,
+		IsActive:   model.IsActive,
+		UpdatedAt:  time.Now(),
+	}
+}
+
+func (m *pgVectorModel) toDomain() *domain.Model {
+	return &domain.Model{
+		ID:         m.ID,
+		Type:       m.Type,
+		Provider:   m.Provider,
+		Model:      m.Model,
+		BaseURL:    m.BaseURL,
+		APIKey:     m.APIKey,
+		APIHeader:  m.APIHeader,
+		APIVersion: m.APIVersion,
+		IsActive:   m.IsActive,
+	}
+}
+
+// endpoint follows the ModelKit convention, a base url ending with '#' is used as is
+func (m *pgVectorModel) endpoint(path string) string {
+	if strings.HasSuffix(m.BaseURL, "#") {
+		return strings.TrimSuffix(m.BaseURL, "#")
+	}
+	return strings.TrimSuffix(m.BaseURL, "/") + path
+}
+
+func (m *pgVectorModel) post(ctx context.Context, client *http.Client, path string, reqBody, respBody any) error {
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.endpoint(path), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.APIKey)
+	}
+	for k, v := range utils.GetHeaderMap(m.APIHeader) {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request %s failed: %s %s", m.Model, resp.Status, string(data))
+	}
+	return json.Unmarshal(data, respBody)
+}
+
+// embed calls the OpenAI compatible embeddings api
+func (m *pgVectorModel) embed(ctx context.Context, client *http.Client, inputs []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(inputs))
+	for start := 0; start < len(inputs); start += embeddingBatchSize {
+		batch := inputs[start:min(start+embeddingBatchSize, len(inputs))]
+		var resp struct {
+			Data []struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			} `json:"data"`
+		}
+		if err := m.post(ctx, client, "/embeddings", map[string]any{
+			"model":           m.Model,
+			"input":           batch,
+			"encoding_format": "float",
+		}, &resp); err != nil {
+			return nil, fmt.Errorf("embedding failed: %w", err)
+		}
+		if len(resp.Data) != len(batch) {
+			return nil, fmt.Errorf("embedding failed: expect %d vectors, got %d", len(batch), len(resp.Data))
+		}
+		sort.Slice(resp.Data, func(i, j int) bool { return resp.Data[i].Index < resp.Data[j].Index })
+		for _, item := range resp.Data {
+			vectors = append(vectors, item.Embedding)
+		}
+	}
+	return vectors, nil
+}
+
+// EmbedTexts embeds inputs with an OpenAI compatible embedding model outside of any rag store
+func EmbedTexts(ctx context.Context, client *http.Client, model *domain.Model, inputs []string) ([][]float32, error) {
+	return newPGVectorModel(model).embed(ctx, client, inputs)
+}
+
+// CreateEmbeddings sends the OpenAI embeddings request to an OpenAI compatible embedding model as is, except the model name
+func CreateEmbeddings(ctx context.Context, client *http.Client, model *domain.Model, req *domain.OpenAIEmbeddingsRequest) (*domain.OpenAIEmbeddingsResponse, error) {
+	body := *req
+	body.Model = model.Model
+	var resp domain.OpenAIEmbeddingsResponse
+	if err := newPGVectorModel(model).post(ctx, client, "/embeddings", &body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// rerank calls the jina/cohere compatible rerank api and returns document indexes ordered by relevance
+func (m *pgVectorModel) rerank(ctx context.Context, client *http.Client, query string, documents []string, topN int) ([]int, error) {
+	var resp struct {
+		Results []struct {
+			Index          int     `json:"index"`
+			RelevanceScore float64 `json:"relevance_score"`
+		} `json:"results"`
+	}
+	if err := m.post(ctx, client, "/rerank", map[string]any{
+		"model":     m.Model,
+		"query":     query,
+		"documents": documents,
+		"top_n":     topN,
+	}, &resp); err != nil {
+		return nil, fmt.Errorf("rerank failed: %w", err)
+	}
+	sort.Slice(resp.Results, func(i, j int) bool { return resp.Results[i].RelevanceScore > resp.Results[j].RelevanceScore })
+	indexes := make([]int, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		if r.Index >= 0 && r.Index < len(documents) {
+			indexes = append(indexes, r.Index)
+		}
+	}
+	return indexes, nil
+}
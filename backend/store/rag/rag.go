@@ -65,6 +65,8 @@ func NewRAGService(config *config.Config, logger *log.Logger) (RAGService, error
 	switch config.RAG.Provider {
 	case "ct":
 		return NewCTRAG(config, logger)
+	case "pgvector":
+		return NewPGVectorRAG(config, logger)
 	default:
 		return nil, fmt.Errorf("unsupported vector provider: %s", config.RAG.Provider)
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,6 +18,9 @@ import (
 	"github.com/chaitin/panda-wiki/pkg/bot/discord"
 	"github.com/chaitin/panda-wiki/pkg/bot/feishu"
 	"github.com/chaitin/panda-wiki/pkg/bot/lark"
+	"github.com/chaitin/panda-wiki/pkg/bot/slack"
+	"github.com/chaitin/panda-wiki/pkg/bot/teams"
+	"github.com/chaitin/panda-wiki/pkg/bot/telegram"
 	"github.com/chaitin/panda-wiki/repo/pg"
 	"github.com/chaitin/panda-wiki/store/cache"
 )
@@ -39,6 +43,12 @@ type AppUsecase struct {
 	larkMutex     sync.RWMutex
 	discordBots   map[string]*discord.DiscordClient
 	discordMutex  sync.RWMutex
+	slackBots     map[string]*slack.SlackClient
+	slackMutex    sync.RWMutex
+	teamsBots     map[string]*teams.TeamsClient
+	teamsMutex    sync.RWMutex
+	telegramBots  map[string]*telegram.TelegramClient
+	telegramMutex sync.RWMutex
 }
 
 func NewAppUsecase(
@@ -66,10 +76,13 @@ func NewAppUsecase(
 		feishuBots:   make(map[string]*feishu.FeishuClient),
 		larkBots:     make(map[string]*lark.LarkClient),
 		discordBots:  make(map[string]*discord.DiscordClient),
+		slackBots:    make(map[string]*slack.SlackClient),
+		teamsBots:    make(map[string]*teams.TeamsClient),
+		telegramBots: make(map[string]*telegram.TelegramClient),
 	}
 
-	// Initialize all valid DingTalkBot, FeishuBot, LarkBot and DiscordBot instances
-	apps, err := u.repo.GetAppsByTypes(context.Background(), []domain.AppType{domain.AppTypeDingTalkBot, domain.AppTypeFeishuBot, domain.AppTypeLarkBot, domain.AppTypeDisCordBot})
+	// Initialize all valid DingTalkBot, FeishuBot, LarkBot, DiscordBot, SlackBot, TeamsBot and TelegramBot instances
+	apps, err := u.repo.GetAppsByTypes(context.Background(), []domain.AppType{domain.AppTypeDingTalkBot, domain.AppTypeFeishuBot, domain.AppTypeLarkBot, domain.AppTypeDisCordBot, domain.AppTypeSlackBot, domain.AppTypeTeamsBot, domain.AppTypeTelegramBot})
 	if err != nil {
 		u.logger.Error("failed to get dingtalk bot apps", log.Error(err))
 		return u
@@ -85,6 +98,12 @@ func NewAppUsecase(
 			u.updateLarkBot(app)
 		case domain.AppTypeDisCordBot:
 			u.updateDisCordBot(app)
+		case domain.AppTypeSlackBot:
+			u.updateSlackBot(app)
+		case domain.AppTypeTeamsBot:
+			u.updateTeamsBot(app)
+		case domain.AppTypeTelegramBot:
+			u.updateTelegramBot(app)
 		}
 	}
 
@@ -181,28 +200,39 @@ func (u *AppUsecase) UpdateApp(ctx context.Context, id string, appRequest *domai
 			u.updateLarkBot(app)
 		case domain.AppTypeDisCordBot:
 			u.updateDisCordBot(app)
+		case domain.AppTypeSlackBot:
+			u.updateSlackBot(app)
+		case domain.AppTypeTeamsBot:
+			u.updateTeamsBot(app)
+		case domain.AppTypeTelegramBot:
+			u.updateTelegramBot(app)
 		}
 	}
 	return nil
 }
 
+// botChat asks the question as the auth of the bot source type, so the conversation is recorded under the bot
+func (u *AppUsecase) botChat(ctx context.Context, kbID string, appType domain.AppType, msg string, info domain.ConversationInfo, conversationID string) (<-chan domain.SSEEvent, error) {
+	auth, err := u.authRepo.GetAuthByKBIDAndSourceType(ctx, kbID, appType.ToSourceType())
+	if err != nil {
+		u.logger.Error("get auth failed", log.Error(err))
+		return nil, err
+	}
+	info.UserInfo.AuthUserID = auth.ID
+
+	return u.chatUsecase.Chat(ctx, &domain.ChatRequest{
+		Message:        msg,
+		KBID:           kbID,
+		AppType:        appType,
+		RemoteIP:       "",
+		ConversationID: conversationID,
+		Info:           info,
+	})
+}
+
 func (u *AppUsecase) getQAFunc(kbID string, appType domain.AppType) bot.GetQAFun {
 	return func(ctx context.Context, msg string, info domain.ConversationInfo, ConversationID string) (chan string, error) {
-		auth, err := u.authRepo.GetAuthByKBIDAndSourceType(ctx, kbID, appType.ToSourceType())
-		if err != nil {
-			u.logger.Error("get auth failed", log.Error(err))
-			return nil, err
-		}
-		info.UserInfo.AuthUserID = auth.ID
-
-		eventCh, err := u.chatUsecase.Chat(ctx, &domain.ChatRequest{
-			Message:        msg,
-			KBID:           kbID,
-			AppType:        appType,
-			RemoteIP:       "",
-			ConversationID: ConversationID,
-			Info:           info,
-		})
+		eventCh, err := u.botChat(ctx, kbID, appType, msg, info, ConversationID)
 		if err != nil {
 			return nil, err
 		}
@@ -240,6 +270,13 @@ func (u *AppUsecase) getQAFunc(kbID string, appType domain.AppType) bot.GetQAFun
 					messageId = event.Content
 				}
 			}
+			// the bot collects the feedback by itself
+			if recorder := bot.MessageIDRecorder(ctx); recorder != nil {
+				if messageId != "" && (appinfo.Settings.AIFeedbackSettings.AIFeedbackIsEnabled == nil || *appinfo.Settings.AIFeedbackSettings.AIFeedbackIsEnabled) {
+					recorder(messageId)
+				}
+				return
+			}
 			// check again
 			// contact --> send
 			if kb != nil && (appinfo.Settings.AIFeedbackSettings.AIFeedbackIsEnabled == nil || *appinfo.Settings.AIFeedbackSettings.AIFeedbackIsEnabled) { // open
@@ -253,6 +290,44 @@ func (u *AppUsecase) getQAFunc(kbID string, appType domain.AppType) bot.GetQAFun
 	}
 }
 
+// getQAWithReferencesFunc is like getQAFunc, the documents retrieved for the answer are sent as references
+// instead of appending the feedback links to the answer
+func (u *AppUsecase) getQAWithReferencesFunc(kbID string, appType domain.AppType) bot.GetQAWithReferencesFun {
+	return func(ctx context.Context, msg string, info domain.ConversationInfo, ConversationID string) (chan bot.QAChunk, error) {
+		eventCh, err := u.botChat(ctx, kbID, appType, msg, info, ConversationID)
+		if err != nil {
+			return nil, err
+		}
+		kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+		if err != nil {
+			u.logger.Error("get kb failed", log.Error(err), log.String("kb_id", kbID))
+		}
+
+		chunkCh := make(chan bot.QAChunk, 10)
+		go func() {
+			defer close(chunkCh)
+			for event := range eventCh {
+				switch event.Type {
+				case "done", "error":
+					return
+				case "data":
+					chunkCh <- bot.QAChunk{Content: event.Content}
+				case "chunk_result":
+					if event.ChunkResult == nil {
+						continue
+					}
+					ref := &bot.Reference{Title: event.ChunkResult.Name}
+					if kb != nil && kb.AccessSettings.BaseURL != "" {
+						ref.URL = fmt.Sprintf("%s/node/%s", kb.AccessSettings.BaseURL, event.ChunkResult.NodeID)
+					}
+					chunkCh <- bot.QAChunk{Reference: ref}
+				}
+			}
+		}()
+		return chunkCh, nil
+	}
+}
+
 func (u *AppUsecase) updateFeishuBot(app *domain.App) {
 	u.feishuMutex.Lock()
 	defer u.feishuMutex.Unlock()
@@ -420,6 +495,164 @@ func (u *AppUsecase) updateDisCordBot(app *domain.App) {
 	u.discordBots[app.ID] = discordBots
 }
 
+func (u *AppUsecase) updateSlackBot(app *domain.App) {
+	u.slackMutex.Lock()
+	defer u.slackMutex.Unlock()
+
+	if bot, exists := u.slackBots[app.ID]; exists {
+		if bot != nil {
+			bot.Stop()
+			delete(u.slackBots, app.ID)
+		}
+	}
+
+	settings := app.Settings.SlackBotSettings
+	if (settings.IsEnabled != nil && !*settings.IsEnabled) || settings.BotToken == "" || (settings.AppToken == "" && settings.SigningSecret == "") {
+		return
+	}
+
+	getQA := u.getQAFunc(app.KBID, app.Type)
+
+	botCtx, cancel := context.WithCancel(context.Background())
+	slackClient, err := slack.NewSlackClient(
+		botCtx,
+		cancel,
+		settings.BotToken,
+		settings.AppToken,
+		settings.SigningSecret,
+		u.logger,
+		getQA,
+	)
+	if err != nil {
+		u.logger.Error("failed to create slack client", log.Error(err))
+		cancel()
+		return
+	}
+
+	go func() {
+		u.logger.Info("slack bot is starting", log.String("app_id", app.ID))
+		err := slackClient.Start()
+		if err != nil {
+			u.logger.Error("failed to start slack client", log.Error(err))
+			cancel()
+			return
+		}
+	}()
+
+	u.slackBots[app.ID] = slackClient
+}
+
+func (u *AppUsecase) updateTeamsBot(app *domain.App) {
+	u.teamsMutex.Lock()
+	defer u.teamsMutex.Unlock()
+
+	if bot, exists := u.teamsBots[app.ID]; exists {
+		if bot != nil {
+			bot.Stop()
+			delete(u.teamsBots, app.ID)
+		}
+	}
+
+	settings := app.Settings.TeamsBotSettings
+	if (settings.IsEnabled != nil && !*settings.IsEnabled) || settings.AppID == "" || settings.AppPassword == "" {
+		return
+	}
+
+	getQA := u.getQAWithReferencesFunc(app.KBID, app.Type)
+
+	botCtx, cancel := context.WithCancel(context.Background())
+	teamsClient, err := teams.NewTeamsClient(
+		botCtx,
+		cancel,
+		settings.AppID,
+		settings.AppPassword,
+		settings.TenantID,
+		u.logger,
+		getQA,
+	)
+	if err != nil {
+		u.logger.Error("failed to create teams client", log.Error(err))
+		cancel()
+		return
+	}
+
+	go func() {
+		u.logger.Info("teams bot is starting", log.String("app_id", settings.AppID))
+		if err := teamsClient.Start(); err != nil {
+			u.logger.Error("failed to start teams client", log.Error(err))
+			cancel()
+		}
+	}()
+
+	u.teamsBots[app.ID] = teamsClient
+}
+
+func (u *AppUsecase) updateTelegramBot(app *domain.App) {
+	u.telegramMutex.Lock()
+	defer u.telegramMutex.Unlock()
+
+	if bot, exists := u.telegramBots[app.ID]; exists {
+		if bot != nil {
+			bot.Stop()
+			delete(u.telegramBots, app.ID)
+		}
+	}
+
+	settings := app.Settings.TelegramBotSettings
+	if (settings.IsEnabled != nil && !*settings.IsEnabled) || settings.BotToken == "" {
+		return
+	}
+
+	var webhookURL string
+	if settings.Mode == domain.TelegramBotModeWebhook {
+		kb, err := u.kbRepo.GetKnowledgeBaseByID(context.Background(), app.KBID)
+		if err != nil {
+			u.logger.Error("get kb failed", log.Error(err), log.String("kb_id", app.KBID))
+			return
+		}
+		if kb.AccessSettings.BaseURL == "" {
+			u.logger.Error("telegram bot webhook mode requires the base url of the knowledge base", log.String("kb_id", app.KBID))
+			return
+		}
+		webhookURL = fmt.Sprintf("%s/share/v1/openapi/telegram/bot/%s", strings.TrimSuffix(kb.AccessSettings.BaseURL, "/"), app.KBID)
+	}
+
+	getQA := u.getQAFunc(app.KBID, app.Type)
+
+	botCtx, cancel := context.WithCancel(context.Background())
+	telegramClient, err := telegram.NewTelegramClient(
+		botCtx,
+		cancel,
+		settings.BotToken,
+		webhookURL,
+		u.logger,
+		getQA,
+		u.feedbackFunc(app.KBID),
+	)
+	if err != nil {
+		u.logger.Error("failed to create telegram client", log.Error(err))
+		cancel()
+		return
+	}
+
+	go func() {
+		u.logger.Info("telegram bot is starting", log.String("app_id", app.ID), log.String("mode", settings.Mode))
+		if err := telegramClient.Start(); err != nil {
+			u.logger.Error("failed to start telegram client", log.Error(err))
+			cancel()
+		}
+	}()
+
+	u.telegramBots[app.ID] = telegramClient
+}
+
+// feedbackFunc records the feedback collected by the bots, only the messages of the knowledge base are accepted
+func (u *AppUsecase) feedbackFunc(kbID string) bot.FeedbackFun {
+	return func(ctx context.Context, messageID string, score domain.ScoreType) error {
+		return u.chatUsecase.conversationUsecase.FeedBackByKB(ctx, kbID, messageID, score)
+	}
+}
+
 func (u *AppUsecase) DeleteApp(ctx context.Context, id, kbID string) error {
 	return u.repo.DeleteApp(ctx, id, kbID)
 }
@@ -433,6 +666,33 @@ func (u *AppUsecase) GetLarkBotClient(appID string) (*lark.LarkClient, bool) {
 	return client, ok
 }
 
+// GetSlackBotClient returns the Slack bot client for a given app ID
+// This is used to handle the Events API callbacks
+func (u *AppUsecase) GetSlackBotClient(appID string) (*slack.SlackClient, bool) {
+	u.slackMutex.RLock()
+	defer u.slackMutex.RUnlock()
+	client, ok := u.slackBots[appID]
+	return client, ok
+}
+
+// GetTeamsBotClient returns the Teams bot client for a given app ID
+// This is used to handle the activities posted to the Bot Framework endpoint
+func (u *AppUsecase) GetTeamsBotClient(appID string) (*teams.TeamsClient, bool) {
+	u.teamsMutex.RLock()
+	defer u.teamsMutex.RUnlock()
+	client, ok := u.teamsBots[appID]
+	return client, ok
+}
+
+// GetTelegramBotClient returns the Telegram bot client for a given app ID
+// This is used to handle the webhook callbacks
+func (u *AppUsecase) GetTelegramBotClient(appID string) (*telegram.TelegramClient, bool) {
+	u.telegramMutex.RLock()
+	defer u.telegramMutex.RUnlock()
+	client, ok := u.telegramBots[appID]
+	return client, ok
+}
+
 func (u *AppUsecase) GetAppDetailByKBIDAndAppType(ctx context.Context, kbID string, appType domain.AppType) (*domain.AppDetailResp, error) {
 	app, err := u.repo.GetOrCreateAppByKBIDAndType(ctx, kbID, appType)
 	if err != nil {
@@ -491,6 +751,12 @@ func (u *AppUsecase) GetAppDetailByKBIDAndAppType(ctx context.Context, kbID stri
 		FeishuBotAppSecret: app.Settings.FeishuBotAppSecret,
 		// LarkBot
 		LarkBotSettings: app.Settings.LarkBotSettings,
+		// SlackBot
+		SlackBotSettings: app.Settings.SlackBotSettings,
+		// TeamsBot
+		TeamsBotSettings: app.Settings.TeamsBotSettings,
+		// TelegramBot
+		TelegramBotSettings: app.Settings.TelegramBotSettings,
 		// WechatBot
 		WeChatAppIsEnabled:       app.Settings.WeChatAppIsEnabled,
 		WeChatAppToken:           app.Settings.WeChatAppToken,
@@ -547,6 +813,7 @@ func (u *AppUsecase) GetAppDetailByKBIDAndAppType(ctx context.Context, kbID stri
 		ContributeSettings:  app.Settings.ContributeSettings,
 		HomePageSetting:     app.Settings.HomePageSetting,
 		ConversationSetting: app.Settings.ConversationSetting,
+		ChatModelID:         app.Settings.ChatModelID,
 
 		WecomAIBotSettings: app.Settings.WecomAIBotSettings,
 
@@ -804,6 +1071,30 @@ func (u *AppUsecase) handleBotAuths(ctx context.Context, id string, newSettings
 		}
 	}
 
+	// Handle Slack Bot
+	if currentApp.Settings.SlackBotSettings.IsEnabled != newSettings.SlackBotSettings.IsEnabled {
+		if err := u.handleBotAuth(ctx, currentApp.KBID, currentApp.ID, currentApp.Settings.SlackBotSettings.IsEnabled,
+			newSettings.SlackBotSettings.IsEnabled, consts.SourceTypeSlackBot); err != nil {
+			u.logger.Error("failed to handle slack bot auth", log.Error(err))
+		}
+	}
+
+	// Handle Teams Bot
+	if currentApp.Settings.TeamsBotSettings.IsEnabled != newSettings.TeamsBotSettings.IsEnabled {
+		if err := u.handleBotAuth(ctx, currentApp.KBID, currentApp.ID, currentApp.Settings.TeamsBotSettings.IsEnabled,
+			newSettings.TeamsBotSettings.IsEnabled, consts.SourceTypeTeamsBot); err != nil {
+			u.logger.Error("failed to handle teams bot auth", log.Error(err))
+		}
+	}
+
+	// Handle Telegram Bot
+	if currentApp.Settings.TelegramBotSettings.IsEnabled != newSettings.TelegramBotSettings.IsEnabled {
+		if err := u.handleBotAuth(ctx, currentApp.KBID, currentApp.ID, currentApp.Settings.TelegramBotSettings.IsEnabled,
+			newSettings.TelegramBotSettings.IsEnabled, consts.SourceTypeTelegramBot); err != nil {
+			u.logger.Error("failed to handle telegram bot auth", log.Error(err))
+		}
+	}
+
 	// Handle WeChat Bot
 	if currentApp.Settings.WeChatAppIsEnabled != newSettings.WeChatAppIsEnabled {
 		if err := u.handleBotAuth(ctx, currentApp.KBID, currentApp.ID, currentApp.Settings.WeChatAppIsEnabled,
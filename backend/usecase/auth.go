@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/url"
 	"slices"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,6 +28,8 @@ type AuthUsecase struct {
 	logger   *log.Logger
 	kbRepo   *pg.KnowledgeBaseRepository
 	cache    *cache.Cache
+
+	samlMetadata sync.Map // kbID -> *samlMetadataCacheItem
 }
 
 func NewAuthUsecase(authRepo *pg.AuthRepo, logger *log.Logger, kbRepo *pg.KnowledgeBaseRepository, cache *cache.Cache) (*AuthUsecase, error) {
@@ -43,6 +46,22 @@ type StateInfo struct {
 	KbId        string `json:"kb_id"`
 	RedirectUrl string `json:"redirect_url"`
 	Verifier    string `json:"verifier"`
+	RequestID   string `json:"request_id,omitempty"` // SAML AuthnRequest ID
+}
+
+var ErrEnterpriseSourceTypeNotConfigured = errors.New("enterprise auth source type of the knowledge base is not configured")
+
+// enterpriseSourceType 知识库配置的企业认证方式，SCIM 和 LDAP 同步的用户使用该来源，用户登录时使用同步创建的记录
+func (u *AuthUsecase) enterpriseSourceType(ctx context.Context, kbID string) (consts.SourceType, error) {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return "", err
+	}
+	sourceType := kb.AccessSettings.SourceType
+	if sourceType == "" || slices.Contains(consts.BotSourceTypes, sourceType) {
+		return "", ErrEnterpriseSourceTypeNotConfigured
+	}
+	return sourceType, nil
 }
 
 func (u *AuthUsecase) GetAuthBySourceType(ctx context.Context, sourceType consts.SourceType) (*domain.Auth, error) {
@@ -59,12 +78,17 @@ func (u *AuthUsecase) SetAuth(ctx context.Context, req v1.AuthSetReq) error {
 			ClientID:     req.ClientID,
 			ClientSecret: req.ClientSecret,
 			Proxy:        req.Proxy,
+			SAML:         req.SAML,
+			LDAP:         req.LDAP,
 		},
 		KbID:       req.KBID,
 		SourceType: req.SourceType,
 	}); err != nil {
 		return err
 	}
+	if req.SourceType == consts.SourceTypeSAML {
+		u.samlMetadata.Delete(req.KBID)
+	}
 	return nil
 }
 
@@ -110,6 +134,8 @@ func (u *AuthUsecase) GetAuth(ctx context.Context, kbID string, sourceType const
 		ClientSecret: authConfig.AuthSetting.ClientSecret,
 		SourceType:   authConfig.SourceType,
 		Proxy:        authConfig.AuthSetting.Proxy,
+		SAML:         authConfig.AuthSetting.SAML,
+		LDAP:         authConfig.AuthSetting.LDAP,
 		Auths:        as,
 	}
 	return resp, nil
@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+
+	v1 "github.com/chaitin/panda-wiki/api/auth/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/pkg/ldap"
+)
+
+func (u *AuthUsecase) getLDAPClient(ctx context.Context, kbID string) (*ldap.Client, error) {
+	authConfig, err := u.AuthRepo.GetAuthConfig(ctx, kbID, consts.SourceTypeLDAP)
+	if err != nil {
+		return nil, err
+	}
+	setting := authConfig.AuthSetting.LDAP
+	if setting == nil {
+		return nil, fmt.Errorf("ldap is not configured")
+	}
+	return ldap.NewClient(ctx, u.logger, ldap.Config{
+		ServerURL:       setting.ServerURL,
+		BindDN:          setting.BindDN,
+		BindPassword:    setting.BindPassword,
+		UserBaseDN:      setting.UserBaseDN,
+		UserIDAttr:      setting.UserIDAttr,
+		UserNameAttr:    setting.UserNameAttr,
+		UserEmailAttr:   setting.UserEmailAttr,
+		GroupDNs:        setting.GroupDNs,
+		GroupNameAttr:   setting.GroupNameAttr,
+		GroupMemberAttr: setting.GroupMemberAttr,
+	})
+}
+
+// SyncAllLDAPGroups 同步所有开启了定时同步的知识库，单个知识库失败不影响其他知识库
+func (u *AuthUsecase) SyncAllLDAPGroups(ctx context.Context) error {
+	authConfigs, err := u.AuthRepo.ListAuthConfigsBySourceType(ctx, consts.SourceTypeLDAP)
+	if err != nil {
+		return fmt.Errorf("list ldap auth configs failed: %w", err)
+	}
+	for _, authConfig := range authConfigs {
+		if authConfig.AuthSetting.LDAP == nil || !authConfig.AuthSetting.LDAP.SyncEnabled {
+			continue
+		}
+		resp, err := u.SyncLDAPGroups(ctx, authConfig.KbID)
+		if err != nil {
+			u.logger.Error("sync ldap groups failed", log.String("kb_id", authConfig.KbID), log.Error(err))
+			continue
+		}
+		u.logger.Info("sync ldap groups done", log.String("kb_id", authConfig.KbID), log.Any("result", resp))
+	}
+	return nil
+}
+
+// SyncLDAPGroups 将配置的 LDAP 组及其嵌套的子组同步为知识库的用户组。
+// 组按DN对应，子组挂在父组下以继承父组的文档权限，不再存在的组会被删除（配置的组不存在时不同步），
+// 组成员按用户ID对应企业认证用户，不存在的用户会被创建，用户首次登录时即可获得组的权限
+func (u *AuthUsecase) SyncLDAPGroups(ctx context.Context, kbID string) (*v1.LDAPGroupSyncResp, error) {
+	sourceType, err := u.enterpriseSourceType(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	client, err := u.getLDAPClient(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	ldapGroups, err := client.ListGroups()
+	if err != nil {
+		return nil, err
+	}
+	// 没有读取到任何组时不同步，避免配置错误时删除所有 LDAP 用户组及其文档权限
+	if len(ldapGroups) == 0 {
+		return nil, fmt.Errorf("no ldap groups found")
+	}
+
+	existingGroups, err := u.AuthRepo.ListAuthGroupsBySourceType(ctx, kbID, consts.SourceTypeLDAP)
+	if err != nil {
+		return nil, err
+	}
+	existingByDN := make(map[string]domain.AuthGroup, len(existingGroups))
+	for _, group := range existingGroups {
+		existingByDN[group.SyncId] = group
+	}
+
+	resp := &v1.LDAPGroupSyncResp{Groups: len(ldapGroups)}
+	authIDByUserID := make(map[string]int64)
+	groupIDByDN := make(map[string]uint, len(ldapGroups))
+	// 父组在子组之前，创建子组时父组已有 ID
+	for _, ldapGroup := range ldapGroups {
+		authIDs := make(pq.Int64Array, 0, len(ldapGroup.Members))
+		for _, member := range ldapGroup.Members {
+			authID, ok := authIDByUserID[member.ID]
+			if !ok {
+				authID, err = u.getOrCreateLDAPAuth(ctx, kbID, sourceType, member)
+				if err != nil {
+					u.logger.Warn("map ldap user to auth failed", log.String("kb_id", kbID), log.String("user", member.ID), log.Error(err))
+				}
+				authIDByUserID[member.ID] = authID
+			}
+			if authID != 0 {
+				authIDs = append(authIDs, authID)
+			}
+		}
+
+		var parentID *uint
+		if id, ok := groupIDByDN[ldapGroup.ParentDN]; ok {
+			parentID = &id
+		}
+
+		existing, ok := existingByDN[ldapGroup.DN]
+		if !ok {
+			group := &domain.AuthGroup{
+				Name:         ldapGroup.Name,
+				KbID:         kbID,
+				ParentID:     parentID,
+				AuthIDs:      authIDs,
+				UserIDs:      pq.StringArray{},
+				SyncId:       ldapGroup.DN,
+				SyncParentId: ldapGroup.ParentDN,
+				SourceType:   consts.SourceTypeLDAP,
+			}
+			if err := u.AuthRepo.CreateAuthGroup(ctx, group); err != nil {
+				return nil, fmt.Errorf("create auth group %s failed: %w", ldapGroup.DN, err)
+			}
+			groupIDByDN[ldapGroup.DN] = group.ID
+			resp.Created++
+			continue
+		}
+
+		delete(existingByDN, ldapGroup.DN)
+		groupIDByDN[ldapGroup.DN] = existing.ID
+		if err := u.AuthRepo.UpdateAuthGroupFields(ctx, existing.ID, map[string]any{
+			"name":           ldapGroup.Name,
+			"parent_id":      parentID,
+			"auth_ids":       authIDs,
+			"sync_parent_id": ldapGroup.ParentDN,
+		}); err != nil {
+			return nil, fmt.Errorf("update auth group %s failed: %w", ldapGroup.DN, err)
+		}
+	}
+
+	for _, group := range existingByDN {
+		if err := u.AuthRepo.DeleteAuthGroup(ctx, group.ID); err != nil {
+			return nil, fmt.Errorf("delete auth group %s failed: %w", group.SyncId, err)
+		}
+		resp.Deleted++
+	}
+
+	return resp, nil
+}
+
+// getOrCreateLDAPAuth 按用户ID获取企业认证用户，不存在时创建，已存在的用户信息以登录时为准
+func (u *AuthUsecase) getOrCreateLDAPAuth(ctx context.Context, kbID string, sourceType consts.SourceType, user *ldap.UserInfo) (int64, error) {
+	auth, err := u.AuthRepo.GetAuthByUnionID(ctx, kbID, sourceType, user.ID)
+	if err == nil {
+		return int64(auth.ID), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+	auth, err = u.AuthRepo.GetOrCreateAuth(ctx, &domain.Auth{
+		KBID:       kbID,
+		UnionID:    user.ID,
+		SourceType: sourceType,
+		UserInfo: domain.AuthUserInfo{
+			Username: user.Username,
+			Email:    user.Email,
+		},
+	}, sourceType)
+	if err != nil {
+		return 0, err
+	}
+	return int64(auth.ID), nil
+}
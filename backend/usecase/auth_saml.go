@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	shareV1 "github.com/chaitin/panda-wiki/api/share/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/pkg/saml"
+)
+
+const (
+	samlMetadataPath = "/share/v1/openapi/saml/metadata/"
+	samlAcsPath      = "/share/v1/openapi/saml/acs/"
+
+	// samlMetadataCacheTTL 通过地址获取的IdP元数据的缓存时间，IdP轮换证书后需要重新获取
+	samlMetadataCacheTTL = time.Hour
+)
+
+// samlMetadataCacheItem 缓存的IdP元数据，元数据地址或内容变化后失效
+type samlMetadataCacheItem struct {
+	url       string
+	xml       string
+	metadata  *saml.IDPMetadata
+	expiresAt time.Time
+}
+
+func (u *AuthUsecase) getSAMLClient(ctx context.Context, kbId string) (*saml.Client, error) {
+	authConfig, err := u.AuthRepo.GetAuthConfig(ctx, kbId, consts.SourceTypeSAML)
+	if err != nil {
+		return nil, err
+	}
+	setting := authConfig.AuthSetting.SAML
+	if setting == nil {
+		return nil, fmt.Errorf("saml is not configured")
+	}
+
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbId)
+	if err != nil {
+		return nil, err
+	}
+	// IdP 需要固定的 SP 地址，所以必须配置知识库的访问地址
+	if kb.AccessSettings.BaseURL == "" {
+		return nil, fmt.Errorf("base url of the knowledge base is required by saml")
+	}
+	baseURL := strings.TrimSuffix(kb.AccessSettings.BaseURL, "/")
+
+	var idpMetadata *saml.IDPMetadata
+	if v, ok := u.samlMetadata.Load(kbId); ok {
+		item := v.(*samlMetadataCacheItem)
+		if item.url == setting.IDPMetadataURL && item.xml == setting.IDPMetadataXML && time.Now().Before(item.expiresAt) {
+			idpMetadata = item.metadata
+		}
+	}
+
+	client, err := saml.NewClient(ctx, u.logger, saml.Config{
+		EntityID:       setting.EntityID,
+		MetadataURL:    baseURL + samlMetadataPath + kbId,
+		AcsURL:         baseURL + samlAcsPath + kbId,
+		IDPMetadataURL: setting.IDPMetadataURL,
+		IDPMetadataXML: setting.IDPMetadataXML,
+		IDPMetadata:    idpMetadata,
+		Certificate:    setting.Certificate,
+		PrivateKey:     setting.PrivateKey,
+		NameIDFormat:   setting.NameIDFormat,
+		Mapping: saml.AttributeMapping{
+			Username:  setting.UsernameAttribute,
+			Email:     setting.EmailAttribute,
+			AvatarURL: setting.AvatarUrlAttribute,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if idpMetadata == nil {
+		u.samlMetadata.Store(kbId, &samlMetadataCacheItem{
+			url:       setting.IDPMetadataURL,
+			xml:       setting.IDPMetadataXML,
+			metadata:  client.IDPMetadata(),
+			expiresAt: time.Now().Add(samlMetadataCacheTTL),
+		})
+	}
+	return client, nil
+}
+
+// GetSAMLMetadata 获取SP元数据
+func (u *AuthUsecase) GetSAMLMetadata(ctx context.Context, kbId string) ([]byte, error) {
+	samlClient, err := u.getSAMLClient(ctx, kbId)
+	if err != nil {
+		return nil, fmt.Errorf("get samlClient failed: %w", err)
+	}
+	return samlClient.Metadata()
+}
+
+func (u *AuthUsecase) GenerateSAMLAuthUrl(ctx context.Context, req shareV1.AuthSAMLReq) (string, error) {
+	samlClient, err := u.getSAMLClient(ctx, req.KbID)
+	if err != nil {
+		return "", fmt.Errorf("get samlClient failed: %w", err)
+	}
+
+	authnRequest, err := samlClient.NewAuthnRequest()
+	if err != nil {
+		return "", err
+	}
+
+	state, err := u.genState(ctx, StateInfo{
+		KbId:        req.KbID,
+		RedirectUrl: req.RedirectUrl,
+		RequestID:   authnRequest.ID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("gen state failed: %w", err)
+	}
+
+	return authnRequest.RedirectURL(state)
+}
+
+// SAMLCallback 处理IdP POST到断言消费地址的SAMLResponse，RelayState为登录时生成的state
+func (u *AuthUsecase) SAMLCallback(ctx context.Context, kbId string, r *http.Request) (*domain.Auth, string, error) {
+	state := r.FormValue("RelayState")
+	if state == "" {
+		return nil, "", fmt.Errorf("relay state is required")
+	}
+	statInfo, err := u.getStateInfo(ctx, state)
+	if err != nil {
+		return nil, "", err
+	}
+	if statInfo.KbId != kbId || statInfo.RequestID == "" {
+		return nil, "", fmt.Errorf("invalid relay state")
+	}
+	// state 只能使用一次，防止响应被重放
+	if err := u.cache.Del(ctx, state).Err(); err != nil {
+		return nil, "", err
+	}
+
+	samlClient, err := u.getSAMLClient(ctx, kbId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	userInfo, err := samlClient.ParseResponse(r, statInfo.RequestID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	auth := &domain.Auth{
+		UserInfo: domain.AuthUserInfo{
+			Username:  userInfo.Username,
+			AvatarUrl: userInfo.AvatarURL,
+			Email:     userInfo.Email,
+		},
+		KBID:       kbId,
+		UnionID:    userInfo.ID,
+		SourceType: consts.SourceTypeSAML,
+	}
+
+	auth, err = u.AuthRepo.GetOrCreateAuth(ctx, auth, consts.SourceTypeSAML)
+	if err != nil {
+		return nil, "", fmt.Errorf("create auth failed: %w", err)
+	}
+
+	return auth, statInfo.RedirectUrl, nil
+}
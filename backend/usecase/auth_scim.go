@@ -0,0 +1,467 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/lib/pq"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/pkg/scim"
+)
+
+const scimDefaultCount = 100
+
+var (
+	// scimUserFilterColumns SCIM 用户属性到 auths 字段的映射
+	scimUserFilterColumns = map[string]string{
+		"id":           "id::text",
+		"username":     "union_id",
+		"externalid":   "external_id",
+		"displayname":  "user_info->>'username'",
+		"emails":       "user_info->>'email'",
+		"emails.value": "user_info->>'email'",
+	}
+	// scimGroupFilterColumns SCIM 组属性到 auth_groups 字段的映射
+	scimGroupFilterColumns = map[string]string{
+		"id":          "id::text",
+		"displayname": "name",
+		"externalid":  "sync_id",
+	}
+)
+
+// scimSourceType SCIM 用户的来源为知识库配置的企业认证方式，用户通过该方式登录时使用 SCIM 创建的记录，
+// 所以 SCIM 的 userName 需要与登录时的用户唯一标识一致
+func (u *AuthUsecase) scimSourceType(ctx context.Context, kbID string) (consts.SourceType, error) {
+	sourceType, err := u.enterpriseSourceType(ctx, kbID)
+	if errors.Is(err, ErrEnterpriseSourceTypeNotConfigured) {
+		return "", scim.NewError(http.StatusBadRequest, "", err.Error())
+	}
+	return sourceType, err
+}
+
+// scimPage 将 SCIM 从 1 开始的 startIndex 和 count 转换为 offset 和 limit
+func scimPage(startIndex, count int) (int, int) {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 {
+		count = scimDefaultCount
+	}
+	return startIndex - 1, min(count, scim.MaxResults)
+}
+
+func scimFilterColumn(filter string, columns map[string]string) (string, string, error) {
+	f, err := scim.ParseFilter(filter)
+	if err != nil {
+		return "", "", err
+	}
+	if f == nil {
+		return "", "", nil
+	}
+	column, ok := columns[f.Attribute]
+	if !ok {
+		return "", "", scim.NewError(http.StatusBadRequest, scim.ErrTypeInvalidFilter, "unsupported filter attribute: "+f.Attribute)
+	}
+	return column, f.Value, nil
+}
+
+func parseSCIMID(id string) (uint, error) {
+	v, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, scim.NewError(http.StatusNotFound, "", "resource "+id+" not found")
+	}
+	return uint(v), nil
+}
+
+func toSCIMUser(auth *domain.Auth) *scim.User {
+	active := !auth.Disabled
+	user := &scim.User{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          strconv.FormatUint(uint64(auth.ID), 10),
+		ExternalID:  auth.ExternalID,
+		UserName:    auth.UnionID,
+		DisplayName: auth.UserInfo.Username,
+		Active:      &active,
+		Meta: &scim.Meta{
+			ResourceType: scim.ResourceTypeUser,
+			Created:      &auth.CreatedAt,
+			LastModified: &auth.UpdatedAt,
+		},
+	}
+	if auth.UserInfo.Email != "" {
+		user.Emails = []scim.MultiValue{{Value: auth.UserInfo.Email, Type: "work", Primary: true}}
+	}
+	if auth.UserInfo.AvatarUrl != "" {
+		user.Photos = []scim.MultiValue{{Value: auth.UserInfo.AvatarUrl, Type: "photo", Primary: true}}
+	}
+	return user
+}
+
+// scimUserInfo 显示名称依次使用 displayName、name.formatted、userName
+func scimUserInfo(user *scim.User) domain.AuthUserInfo {
+	username := user.DisplayName
+	if username == "" && user.Name != nil {
+		username = user.Name.Formatted
+	}
+	if username == "" {
+		username = user.UserName
+	}
+	return domain.AuthUserInfo{
+		Username:  username,
+		Email:     user.PrimaryEmail(),
+		AvatarUrl: user.PrimaryPhoto(),
+	}
+}
+
+func (u *AuthUsecase) getSCIMAuth(ctx context.Context, kbID, id string) (*domain.Auth, error) {
+	authID, err := parseSCIMID(id)
+	if err != nil {
+		return nil, err
+	}
+	sourceType, err := u.scimSourceType(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := u.AuthRepo.GetAuthById(ctx, kbID, authID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, scim.NewError(http.StatusNotFound, "", "user "+id+" not found")
+		}
+		return nil, err
+	}
+	if auth.SourceType != sourceType {
+		return nil, scim.NewError(http.StatusNotFound, "", "user "+id+" not found")
+	}
+	return auth, nil
+}
+
+func (u *AuthUsecase) ListSCIMUsers(ctx context.Context, kbID, filter string, startIndex, count int) (*scim.ListResponse, error) {
+	sourceType, err := u.scimSourceType(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	column, value, err := scimFilterColumn(filter, scimUserFilterColumns)
+	if err != nil {
+		return nil, err
+	}
+	offset, limit := scimPage(startIndex, count)
+	auths, total, err := u.AuthRepo.ListAuthsByAttr(ctx, kbID, sourceType, column, value, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]any, 0, len(auths))
+	for i := range auths {
+		resources = append(resources, toSCIMUser(&auths[i]))
+	}
+	return scim.NewListResponse(total, offset+1, resources), nil
+}
+
+func (u *AuthUsecase) GetSCIMUser(ctx context.Context, kbID, id string) (*scim.User, error) {
+	auth, err := u.getSCIMAuth(ctx, kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	user := toSCIMUser(auth)
+	groups, err := u.AuthRepo.GetAuthGroupByAuthId(ctx, auth.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		user.Groups = append(user.Groups, scim.Member{
+			Value:   strconv.FormatUint(uint64(group.ID), 10),
+			Display: group.Name,
+		})
+	}
+	return user, nil
+}
+
+// CreateSCIMUser 创建用户，用户通过企业认证登录前即可加入用户组并获得文档权限
+func (u *AuthUsecase) CreateSCIMUser(ctx context.Context, kbID string, user *scim.User) (*scim.User, error) {
+	if user.UserName == "" {
+		return nil, scim.NewError(http.StatusBadRequest, scim.ErrTypeInvalidValue, "userName is required")
+	}
+	sourceType, err := u.scimSourceType(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.AuthRepo.GetAuthByUnionID(ctx, kbID, sourceType, user.UserName); err == nil {
+		return nil, scim.NewError(http.StatusConflict, scim.ErrTypeUniqueness, "user "+user.UserName+" already exists")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	auth, err := u.AuthRepo.GetOrCreateAuth(ctx, &domain.Auth{
+		KBID:       kbID,
+		UnionID:    user.UserName,
+		SourceType: sourceType,
+		UserInfo:   scimUserInfo(user),
+		Disabled:   !user.IsActive(),
+		ExternalID: user.ExternalID,
+	}, sourceType)
+	if err != nil {
+		return nil, fmt.Errorf("create auth failed: %w", err)
+	}
+	u.logger.Info("scim user created", log.String("kb_id", kbID), log.String("union_id", auth.UnionID))
+	return toSCIMUser(auth), nil
+}
+
+// ReplaceSCIMUser 使用请求中的用户信息替换现有用户
+func (u *AuthUsecase) ReplaceSCIMUser(ctx context.Context, kbID, id string, user *scim.User) (*scim.User, error) {
+	auth, err := u.getSCIMAuth(ctx, kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	return u.saveSCIMUser(ctx, auth, user)
+}
+
+func (u *AuthUsecase) PatchSCIMUser(ctx context.Context, kbID, id string, req *scim.PatchRequest) (*scim.User, error) {
+	auth, err := u.getSCIMAuth(ctx, kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	user := toSCIMUser(auth)
+	if err := user.ApplyPatch(req.Operations); err != nil {
+		return nil, err
+	}
+	return u.saveSCIMUser(ctx, auth, user)
+}
+
+func (u *AuthUsecase) saveSCIMUser(ctx context.Context, auth *domain.Auth, user *scim.User) (*scim.User, error) {
+	if user.UserName == "" {
+		return nil, scim.NewError(http.StatusBadRequest, scim.ErrTypeInvalidValue, "userName is required")
+	}
+	if user.UserName != auth.UnionID {
+		if _, err := u.AuthRepo.GetAuthByUnionID(ctx, auth.KBID, auth.SourceType, user.UserName); err == nil {
+			return nil, scim.NewError(http.StatusConflict, scim.ErrTypeUniqueness, "user "+user.UserName+" already exists")
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	auth.UnionID = user.UserName
+	auth.UserInfo = scimUserInfo(user)
+	auth.Disabled = !user.IsActive()
+	auth.ExternalID = user.ExternalID
+	if err := u.AuthRepo.UpdateAuth(ctx, auth.KBID, auth.ID, map[string]any{
+		"union_id":    auth.UnionID,
+		"user_info":   &auth.UserInfo,
+		"disabled":    auth.Disabled,
+		"external_id": auth.ExternalID,
+	}); err != nil {
+		return nil, fmt.Errorf("update auth failed: %w", err)
+	}
+	if auth.Disabled {
+		u.logger.Info("scim user disabled", log.String("kb_id", auth.KBID), log.String("union_id", auth.UnionID))
+	}
+	return toSCIMUser(auth), nil
+}
+
+func (u *AuthUsecase) DeleteSCIMUser(ctx context.Context, kbID, id string) error {
+	auth, err := u.getSCIMAuth(ctx, kbID, id)
+	if err != nil {
+		return err
+	}
+	return u.AuthRepo.DeleteAuthWithGroups(ctx, kbID, auth.ID)
+}
+
+func (u *AuthUsecase) toSCIMGroup(ctx context.Context, group *domain.AuthGroup) (*scim.Group, error) {
+	authIDs := lo.Map(group.AuthIDs, func(id int64, _ int) uint { return uint(id) })
+	auths, err := u.AuthRepo.GetAuthsByIDs(ctx, group.KbID, authIDs)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]scim.Member, 0, len(auths))
+	for _, auth := range auths {
+		members = append(members, scim.Member{
+			Value:   strconv.FormatUint(uint64(auth.ID), 10),
+			Display: auth.UserInfo.Username,
+		})
+	}
+	return &scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          strconv.FormatUint(uint64(group.ID), 10),
+		ExternalID:  group.SyncId,
+		DisplayName: group.Name,
+		Members:     members,
+		Meta: &scim.Meta{
+			ResourceType: scim.ResourceTypeGroup,
+			Created:      &group.CreatedAt,
+			LastModified: &group.UpdatedAt,
+		},
+	}, nil
+}
+
+// getSCIMGroup 只能操作 SCIM 创建的用户组，手动创建的用户组不受影响
+func (u *AuthUsecase) getSCIMGroup(ctx context.Context, kbID, id string) (*domain.AuthGroup, error) {
+	groupID, err := parseSCIMID(id)
+	if err != nil {
+		return nil, err
+	}
+	group, err := u.AuthRepo.GetAuthGroup(ctx, groupID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, scim.NewError(http.StatusNotFound, "", "group "+id+" not found")
+		}
+		return nil, err
+	}
+	if group.KbID != kbID || group.SourceType != consts.SourceTypeSCIM {
+		return nil, scim.NewError(http.StatusNotFound, "", "group "+id+" not found")
+	}
+	return group, nil
+}
+
+// scimGroupMembers 校验成员都是知识库中的用户
+func (u *AuthUsecase) scimGroupMembers(ctx context.Context, kbID string, members []scim.Member) (pq.Int64Array, error) {
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseUint(member.Value, 10, 64)
+		if err != nil {
+			return nil, scim.NewError(http.StatusBadRequest, scim.ErrTypeInvalidValue, "invalid member: "+member.Value)
+		}
+		ids = append(ids, uint(id))
+	}
+	ids = lo.Uniq(ids)
+	auths, err := u.AuthRepo.GetAuthsByIDs(ctx, kbID, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(auths) != len(ids) {
+		return nil, scim.NewError(http.StatusBadRequest, scim.ErrTypeInvalidValue, "members contain unknown users")
+	}
+	authIDs := make(pq.Int64Array, 0, len(ids))
+	for _, id := range ids {
+		authIDs = append(authIDs, int64(id))
+	}
+	return authIDs, nil
+}
+
+func (u *AuthUsecase) checkSCIMGroupName(ctx context.Context, kbID, name string, id uint) error {
+	if name == "" {
+		return scim.NewError(http.StatusBadRequest, scim.ErrTypeInvalidValue, "displayName is required")
+	}
+	// 同一知识库内用户组名称不重复
+	existing, err := u.AuthRepo.GetAuthGroupByName(ctx, kbID, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if existing.ID != id {
+		return scim.NewError(http.StatusConflict, scim.ErrTypeUniqueness, "group "+name+" already exists")
+	}
+	return nil
+}
+
+func (u *AuthUsecase) ListSCIMGroups(ctx context.Context, kbID, filter string, startIndex, count int) (*scim.ListResponse, error) {
+	column, value, err := scimFilterColumn(filter, scimGroupFilterColumns)
+	if err != nil {
+		return nil, err
+	}
+	offset, limit := scimPage(startIndex, count)
+	groups, total, err := u.AuthRepo.ListAuthGroupsByAttr(ctx, kbID, consts.SourceTypeSCIM, column, value, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]any, 0, len(groups))
+	for i := range groups {
+		group, err := u.toSCIMGroup(ctx, &groups[i])
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, group)
+	}
+	return scim.NewListResponse(total, offset+1, resources), nil
+}
+
+func (u *AuthUsecase) GetSCIMGroup(ctx context.Context, kbID, id string) (*scim.Group, error) {
+	group, err := u.getSCIMGroup(ctx, kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	return u.toSCIMGroup(ctx, group)
+}
+
+// CreateSCIMGroup 创建顶级用户组，成员的文档权限通过用户组继承
+func (u *AuthUsecase) CreateSCIMGroup(ctx context.Context, kbID string, req *scim.Group) (*scim.Group, error) {
+	if err := u.checkSCIMGroupName(ctx, kbID, req.DisplayName, 0); err != nil {
+		return nil, err
+	}
+	authIDs, err := u.scimGroupMembers(ctx, kbID, req.Members)
+	if err != nil {
+		return nil, err
+	}
+	group := &domain.AuthGroup{
+		Name:       req.DisplayName,
+		KbID:       kbID,
+		AuthIDs:    authIDs,
+		UserIDs:    pq.StringArray{},
+		SyncId:     req.ExternalID,
+		SourceType: consts.SourceTypeSCIM,
+	}
+	if err := u.AuthRepo.CreateAuthGroup(ctx, group); err != nil {
+		return nil, fmt.Errorf("create auth group failed: %w", err)
+	}
+	u.logger.Info("scim group created", log.String("kb_id", kbID), log.String("name", group.Name))
+	return u.toSCIMGroup(ctx, group)
+}
+
+func (u *AuthUsecase) ReplaceSCIMGroup(ctx context.Context, kbID, id string, req *scim.Group) (*scim.Group, error) {
+	group, err := u.getSCIMGroup(ctx, kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	return u.saveSCIMGroup(ctx, group, req)
+}
+
+func (u *AuthUsecase) PatchSCIMGroup(ctx context.Context, kbID, id string, req *scim.PatchRequest) (*scim.Group, error) {
+	group, err := u.getSCIMGroup(ctx, kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	scimGroup, err := u.toSCIMGroup(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+	if err := scimGroup.ApplyPatch(req.Operations); err != nil {
+		return nil, err
+	}
+	return u.saveSCIMGroup(ctx, group, scimGroup)
+}
+
+func (u *AuthUsecase) saveSCIMGroup(ctx context.Context, group *domain.AuthGroup, req *scim.Group) (*scim.Group, error) {
+	if err := u.checkSCIMGroupName(ctx, group.KbID, req.DisplayName, group.ID); err != nil {
+		return nil, err
+	}
+	authIDs, err := u.scimGroupMembers(ctx, group.KbID, req.Members)
+	if err != nil {
+		return nil, err
+	}
+	group.Name = req.DisplayName
+	group.SyncId = req.ExternalID
+	group.AuthIDs = authIDs
+	if err := u.AuthRepo.UpdateAuthGroupFields(ctx, group.ID, map[string]any{
+		"name":     group.Name,
+		"sync_id":  group.SyncId,
+		"auth_ids": group.AuthIDs,
+	}); err != nil {
+		return nil, fmt.Errorf("update auth group failed: %w", err)
+	}
+	return u.toSCIMGroup(ctx, group)
+}
+
+func (u *AuthUsecase) DeleteSCIMGroup(ctx context.Context, kbID, id string) error {
+	group, err := u.getSCIMGroup(ctx, kbID, id)
+	if err != nil {
+		return err
+	}
+	return u.AuthRepo.DeleteAuthGroup(ctx, group.ID)
+}
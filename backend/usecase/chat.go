@@ -9,6 +9,7 @@ import (
 	modelkit "github.com/chaitin/ModelKit/v2/usecase"
 	"github.com/cloudwego/eino/schema"
 	"github.com/google/uuid"
+	"github.com/samber/lo"
 	"gorm.io/gorm"
 
 	"github.com/chaitin/panda-wiki/domain"
@@ -17,10 +18,14 @@ import (
 	"github.com/chaitin/panda-wiki/utils"
 )
 
+// chatFirstChunkTimeout is the time to wait for the first chunk before falling back to the next chat model
+const chatFirstChunkTimeout = 60 * time.Second
+
 type ChatUsecase struct {
 	llmUsecase          *LLMUsecase
 	conversationUsecase *ConversationUsecase
 	modelUsecase        *ModelUsecase
+	tokenBudgetUsecase  *TokenBudgetUsecase
 	appRepo             *pg.AppRepository
 	blockWordRepo       *pg.BlockWordRepo
 	kbRepo              *pg.KnowledgeBaseRepository
@@ -29,13 +34,15 @@ type ChatUsecase struct {
 	modelkit            *modelkit.ModelKit
 }
 
-func NewChatUsecase(llmUsecase *LLMUsecase, kbRepo *pg.KnowledgeBaseRepository, conversationUsecase *ConversationUsecase, modelUsecase *ModelUsecase, appRepo *pg.AppRepository,
+func NewChatUsecase(llmUsecase *LLMUsecase, kbRepo *pg.KnowledgeBaseRepository, conversationUsecase *ConversationUsecase, modelUsecase *ModelUsecase,
+	tokenBudgetUsecase *TokenBudgetUsecase, appRepo *pg.AppRepository,
 	blockWordRepo *pg.BlockWordRepo, authRepo *pg.AuthRepo, logger *log.Logger) (*ChatUsecase, error) {
 	modelkit := modelkit.NewModelKit(logger.Logger)
 	u := &ChatUsecase{
 		llmUsecase:          llmUsecase,
 		conversationUsecase: conversationUsecase,
 		modelUsecase:        modelUsecase,
+		tokenBudgetUsecase:  tokenBudgetUsecase,
 		appRepo:             appRepo,
 		blockWordRepo:       blockWordRepo,
 		kbRepo:              kbRepo,
@@ -84,8 +91,12 @@ func (u *ChatUsecase) Chat(ctx context.Context, req *domain.ChatRequest) (<-chan
 		req.KBID = app.KBID
 		req.AppID = app.ID
 		req.AppType = app.Type
-		// 2. get model and validate model
-		model, err := u.modelUsecase.GetChatModel(ctx)
+		if msg := u.tokenBudgetExceededMsg(ctx, req.KBID, req.AppType); msg != "" {
+			eventCh <- domain.SSEEvent{Type: "error", Content: msg}
+			return
+		}
+		// 2. get models and validate models
+		models, err := u.modelUsecase.GetChatModels(ctx, app.Settings.ChatModelID)
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
 				eventCh <- domain.SSEEvent{Type: "error", Content: "请前往管理后台，点击右上角的“系统设置”配置推理大模型。"}
@@ -94,7 +105,7 @@ func (u *ChatUsecase) Chat(ctx context.Context, req *domain.ChatRequest) (<-chan
 			}
 			return
 		}
-		req.ModelInfo = model
+		req.ModelInfo = models[0]
 		// 3. conversation management
 		if req.AppType == domain.AppTypeWechatServiceBot || req.AppType == domain.AppTypeWechatBot || req.AppType == domain.AppTypeWecomAIBot { // wechat service has its own id
 			nonce := uuid.New().String()
@@ -240,23 +251,12 @@ func (u *ChatUsecase) Chat(ctx context.Context, req *domain.ChatRequest) (<-chan
 		answer := ""
 		usage := schema.TokenUsage{}
 
-		modelkitModel, err := req.ModelInfo.ToModelkitModel()
-		if err != nil {
-			u.logger.Error("failed to convert model to modelkit model", log.Error(err))
-			eventCh <- domain.SSEEvent{Type: "error", Content: "failed to convert model to modelkit model"}
-			return
-		}
-		chatModel, err := u.modelkit.GetChatModel(ctx, modelkitModel)
-
-		if err != nil {
-			u.logger.Error("failed to get chat model", log.Error(err))
-			eventCh <- domain.SSEEvent{Type: "error", Content: "failed to get chat model"}
-			return
-		}
 		// get words
 		onChunkAC, flushBuffer := u.CreateAcOnChunk(ctx, req.KBID, &answer, eventCh, blockWords)
 
-		chatErr := u.llmUsecase.ChatWithAgent(ctx, chatModel, messages, &usage, onChunkAC)
+		// record the model that answered
+		model, chatErr := u.chatWithFallback(ctx, models, messages, &usage, onChunkAC)
+		req.ModelInfo = model
 
 		// 处理缓冲区中剩余的内容
 		if flushBuffer != nil {
@@ -266,19 +266,20 @@ func (u *ChatUsecase) Chat(ctx context.Context, req *domain.ChatRequest) (<-chan
 		// save assistant answer to conversation message
 
 		if err := u.conversationUsecase.CreateChatConversationMessage(ctx, req.KBID, &domain.ConversationMessage{
-			ID:               messageId,
-			ConversationID:   req.ConversationID,
-			KBID:             req.KBID,
-			AppID:            req.AppID,
-			Role:             schema.Assistant,
-			Content:          answer,
-			Provider:         req.ModelInfo.Provider,
-			Model:            string(req.ModelInfo.Model),
-			PromptTokens:     usage.PromptTokens,
-			CompletionTokens: usage.CompletionTokens,
-			TotalTokens:      usage.TotalTokens,
-			RemoteIP:         req.RemoteIP,
-			ParentID:         userMessageId,
+			ID:                 messageId,
+			ConversationID:     req.ConversationID,
+			KBID:               req.KBID,
+			AppID:              req.AppID,
+			Role:               schema.Assistant,
+			Content:            answer,
+			Provider:           req.ModelInfo.Provider,
+			Model:              string(req.ModelInfo.Model),
+			PromptTokens:       usage.PromptTokens,
+			CompletionTokens:   usage.CompletionTokens,
+			TotalTokens:        usage.TotalTokens,
+			RetrievedNodeCount: lo.ToPtr(len(rankedNodes)),
+			RemoteIP:           req.RemoteIP,
+			ParentID:           userMessageId,
 		}); err != nil {
 			u.logger.Error("failed to save assistant answer to conversation message", log.Error(err))
 			eventCh <- domain.SSEEvent{Type: "error", Content: "failed to save assistant answer to conversation message"}
@@ -301,6 +302,68 @@ func (u *ChatUsecase) Chat(ctx context.Context, req *domain.ChatRequest) (<-chan
 	return eventCh, nil
 }
 
+// tokenBudgetExceededMsg returns the message telling that a token budget of the app type is used up, empty if none is.
+// The answer is allowed if the check fails.
+func (u *ChatUsecase) tokenBudgetExceededMsg(ctx context.Context, kbID string, appType domain.AppType) string {
+	budget, err := u.tokenBudgetUsecase.CheckBudget(ctx, kbID, appType)
+	if err != nil {
+		u.logger.Error("failed to check token budget", log.Error(err))
+		return ""
+	}
+	if budget == nil {
+		return ""
+	}
+	u.logger.Info("token budget used up", log.String("kb_id", kbID), log.String("budget_id", budget.ID))
+	return lo.Ternary(budget.Period == domain.TokenBudgetPeriodMonthly,
+		"本月的 AI 问答额度已用完，请下月再试。", "今天的 AI 问答额度已用完，请明天再试。")
+}
+
+// chatWithFallback streams the answer with the models in order. It falls back to the next model
+// if one fails or sends nothing in time, as long as no content has been sent yet.
+// It returns the model that answered, or the last model tried.
+func (u *ChatUsecase) chatWithFallback(ctx context.Context, models []*domain.Model, messages []*schema.Message, usage *schema.TokenUsage,
+	onChunk func(ctx context.Context, dataType, chunk string) error) (*domain.Model, error) {
+	var lastModel *domain.Model
+	var lastErr error
+	for i, model := range models {
+		lastModel = model
+		modelkitModel, err := model.ToModelkitModel()
+		if err != nil {
+			lastErr = fmt.Errorf("convert model to modelkit model failed: %w", err)
+			continue
+		}
+		chatModel, err := u.modelkit.GetChatModel(ctx, modelkitModel)
+		if err != nil {
+			lastErr = fmt.Errorf("get chat model failed: %w", err)
+			continue
+		}
+
+		attemptCtx, cancel := context.WithCancel(ctx)
+		timer := time.AfterFunc(chatFirstChunkTimeout, cancel)
+		sent := false
+		*usage = schema.TokenUsage{}
+		lastErr = u.llmUsecase.ChatWithAgent(attemptCtx, chatModel, messages, usage, func(ctx context.Context, dataType, chunk string) error {
+			if !sent {
+				sent = true
+				timer.Stop()
+			}
+			return onChunk(ctx, dataType, chunk)
+		})
+		timer.Stop()
+		cancel()
+		if lastErr == nil || sent || ctx.Err() != nil {
+			return model, lastErr
+		}
+		if i < len(models)-1 {
+			u.logger.Warn("chat model failed, fall back to the next model",
+				log.String("provider", string(model.Provider)),
+				log.String("model", model.Model),
+				log.Error(lastErr))
+		}
+	}
+	return lastModel, lastErr
+}
+
 func (u *ChatUsecase) ChatRagOnly(ctx context.Context, req *domain.ChatRagOnlyRequest) (<-chan domain.SSEEvent, error) {
 	eventCh := make(chan domain.SSEEvent, 100)
 	go func() {
@@ -344,6 +407,7 @@ func (u *ChatUsecase) ChatRagOnly(ctx context.Context, req *domain.ChatRagOnlyRe
 			return
 		}
 		_, rankedNodes, err := u.llmUsecase.GetRankNodes(ctx, GetRankNodesRequest{
+			KBID:                kb.ID,
 			DatasetID:           kb.DatasetID,
 			Question:            req.Message,
 			GroupIDs:            groupIds,
@@ -438,6 +502,7 @@ func (u *ChatUsecase) Search(ctx context.Context, req *domain.ChatSearchReq) (*d
 		return nil, err
 	}
 	_, rankedNodes, err := u.llmUsecase.GetRankNodes(ctx, GetRankNodesRequest{
+		KBID:                kb.ID,
 		DatasetID:           kb.DatasetID,
 		Question:            req.Message,
 		GroupIDs:            groupIds,
@@ -0,0 +1,496 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino-ext/libs/acl/openai"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/utils"
+)
+
+const (
+	// ChatCompletionsSearchTool is the built-in tool of the OpenAI API app searching the knowledge base
+	ChatCompletionsSearchTool = "search_knowledge_base"
+	// chatCompletionsMaxSearchRounds bounds the rounds of built-in tool calls answering one request
+	chatCompletionsMaxSearchRounds = 3
+)
+
+const (
+	chatCompletionsSystemPrompt = "你是一个专业的AI知识库问答助手，当前日期为：%s。"
+	chatCompletionsSearchPrompt = "\n回答与知识库相关的问题前，请先调用 search_knowledge_base 工具检索知识库，基于检索到的文档回答问题，并在回答中引用文档链接；如果文档不足以回答问题，请如实说明，不要编造。"
+)
+
+var chatCompletionsSearchToolInfo = &schema.ToolInfo{
+	Name: ChatCompletionsSearchTool,
+	Desc: "搜索知识库中与问题相关的文档，返回文档的标题、链接和内容片段",
+	ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+		"query": {Type: schema.String, Desc: "要搜索的问题或关键词", Required: true},
+	}),
+}
+
+// chatCompletionsParams is the OpenAI API request converted for the chat model
+type chatCompletionsParams struct {
+	messages       []*schema.Message
+	question       string
+	clientTools    []*schema.ToolInfo
+	searchTool     bool
+	toolChoice     *schema.ToolChoice
+	responseFormat *openai.ChatCompletionResponseFormat
+	formatPrompt   string
+}
+
+// tools returns the tools bound in a round, the search tool is left out after the last search round
+func (p *chatCompletionsParams) tools(searchAllowed bool) []*schema.ToolInfo {
+	tools := append([]*schema.ToolInfo{}, p.clientTools...)
+	if p.searchTool && searchAllowed {
+		tools = append(tools, chatCompletionsSearchToolInfo)
+	}
+	return tools
+}
+
+// ChatCompletions answers the OpenAI API request with its tools and response format. The knowledge base is
+// searched by the built-in tool when the model calls it, the calls of the client tools are sent back in a
+// "tool_calls" event for the client to run them and continue with the results.
+func (u *ChatUsecase) ChatCompletions(ctx context.Context, kbID, remoteIP string, req *domain.OpenAICompletionsRequest) (<-chan domain.SSEEvent, error) {
+	params, err := newChatCompletionsParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	eventCh := make(chan domain.SSEEvent, 100)
+	go func() {
+		defer close(eventCh)
+		app, err := u.appRepo.GetOrCreateAppByKBIDAndType(ctx, kbID, domain.AppTypeOpenAIAPI)
+		if err != nil {
+			eventCh <- domain.SSEEvent{Type: "error", Content: "app not found"}
+			return
+		}
+		if msg := u.tokenBudgetExceededMsg(ctx, app.KBID, app.Type); msg != "" {
+			eventCh <- domain.SSEEvent{Type: "error", Content: msg}
+			return
+		}
+		models, err := u.modelUsecase.GetChatModels(ctx, app.Settings.ChatModelID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				eventCh <- domain.SSEEvent{Type: "error", Content: "请前往管理后台，点击右上角的“系统设置”配置推理大模型。"}
+			} else {
+				eventCh <- domain.SSEEvent{Type: "error", Content: "模型获取失败"}
+			}
+			return
+		}
+		kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, app.KBID)
+		if err != nil {
+			u.logger.Error("failed to get kb", log.Error(err))
+			eventCh <- domain.SSEEvent{Type: "error", Content: "failed to get kb"}
+			return
+		}
+
+		blockWords, err := u.blockWordRepo.GetBlockWords(ctx, kb.ID)
+		if err != nil {
+			u.logger.Error("failed to get question block words", log.Error(err))
+			eventCh <- domain.SSEEvent{Type: "error", Content: "failed to get question block words"}
+			return
+		}
+		if len(blockWords) > 0 {
+			if err := utils.GetDFA(kb.ID).DFA.Check(params.question); err != nil {
+				eventCh <- domain.SSEEvent{Type: "error", Content: "**您的问题包含敏感词, AI 无法回答您的问题。**"}
+				return
+			}
+		}
+
+		var groupIDs []int
+		if params.searchTool {
+			var authUserID uint
+			if auth, _ := u.AuthRepo.GetAuthBySourceType(ctx, app.Type.ToSourceType()); auth != nil {
+				authUserID = auth.ID
+			}
+			groupIDs, err = u.AuthRepo.GetAuthGroupIdsWithParentsByAuthId(ctx, authUserID)
+			if err != nil {
+				u.logger.Error("failed to get auth groupIds", log.Error(err))
+				eventCh <- domain.SSEEvent{Type: "error", Content: "failed to get auth groupIds"}
+				return
+			}
+		}
+
+		// the request is recorded as a new conversation, as the ones answered by Chat
+		conversationID := uuid.New().String()
+		if id, err := uuid.NewV7(); err == nil {
+			conversationID = id.String()
+		}
+		if err := u.conversationUsecase.CreateConversation(ctx, &domain.Conversation{
+			ID:        conversationID,
+			Nonce:     uuid.New().String(),
+			AppID:     app.ID,
+			KBID:      kb.ID,
+			Subject:   params.question,
+			RemoteIP:  remoteIP,
+			CreatedAt: time.Now(),
+		}); err != nil {
+			u.logger.Error("failed to create chat conversation", log.Error(err))
+			eventCh <- domain.SSEEvent{Type: "error", Content: "failed to create chat conversation"}
+			return
+		}
+		userMessageID := uuid.New().String()
+		if err := u.conversationUsecase.CreateChatConversationMessage(ctx, kb.ID, &domain.ConversationMessage{
+			ID:             userMessageID,
+			ConversationID: conversationID,
+			KBID:           kb.ID,
+			AppID:          app.ID,
+			Role:           schema.User,
+			Content:        params.question,
+			RemoteIP:       remoteIP,
+		}); err != nil {
+			u.logger.Error("failed to save user question to conversation message", log.Error(err))
+			eventCh <- domain.SSEEvent{Type: "error", Content: "failed to save user question to conversation message"}
+			return
+		}
+
+		systemPrompt := fmt.Sprintf(chatCompletionsSystemPrompt, time.Now().Format("2006-01-02"))
+		if params.searchTool {
+			systemPrompt += chatCompletionsSearchPrompt
+		}
+		systemPrompt += params.formatPrompt
+		messages := append([]*schema.Message{schema.SystemMessage(systemPrompt)}, params.messages...)
+
+		answer := ""
+		onChunk, flushBuffer := u.CreateAcOnChunk(ctx, kb.ID, &answer, eventCh, blockWords)
+		var toolCalls []domain.OpenAIToolCall
+		var chatModel model.BaseChatModel
+		var modelInfo *domain.Model
+		usage := schema.TokenUsage{}
+		retrievedNodeCount := 0
+		toolChoice := params.toolChoice
+		var chatErr error
+		for round := 0; round <= chatCompletionsMaxSearchRounds; round++ {
+			tools := params.tools(round < chatCompletionsMaxSearchRounds)
+			opts := []model.Option{model.WithTools(tools)}
+			if len(tools) > 0 && toolChoice != nil {
+				opts = append(opts, model.WithToolChoice(*toolChoice))
+			}
+			var reply *schema.Message
+			if chatModel == nil {
+				chatModel, modelInfo, reply, chatErr = u.completeWithFallback(ctx, models, params.responseFormat, messages, onChunk, opts...)
+			} else {
+				reply, chatErr = u.streamCompletion(ctx, chatModel, messages, onChunk, opts...)
+			}
+			if reply != nil && reply.ResponseMeta != nil && reply.ResponseMeta.Usage != nil {
+				usage.PromptTokens += reply.ResponseMeta.Usage.PromptTokens
+				usage.CompletionTokens += reply.ResponseMeta.Usage.CompletionTokens
+				usage.TotalTokens += reply.ResponseMeta.Usage.TotalTokens
+			}
+			if chatErr != nil || len(reply.ToolCalls) == 0 {
+				break
+			}
+
+			// the client tools are run by the client, built-in ones called in the same reply are dropped
+			searchCalls, clientCalls := lo.FilterReject(reply.ToolCalls, func(call schema.ToolCall, _ int) bool {
+				return call.Function.Name == ChatCompletionsSearchTool
+			})
+			if len(clientCalls) > 0 {
+				for i, call := range clientCalls {
+					toolCalls = append(toolCalls, domain.OpenAIToolCall{
+						Index: lo.ToPtr(i),
+						ID:    call.ID,
+						Type:  "function",
+						Function: domain.OpenAIFunctionCall{
+							Name:      call.Function.Name,
+							Arguments: call.Function.Arguments,
+						},
+					})
+				}
+				break
+			}
+			messages = append(messages, schema.AssistantMessage(reply.Content, searchCalls))
+			for _, call := range searchCalls {
+				result, count := u.searchForCompletion(ctx, kb, groupIDs, call.Function.Arguments)
+				retrievedNodeCount += count
+				messages = append(messages, schema.ToolMessage(result, call.ID, schema.WithToolName(call.Function.Name)))
+			}
+			// the model chooses freely after the forced search
+			if toolChoice != nil && *toolChoice == schema.ToolChoiceForced && len(params.clientTools) == 0 {
+				toolChoice = lo.ToPtr(schema.ToolChoiceAllowed)
+			}
+		}
+		if flushBuffer != nil {
+			flushBuffer(ctx, "data")
+		}
+
+		if modelInfo != nil {
+			content := answer
+			for _, call := range toolCalls {
+				content += fmt.Sprintf("\n%s(%s)", call.Function.Name, call.Function.Arguments)
+			}
+			if err := u.conversationUsecase.CreateChatConversationMessage(ctx, kb.ID, &domain.ConversationMessage{
+				ID:                 uuid.New().String(),
+				ConversationID:     conversationID,
+				KBID:               kb.ID,
+				AppID:              app.ID,
+				Role:               schema.Assistant,
+				Content:            strings.TrimSpace(content),
+				Provider:           modelInfo.Provider,
+				Model:              modelInfo.Model,
+				PromptTokens:       usage.PromptTokens,
+				CompletionTokens:   usage.CompletionTokens,
+				TotalTokens:        usage.TotalTokens,
+				RetrievedNodeCount: lo.ToPtr(retrievedNodeCount),
+				RemoteIP:           remoteIP,
+				ParentID:           userMessageID,
+			}); err != nil {
+				u.logger.Error("failed to save assistant answer to conversation message", log.Error(err))
+			}
+			if err := u.modelUsecase.UpdateUsage(ctx, modelInfo.ID, &usage); err != nil {
+				u.logger.Error("failed to update model usage", log.Error(err))
+			}
+		}
+
+		if chatErr != nil {
+			u.logger.Error("对话失败", log.Error(chatErr))
+			eventCh <- domain.SSEEvent{Type: "error", Content: "对话失败，请稍后再试"}
+			return
+		}
+		if len(toolCalls) > 0 {
+			eventCh <- domain.SSEEvent{Type: "tool_calls", ToolCalls: toolCalls}
+		}
+		eventCh <- domain.SSEEvent{Type: "done"}
+	}()
+	return eventCh, nil
+}
+
+// completeWithFallback streams the first reply with the models in order, falling back to the next model
+// if one fails before sending any content. It returns the chat model that replied for the later rounds.
+func (u *ChatUsecase) completeWithFallback(ctx context.Context, models []*domain.Model, responseFormat *openai.ChatCompletionResponseFormat,
+	messages []*schema.Message, onChunk func(ctx context.Context, dataType, chunk string) error, opts ...model.Option,
+) (model.BaseChatModel, *domain.Model, *schema.Message, error) {
+	var lastErr error
+	for _, modelInfo := range models {
+		modelkitModel, err := modelInfo.ToModelkitModel()
+		if err != nil {
+			lastErr = fmt.Errorf("convert model to modelkit model failed: %w", err)
+			continue
+		}
+		modelkitModel.ResponseFormat = responseFormat
+		chatModel, err := u.modelkit.GetChatModel(ctx, modelkitModel)
+		if err != nil {
+			lastErr = fmt.Errorf("get chat model failed: %w", err)
+			continue
+		}
+		sent := false
+		reply, err := u.streamCompletion(ctx, chatModel, messages, func(ctx context.Context, dataType, chunk string) error {
+			sent = true
+			return onChunk(ctx, dataType, chunk)
+		}, opts...)
+		if err == nil || sent || ctx.Err() != nil {
+			return chatModel, modelInfo, reply, err
+		}
+		lastErr = err
+		u.logger.Warn("chat model failed, fall back to the next model",
+			log.String("provider", string(modelInfo.Provider)),
+			log.String("model", modelInfo.Model),
+			log.Error(err))
+	}
+	return nil, nil, nil, lastErr
+}
+
+// streamCompletion streams one reply of the chat model, sending its content chunks, and returns the whole reply
+func (u *ChatUsecase) streamCompletion(ctx context.Context, chatModel model.BaseChatModel, messages []*schema.Message,
+	onChunk func(ctx context.Context, dataType, chunk string) error, opts ...model.Option,
+) (*schema.Message, error) {
+	stream, err := chatModel.Stream(ctx, messages, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("stream failed: %w", err)
+	}
+	defer stream.Close()
+	chunks := make([]*schema.Message, 0)
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("recv failed: %w", err)
+		}
+		if chunk.Content != "" {
+			if err := onChunk(ctx, "data", chunk.Content); err != nil {
+				return nil, fmt.Errorf("on chunk data: %w", err)
+			}
+		}
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) == 0 {
+		return nil, errors.New("empty reply")
+	}
+	reply, err := schema.ConcatMessages(chunks)
+	if err != nil {
+		return nil, fmt.Errorf("concat reply failed: %w", err)
+	}
+	return reply, nil
+}
+
+// searchForCompletion runs the built-in search tool, returns the documents found for the model and their count
+func (u *ChatUsecase) searchForCompletion(ctx context.Context, kb *domain.KnowledgeBase, groupIDs []int, arguments string) (string, int) {
+	var args struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil || strings.TrimSpace(args.Query) == "" {
+		return "参数错误，query 不能为空", 0
+	}
+	_, rankedNodes, err := u.llmUsecase.GetRankNodes(ctx, GetRankNodesRequest{
+		KBID:                kb.ID,
+		DatasetID:           kb.DatasetID,
+		Question:            args.Query,
+		GroupIDs:            groupIDs,
+		SimilarityThreshold: 0.2,
+	})
+	if err != nil {
+		u.logger.Error("failed to get rank nodes", log.Error(err))
+		return "检索知识库失败", 0
+	}
+	if len(rankedNodes) == 0 {
+		return "未检索到相关文档", 0
+	}
+	return domain.FormatNodeChunks(rankedNodes, kb.AccessSettings.BaseURL), len(rankedNodes)
+}
+
+func newChatCompletionsParams(req *domain.OpenAICompletionsRequest) (*chatCompletionsParams, error) {
+	params := &chatCompletionsParams{searchTool: true}
+	for _, msg := range req.Messages {
+		content := ""
+		if msg.Content != nil {
+			content = msg.Content.String()
+		}
+		switch msg.Role {
+		case "system", "developer":
+			params.messages = append(params.messages, schema.SystemMessage(content))
+		case "user":
+			params.messages = append(params.messages, schema.UserMessage(content))
+			params.question = content
+		case "assistant":
+			toolCalls := make([]schema.ToolCall, 0, len(msg.ToolCalls))
+			for _, call := range msg.ToolCalls {
+				toolCalls = append(toolCalls, schema.ToolCall{
+					ID:       call.ID,
+					Type:     call.Type,
+					Function: schema.FunctionCall{Name: call.Function.Name, Arguments: call.Function.Arguments},
+				})
+			}
+			params.messages = append(params.messages, schema.AssistantMessage(content, toolCalls))
+		case "tool":
+			if msg.ToolCallID == "" {
+				return nil, errors.New("tool_call_id is required for tool messages")
+			}
+			params.messages = append(params.messages, schema.ToolMessage(content, msg.ToolCallID, schema.WithToolName(msg.Name)))
+		default:
+			return nil, fmt.Errorf("invalid message role: %s", msg.Role)
+		}
+	}
+	if params.question == "" {
+		return nil, errors.New("no user message found")
+	}
+
+	for _, tool := range req.Tools {
+		if tool.Type != "function" || tool.Function == nil {
+			return nil, fmt.Errorf("unsupported tool type: %s", tool.Type)
+		}
+		if tool.Function.Name == ChatCompletionsSearchTool {
+			return nil, fmt.Errorf("tool name %s is reserved", ChatCompletionsSearchTool)
+		}
+		parameters, err := toOpenAPIV3Schema(tool.Function.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameters of tool %s: %w", tool.Function.Name, err)
+		}
+		if parameters == nil {
+			parameters = openapi3.NewObjectSchema()
+		}
+		params.clientTools = append(params.clientTools, &schema.ToolInfo{
+			Name:        tool.Function.Name,
+			Desc:        tool.Function.Description,
+			ParamsOneOf: schema.NewParamsOneOfByOpenAPIV3(parameters),
+		})
+	}
+
+	if choice := req.ToolChoice; choice != nil {
+		switch choice.Type {
+		case "", domain.OpenAIToolChoiceAuto:
+		case domain.OpenAIToolChoiceNone:
+			params.clientTools = nil
+			params.searchTool = false
+		case domain.OpenAIToolChoiceRequired:
+			params.toolChoice = lo.ToPtr(schema.ToolChoiceForced)
+		case domain.OpenAIToolChoiceFunction:
+			if choice.Function == nil {
+				return nil, errors.New("tool_choice function is required")
+			}
+			// only the chosen tool is bound to force the model to call it
+			if choice.Function.Name == ChatCompletionsSearchTool {
+				params.clientTools = nil
+			} else {
+				tool, ok := lo.Find(params.clientTools, func(tool *schema.ToolInfo) bool {
+					return tool.Name == choice.Function.Name
+				})
+				if !ok {
+					return nil, fmt.Errorf("tool %s in tool_choice is not found", choice.Function.Name)
+				}
+				params.clientTools = []*schema.ToolInfo{tool}
+				params.searchTool = false
+			}
+			params.toolChoice = lo.ToPtr(schema.ToolChoiceForced)
+		default:
+			return nil, fmt.Errorf("invalid tool_choice: %s", choice.Type)
+		}
+	}
+
+	// the format is also told in the prompt for the models not supporting response_format
+	if format := req.ResponseFormat; format != nil {
+		switch format.Type {
+		case domain.OpenAIResponseFormatJSONObject:
+			params.responseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+			params.formatPrompt = "\n请只输出一个合法的 JSON 对象，不要输出其他内容。"
+		case domain.OpenAIResponseFormatJSONSchema:
+			jsonSchema, err := toOpenAPIV3Schema(format.JSONSchema.Schema)
+			if err != nil {
+				return nil, fmt.Errorf("invalid json_schema: %w", err)
+			}
+			params.responseFormat = &openai.ChatCompletionResponseFormat{
+				Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+				JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+					Name:        format.JSONSchema.Name,
+					Description: format.JSONSchema.Description,
+					Schema:      jsonSchema,
+					Strict:      format.JSONSchema.Strict,
+				},
+			}
+			schemaJSON, _ := json.Marshal(format.JSONSchema.Schema)
+			params.formatPrompt = fmt.Sprintf("\n请只输出一个符合以下 JSON Schema 的 JSON 对象，不要输出其他内容：\n%s", schemaJSON)
+		}
+	}
+	return params, nil
+}
+
+func toOpenAPIV3Schema(value map[string]any) (*openapi3.Schema, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	s := &openapi3.Schema{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudwego/eino-ext/libs/acl/openai"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+func parseCompletionsRequest(t *testing.T, body string) *domain.OpenAICompletionsRequest {
+	req := &domain.OpenAICompletionsRequest{}
+	require.NoError(t, json.Unmarshal([]byte(body), req))
+	return req
+}
+
+func TestNewChatCompletionsParams_ToolChoice(t *testing.T) {
+	const tools = `"tools":[{"type":"function","function":{"name":"get_weather","parameters":{"type":"object","properties":{"city":{"type":"string"}}}}},{"type":"function","function":{"name":"get_time"}}]`
+	tests := []struct {
+		name        string
+		toolChoice  string
+		clientTools []string
+		searchTool  bool
+		forced      bool
+		wantErr     bool
+	}{
+		{"default", ``, []string{"get_weather", "get_time"}, true, false, false},
+		{"auto", `"auto"`, []string{"get_weather", "get_time"}, true, false, false},
+		{"none", `"none"`, nil, false, false, false},
+		{"required", `"required"`, []string{"get_weather", "get_time"}, true, true, false},
+		{"function", `{"type":"function","function":{"name":"get_time"}}`, []string{"get_time"}, false, true, false},
+		{"search tool", `{"type":"function","function":{"name":"search_knowledge_base"}}`, nil, true, true, false},
+		{"unknown function", `{"type":"function","function":{"name":"missing"}}`, nil, false, false, true},
+		{"function without name", `{"type":"function"}`, nil, false, false, true},
+		{"invalid", `"sometimes"`, nil, false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"model":"m","messages":[{"role":"user","content":"hi"}],` + tools
+			if tt.toolChoice != "" {
+				body += `,"tool_choice":` + tt.toolChoice
+			}
+			params, err := newChatCompletionsParams(parseCompletionsRequest(t, body+`}`))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			names := make([]string, 0, len(params.clientTools))
+			for _, tool := range params.clientTools {
+				names = append(names, tool.Name)
+			}
+			if tt.clientTools == nil {
+				assert.Empty(t, names)
+			} else {
+				assert.Equal(t, tt.clientTools, names)
+			}
+			assert.Equal(t, tt.searchTool, params.searchTool)
+			if tt.forced {
+				require.NotNil(t, params.toolChoice)
+				assert.Equal(t, schema.ToolChoiceForced, *params.toolChoice)
+			} else {
+				assert.Nil(t, params.toolChoice)
+			}
+		})
+	}
+}
+
+func TestNewChatCompletionsParams_Tools(t *testing.T) {
+	tests := []struct {
+		name    string
+		tools   string
+		wantErr bool
+	}{
+		{"function", `[{"type":"function","function":{"name":"get_weather"}}]`, false},
+		{"reserved name", `[{"type":"function","function":{"name":"search_knowledge_base"}}]`, true},
+		{"unsupported type", `[{"type":"code_interpreter"}]`, true},
+		{"invalid parameters", `[{"type":"function","function":{"name":"get_weather","parameters":{"type":1}}}]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newChatCompletionsParams(parseCompletionsRequest(t,
+				`{"model":"m","messages":[{"role":"user","content":"hi"}],"tools":`+tt.tools+`}`))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewChatCompletionsParams_Messages(t *testing.T) {
+	params, err := newChatCompletionsParams(parseCompletionsRequest(t, `{"model":"m","messages":[
+		{"role":"developer","content":"be brief"},
+		{"role":"user","content":"weather?"},
+		{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{}"}}]},
+		{"role":"tool","tool_call_id":"call_1","content":"sunny"},
+		{"role":"user","content":"thanks"}
+	]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "thanks", params.question)
+	require.Len(t, params.messages, 5)
+	assert.Equal(t, schema.System, params.messages[0].Role)
+	assert.Equal(t, "call_1", params.messages[2].ToolCalls[0].ID)
+	assert.Equal(t, "call_1", params.messages[3].ToolCallID)
+
+	for name, body := range map[string]string{
+		"tool without call id": `{"model":"m","messages":[{"role":"user","content":"hi"},{"role":"tool","content":"x"}]}`,
+		"invalid role":         `{"model":"m","messages":[{"role":"robot","content":"hi"}]}`,
+		"no user message":      `{"model":"m","messages":[{"role":"system","content":"hi"}]}`,
+	} {
+		_, err := newChatCompletionsParams(parseCompletionsRequest(t, body))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestNewChatCompletionsParams_ResponseFormat(t *testing.T) {
+	const messages = `"messages":[{"role":"user","content":"hi"}]`
+
+	params, err := newChatCompletionsParams(parseCompletionsRequest(t, `{"model":"m",`+messages+`,"response_format":{"type":"text"}}`))
+	require.NoError(t, err)
+	assert.Nil(t, params.responseFormat)
+	assert.Empty(t, params.formatPrompt)
+
+	params, err = newChatCompletionsParams(parseCompletionsRequest(t, `{"model":"m",`+messages+`,"response_format":{"type":"json_object"}}`))
+	require.NoError(t, err)
+	require.NotNil(t, params.responseFormat)
+	assert.Equal(t, openai.ChatCompletionResponseFormatTypeJSONObject, params.responseFormat.Type)
+	assert.NotEmpty(t, params.formatPrompt)
+
+	params, err = newChatCompletionsParams(parseCompletionsRequest(t, `{"model":"m",`+messages+`,"response_format":{"type":"json_schema","json_schema":{
+		"name":"answer","description":"the answer","strict":true,
+		"schema":{"type":"object","properties":{"answer":{"type":"string"}},"required":["answer"]}}}}`))
+	require.NoError(t, err)
+	require.NotNil(t, params.responseFormat)
+	assert.Equal(t, openai.ChatCompletionResponseFormatTypeJSONSchema, params.responseFormat.Type)
+	jsonSchema := params.responseFormat.JSONSchema
+	require.NotNil(t, jsonSchema)
+	assert.Equal(t, "answer", jsonSchema.Name)
+	assert.Equal(t, "the answer", jsonSchema.Description)
+	assert.True(t, jsonSchema.Strict)
+	data, err := json.Marshal(jsonSchema.Schema)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"object","properties":{"answer":{"type":"string"}},"required":["answer"]}`, string(data))
+	assert.Contains(t, params.formatPrompt, `"answer"`)
+
+	_, err = newChatCompletionsParams(parseCompletionsRequest(t, `{"model":"m",`+messages+`,"response_format":{"type":"json_schema","json_schema":{"name":"answer","schema":{"type":1}}}}`))
+	assert.Error(t, err)
+}
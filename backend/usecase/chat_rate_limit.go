@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/pkg/ratelimit"
+	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/store/cache"
+)
+
+const chatRateLimitDefaultWindow = 60 * time.Second
+
+type ChatRateLimitUsecase struct {
+	rateLimiter *ratelimit.RateLimiter
+	kbUsecase   *KnowledgeBaseUsecase
+	statRepo    *pg.StatRepository
+	logger      *log.Logger
+}
+
+func NewChatRateLimitUsecase(kbUsecase *KnowledgeBaseUsecase, statRepo *pg.StatRepository, cache *cache.Cache, logger *log.Logger) *ChatRateLimitUsecase {
+	logger = logger.WithModule("usecase.chat_rate_limit")
+	return &ChatRateLimitUsecase{
+		rateLimiter: ratelimit.NewRateLimiter(logger, cache),
+		kbUsecase:   kbUsecase,
+		statRepo:    statRepo,
+		logger:      logger,
+	}
+}
+
+// Check tells whether the visitor may send one more chat message to the knowledge base by its IP,
+// auth user and conversation, empty or zero ones are not limited. Requests are allowed if the
+// limiter fails, rejected ones are counted in the stats of the knowledge base.
+// Returns:
+// - bool: whether the request is allowed
+// - time.Duration: time to wait before retrying if not allowed
+func (u *ChatRateLimitUsecase) Check(ctx context.Context, kbID, ip string, authUserID uint, conversationID string) (bool, time.Duration) {
+	kb, err := u.kbUsecase.GetKnowledgeBase(ctx, kbID)
+	if err != nil {
+		u.logger.Error("get knowledge base failed", log.Error(err), log.String("kb_id", kbID))
+		return true, 0
+	}
+	settings := kb.AccessSettings.ChatRateLimit
+	if !settings.Enabled {
+		return true, 0
+	}
+	window := chatRateLimitDefaultWindow
+	if settings.WindowSeconds > 0 {
+		window = time.Duration(settings.WindowSeconds) * time.Second
+	}
+
+	checks := []struct {
+		key   string
+		limit int
+		skip  bool
+	}{
+		{key: fmt.Sprintf("chat:%s:ip:%s", kbID, ip), limit: settings.IPLimit, skip: ip == ""},
+		{key: fmt.Sprintf("chat:%s:user:%d", kbID, authUserID), limit: settings.UserLimit, skip: authUserID == 0},
+		{key: fmt.Sprintf("chat:%s:conversation:%s", kbID, conversationID), limit: settings.ConversationLimit, skip: conversationID == ""},
+	}
+	limits := make([]ratelimit.SlidingWindowLimit, 0, len(checks))
+	for _, check := range checks {
+		if check.skip || check.limit <= 0 {
+			continue
+		}
+		limits = append(limits, ratelimit.SlidingWindowLimit{Key: check.key, Limit: check.limit})
+	}
+	allowed, retryAfter, rejected, err := u.rateLimiter.AllowSlidingWindow(ctx, limits, window)
+	if err != nil {
+		u.logger.Error("check chat rate limit failed", log.Error(err), log.String("kb_id", kbID))
+		return true, 0
+	}
+	if !allowed {
+		u.logger.Info("chat rate limited", log.String("key", limits[rejected].Key), log.Any("retry_after", retryAfter))
+		if err := u.statRepo.IncrChatRateLimited(ctx, kbID); err != nil {
+			u.logger.Warn("incr chat rate limited count failed", log.Error(err), log.String("kb_id", kbID))
+		}
+		return false, retryAfter
+	}
+	return true, 0
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/chaitin/panda-wiki/domain"
 	"github.com/chaitin/panda-wiki/log"
 	"github.com/chaitin/panda-wiki/repo/ipdb"
+	"github.com/chaitin/panda-wiki/repo/mq"
 	"github.com/chaitin/panda-wiki/repo/pg"
 )
 
@@ -21,16 +22,18 @@ type CommentUsecase struct {
 	NodeRepo    *pg.NodeRepository
 	ipRepo      *ipdb.IPAddressRepo
 	authRepo    *pg.AuthRepo
+	webhookRepo *mq.WebhookRepository
 }
 
 func NewCommentUsecase(commentRepo *pg.CommentRepository, logger *log.Logger,
-	nodeRepo *pg.NodeRepository, ipRepo *ipdb.IPAddressRepo, authRepo *pg.AuthRepo) *CommentUsecase {
+	nodeRepo *pg.NodeRepository, ipRepo *ipdb.IPAddressRepo, authRepo *pg.AuthRepo, webhookRepo *mq.WebhookRepository) *CommentUsecase {
 	return &CommentUsecase{
 		logger:      logger.WithModule("usecase.comment"),
 		CommentRepo: commentRepo,
 		NodeRepo:    nodeRepo,
 		ipRepo:      ipRepo,
 		authRepo:    authRepo,
+		webhookRepo: webhookRepo,
 	}
 }
 
@@ -68,6 +71,16 @@ func (u *CommentUsecase) CreateComment(ctx context.Context, commentReq *domain.C
 		return "", err
 	}
 
+	if err := u.webhookRepo.AsyncTriggerEvent(ctx, KbID, domain.WebhookEventCommentCreated, &domain.WebhookCommentEventData{
+		CommentID: CommentStr,
+		NodeID:    commentReq.NodeID,
+		Content:   commentReq.Content,
+		UserName:  commentReq.UserName,
+		Status:    status,
+	}); err != nil {
+		u.logger.Error("trigger comment created webhook failed", log.String("comment_id", CommentStr), log.Error(err))
+	}
+
 	// success
 	return CommentStr, nil
 }
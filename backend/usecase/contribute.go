@@ -0,0 +1,274 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	v1 "github.com/chaitin/panda-wiki/api/contribute/v1"
+	shareV1 "github.com/chaitin/panda-wiki/api/share/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/mq"
+	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/utils"
+)
+
+type ContributeUsecase struct {
+	repo        *pg.ContributeRepository
+	nodeRepo    *pg.NodeRepository
+	authRepo    *pg.AuthRepo
+	webhookRepo *mq.WebhookRepository
+	nodeUsecase *NodeUsecase
+	logger      *log.Logger
+}
+
+func NewContributeUsecase(
+	repo *pg.ContributeRepository,
+	nodeRepo *pg.NodeRepository,
+	authRepo *pg.AuthRepo,
+	webhookRepo *mq.WebhookRepository,
+	nodeUsecase *NodeUsecase,
+	logger *log.Logger,
+) *ContributeUsecase {
+	return &ContributeUsecase{
+		repo:        repo,
+		nodeRepo:    nodeRepo,
+		authRepo:    authRepo,
+		webhookRepo: webhookRepo,
+		nodeUsecase: nodeUsecase,
+		logger:      logger.WithModule("usecase.contribute"),
+	}
+}
+
+// Submit records a contribution from the share site, it takes effect after being approved
+func (u *ContributeUsecase) Submit(ctx context.Context, kbID string, req *shareV1.ContributeSubmitReq, authID uint, remoteIP string) (string, error) {
+	contribute := &domain.Contribute{
+		Id:       uuid.New().String(),
+		KBId:     kbID,
+		Status:   consts.ContributeStatusPending,
+		Type:     req.Type,
+		Name:     req.Name,
+		Content:  req.Content,
+		Meta:     domain.NodeMeta{ContentType: req.ContentType},
+		RemoteIP: remoteIP,
+	}
+	if authID != 0 {
+		contribute.AuthId = lo.ToPtr(int64(authID))
+	}
+	if req.Type == consts.ContributeTypeEdit {
+		if req.NodeID == "" {
+			return "", errors.New("node_id is required for edit contribution")
+		}
+		node, err := u.nodeRepo.GetByID(ctx, req.NodeID, kbID)
+		if err != nil {
+			return "", fmt.Errorf("get node failed: %w", err)
+		}
+		if node.Type != domain.NodeTypeDocument {
+			return "", errors.New("only documents can be edited")
+		}
+		contribute.NodeId = node.ID
+		if contribute.Meta.ContentType == "" {
+			contribute.Meta.ContentType = node.Meta.ContentType
+		}
+	}
+	if err := u.repo.Create(ctx, contribute); err != nil {
+		return "", err
+	}
+
+	if err := u.webhookRepo.AsyncTriggerEvent(ctx, kbID, domain.WebhookEventContributeCreated, &domain.WebhookContributeEventData{
+		ContributeID: contribute.Id,
+		Type:         contribute.Type,
+		NodeID:       contribute.NodeId,
+		Name:         contribute.Name,
+	}); err != nil {
+		u.logger.Error("trigger contribute created webhook failed", log.String("contribute_id", contribute.Id), log.Error(err))
+	}
+	return contribute.Id, nil
+}
+
+func (u *ContributeUsecase) GetList(ctx context.Context, req *v1.ContributeListReq) (*v1.ContributeListResp, error) {
+	total, contributes, err := u.repo.GetList(ctx, req.KbId, req.Status, &req.Pager)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeIDs := lo.Uniq(lo.FilterMap(contributes, func(c *domain.Contribute, _ int) (string, bool) {
+		return c.NodeId, c.NodeId != ""
+	}))
+	nodeNames, err := u.nodeRepo.GetNodeNameByNodeIDs(ctx, nodeIDs)
+	if err != nil {
+		return nil, err
+	}
+	authIDs := lo.Uniq(lo.FilterMap(contributes, func(c *domain.Contribute, _ int) (uint, bool) {
+		return uint(lo.FromPtr(c.AuthId)), c.AuthId != nil
+	}))
+	authMap, err := u.authRepo.GetAuthUserinfoByIDs(ctx, authIDs)
+	if err != nil {
+		u.logger.Error("get auth user info failed", log.Error(err))
+	}
+
+	items := lo.Map(contributes, func(c *domain.Contribute, _ int) *v1.ContributeListItem {
+		item := &v1.ContributeListItem{
+			ID:          c.Id,
+			Type:        c.Type,
+			Status:      c.Status,
+			NodeID:      c.NodeId,
+			NodeName:    nodeNames[c.NodeId],
+			Name:        c.Name,
+			AuthID:      c.AuthId,
+			RemoteIP:    c.RemoteIP,
+			Reason:      c.Reason,
+			AuditUserID: c.AuditUserID,
+			AuditTime:   c.AuditTime,
+			CreatedAt:   c.CreatedAt,
+		}
+		if c.AuthId != nil {
+			if auth, ok := authMap[uint(*c.AuthId)]; ok {
+				item.AuthName = auth.AuthUserInfo.Username
+			}
+		}
+		return item
+	})
+	return domain.NewPaginatedResult(items, uint64(total)), nil
+}
+
+func (u *ContributeUsecase) GetDetail(ctx context.Context, kbID, id string) (*v1.ContributeDetailResp, error) {
+	contribute, err := u.repo.GetByID(ctx, kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := &v1.ContributeDetailResp{Contribute: *contribute}
+	if contribute.Type == consts.ContributeTypeEdit {
+		node, err := u.nodeRepo.GetByID(ctx, contribute.NodeId, kbID)
+		if err != nil {
+			return nil, fmt.Errorf("get node failed: %w", err)
+		}
+		resp.OriginalName = node.Name
+		resp.OriginalContent = node.Content
+	}
+	return resp, nil
+}
+
+// GetDiff compares the contribution against the current node content,
+// add contributions are compared against an empty document
+func (u *ContributeUsecase) GetDiff(ctx context.Context, kbID, id string) (*v1.ContributeDiffResp, error) {
+	detail, err := u.GetDetail(ctx, kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := &v1.ContributeDiffResp{
+		OldName: detail.OriginalName,
+		NewName: detail.Name,
+	}
+	resp.Lines, resp.Stats = utils.DiffLines(
+		u.nodeUsecase.diffableContent(detail.OriginalContent),
+		u.nodeUsecase.diffableContent(detail.Content),
+	)
+	resp.Rows = utils.SideBySideDiff(resp.Lines)
+	resp.HTML = utils.RenderDiffHTML(resp.Lines)
+	return resp, nil
+}
+
+// Approve applies the contribution to nodes, a new document is created for add contributions
+func (u *ContributeUsecase) Approve(ctx context.Context, req *v1.ContributeApproveReq, userID string, maxNode int) (string, error) {
+	contribute, err := u.repo.GetByID(ctx, req.KbId, req.ID)
+	if err != nil {
+		return "", err
+	}
+	if contribute.Status != consts.ContributeStatusPending {
+		return "", errors.New("contribution has been audited")
+	}
+	if contribute.Type == consts.ContributeTypeAdd && req.ParentID != "" {
+		parent, err := u.nodeRepo.GetByID(ctx, req.ParentID, req.KbId)
+		if err != nil {
+			return "", fmt.Errorf("get parent node failed: %w", err)
+		}
+		if parent.Type != domain.NodeTypeFolder {
+			return "", errors.New("parent node is not a folder")
+		}
+	}
+
+	// claim before applying so that concurrent approvals do not apply twice
+	claimed, err := u.repo.Audit(ctx, req.KbId, req.ID, consts.ContributeStatusApproved, userID, req.Reason)
+	if err != nil {
+		return "", err
+	}
+	if !claimed {
+		return "", errors.New("contribution has been audited")
+	}
+
+	nodeID, err := u.apply(ctx, contribute, req.ParentID, userID, maxNode)
+	if err != nil {
+		if revertErr := u.repo.Update(ctx, contribute.Id, map[string]any{
+			"status":        consts.ContributeStatusPending,
+			"audit_user_id": "",
+			"audit_time":    nil,
+			"reason":        "",
+		}); revertErr != nil {
+			u.logger.Error("revert contribution audit failed", log.String("contribute_id", contribute.Id), log.Error(revertErr))
+		}
+		return "", err
+	}
+	if contribute.Type == consts.ContributeTypeAdd {
+		if err := u.repo.Update(ctx, contribute.Id, map[string]any{"node_id": nodeID}); err != nil {
+			return "", err
+		}
+	}
+	u.logger.Info("contribution approved",
+		log.String("kb_id", req.KbId),
+		log.String("contribute_id", contribute.Id),
+		log.String("node_id", nodeID),
+		log.String("user_id", userID))
+	return nodeID, nil
+}
+
+func (u *ContributeUsecase) apply(ctx context.Context, contribute *domain.Contribute, parentID, userID string, maxNode int) (string, error) {
+	var contentType *string
+	if contribute.Meta.ContentType != "" {
+		contentType = lo.ToPtr(contribute.Meta.ContentType)
+	}
+	switch contribute.Type {
+	case consts.ContributeTypeAdd:
+		return u.nodeUsecase.Create(ctx, &domain.CreateNodeReq{
+			KBID:        contribute.KBId,
+			ParentID:    parentID,
+			Type:        domain.NodeTypeDocument,
+			Name:        contribute.Name,
+			Content:     contribute.Content,
+			ContentType: contentType,
+			MaxNode:     maxNode,
+		}, userID)
+	case consts.ContributeTypeEdit:
+		if _, err := u.nodeRepo.GetByID(ctx, contribute.NodeId, contribute.KBId); err != nil {
+			return "", fmt.Errorf("get node failed: %w", err)
+		}
+		if err := u.nodeUsecase.Update(ctx, &domain.UpdateNodeReq{
+			ID:          contribute.NodeId,
+			KBID:        contribute.KBId,
+			Name:        lo.ToPtr(contribute.Name),
+			Content:     lo.ToPtr(contribute.Content),
+			ContentType: contentType,
+		}, userID); err != nil {
+			return "", err
+		}
+		return contribute.NodeId, nil
+	default:
+		return "", fmt.Errorf("unsupported contribution type: %s", contribute.Type)
+	}
+}
+
+func (u *ContributeUsecase) Reject(ctx context.Context, req *v1.ContributeRejectReq, userID string) error {
+	claimed, err := u.repo.Audit(ctx, req.KbId, req.ID, consts.ContributeStatusRejected, userID, req.Reason)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return errors.New("contribution not found or has been audited")
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/chaitin/panda-wiki/log"
 	"github.com/chaitin/panda-wiki/repo/cache"
 	"github.com/chaitin/panda-wiki/repo/ipdb"
+	"github.com/chaitin/panda-wiki/repo/mq"
 	"github.com/chaitin/panda-wiki/repo/pg"
 )
 
@@ -21,6 +22,7 @@ type ConversationUsecase struct {
 	logger       *log.Logger
 	ipRepo       *ipdb.IPAddressRepo
 	authRepo     *pg.AuthRepo
+	webhookRepo  *mq.WebhookRepository
 }
 
 func NewConversationUsecase(
@@ -30,6 +32,7 @@ func NewConversationUsecase(
 	logger *log.Logger,
 	ipRepo *ipdb.IPAddressRepo,
 	authRepo *pg.AuthRepo,
+	webhookRepo *mq.WebhookRepository,
 ) *ConversationUsecase {
 	return &ConversationUsecase{
 		repo:         repo,
@@ -37,6 +40,7 @@ func NewConversationUsecase(
 		geoCacheRepo: geoCacheRepo,
 		ipRepo:       ipRepo,
 		authRepo:     authRepo,
+		webhookRepo:  webhookRepo,
 		logger:       logger.WithModule("usecase.conversation"),
 	}
 }
@@ -200,12 +204,44 @@ func (u *ConversationUsecase) FeedBack(ctx context.Context, feedback *domain.Fee
 		if err := u.repo.UpdateMessageFeedback(ctx, feedback); err != nil {
 			return err
 		}
+		if feedback.Score == domain.DisLike {
+			var question string
+			if messages.ParentID != "" {
+				if parent, err := u.repo.GetConversationMessagesDetailByID(ctx, messages.ParentID); err == nil {
+					question = parent.Content
+				}
+			}
+			if err := u.webhookRepo.AsyncTriggerEvent(ctx, messages.KBID, domain.WebhookEventFeedbackNegative, &domain.WebhookFeedbackEventData{
+				ConversationID:  messages.ConversationID,
+				MessageID:       messages.ID,
+				AppID:           messages.AppID,
+				Question:        question,
+				Answer:          messages.Content,
+				Type:            feedback.Type,
+				FeedbackContent: feedback.FeedbackContent,
+			}); err != nil {
+				u.logger.Error("trigger negative feedback webhook failed", log.String("message_id", messages.ID), log.Error(err))
+			}
+		}
 	} else {
 		return fmt.Errorf("already voted for this message, please do not vote again")
 	}
 	return nil
 }
 
+// FeedBackByKB 记录知识库中消息的反馈，用于机器人等只知道知识库和消息ID的场景
+func (u *ConversationUsecase) FeedBackByKB(ctx context.Context, kbID, messageID string, score domain.ScoreType) error {
+	message, err := u.repo.GetConversationMessagesDetailByKbID(ctx, kbID, messageID)
+	if err != nil {
+		return err
+	}
+	return u.FeedBack(ctx, &domain.FeedbackRequest{
+		ConversationId: message.ConversationID,
+		MessageId:      message.ID,
+		Score:          score,
+	})
+}
+
 func (u *ConversationUsecase) GetMessageList(ctx context.Context, req *domain.MessageListReq) (*domain.PaginatedResult[[]*domain.ConversationMessageListItem], error) {
 	total, messageList, err := u.repo.GetMessageFeedBackList(ctx, req)
 	if err != nil {
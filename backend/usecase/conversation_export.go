@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/chaitin/panda-wiki/api/conversation/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+)
+
+const (
+	conversationExportBatchSize = 500
+	// conversationExportMaxCachedIPs bounds the geo lookup cache of one export
+	conversationExportMaxCachedIPs = 10000
+)
+
+var conversationExportCSVHeader = []string{
+	"message_id", "conversation_id", "subject", "app_id", "app_name", "app_type",
+	"question", "answer", "provider", "model", "prompt_tokens", "completion_tokens", "total_tokens",
+	"score", "feedback_type", "feedback_content", "references",
+	"remote_ip", "country", "province", "city", "created_at",
+}
+
+// Export writes the answers matching the request with their questions to w in CSV or JSONL,
+// flushing after every batch if w is a http.Flusher. Nothing is written before the first batch
+// is loaded, so that an early error can still be responded by the caller.
+func (u *ConversationUsecase) Export(ctx context.Context, req *v1.ConversationExportReq, w io.Writer) error {
+	var csvWriter *csv.Writer
+	var jsonEncoder *json.Encoder
+	started := false
+	start := func() error {
+		started = true
+		if req.Format == domain.ConversationExportFormatJSONL {
+			jsonEncoder = json.NewEncoder(w)
+			jsonEncoder.SetEscapeHTML(false)
+			return nil
+		}
+		// BOM for spreadsheet applications to recognize utf-8
+		if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return err
+		}
+		csvWriter = csv.NewWriter(w)
+		return csvWriter.Write(conversationExportCSVHeader)
+	}
+
+	ipAddressMap := make(map[string]*domain.IPAddress)
+	total := 0
+	err := u.repo.ExportMessages(ctx, req, conversationExportBatchSize, func(rows []*domain.ConversationExportRow) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		for _, row := range rows {
+			row.IPAddress = u.exportIPAddress(ctx, ipAddressMap, row.RemoteIP)
+			if jsonEncoder != nil {
+				if err := jsonEncoder.Encode(row); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := csvWriter.Write(conversationExportCSVRecord(row)); err != nil {
+				return err
+			}
+		}
+		if csvWriter != nil {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return err
+			}
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		total += len(rows)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !started {
+		if err := start(); err != nil {
+			return err
+		}
+	}
+	if csvWriter != nil {
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return err
+		}
+	}
+	u.logger.Info("conversations exported", log.String("kb_id", req.KbID), log.String("format", string(req.Format)), log.Int("rows", total))
+	return nil
+}
+
+func (u *ConversationUsecase) exportIPAddress(ctx context.Context, ipAddressMap map[string]*domain.IPAddress, ip string) *domain.IPAddress {
+	if ip == "" {
+		return nil
+	}
+	if ipAddress, ok := ipAddressMap[ip]; ok {
+		return ipAddress
+	}
+	ipAddress, err := u.ipRepo.GetIPAddress(ctx, ip)
+	if err != nil {
+		u.logger.Warn("get ip address failed", log.Error(err), log.String("ip", ip))
+	}
+	if len(ipAddressMap) >= conversationExportMaxCachedIPs {
+		clear(ipAddressMap)
+	}
+	ipAddressMap[ip] = ipAddress
+	return ipAddress
+}
+
+func conversationExportCSVRecord(row *domain.ConversationExportRow) []string {
+	references := make([]string, 0, len(row.References))
+	for _, reference := range row.References {
+		references = append(references, reference.Name+" "+reference.URL)
+	}
+	var country, province, city string
+	if row.IPAddress != nil {
+		country, province, city = row.IPAddress.Country, row.IPAddress.Province, row.IPAddress.City
+	}
+	return []string{
+		csvSafeCell(row.ID),
+		csvSafeCell(row.ConversationID),
+		csvSafeCell(row.Subject),
+		csvSafeCell(row.AppID),
+		csvSafeCell(row.AppName),
+		strconv.Itoa(int(row.AppType)),
+		csvSafeCell(row.Question),
+		csvSafeCell(row.Answer),
+		string(row.Provider),
+		csvSafeCell(row.Model),
+		strconv.Itoa(row.PromptTokens),
+		strconv.Itoa(row.CompletionTokens),
+		strconv.Itoa(row.TotalTokens),
+		strconv.Itoa(int(row.Info.Score)),
+		string(row.Info.FeedbackType),
+		csvSafeCell(row.Info.FeedbackContent),
+		csvSafeCell(strings.Join(references, "\n")),
+		csvSafeCell(row.RemoteIP),
+		csvSafeCell(country),
+		csvSafeCell(province),
+		csvSafeCell(city),
+		row.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// csvSafeCell 以公式字符开头的单元格前加单引号，避免用户输入在表格软件中被当作公式执行
+func csvSafeCell(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
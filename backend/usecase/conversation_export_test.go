@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+func TestCSVSafeCell(t *testing.T) {
+	cases := map[string]string{
+		"":                     "",
+		"hello":                "hello",
+		"=HYPERLINK(\"x\")":    "'=HYPERLINK(\"x\")",
+		"+1":                   "'+1",
+		"-1+cmd|' /C calc'!A0": "'-1+cmd|' /C calc'!A0",
+		"@SUM(A1)":             "'@SUM(A1)",
+		"\t=1":                 "'\t=1",
+		"\r=1":                 "'\r=1",
+		"a=b":                  "a=b",
+	}
+	for cell, expected := range cases {
+		if got := csvSafeCell(cell); got != expected {
+			t.Errorf("csvSafeCell(%q) = %q, expected %q", cell, got, expected)
+		}
+	}
+}
+
+func TestConversationExportCSVRecord(t *testing.T) {
+	record := conversationExportCSVRecord(&domain.ConversationExportRow{
+		Question:  "=1+1",
+		Answer:    "@cmd",
+		Info:      domain.FeedBackInfo{Score: -1},
+		CreatedAt: time.Now(),
+	})
+	if record[6] != "'=1+1" || record[7] != "'@cmd" {
+		t.Errorf("expected question and answer to be escaped, got %q %q", record[6], record[7])
+	}
+	if record[13] != "-1" {
+		t.Errorf("expected score to be kept, got %q", record[13])
+	}
+}
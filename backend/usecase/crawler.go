@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"slices"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -217,3 +218,27 @@ func (u *CrawlerUsecase) ScrapeGetResults(ctx context.Context, taskIds []string)
 		List:   list,
 	}, nil
 }
+
+// ExportDocContent exports a parsed doc and waits for the markdown content
+func (u *CrawlerUsecase) ExportDocContent(ctx context.Context, req *v1.CrawlerExportReq) (string, error) {
+	exportResp, err := u.ExportDoc(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-ticker.C:
+			result, err := u.ScrapeGetResult(ctx, exportResp.TaskId)
+			if err != nil {
+				return "", err
+			}
+			if result.Status == consts.CrawlerStatusCompleted {
+				return result.Content, nil
+			}
+		}
+	}
+}
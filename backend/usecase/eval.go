@@ -0,0 +1,426 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	modelkit "github.com/chaitin/ModelKit/v2/usecase"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/utils"
+)
+
+const (
+	evalDefaultTopK = 10
+	evalRunTimeout  = 2 * time.Hour
+	// evalRunStaleTimeout a run updates its progress after every case,
+	// one not updated for longer was interrupted, e.g. by a restart
+	evalRunStaleTimeout = 10 * time.Minute
+)
+
+type EvalUsecase struct {
+	repo         *pg.EvalRepository
+	llmUsecase   *LLMUsecase
+	modelUsecase *ModelUsecase
+	logger       *log.Logger
+	modelkit     *modelkit.ModelKit
+}
+
+func NewEvalUsecase(
+	repo *pg.EvalRepository,
+	llmUsecase *LLMUsecase,
+	modelUsecase *ModelUsecase,
+	logger *log.Logger,
+) *EvalUsecase {
+	return &EvalUsecase{
+		repo:         repo,
+		llmUsecase:   llmUsecase,
+		modelUsecase: modelUsecase,
+		logger:       logger.WithModule("usecase.eval"),
+		modelkit:     modelkit.NewModelKit(logger.Logger),
+	}
+}
+
+// FailInterruptedRuns marks the runs not updated for a while failed,
+// runs are executed in background of the api process and those interrupted by an exit never finish
+func (u *EvalUsecase) FailInterruptedRuns(ctx context.Context) error {
+	failed, err := u.repo.FailStaleRuns(ctx, time.Now().Add(-evalRunStaleTimeout), "eval run was interrupted")
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		u.logger.Warn("interrupted eval runs marked failed", log.Int("count", int(failed)))
+	}
+	return nil
+}
+
+func (u *EvalUsecase) CreateSet(ctx context.Context, req *v1.EvalSetCreateReq) (string, error) {
+	now := time.Now()
+	set := &domain.EvalSet{
+		ID:          uuid.New().String(),
+		KBID:        req.KbID,
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := u.repo.CreateSet(ctx, set, u.newCases(req.KbID, set.ID, req.Cases)); err != nil {
+		return "", err
+	}
+	return set.ID, nil
+}
+
+func (u *EvalUsecase) newCases(kbID, setID string, items []*v1.EvalCaseItem) []*domain.EvalCase {
+	now := time.Now()
+	return lo.Map(items, func(item *v1.EvalCaseItem, i int) *domain.EvalCase {
+		expected := lo.Uniq(lo.FilterMap(item.ExpectedNodeIDs, func(id string, _ int) (string, bool) {
+			id = strings.TrimSpace(id)
+			return id, id != ""
+		}))
+		return &domain.EvalCase{
+			ID:              uuid.New().String(),
+			SetID:           setID,
+			KBID:            kbID,
+			Question:        strings.TrimSpace(item.Question),
+			ExpectedNodeIDs: pq.StringArray(expected),
+			ReferenceAnswer: strings.TrimSpace(item.ReferenceAnswer),
+			// keep the uploaded order
+			CreatedAt: now.Add(time.Duration(i) * time.Microsecond),
+		}
+	})
+}
+
+func (u *EvalUsecase) GetSetList(ctx context.Context, kbID string) ([]*v1.EvalSetListItem, error) {
+	return u.repo.GetSetList(ctx, kbID)
+}
+
+func (u *EvalUsecase) GetSetDetail(ctx context.Context, kbID, id string) (*v1.EvalSetDetailResp, error) {
+	set, err := u.repo.GetSet(ctx, kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	cases, err := u.repo.GetCases(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.EvalSetDetailResp{EvalSet: *set, Cases: cases}, nil
+}
+
+func (u *EvalUsecase) AddCases(ctx context.Context, req *v1.EvalSetCasesAddReq) error {
+	if _, err := u.repo.GetSet(ctx, req.KbID, req.ID); err != nil {
+		return err
+	}
+	return u.repo.AddCases(ctx, req.ID, u.newCases(req.KbID, req.ID, req.Cases))
+}
+
+func (u *EvalUsecase) DeleteCase(ctx context.Context, kbID, caseID string) error {
+	return u.repo.DeleteCase(ctx, kbID, caseID)
+}
+
+func (u *EvalUsecase) DeleteSet(ctx context.Context, kbID, id string) error {
+	return u.repo.DeleteSet(ctx, kbID, id)
+}
+
+// CreateRun evaluates every case of the set in background with the current chat model,
+// no conversation is created and the progress can be polled with GetRunDetail
+func (u *EvalUsecase) CreateRun(ctx context.Context, req *v1.EvalRunCreateReq, userID string) (string, error) {
+	if _, err := u.repo.GetSet(ctx, req.KbID, req.SetID); err != nil {
+		return "", err
+	}
+	cases, err := u.repo.GetCases(ctx, req.SetID)
+	if err != nil {
+		return "", err
+	}
+	if len(cases) == 0 {
+		return "", errors.New("eval set has no cases")
+	}
+	chatModel, err := u.modelUsecase.GetChatModel(ctx)
+	if err != nil {
+		u.logger.Error("get chat model failed", log.Error(err))
+		return "", domain.ErrModelNotConfigured
+	}
+
+	now := time.Now()
+	run := &domain.EvalRun{
+		ID:        uuid.New().String(),
+		KBID:      req.KbID,
+		SetID:     req.SetID,
+		Name:      req.Name,
+		Prompt:    req.Prompt,
+		TopK:      lo.Ternary(req.TopK > 0, req.TopK, evalDefaultTopK),
+		Provider:  chatModel.Provider,
+		Model:     chatModel.Model,
+		Status:    domain.EvalRunStatusPending,
+		Total:     len(cases),
+		CreatorID: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if run.Name == "" {
+		run.Name = fmt.Sprintf("%s %s", chatModel.Model, now.Format("2006-01-02 15:04"))
+	}
+	if err := u.repo.CreateRun(ctx, run); err != nil {
+		return "", err
+	}
+
+	go func() {
+		bgCtx, cancel := context.WithTimeout(context.Background(), evalRunTimeout)
+		defer cancel()
+		u.execute(bgCtx, run, cases, chatModel, req.GroupIDs, req.Judge)
+	}()
+	return run.ID, nil
+}
+
+func (u *EvalUsecase) execute(ctx context.Context, run *domain.EvalRun, cases []*domain.EvalCase, chatModelInfo *domain.Model, groupIDs []int, judge bool) {
+	fail := func(err error) {
+		u.logger.Error("eval run failed", log.String("run_id", run.ID), log.Error(err))
+		if err := u.repo.UpdateRun(context.Background(), run.ID, map[string]any{
+			"status":      domain.EvalRunStatusFailed,
+			"error":       err.Error(),
+			"finished_at": time.Now(),
+		}); err != nil {
+			u.logger.Error("update eval run failed", log.String("run_id", run.ID), log.Error(err))
+		}
+	}
+
+	if err := u.repo.UpdateRun(ctx, run.ID, map[string]any{"status": domain.EvalRunStatusRunning}); err != nil {
+		fail(err)
+		return
+	}
+	modelkitModel, err := chatModelInfo.ToModelkitModel()
+	if err != nil {
+		fail(fmt.Errorf("convert model failed: %w", err))
+		return
+	}
+	chatModel, err := u.modelkit.GetChatModel(ctx, modelkitModel)
+	if err != nil {
+		fail(fmt.Errorf("get chat model failed: %w", err))
+		return
+	}
+
+	results := make([]*domain.EvalResult, 0, len(cases))
+	var promptTokens, completionTokens int64
+	for i, evalCase := range cases {
+		if ctx.Err() != nil {
+			fail(fmt.Errorf("eval run timeout after %d cases", i))
+			return
+		}
+		result := u.evaluateCase(ctx, run, evalCase, chatModel, groupIDs, judge)
+		if err := u.repo.CreateResult(ctx, result); err != nil {
+			fail(fmt.Errorf("save eval result failed: %w", err))
+			return
+		}
+		if result.PromptTokens > 0 || result.CompletionTokens > 0 {
+			if err := u.modelUsecase.UpdateUsage(ctx, chatModelInfo.ID, &schema.TokenUsage{
+				PromptTokens:     int(result.PromptTokens),
+				CompletionTokens: int(result.CompletionTokens),
+				TotalTokens:      int(result.PromptTokens + result.CompletionTokens),
+			}); err != nil {
+				u.logger.Error("update model usage failed", log.String("run_id", run.ID), log.Error(err))
+			}
+		}
+		results = append(results, result)
+		promptTokens += result.PromptTokens
+		completionTokens += result.CompletionTokens
+		if err := u.repo.UpdateRun(ctx, run.ID, map[string]any{
+			"finished":          i + 1,
+			"prompt_tokens":     promptTokens,
+			"completion_tokens": completionTokens,
+		}); err != nil {
+			u.logger.Error("update eval run progress failed", log.String("run_id", run.ID), log.Error(err))
+		}
+	}
+
+	if err := u.repo.UpdateRun(ctx, run.ID, map[string]any{
+		"status": domain.EvalRunStatusSucceeded,
+		"recall_at_k": averageEvalMetric(results, func(r *domain.EvalResult) *float64 {
+			return r.RecallAtK
+		}),
+		"citation_accuracy": averageEvalMetric(results, func(r *domain.EvalResult) *float64 {
+			return r.CitationAccuracy
+		}),
+		"answer_score": averageEvalMetric(results, func(r *domain.EvalResult) *float64 {
+			return r.AnswerScore
+		}),
+		"finished_at": time.Now(),
+	}); err != nil {
+		fail(err)
+		return
+	}
+	u.logger.Info("eval run finished", log.String("run_id", run.ID), log.String("kb_id", run.KBID), log.Int("cases", len(results)))
+}
+
+// evaluateCase answers the question like a chat without history, failures are recorded in the result
+func (u *EvalUsecase) evaluateCase(ctx context.Context, run *domain.EvalRun, evalCase *domain.EvalCase, chatModel model.BaseChatModel, groupIDs []int, judge bool) *domain.EvalResult {
+	result := &domain.EvalResult{
+		ID:               uuid.New().String(),
+		RunID:            run.ID,
+		CaseID:           evalCase.ID,
+		Question:         evalCase.Question,
+		RetrievedNodeIDs: pq.StringArray{},
+		CitedNodeIDs:     pq.StringArray{},
+		CreatedAt:        time.Now(),
+	}
+
+	messages, rankedNodes, err := u.llmUsecase.BuildMessagesWithRAG(ctx, run.KBID, groupIDs, run.Prompt, []*schema.Message{
+		schema.UserMessage(evalCase.Question),
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.RetrievedNodeIDs = lo.Map(rankedNodes, func(node *domain.RankedNodeChunks, _ int) string {
+		return node.NodeID
+	})
+	if recall, ok := utils.RecallAtK(evalCase.ExpectedNodeIDs, result.RetrievedNodeIDs, run.TopK); ok {
+		result.RecallAtK = &recall
+	}
+
+	answer := ""
+	usage := schema.TokenUsage{}
+	if err := u.llmUsecase.ChatWithAgent(ctx, chatModel, messages, &usage, func(_ context.Context, _, chunk string) error {
+		answer += chunk
+		return nil
+	}); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Answer = strings.TrimSpace(u.llmUsecase.trimThinking(answer))
+	result.PromptTokens = int64(usage.PromptTokens)
+	result.CompletionTokens = int64(usage.CompletionTokens)
+	result.CitedNodeIDs = utils.CitedNodeIDs(result.Answer)
+	if accuracy, ok := utils.CitationAccuracy(evalCase.ExpectedNodeIDs, result.CitedNodeIDs); ok {
+		result.CitationAccuracy = &accuracy
+	}
+
+	if judge && evalCase.ReferenceAnswer != "" {
+		score, reason, err := u.judgeAnswer(ctx, chatModel, evalCase, result.Answer)
+		if err != nil {
+			result.Error = fmt.Sprintf("judge answer failed: %s", err)
+			return result
+		}
+		result.AnswerScore = &score
+		result.JudgeReason = reason
+	}
+	return result
+}
+
+func (u *EvalUsecase) judgeAnswer(ctx context.Context, chatModel model.BaseChatModel, evalCase *domain.EvalCase, answer string) (float64, string, error) {
+	template := prompt.FromMessages(schema.GoTemplate,
+		schema.SystemMessage(domain.EvalJudgePrompt),
+		schema.UserMessage(domain.EvalJudgeUserFormatter),
+	)
+	messages, err := template.Format(ctx, map[string]any{
+		"Question":        evalCase.Question,
+		"ReferenceAnswer": evalCase.ReferenceAnswer,
+		"Answer":          answer,
+	})
+	if err != nil {
+		return 0, "", fmt.Errorf("format judge messages failed: %w", err)
+	}
+	content, err := u.llmUsecase.Generate(ctx, chatModel, messages)
+	if err != nil {
+		return 0, "", err
+	}
+	content = u.llmUsecase.trimThinking(content)
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start == -1 || end < start {
+		return 0, "", fmt.Errorf("invalid judge output: %s", content)
+	}
+	var verdict struct {
+		Score  float64 `json:"score"`
+		Reason string  `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &verdict); err != nil {
+		return 0, "", fmt.Errorf("invalid judge output: %w", err)
+	}
+	return lo.Clamp(verdict.Score, 0, 10), verdict.Reason, nil
+}
+
+// averageEvalMetric averages a metric over the results it applies to, nil if it applies to none
+func averageEvalMetric(results []*domain.EvalResult, metric func(*domain.EvalResult) *float64) *float64 {
+	values := lo.FilterMap(results, func(r *domain.EvalResult, _ int) (float64, bool) {
+		value := metric(r)
+		return lo.FromPtr(value), value != nil
+	})
+	if len(values) == 0 {
+		return nil
+	}
+	return lo.ToPtr(lo.Sum(values) / float64(len(values)))
+}
+
+func (u *EvalUsecase) GetRunList(ctx context.Context, req *v1.EvalRunListReq) (*v1.EvalRunListResp, error) {
+	total, runs, err := u.repo.GetRunList(ctx, req.KbID, req.SetID, &req.Pager)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewPaginatedResult(runs, uint64(total)), nil
+}
+
+func (u *EvalUsecase) GetRunDetail(ctx context.Context, kbID, id string) (*v1.EvalRunDetailResp, error) {
+	run, err := u.repo.GetRun(ctx, kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	results, err := u.repo.GetResults(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.EvalRunDetailResp{EvalRun: *run, Results: results}, nil
+}
+
+// CompareRuns pairs the results of two runs of the same set by case
+func (u *EvalUsecase) CompareRuns(ctx context.Context, req *v1.EvalRunCompareReq) (*v1.EvalRunCompareResp, error) {
+	base, err := u.GetRunDetail(ctx, req.KbID, req.BaseRunID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("base run not found")
+		}
+		return nil, err
+	}
+	current, err := u.GetRunDetail(ctx, req.KbID, req.RunID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("run not found")
+		}
+		return nil, err
+	}
+	if base.SetID != current.SetID {
+		return nil, errors.New("runs belong to different eval sets")
+	}
+
+	items := make([]*v1.EvalRunCompareItem, 0, len(base.Results))
+	itemMap := make(map[string]*v1.EvalRunCompareItem)
+	for _, result := range base.Results {
+		item := &v1.EvalRunCompareItem{CaseID: result.CaseID, Question: result.Question, Base: result}
+		itemMap[result.CaseID] = item
+		items = append(items, item)
+	}
+	for _, result := range current.Results {
+		item, ok := itemMap[result.CaseID]
+		if !ok {
+			item = &v1.EvalRunCompareItem{CaseID: result.CaseID, Question: result.Question}
+			items = append(items, item)
+		}
+		item.Current = result
+	}
+	return &v1.EvalRunCompareResp{
+		Base:    &base.EvalRun,
+		Current: &current.EvalRun,
+		Cases:   items,
+	}, nil
+}
@@ -0,0 +1,401 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+
+	v1 "github.com/chaitin/panda-wiki/api/crawler/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/pkg/anydoc"
+	"github.com/chaitin/panda-wiki/repo/pg"
+)
+
+// importDocExportTimeout limits the time waiting for one document to be exported by the crawler
+const importDocExportTimeout = 5 * time.Minute
+
+type ImportSubscriptionUsecase struct {
+	repo           *pg.ImportSubscriptionRepository
+	nodeRepo       *pg.NodeRepository
+	crawlerUsecase *CrawlerUsecase
+	kbUsecase      *KnowledgeBaseUsecase
+	logger         *log.Logger
+}
+
+func NewImportSubscriptionUsecase(
+	repo *pg.ImportSubscriptionRepository,
+	nodeRepo *pg.NodeRepository,
+	crawlerUsecase *CrawlerUsecase,
+	kbUsecase *KnowledgeBaseUsecase,
+	logger *log.Logger,
+) *ImportSubscriptionUsecase {
+	return &ImportSubscriptionUsecase{
+		repo:           repo,
+		nodeRepo:       nodeRepo,
+		crawlerUsecase: crawlerUsecase,
+		kbUsecase:      kbUsecase,
+		logger:         logger.WithModule("usecase.import_subscription"),
+	}
+}
+
+// Create creates a subscription, the first sync runs at the next cron tick and imports all documents
+func (u *ImportSubscriptionUsecase) Create(ctx context.Context, req *v1.SubscriptionCreateReq, userID string) (string, error) {
+	if _, err := cron.ParseStandard(req.Schedule); err != nil {
+		return "", fmt.Errorf("invalid schedule: %w", err)
+	}
+	if req.ParentID != "" {
+		parent, err := u.nodeRepo.GetByID(ctx, req.ParentID, req.KbID)
+		if err != nil {
+			return "", fmt.Errorf("get parent node failed: %w", err)
+		}
+		if parent.Type != domain.NodeTypeFolder {
+			return "", errors.New("parent node is not a folder")
+		}
+	}
+	name := req.Name
+	if name == "" {
+		name = req.Key
+	}
+	now := time.Now()
+	sub := &domain.ImportSubscription{
+		ID:            uuid.New().String(),
+		KBID:          req.KbID,
+		Name:          name,
+		CrawlerSource: req.CrawlerSource,
+		SourceKey:     req.Key,
+		Setting: domain.ImportSubscriptionSetting{
+			FeishuAppID:           req.FeishuSetting.AppID,
+			FeishuAppSecret:       req.FeishuSetting.AppSecret,
+			FeishuUserAccessToken: req.FeishuSetting.UserAccessToken,
+			FeishuSpaceID:         req.FeishuSetting.SpaceId,
+		},
+		ParentID:       req.ParentID,
+		Schedule:       req.Schedule,
+		AutoPublish:    req.AutoPublish,
+		Enabled:        true,
+		CreatorID:      userID,
+		NextSyncAt:     &now,
+		LastSyncStatus: domain.ImportSyncStatusPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := u.repo.Create(ctx, sub); err != nil {
+		return "", err
+	}
+	return sub.ID, nil
+}
+
+func (u *ImportSubscriptionUsecase) GetList(ctx context.Context, kbID string) ([]*v1.SubscriptionListItem, error) {
+	subs, err := u.repo.GetList(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(subs, func(sub *domain.ImportSubscription, _ int) *v1.SubscriptionListItem {
+		return &v1.SubscriptionListItem{
+			ID:             sub.ID,
+			Name:           sub.Name,
+			CrawlerSource:  sub.CrawlerSource,
+			Key:            sub.SourceKey,
+			ParentID:       sub.ParentID,
+			Schedule:       sub.Schedule,
+			AutoPublish:    sub.AutoPublish,
+			Enabled:        sub.Enabled,
+			NextSyncAt:     sub.NextSyncAt,
+			LastSyncAt:     sub.LastSyncAt,
+			LastSyncStatus: sub.LastSyncStatus,
+			LastSyncError:  sub.LastSyncError,
+			LastSyncResult: sub.LastSyncResult,
+			CreatedAt:      sub.CreatedAt,
+		}
+	}), nil
+}
+
+func (u *ImportSubscriptionUsecase) Update(ctx context.Context, req *v1.SubscriptionUpdateReq) error {
+	sub, err := u.repo.GetByID(ctx, req.KbID, req.ID)
+	if err != nil {
+		return err
+	}
+	updateMap := make(map[string]any)
+	if req.Name != nil {
+		updateMap["name"] = *req.Name
+	}
+	if req.AutoPublish != nil {
+		updateMap["auto_publish"] = *req.AutoPublish
+	}
+	schedule := sub.Schedule
+	if req.Schedule != nil {
+		schedule = *req.Schedule
+		updateMap["schedule"] = schedule
+	}
+	enabled := sub.Enabled
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+		updateMap["enabled"] = enabled
+	}
+	if req.Schedule != nil || (enabled && !sub.Enabled) {
+		spec, err := cron.ParseStandard(schedule)
+		if err != nil {
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+		updateMap["next_sync_at"] = spec.Next(time.Now())
+	}
+	return u.repo.Update(ctx, req.KbID, req.ID, updateMap)
+}
+
+func (u *ImportSubscriptionUsecase) Delete(ctx context.Context, kbID, id string) error {
+	return u.repo.Delete(ctx, kbID, id)
+}
+
+// TriggerSync schedules the subscription to sync at the next cron tick
+func (u *ImportSubscriptionUsecase) TriggerSync(ctx context.Context, kbID, id string) error {
+	sub, err := u.repo.GetByID(ctx, kbID, id)
+	if err != nil {
+		return err
+	}
+	if !sub.Enabled {
+		return errors.New("subscription is disabled")
+	}
+	// a sync running for too long was interrupted and does not block syncing again
+	if sub.LastSyncStatus == domain.ImportSyncStatusRunning && time.Since(sub.UpdatedAt) < domain.ImportSyncTimeout {
+		return errors.New("subscription is syncing")
+	}
+	return u.repo.Update(ctx, kbID, id, map[string]any{
+		"next_sync_at":     time.Now(),
+		"last_sync_status": domain.ImportSyncStatusPending,
+	})
+}
+
+// SyncDueSubscriptions syncs all subscriptions whose schedule has come
+func (u *ImportSubscriptionUsecase) SyncDueSubscriptions(ctx context.Context) error {
+	now := time.Now()
+	subs, err := u.repo.GetDueList(ctx, now)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		spec, err := cron.ParseStandard(sub.Schedule)
+		if err != nil {
+			u.logger.Error("invalid subscription schedule", log.String("id", sub.ID), log.Error(err))
+			continue
+		}
+		claimed, err := u.repo.ClaimSync(ctx, sub, spec.Next(now))
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		status := domain.ImportSyncStatusSucceeded
+		syncErr := ""
+		result, err := u.Sync(ctx, sub)
+		if err != nil {
+			u.logger.Error("sync import subscription failed", log.String("id", sub.ID), log.Error(err))
+			status = domain.ImportSyncStatusFailed
+			syncErr = err.Error()
+		}
+		if err := u.repo.FinishSync(ctx, sub.ID, status, syncErr, result); err != nil {
+			u.logger.Error("finish import subscription sync failed", log.String("id", sub.ID), log.Error(err))
+			continue
+		}
+		u.logger.Info("sync import subscription finished",
+			log.String("id", sub.ID),
+			log.Int("added", len(result.Added)),
+			log.Int("changed", len(result.Changed)),
+			log.Int("removed", len(result.Removed)),
+			log.Int("failed", len(result.Failed)),
+			log.Int("conflicted", len(result.Conflicted)))
+	}
+	return nil
+}
+
+// Sync re-fetches the source, creates nodes for new documents and updates nodes of changed documents as drafts.
+// Nodes edited locally since the last sync and nodes of removed documents are kept and only reported.
+func (u *ImportSubscriptionUsecase) Sync(ctx context.Context, sub *domain.ImportSubscription) (domain.ImportSyncResult, error) {
+	result := domain.ImportSyncResult{}
+	parseResp, err := u.crawlerUsecase.ParseUrl(ctx, &v1.CrawlerParseReq{
+		Key:           sub.SourceKey,
+		KbID:          sub.KBID,
+		CrawlerSource: sub.CrawlerSource,
+		FeishuSetting: v1.FeishuSetting{
+			UserAccessToken: sub.Setting.FeishuUserAccessToken,
+			AppID:           sub.Setting.FeishuAppID,
+			AppSecret:       sub.Setting.FeishuAppSecret,
+			SpaceId:         sub.Setting.FeishuSpaceID,
+		},
+	})
+	if err != nil {
+		return result, fmt.Errorf("parse source failed: %w", err)
+	}
+	docs, err := u.repo.GetDocs(ctx, sub.ID)
+	if err != nil {
+		return result, err
+	}
+	docMap := lo.KeyBy(docs, func(doc *domain.ImportSubscriptionDoc) string {
+		return doc.RemoteID
+	})
+
+	seen := make(map[string]bool)
+	publishNodeIDs := make([]string, 0)
+	for _, remote := range flattenImportDocs(parseResp.Docs) {
+		if seen[remote.ID] {
+			continue
+		}
+		seen[remote.ID] = true
+		title := remote.Title
+		if title == "" {
+			title = remote.ID
+		}
+		syncDoc := domain.ImportSyncDoc{RemoteID: remote.ID, Title: title}
+
+		exportCtx, cancel := context.WithTimeout(ctx, importDocExportTimeout)
+		content, err := u.crawlerUsecase.ExportDocContent(exportCtx, &v1.CrawlerExportReq{
+			KbID:     sub.KBID,
+			ID:       parseResp.ID,
+			DocID:    remote.ID,
+			SpaceId:  sub.Setting.FeishuSpaceID,
+			FileType: remote.FileType,
+		})
+		cancel()
+		if err != nil {
+			syncDoc.Error = err.Error()
+			result.Failed = append(result.Failed, syncDoc)
+			continue
+		}
+		hash := sha256.Sum256([]byte(content))
+		contentHash := hex.EncodeToString(hash[:])
+
+		doc, exists := docMap[remote.ID]
+		if exists && !doc.Removed && doc.ContentHash == contentHash && doc.Title == title {
+			continue
+		}
+		nodeID := ""
+		if exists {
+			var edited bool
+			edited, err = u.isImportedNodeEdited(ctx, sub, doc)
+			if err != nil {
+				syncDoc.Error = err.Error()
+				result.Failed = append(result.Failed, syncDoc)
+				continue
+			}
+			if edited {
+				syncDoc.NodeID = doc.NodeID
+				result.Conflicted = append(result.Conflicted, syncDoc)
+				continue
+			}
+			nodeID, err = u.updateImportedNode(ctx, sub, doc.NodeID, title, content)
+		} else {
+			nodeID, err = u.createImportedNode(ctx, sub, title, content)
+		}
+		if err != nil {
+			syncDoc.Error = err.Error()
+			result.Failed = append(result.Failed, syncDoc)
+			continue
+		}
+		syncDoc.NodeID = nodeID
+		if err := u.repo.UpsertDoc(ctx, &domain.ImportSubscriptionDoc{
+			SubscriptionID: sub.ID,
+			RemoteID:       remote.ID,
+			NodeID:         nodeID,
+			Title:          title,
+			ContentHash:    contentHash,
+		}); err != nil {
+			return result, err
+		}
+		if exists {
+			result.Changed = append(result.Changed, syncDoc)
+		} else {
+			result.Added = append(result.Added, syncDoc)
+		}
+		publishNodeIDs = append(publishNodeIDs, nodeID)
+	}
+
+	removedIDs := make([]string, 0)
+	for _, doc := range docs {
+		if seen[doc.RemoteID] || doc.Removed {
+			continue
+		}
+		removedIDs = append(removedIDs, doc.RemoteID)
+		result.Removed = append(result.Removed, domain.ImportSyncDoc{
+			RemoteID: doc.RemoteID,
+			Title:    doc.Title,
+			NodeID:   doc.NodeID,
+		})
+	}
+	if err := u.repo.MarkDocsRemoved(ctx, sub.ID, removedIDs); err != nil {
+		return result, err
+	}
+
+	if sub.AutoPublish && len(publishNodeIDs) > 0 {
+		if _, err := u.kbUsecase.CreateKBRelease(ctx, &domain.CreateKBReleaseReq{
+			KBID:    sub.KBID,
+			Message: fmt.Sprintf("同步导入源 %s", sub.Name),
+			Tag:     fmt.Sprintf("sync-%s", time.Now().Format("20060102150405")),
+			NodeIDs: publishNodeIDs,
+		}, sub.CreatorID); err != nil {
+			return result, fmt.Errorf("publish synced nodes failed: %w", err)
+		}
+	}
+	return result, nil
+}
+
+func (u *ImportSubscriptionUsecase) createImportedNode(ctx context.Context, sub *domain.ImportSubscription, title, content string) (string, error) {
+	return u.nodeRepo.Create(ctx, &domain.CreateNodeReq{
+		KBID:        sub.KBID,
+		ParentID:    sub.ParentID,
+		Type:        domain.NodeTypeDocument,
+		Name:        title,
+		Content:     content,
+		ContentType: lo.ToPtr(domain.ContentTypeMD),
+	}, sub.CreatorID)
+}
+
+// isImportedNodeEdited reports whether the node differs from what the last sync wrote, a deleted node is not edited
+func (u *ImportSubscriptionUsecase) isImportedNodeEdited(ctx context.Context, sub *domain.ImportSubscription, doc *domain.ImportSubscriptionDoc) (bool, error) {
+	node, err := u.nodeRepo.GetByID(ctx, doc.NodeID, sub.KBID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	hash := sha256.Sum256([]byte(node.Content))
+	return node.Name != doc.Title || hex.EncodeToString(hash[:]) != doc.ContentHash, nil
+}
+
+// updateImportedNode updates the node as draft, the node is created again if it has been deleted
+func (u *ImportSubscriptionUsecase) updateImportedNode(ctx context.Context, sub *domain.ImportSubscription, nodeID, title, content string) (string, error) {
+	err := u.nodeRepo.UpdateNodeContent(ctx, &domain.UpdateNodeReq{
+		ID:      nodeID,
+		KBID:    sub.KBID,
+		Name:    &title,
+		Content: &content,
+	}, sub.CreatorID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return u.createImportedNode(ctx, sub, title, content)
+	}
+	if err != nil {
+		return "", err
+	}
+	return nodeID, nil
+}
+
+// flattenImportDocs returns the documents in the parsed tree, folders are skipped
+func flattenImportDocs(child anydoc.Child) []anydoc.Value {
+	docs := make([]anydoc.Value, 0)
+	if child.Value.ID != "" && (child.Value.File || len(child.Children) == 0) {
+		docs = append(docs, child.Value)
+	}
+	for _, c := range child.Children {
+		docs = append(docs, flattenImportDocs(c)...)
+	}
+	return docs
+}
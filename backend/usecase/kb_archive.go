@@ -0,0 +1,590 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/samber/lo"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/store/s3"
+	"github.com/chaitin/panda-wiki/utils"
+)
+
+// staticFileRefRegex matches references to uploaded files in node content and app settings,
+// both `/static-file/<key>` and absolute urls like `http://panda-wiki-minio:9000/static-file/<key>`
+var staticFileRefRegex = regexp.MustCompile(`(?:https?://[^/\s"'()<>]+)?/static-file/([^\s"'()<>\[\]?#\\]+)`)
+
+// replaceStaticFileRefs rewrites each file reference in text, replace receives the s3 key
+// and returns the new reference or false to keep it as is
+func replaceStaticFileRefs(text string, replace func(key string) (string, bool)) string {
+	return staticFileRefRegex.ReplaceAllStringFunc(text, func(ref string) string {
+		if newRef, ok := replace(staticFileRefRegex.FindStringSubmatch(ref)[1]); ok {
+			return newRef
+		}
+		return ref
+	})
+}
+
+type KBArchiveUsecase struct {
+	nodeRepo    *pg.NodeRepository
+	appRepo     *pg.AppRepository
+	authRepo    *pg.AuthRepo
+	kbUsecase   *KnowledgeBaseUsecase
+	appUsecase  *AppUsecase
+	fileUsecase *FileUsecase
+	s3Client    *s3.MinioClient
+	logger      *log.Logger
+}
+
+func NewKBArchiveUsecase(
+	nodeRepo *pg.NodeRepository,
+	appRepo *pg.AppRepository,
+	authRepo *pg.AuthRepo,
+	kbUsecase *KnowledgeBaseUsecase,
+	appUsecase *AppUsecase,
+	fileUsecase *FileUsecase,
+	s3Client *s3.MinioClient,
+	logger *log.Logger,
+) *KBArchiveUsecase {
+	return &KBArchiveUsecase{
+		nodeRepo:    nodeRepo,
+		appRepo:     appRepo,
+		authRepo:    authRepo,
+		kbUsecase:   kbUsecase,
+		appUsecase:  appUsecase,
+		fileUsecase: fileUsecase,
+		s3Client:    s3Client,
+		logger:      logger.WithModule("usecase.kb_archive"),
+	}
+}
+
+// Export writes the knowledge base as a zip archive: manifest.json with apps and auth groups,
+// one markdown or html file with front-matter per node and the uploaded files they reference
+func (u *KBArchiveUsecase) Export(ctx context.Context, kbID string, w io.Writer) error {
+	kb, err := u.kbUsecase.GetKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return fmt.Errorf("get knowledge base failed: %w", err)
+	}
+	nodes, err := u.nodeRepo.GetNodesByKBID(ctx, kbID)
+	if err != nil {
+		return fmt.Errorf("get nodes failed: %w", err)
+	}
+	published := make(map[string]bool)
+	if len(nodes) > 0 {
+		releases, err := u.nodeRepo.GetLatestNodeReleaseByNodeIDs(ctx, kbID, lo.Map(nodes, func(node *domain.Node, _ int) string {
+			return node.ID
+		}))
+		if err != nil {
+			return fmt.Errorf("get node releases failed: %w", err)
+		}
+		for _, release := range releases {
+			published[release.NodeID] = true
+		}
+	}
+	nodeGroups, err := u.nodeRepo.GetNodeAuthGroupsByKBID(ctx, kbID)
+	if err != nil {
+		return fmt.Errorf("get node auth groups failed: %w", err)
+	}
+	nodeGroupMap := make(map[string]map[consts.NodePermName][]uint)
+	for _, nodeGroup := range nodeGroups {
+		if nodeGroupMap[nodeGroup.NodeID] == nil {
+			nodeGroupMap[nodeGroup.NodeID] = make(map[consts.NodePermName][]uint)
+		}
+		nodeGroupMap[nodeGroup.NodeID][nodeGroup.Perm] = append(nodeGroupMap[nodeGroup.NodeID][nodeGroup.Perm], uint(nodeGroup.AuthGroupID))
+	}
+	apps, err := u.appRepo.GetAppList(ctx, kbID)
+	if err != nil {
+		return fmt.Errorf("get apps failed: %w", err)
+	}
+	authGroups, err := u.authRepo.ListAuthGroups(ctx, kbID)
+	if err != nil {
+		return fmt.Errorf("get auth groups failed: %w", err)
+	}
+
+	assetKeys := make(map[string]bool)
+	collectAssets := func(text string) {
+		for _, match := range staticFileRefRegex.FindAllStringSubmatch(text, -1) {
+			assetKeys[match[1]] = true
+		}
+	}
+
+	zw := zip.NewWriter(w)
+	for _, node := range nodes {
+		collectAssets(node.Content)
+		data, err := utils.MarshalFrontMatter(&domain.KBArchiveNode{
+			ID:          node.ID,
+			ParentID:    node.ParentID,
+			Type:        node.Type,
+			Name:        node.Name,
+			Position:    node.Position,
+			Published:   published[node.ID],
+			Summary:     node.Meta.Summary,
+			Emoji:       node.Meta.Emoji,
+			ContentType: node.Meta.ContentType,
+			Permissions: node.Permissions,
+			AuthGroups:  nodeGroupMap[node.ID],
+		}, node.Content)
+		if err != nil {
+			return fmt.Errorf("marshal node %s failed: %w", node.ID, err)
+		}
+		ext := ".md"
+		if node.Meta.ContentType == domain.ContentTypeHTML {
+			ext = ".html"
+		}
+		if err := writeZipFile(zw, domain.KBArchiveNodeDir+node.ID+ext, data); err != nil {
+			return err
+		}
+	}
+
+	manifest := &domain.KBArchiveManifest{
+		Version:    domain.KBArchiveVersion,
+		KBName:     kb.Name,
+		ExportedAt: time.Now(),
+		Apps:       make([]domain.KBArchiveApp, 0, len(apps)),
+		AuthGroups: make([]domain.KBArchiveAuthGroup, 0, len(authGroups)),
+	}
+	for _, app := range apps {
+		// bot credentials stay with the source knowledge base
+		copyAppCredentials(&app.Settings, &domain.AppSettings{})
+		settings, err := json.Marshal(app.Settings)
+		if err != nil {
+			return fmt.Errorf("marshal app %s settings failed: %w", app.ID, err)
+		}
+		collectAssets(string(settings))
+		manifest.Apps = append(manifest.Apps, domain.KBArchiveApp{
+			Name:     app.Name,
+			Type:     app.Type,
+			Settings: app.Settings,
+		})
+	}
+	sort.Slice(manifest.Apps, func(i, j int) bool {
+		return manifest.Apps[i].Type < manifest.Apps[j].Type
+	})
+	for _, group := range authGroups {
+		manifest.AuthGroups = append(manifest.AuthGroups, domain.KBArchiveAuthGroup{
+			ID:         group.ID,
+			Name:       group.Name,
+			ParentID:   group.ParentID,
+			Position:   group.Position,
+			SourceType: group.SourceType,
+		})
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal manifest failed: %w", err)
+	}
+	if err := writeZipFile(zw, domain.KBArchiveManifestFile, manifestData); err != nil {
+		return err
+	}
+
+	keys := lo.Keys(assetKeys)
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := u.exportAsset(ctx, zw, key); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func (u *KBArchiveUsecase) exportAsset(ctx context.Context, zw *zip.Writer, key string) error {
+	found, err := writeStaticFileToZip(ctx, u.s3Client, zw, domain.KBArchiveAssetDir+key, key)
+	if err != nil {
+		return err
+	}
+	if !found {
+		u.logger.Warn("referenced file not found, skip it", log.String("key", key))
+	}
+	return nil
+}
+
+// writeStaticFileToZip copies an uploaded file into the zip, returns false if the file does not exist
+func writeStaticFileToZip(ctx context.Context, s3Client *s3.MinioClient, zw *zip.Writer, name, key string) (bool, error) {
+	// keep entries inside their directory when the archive is extracted
+	if strings.Contains(key, "..") {
+		return false, nil
+	}
+	if _, err := s3Client.StatObject(ctx, domain.Bucket, key, minio.StatObjectOptions{}); err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return false, nil
+		}
+		return false, fmt.Errorf("stat file %s failed: %w", key, err)
+	}
+	object, err := s3Client.GetObject(ctx, domain.Bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return false, fmt.Errorf("get file %s failed: %w", key, err)
+	}
+	defer object.Close()
+	fw, err := zw.Create(name)
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(fw, object); err != nil {
+		return false, fmt.Errorf("copy file %s failed: %w", key, err)
+	}
+	return true, nil
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(data)
+	return err
+}
+
+type kbArchiveNodeFile struct {
+	meta    domain.KBArchiveNode
+	content string
+}
+
+// Import recreates the nodes, auth groups, apps and files of an archive in the knowledge base with new ids,
+// nodes published in the source knowledge base are released so that they are indexed again
+func (u *KBArchiveUsecase) Import(ctx context.Context, kbID string, file *multipart.FileHeader, userID string) (*v1.KBImportResp, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open archive failed: %w", err)
+	}
+	defer src.Close()
+	zr, err := zip.NewReader(src, file.Size)
+	if err != nil {
+		return nil, fmt.Errorf("read archive failed: %w", err)
+	}
+
+	var manifest domain.KBArchiveManifest
+	nodeFiles := make([]kbArchiveNodeFile, 0)
+	assetFiles := make([]*zip.File, 0)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		switch {
+		case f.Name == domain.KBArchiveManifestFile:
+			data, err := readZipFile(f)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				return nil, fmt.Errorf("parse manifest failed: %w", err)
+			}
+		case strings.HasPrefix(f.Name, domain.KBArchiveNodeDir):
+			data, err := readZipFile(f)
+			if err != nil {
+				return nil, err
+			}
+			var nodeFile kbArchiveNodeFile
+			if nodeFile.content, err = utils.ParseFrontMatter(data, &nodeFile.meta); err != nil {
+				return nil, fmt.Errorf("parse node file %s failed: %w", f.Name, err)
+			}
+			if nodeFile.meta.ID == "" || nodeFile.meta.Name == "" {
+				return nil, fmt.Errorf("node file %s has no id or name", f.Name)
+			}
+			if nodeFile.meta.Type != domain.NodeTypeFolder && nodeFile.meta.Type != domain.NodeTypeDocument {
+				return nil, fmt.Errorf("node file %s has invalid type %d", f.Name, nodeFile.meta.Type)
+			}
+			nodeFiles = append(nodeFiles, nodeFile)
+		case strings.HasPrefix(f.Name, domain.KBArchiveAssetDir):
+			assetFiles = append(assetFiles, f)
+		}
+	}
+	if manifest.Version == 0 {
+		return nil, fmt.Errorf("%s not found in archive", domain.KBArchiveManifestFile)
+	}
+	if manifest.Version > domain.KBArchiveVersion {
+		return nil, fmt.Errorf("archive version %d is not supported", manifest.Version)
+	}
+	// check the limit before anything is written
+	maxNode := domain.GetBaseEditionLimitation(ctx).MaxNode
+	if err := u.nodeRepo.CheckNodeLimit(ctx, kbID, len(nodeFiles), maxNode); err != nil {
+		return nil, err
+	}
+
+	groups, groupIndexMap, err := u.importAuthGroups(ctx, kbID, manifest.AuthGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	assetKeyMap := make(map[string]string)
+	imported := false
+	// uploaded files are removed if the nodes are not created
+	defer func() {
+		if imported {
+			return
+		}
+		for _, key := range assetKeyMap {
+			if err := u.s3Client.RemoveObject(context.Background(), domain.Bucket, key, minio.RemoveObjectOptions{}); err != nil {
+				u.logger.Warn("remove imported file failed", log.String("key", key), log.Error(err))
+			}
+		}
+	}()
+	for _, f := range assetFiles {
+		oldKey := strings.TrimPrefix(f.Name, domain.KBArchiveAssetDir)
+		newKey, err := u.importAsset(ctx, kbID, f)
+		if err != nil {
+			return nil, fmt.Errorf("import file %s failed: %w", oldKey, err)
+		}
+		assetKeyMap[oldKey] = newKey
+	}
+	replaceAssets := func(text string) string {
+		return replaceStaticFileRefs(text, func(key string) (string, bool) {
+			newKey, ok := assetKeyMap[key]
+			return "/static-file/" + newKey, ok
+		})
+	}
+
+	nodeIDMap := make(map[string]string, len(nodeFiles))
+	for _, nodeFile := range nodeFiles {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return nil, err
+		}
+		nodeIDMap[nodeFile.meta.ID] = id.String()
+	}
+	now := time.Now()
+	nodes := make([]*domain.Node, 0, len(nodeFiles))
+	nodeGroups := make([]domain.NodeAuthGroup, 0)
+	publishNodeIDs := make([]string, 0)
+	for _, nodeFile := range nodeFiles {
+		meta := nodeFile.meta
+		nodeID := nodeIDMap[meta.ID]
+		nodes = append(nodes, &domain.Node{
+			ID:        nodeID,
+			KBID:      kbID,
+			Type:      meta.Type,
+			Status:    domain.NodeStatusDraft,
+			Name:      meta.Name,
+			Content:   replaceAssets(nodeFile.content),
+			ParentID:  nodeIDMap[meta.ParentID],
+			Position:  meta.Position,
+			CreatorId: userID,
+			EditorId:  userID,
+			EditTime:  now,
+			CreatedAt: now,
+			UpdatedAt: now,
+			Meta: domain.NodeMeta{
+				Summary:     meta.Summary,
+				Emoji:       meta.Emoji,
+				ContentType: meta.ContentType,
+			},
+			RagInfo: domain.RagInfo{
+				Status: consts.NodeRagStatusPending,
+			},
+			Permissions: meta.Permissions,
+		})
+		for perm, groupIDs := range meta.AuthGroups {
+			for _, groupID := range groupIDs {
+				if groupIndex, ok := groupIndexMap[groupID]; ok {
+					nodeGroups = append(nodeGroups, domain.NodeAuthGroup{
+						NodeID:      nodeID,
+						AuthGroupID: groupIndex,
+						Perm:        perm,
+						CreatedAt:   now,
+					})
+				}
+			}
+		}
+		if meta.Published {
+			publishNodeIDs = append(publishNodeIDs, nodeID)
+		}
+	}
+	if err := u.nodeRepo.ImportNodes(ctx, kbID, groups, nodes, nodeGroups, maxNode); err != nil {
+		return nil, fmt.Errorf("create nodes failed: %w", err)
+	}
+	imported = true
+
+	resp := &v1.KBImportResp{
+		Nodes:      len(nodes),
+		AuthGroups: len(groups),
+		Assets:     len(assetKeyMap),
+	}
+	for _, archiveApp := range manifest.Apps {
+		imported, err := u.importApp(ctx, kbID, archiveApp, replaceAssets)
+		if err != nil {
+			return nil, fmt.Errorf("import app %s failed: %w", archiveApp.Name, err)
+		}
+		if imported {
+			resp.Apps++
+		}
+	}
+
+	if len(publishNodeIDs) > 0 {
+		releaseID, err := u.kbUsecase.CreateKBRelease(ctx, &domain.CreateKBReleaseReq{
+			KBID:    kbID,
+			Message: fmt.Sprintf("导入知识库 %s", manifest.KBName),
+			Tag:     fmt.Sprintf("import-%s", now.Format("20060102150405")),
+			NodeIDs: publishNodeIDs,
+		}, userID)
+		if err != nil {
+			return nil, fmt.Errorf("publish imported nodes failed: %w", err)
+		}
+		resp.ReleaseID = releaseID
+	}
+	return resp, nil
+}
+
+// importAuthGroups builds the auth groups parents first, they are created together with the nodes.
+// It returns the groups and the map from archive ids to their index, a suffix is added to names
+// already used in the knowledge base
+func (u *KBArchiveUsecase) importAuthGroups(ctx context.Context, kbID string, groups []domain.KBArchiveAuthGroup) ([]*domain.AuthGroup, map[uint]int, error) {
+	existing, err := u.authRepo.ListAuthGroups(ctx, kbID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get auth groups failed: %w", err)
+	}
+	names := lo.SliceToMap(existing, func(group domain.AuthGroup) (string, bool) {
+		return group.Name, true
+	})
+	archiveGroups := lo.KeyBy(groups, func(group domain.KBArchiveAuthGroup) uint {
+		return group.ID
+	})
+
+	authGroups := make([]*domain.AuthGroup, 0, len(groups))
+	indexMap := make(map[uint]int, len(groups))
+	var add func(group domain.KBArchiveAuthGroup, depth int)
+	add = func(group domain.KBArchiveAuthGroup, depth int) {
+		if _, ok := indexMap[group.ID]; ok {
+			return
+		}
+		var parent *domain.AuthGroup
+		// depth guards against parent cycles in a malformed archive
+		if group.ParentID != nil && depth < len(groups) {
+			if archiveParent, ok := archiveGroups[*group.ParentID]; ok {
+				add(archiveParent, depth+1)
+				if i, ok := indexMap[archiveParent.ID]; ok {
+					parent = authGroups[i]
+				}
+			}
+		}
+		name := group.Name
+		for i := 2; names[name]; i++ {
+			name = fmt.Sprintf("%s-%d", group.Name, i)
+		}
+		names[name] = true
+		indexMap[group.ID] = len(authGroups)
+		authGroups = append(authGroups, &domain.AuthGroup{
+			Name:       name,
+			KbID:       kbID,
+			Parent:     parent,
+			Position:   group.Position,
+			SourceType: group.SourceType,
+		})
+	}
+	for _, group := range groups {
+		add(group, 0)
+	}
+	return authGroups, indexMap, nil
+}
+
+func (u *KBArchiveUsecase) importAsset(ctx context.Context, kbID string, f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	return u.fileUsecase.UploadFileFromReader(ctx, kbID, path.Base(f.Name), rc, int64(f.UncompressedSize64))
+}
+
+// importApp overwrites the settings of the app with the same type,
+// returns false if the settings are not allowed by the current edition
+func (u *KBArchiveUsecase) importApp(ctx context.Context, kbID string, archiveApp domain.KBArchiveApp, replaceAssets func(string) string) (bool, error) {
+	app, err := u.appRepo.GetOrCreateAppByKBIDAndType(ctx, kbID, archiveApp.Type)
+	if err != nil {
+		return false, err
+	}
+	data, err := json.Marshal(archiveApp.Settings)
+	if err != nil {
+		return false, err
+	}
+	var settings domain.AppSettings
+	if err := json.Unmarshal([]byte(replaceAssets(string(data))), &settings); err != nil {
+		return false, err
+	}
+	// keep the credentials of the target app so that bots of the source knowledge base are never started here
+	copyAppCredentials(&settings, &app.Settings)
+	req := &domain.UpdateAppReq{
+		Name:     &archiveApp.Name,
+		KbID:     kbID,
+		Settings: &settings,
+	}
+	if err := u.appUsecase.ValidateUpdateApp(ctx, app.ID, req); err != nil {
+		u.logger.Warn("app settings are not allowed, skip it", log.String("kb_id", kbID), log.Int("type", int(archiveApp.Type)), log.Error(err))
+		return false, nil
+	}
+	if err := u.appUsecase.UpdateApp(ctx, app.ID, req); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// copyAppCredentials copies the bot credentials and their switches from src to dst
+func copyAppCredentials(dst, src *domain.AppSettings) {
+	dst.DingTalkBotIsEnabled = src.DingTalkBotIsEnabled
+	dst.DingTalkBotClientID = src.DingTalkBotClientID
+	dst.DingTalkBotClientSecret = src.DingTalkBotClientSecret
+	dst.DingTalkBotTemplateID = src.DingTalkBotTemplateID
+	dst.FeishuBotIsEnabled = src.FeishuBotIsEnabled
+	dst.FeishuBotAppID = src.FeishuBotAppID
+	dst.FeishuBotAppSecret = src.FeishuBotAppSecret
+	dst.LarkBotSettings = src.LarkBotSettings
+	dst.SlackBotSettings = src.SlackBotSettings
+	dst.TeamsBotSettings = src.TeamsBotSettings
+	dst.TelegramBotSettings = src.TelegramBotSettings
+	dst.WeChatAppIsEnabled = src.WeChatAppIsEnabled
+	dst.WeChatAppToken = src.WeChatAppToken
+	dst.WeChatAppEncodingAESKey = src.WeChatAppEncodingAESKey
+	dst.WeChatAppCorpID = src.WeChatAppCorpID
+	dst.WeChatAppSecret = src.WeChatAppSecret
+	dst.WeChatAppAgentID = src.WeChatAppAgentID
+	dst.WecomAIBotSettings = src.WecomAIBotSettings
+	dst.WeChatServiceIsEnabled = src.WeChatServiceIsEnabled
+	dst.WeChatServiceToken = src.WeChatServiceToken
+	dst.WeChatServiceEncodingAESKey = src.WeChatServiceEncodingAESKey
+	dst.WeChatServiceCorpID = src.WeChatServiceCorpID
+	dst.WeChatServiceSecret = src.WeChatServiceSecret
+	dst.DiscordBotIsEnabled = src.DiscordBotIsEnabled
+	dst.DiscordBotToken = src.DiscordBotToken
+	dst.WechatOfficialAccountIsEnabled = src.WechatOfficialAccountIsEnabled
+	dst.WechatOfficialAccountAppID = src.WechatOfficialAccountAppID
+	dst.WechatOfficialAccountAppSecret = src.WechatOfficialAccountAppSecret
+	dst.WechatOfficialAccountToken = src.WechatOfficialAccountToken
+	dst.WechatOfficialAccountEncodingAESKey = src.WechatOfficialAccountEncodingAESKey
+	dst.OpenAIAPIBotSettings = src.OpenAIAPIBotSettings
+	dst.MCPServerSettings.IsEnabled = src.MCPServerSettings.IsEnabled
+	dst.MCPServerSettings.SampleAuth = src.MCPServerSettings.SampleAuth
+}
+
+// kbArchiveMaxFileSize limits the manifest and node files read into memory
+const kbArchiveMaxFileSize = 32 << 20
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open %s failed: %w", f.Name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, kbArchiveMaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read %s failed: %w", f.Name, err)
+	}
+	if len(data) > kbArchiveMaxFileSize {
+		return nil, fmt.Errorf("%s exceeds %d bytes", f.Name, kbArchiveMaxFileSize)
+	}
+	return data, nil
+}
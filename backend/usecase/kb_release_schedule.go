@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+)
+
+type KBReleaseScheduleUsecase struct {
+	repo      *pg.KBReleaseScheduleRepository
+	userRepo  *pg.UserRepository
+	kbUsecase *KnowledgeBaseUsecase
+	logger    *log.Logger
+}
+
+func NewKBReleaseScheduleUsecase(
+	repo *pg.KBReleaseScheduleRepository,
+	userRepo *pg.UserRepository,
+	kbUsecase *KnowledgeBaseUsecase,
+	logger *log.Logger,
+) *KBReleaseScheduleUsecase {
+	return &KBReleaseScheduleUsecase{
+		repo:      repo,
+		userRepo:  userRepo,
+		kbUsecase: kbUsecase,
+		logger:    logger.WithModule("usecase.kb_release_schedule"),
+	}
+}
+
+func (u *KBReleaseScheduleUsecase) Create(ctx context.Context, req *v1.KBReleaseScheduleCreateReq, userID string) (string, error) {
+	if !req.ScheduledAt.After(time.Now()) {
+		return "", errors.New("scheduled time must be in the future")
+	}
+	now := time.Now()
+	schedule := &domain.KBReleaseSchedule{
+		ID:          uuid.New().String(),
+		KBID:        req.KBId,
+		NodeIDs:     pq.StringArray(lo.Uniq(req.NodeIDs)),
+		Tag:         req.Tag,
+		Message:     req.Message,
+		ScheduledAt: req.ScheduledAt,
+		CreatorID:   userID,
+		Status:      domain.KBReleaseScheduleStatusPending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := u.repo.Create(ctx, schedule); err != nil {
+		return "", err
+	}
+	return schedule.ID, nil
+}
+
+func (u *KBReleaseScheduleUsecase) GetList(ctx context.Context, req *v1.KBReleaseScheduleListReq) ([]*v1.KBReleaseScheduleListItem, error) {
+	schedules, err := u.repo.GetList(ctx, req.KBId, req.Status)
+	if err != nil {
+		return nil, err
+	}
+	accountMap, err := u.userRepo.GetUsersAccountMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(schedules, func(schedule *domain.KBReleaseSchedule, _ int) *v1.KBReleaseScheduleListItem {
+		return &v1.KBReleaseScheduleListItem{
+			KBReleaseSchedule: *schedule,
+			CreatorAccount:    accountMap[schedule.CreatorID],
+		}
+	}), nil
+}
+
+// Update reschedules a pending or failed schedule, a failed one is pending again afterwards
+func (u *KBReleaseScheduleUsecase) Update(ctx context.Context, req *v1.KBReleaseScheduleUpdateReq) error {
+	updateMap := map[string]any{
+		"status": domain.KBReleaseScheduleStatusPending,
+		"error":  "",
+	}
+	if req.NodeIDs != nil {
+		updateMap["node_ids"] = pq.StringArray(lo.Uniq(req.NodeIDs))
+	}
+	if req.Tag != nil {
+		if *req.Tag == "" {
+			return errors.New("tag can not be empty")
+		}
+		updateMap["tag"] = *req.Tag
+	}
+	if req.Message != nil {
+		if *req.Message == "" {
+			return errors.New("message can not be empty")
+		}
+		updateMap["message"] = *req.Message
+	}
+	if req.ScheduledAt != nil {
+		if !req.ScheduledAt.After(time.Now()) {
+			return errors.New("scheduled time must be in the future")
+		}
+		updateMap["scheduled_at"] = *req.ScheduledAt
+	}
+	err := u.repo.UpdateIfStatus(ctx, req.KBId, req.ID, domain.KBReleaseScheduleStatusesTo(domain.KBReleaseScheduleStatusPending), updateMap)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("schedule not found or has been executed")
+	}
+	return err
+}
+
+func (u *KBReleaseScheduleUsecase) Cancel(ctx context.Context, kbID, id string) error {
+	err := u.repo.UpdateIfStatus(ctx, kbID, id, domain.KBReleaseScheduleStatusesTo(domain.KBReleaseScheduleStatusCanceled), map[string]any{
+		"status": domain.KBReleaseScheduleStatusCanceled,
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("schedule not found or has been executed")
+	}
+	return err
+}
+
+// RunDueSchedules creates the releases whose scheduled time has come,
+// schedules interrupted while running are marked failed first
+func (u *KBReleaseScheduleUsecase) RunDueSchedules(ctx context.Context) error {
+	now := time.Now()
+	failed, err := u.repo.FailStaleRuns(ctx, now.Add(-domain.KBReleaseScheduleRunTimeout), "release was interrupted, please reschedule it")
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		u.logger.Warn("interrupted scheduled releases marked failed", log.Int("count", int(failed)))
+	}
+	schedules, err := u.repo.GetDueList(ctx, now)
+	if err != nil {
+		return err
+	}
+	for _, schedule := range schedules {
+		claimed, err := u.repo.ClaimRun(ctx, schedule.ID)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		u.run(ctx, schedule)
+	}
+	return nil
+}
+
+func (u *KBReleaseScheduleUsecase) run(ctx context.Context, schedule *domain.KBReleaseSchedule) {
+	releaseID, err := u.kbUsecase.CreateKBRelease(ctx, &domain.CreateKBReleaseReq{
+		KBID:    schedule.KBID,
+		Message: schedule.Message,
+		Tag:     schedule.Tag,
+		NodeIDs: schedule.NodeIDs,
+	}, schedule.CreatorID)
+	status, runErr := domain.KBReleaseScheduleStatusSucceeded, ""
+	if err != nil {
+		status, runErr = domain.KBReleaseScheduleStatusFailed, err.Error()
+		u.logger.Error("run scheduled release failed", log.String("schedule_id", schedule.ID), log.String("kb_id", schedule.KBID), log.Error(err))
+	} else {
+		u.logger.Info("scheduled release created", log.String("schedule_id", schedule.ID), log.String("kb_id", schedule.KBID), log.String("release_id", releaseID))
+	}
+	finished, err := u.repo.FinishRun(ctx, schedule.ID, status, releaseID, runErr)
+	if err != nil {
+		u.logger.Error("finish scheduled release failed", log.String("schedule_id", schedule.ID), log.Error(err))
+		return
+	}
+	if !finished {
+		u.logger.Warn("scheduled release run is stale, result is dropped", log.String("schedule_id", schedule.ID), log.String("status", string(status)), log.String("release_id", releaseID))
+	}
+}
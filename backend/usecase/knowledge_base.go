@@ -21,26 +21,28 @@ import (
 )
 
 type KnowledgeBaseUsecase struct {
-	repo     *pg.KnowledgeBaseRepository
-	nodeRepo *pg.NodeRepository
-	ragRepo  *mq.RAGRepository
-	userRepo *pg.UserRepository
-	rag      rag.RAGService
-	kbCache  *cache.KBRepo
-	logger   *log.Logger
-	config   *config.Config
+	repo        *pg.KnowledgeBaseRepository
+	nodeRepo    *pg.NodeRepository
+	ragRepo     *mq.RAGRepository
+	webhookRepo *mq.WebhookRepository
+	userRepo    *pg.UserRepository
+	rag         rag.RAGService
+	kbCache     *cache.KBRepo
+	logger      *log.Logger
+	config      *config.Config
 }
 
-func NewKnowledgeBaseUsecase(repo *pg.KnowledgeBaseRepository, nodeRepo *pg.NodeRepository, ragRepo *mq.RAGRepository, userRepo *pg.UserRepository, rag rag.RAGService, kbCache *cache.KBRepo, logger *log.Logger, config *config.Config) (*KnowledgeBaseUsecase, error) {
+func NewKnowledgeBaseUsecase(repo *pg.KnowledgeBaseRepository, nodeRepo *pg.NodeRepository, ragRepo *mq.RAGRepository, userRepo *pg.UserRepository, rag rag.RAGService, kbCache *cache.KBRepo, logger *log.Logger, config *config.Config, webhookRepo *mq.WebhookRepository) (*KnowledgeBaseUsecase, error) {
 	u := &KnowledgeBaseUsecase{
-		repo:     repo,
-		nodeRepo: nodeRepo,
-		ragRepo:  ragRepo,
-		userRepo: userRepo,
-		rag:      rag,
-		logger:   logger.WithModule("usecase.knowledge_base"),
-		config:   config,
-		kbCache:  kbCache,
+		repo:        repo,
+		nodeRepo:    nodeRepo,
+		ragRepo:     ragRepo,
+		webhookRepo: webhookRepo,
+		userRepo:    userRepo,
+		rag:         rag,
+		logger:      logger.WithModule("usecase.knowledge_base"),
+		config:      config,
+		kbCache:     kbCache,
 	}
 	return u, nil
 }
@@ -205,6 +207,16 @@ func (u *KnowledgeBaseUsecase) CreateKBRelease(ctx context.Context, req *domain.
 		return "", fmt.Errorf("failed to create kb release: %w", err)
 	}
 
+	if err := u.webhookRepo.AsyncTriggerEvent(ctx, req.KBID, domain.WebhookEventKBReleased, &domain.WebhookReleaseEventData{
+		ReleaseID: release.ID,
+		Tag:       release.Tag,
+		Message:   release.Message,
+		NodeIDs:   req.NodeIDs,
+		UserID:    userId,
+	}); err != nil {
+		u.logger.Error("trigger kb released webhook failed", log.String("kb_id", req.KBID), log.Error(err))
+	}
+
 	return release.ID, nil
 }
 
@@ -217,6 +229,29 @@ func (u *KnowledgeBaseUsecase) GetKBReleaseList(ctx context.Context, req *domain
 	return domain.NewPaginatedResult(releases, uint64(total)), nil
 }
 
+func (u *KnowledgeBaseUsecase) GetRetrievalSettings(ctx context.Context, req v1.KBRetrievalSettingsReq) (*v1.KBRetrievalSettingsResp, error) {
+	settings, err := u.repo.GetRetrievalSettings(ctx, req.KBId)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.KBRetrievalSettingsResp{RetrievalSettings: *settings}, nil
+}
+
+func (u *KnowledgeBaseUsecase) UpdateRetrievalSettings(ctx context.Context, req v1.KBRetrievalSettingsUpdateReq) error {
+	settings := req.RetrievalSettings
+	defaults := domain.DefaultRetrievalSettings()
+	if settings.Mode == "" {
+		settings.Mode = defaults.Mode
+	}
+	if settings.KeywordTopK == 0 {
+		settings.KeywordTopK = defaults.KeywordTopK
+	}
+	if settings.RRFK == 0 {
+		settings.RRFK = defaults.RRFK
+	}
+	return u.repo.UpdateRetrievalSettings(ctx, req.KBId, &settings)
+}
+
 func (u *KnowledgeBaseUsecase) GetKBUserList(ctx context.Context, req v1.KBUserListReq) ([]v1.KBUserListItemResp, error) {
 	users, err := u.repo.GetKBUserlist(ctx, req.KBId)
 	if err != nil {
@@ -65,76 +65,86 @@ func (u *LLMUsecase) BuildConversationMessageWithRAG(
 	groupIDs []int,
 	systemPrompt string,
 ) ([]*schema.Message, []*domain.RankedNodeChunks, error) {
-	messages := make([]*schema.Message, 0)
-	rankedNodes := make([]*domain.RankedNodeChunks, 0)
-
 	msgs, err := u.conversationRepo.GetConversationMessagesByID(ctx, conversationID)
 	if err != nil {
 		u.logger.Error("get conversation messages failed", log.Error(err))
 		return nil, nil, errors.New("get conversation messages failed")
 	}
-	if len(msgs) > 0 {
-		historyMessages := make([]*schema.Message, 0)
-		for _, msg := range msgs {
-			switch msg.Role {
-			case schema.Assistant:
-				historyMessages = append(historyMessages, schema.AssistantMessage(msg.Content, nil))
-			case schema.User:
-				content := u.formatMessageWithImages(msg.Content, msg.ImagePaths)
-				historyMessages = append(historyMessages, schema.UserMessage(content))
-			default:
-				continue
-			}
+	historyMessages := make([]*schema.Message, 0)
+	for _, msg := range msgs {
+		switch msg.Role {
+		case schema.Assistant:
+			historyMessages = append(historyMessages, schema.AssistantMessage(msg.Content, nil))
+		case schema.User:
+			content := u.formatMessageWithImages(msg.Content, msg.ImagePaths)
+			historyMessages = append(historyMessages, schema.UserMessage(content))
+		default:
+			continue
 		}
-		if len(historyMessages) > 0 {
-			question := historyMessages[len(historyMessages)-1].Content
-			var rewrittenQuery string
-			if systemPrompt == "" {
-				if settingPrompt, err := u.promptRepo.GetPrompt(ctx, kbID); err != nil {
-					u.logger.Error("get prompt from settings failed", log.Error(err))
+	}
+	return u.BuildMessagesWithRAG(ctx, kbID, groupIDs, systemPrompt, historyMessages)
+}
+
+// BuildMessagesWithRAG builds the messages answering the last of historyMessages with the retrieved documents,
+// it does not read or write any conversation
+func (u *LLMUsecase) BuildMessagesWithRAG(
+	ctx context.Context,
+	kbID string,
+	groupIDs []int,
+	systemPrompt string,
+	historyMessages []*schema.Message,
+) ([]*schema.Message, []*domain.RankedNodeChunks, error) {
+	messages := make([]*schema.Message, 0)
+	rankedNodes := make([]*domain.RankedNodeChunks, 0)
+	if len(historyMessages) > 0 {
+		question := historyMessages[len(historyMessages)-1].Content
+		var rewrittenQuery string
+		if systemPrompt == "" {
+			if settingPrompt, err := u.promptRepo.GetPrompt(ctx, kbID); err != nil {
+				u.logger.Error("get prompt from settings failed", log.Error(err))
+			} else {
+				if settingPrompt != "" {
+					systemPrompt = settingPrompt
 				} else {
-					if settingPrompt != "" {
-						systemPrompt = settingPrompt
-					} else {
-						systemPrompt = domain.SystemDefaultPrompt
-					}
+					systemPrompt = domain.SystemDefaultPrompt
 				}
 			}
+		}
 
-			template := prompt.FromMessages(schema.GoTemplate,
-				schema.SystemMessage(systemPrompt),
-				schema.UserMessage(domain.UserQuestionFormatter),
-			)
-			kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
-			if err != nil {
-				u.logger.Error("get kb failed", log.Error(err))
-				return nil, nil, errors.New("get kb failed")
-			}
-			rewrittenQuery, rankedNodes, err = u.GetRankNodes(ctx, GetRankNodesRequest{
-				DatasetID:           kb.DatasetID,
-				Question:            question,
-				GroupIDs:            groupIDs,
-				SimilarityThreshold: 0.2,
-				HistoryMessages:     historyMessages[:len(historyMessages)-1],
-			})
-			if err != nil {
-				u.logger.Error("get rank nodes failed", log.Error(err))
-				return nil, nil, errors.New("get rank nodes failed")
-			}
-			documents := domain.FormatNodeChunks(rankedNodes, kb.AccessSettings.BaseURL)
-			u.logger.Debug("documents", log.String("documents", documents))
+		template := prompt.FromMessages(schema.GoTemplate,
+			schema.SystemMessage(systemPrompt),
+			schema.UserMessage(domain.UserQuestionFormatter),
+		)
+		kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+		if err != nil {
+			u.logger.Error("get kb failed", log.Error(err))
+			return nil, nil, errors.New("get kb failed")
+		}
+		rewrittenQuery, rankedNodes, err = u.GetRankNodes(ctx, GetRankNodesRequest{
+			KBID:                kbID,
+			DatasetID:           kb.DatasetID,
+			Question:            question,
+			GroupIDs:            groupIDs,
+			SimilarityThreshold: 0.2,
+			HistoryMessages:     historyMessages[:len(historyMessages)-1],
+		})
+		if err != nil {
+			u.logger.Error("get rank nodes failed", log.Error(err))
+			return nil, nil, errors.New("get rank nodes failed")
+		}
+		documents := domain.FormatNodeChunks(rankedNodes, kb.AccessSettings.BaseURL)
+		u.logger.Debug("documents", log.String("documents", documents))
 
-			formattedMessages, err := template.Format(ctx, map[string]any{
-				"CurrentDate": time.Now().Format("2006-01-02"),
-				"Question":    rewrittenQuery,
-				"Documents":   documents,
-			})
-			if err != nil {
-				u.logger.Error("format messages failed", log.Error(err))
-				return nil, nil, errors.New("format messages failed")
-			}
-			messages = slices.Insert(formattedMessages, 1, historyMessages[:len(historyMessages)-1]...)
+		formattedMessages, err := template.Format(ctx, map[string]any{
+			"CurrentDate": time.Now().Format("2006-01-02"),
+			"Question":    rewrittenQuery,
+			"Documents":   documents,
+		})
+		if err != nil {
+			u.logger.Error("format messages failed", log.Error(err))
+			return nil, nil, errors.New("format messages failed")
 		}
+		messages = slices.Insert(formattedMessages, 1, historyMessages[:len(historyMessages)-1]...)
 	}
 	return messages, rankedNodes, nil
 }
@@ -315,7 +325,11 @@ func (u *LLMUsecase) SplitByTokenLimit(text string, maxTokens int) ([]string, er
 	return result, nil
 }
 
+// keywordSnippetSize is the max characters of the chunk built for nodes only hit by keyword retrieval
+const keywordSnippetSize = 1000
+
 type GetRankNodesRequest struct {
+	KBID                string
 	DatasetID           string
 	Question            string
 	GroupIDs            []int
@@ -339,40 +353,87 @@ func (u *LLMUsecase) GetRankNodes(ctx context.Context, req GetRankNodesRequest)
 		return "", nil, fmt.Errorf("get records from raglite failed: %w", err)
 	}
 	u.logger.Info("get related documents from raglite", log.Any("record_count", len(records)))
-	rankedNodesMap := make(map[string]*domain.RankedNodeChunks)
+	docIDs := lo.Uniq(lo.Map(records, func(item *domain.NodeContentChunk, _ int) string {
+		return item.DocID
+	}))
+
+	// fuse keyword hits of published node releases with vector hits
+	query := rewrittenQuery
+	if query == "" {
+		query = req.Question
+	}
+	var tokens []string
+	if req.KBID != "" {
+		settings, err := u.kbRepo.GetRetrievalSettings(ctx, req.KBID)
+		if err != nil {
+			return "", nil, fmt.Errorf("get retrieval settings failed: %w", err)
+		}
+		if settings.Mode == domain.RetrievalModeHybrid {
+			var keywordDocIDs []string
+			tokens, keywordDocIDs = u.getKeywordDocIDs(ctx, req, query, settings.KeywordTopK)
+			if len(keywordDocIDs) > 0 {
+				docIDs = utils.ReciprocalRankFusion(settings.RRFK, docIDs, keywordDocIDs)
+			}
+		}
+	}
+
 	// get raw node by doc_id
-	if len(records) > 0 {
-		docIDs := lo.Uniq(lo.Map(records, func(item *domain.NodeContentChunk, _ int) string {
-			return item.DocID
-		}))
+	if len(docIDs) > 0 {
 		u.logger.Info("node chunk doc ids", log.Any("docIDs", docIDs))
 		docIDNode, err := u.nodeRepo.GetNodeReleasesWithPathsByDocIDs(ctx, docIDs)
 		if err != nil {
 			return "", nil, fmt.Errorf("get nodes by ids failed: %w", err)
 		}
 		u.logger.Info("get node release by doc ids", log.Any("docIDNode", lo.Keys(docIDNode)))
-		for _, record := range records {
-			if nodeChunk, ok := rankedNodesMap[record.DocID]; !ok {
-				if docNode, ok := docIDNode[record.DocID]; ok {
-					rankNodeChunk := &domain.RankedNodeChunks{
-						NodeID:        docNode.NodeID,
-						NodeName:      docNode.Name,
-						NodeSummary:   docNode.Meta.Summary,
-						NodeEmoji:     docNode.Meta.Emoji,
-						NodePathNames: docNode.PathNames,
-						Chunks:        []*domain.NodeContentChunk{record},
-					}
-					rankedNodes = append(rankedNodes, rankNodeChunk)
-					rankedNodesMap[record.DocID] = rankNodeChunk
+		docChunks := lo.GroupBy(records, func(item *domain.NodeContentChunk) string {
+			return item.DocID
+		})
+		for _, docID := range docIDs {
+			docNode, ok := docIDNode[docID]
+			if !ok {
+				continue
+			}
+			chunks, ok := docChunks[docID]
+			if !ok {
+				// only hit by keyword retrieval, use the most relevant part as chunk
+				content := docNode.Content
+				if utils.IsLikelyHTML(content) {
+					content = utils.HTMLToText(content)
 				}
-			} else {
-				nodeChunk.Chunks = append(nodeChunk.Chunks, record)
+				chunks = []*domain.NodeContentChunk{{
+					ID:      docNode.ID,
+					KBID:    docNode.KBID,
+					DocID:   docID,
+					Name:    docNode.Name,
+					Content: utils.ExtractSearchSnippet(content, tokens, keywordSnippetSize),
+				}}
 			}
+			rankedNodes = append(rankedNodes, &domain.RankedNodeChunks{
+				NodeID:        docNode.NodeID,
+				NodeName:      docNode.Name,
+				NodeSummary:   docNode.Meta.Summary,
+				NodeEmoji:     docNode.Meta.Emoji,
+				NodePathNames: docNode.PathNames,
+				Chunks:        chunks,
+			})
 		}
 	}
 	return rewrittenQuery, rankedNodes, nil
 }
 
+// getKeywordDocIDs searches published node releases by keywords,
+// failures only disable the keyword part of hybrid retrieval
+func (u *LLMUsecase) getKeywordDocIDs(ctx context.Context, req GetRankNodesRequest, query string, limit int) ([]string, []string) {
+	tokens := utils.SearchTokens(query)
+	docIDs, err := u.nodeRepo.SearchNodeReleaseDocIDs(ctx, req.KBID, tokens, req.GroupIDs, limit)
+	if err != nil {
+		u.logger.Error("search node releases by keywords failed", log.Error(err), log.String("kb_id", req.KBID))
+		return nil, nil
+	}
+	u.logger.Info("get related documents from keyword search", log.Any("doc_count", len(docIDs)))
+	return tokens, docIDs
+}
+
 // formatMessageWithImages converts image paths to markdown format and appends to message
 func (u *LLMUsecase) formatMessageWithImages(message string, imagePaths []string) string {
 	if len(imagePaths) == 0 {
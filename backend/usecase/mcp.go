@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+)
+
+// MCPUsecase serves the tools of the MCP server app, documents are searched, read and listed
+// with the permissions of the auth of the MCP server source type
+type MCPUsecase struct {
+	llmUsecase  *LLMUsecase
+	nodeUsecase *NodeUsecase
+	kbRepo      *pg.KnowledgeBaseRepository
+	authRepo    *pg.AuthRepo
+	mcpRepo     *pg.MCPRepository
+	logger      *log.Logger
+}
+
+func NewMCPUsecase(
+	llmUsecase *LLMUsecase,
+	nodeUsecase *NodeUsecase,
+	kbRepo *pg.KnowledgeBaseRepository,
+	authRepo *pg.AuthRepo,
+	mcpRepo *pg.MCPRepository,
+	logger *log.Logger,
+) *MCPUsecase {
+	return &MCPUsecase{
+		llmUsecase:  llmUsecase,
+		nodeUsecase: nodeUsecase,
+		kbRepo:      kbRepo,
+		authRepo:    authRepo,
+		mcpRepo:     mcpRepo,
+		logger:      logger.WithModule("usecase.mcp"),
+	}
+}
+
+// getAuthID returns the auth of the MCP server source type, 0 if there is none so that only open nodes are allowed
+func (u *MCPUsecase) getAuthID(ctx context.Context) uint {
+	auth, err := u.authRepo.GetAuthBySourceType(ctx, consts.SourceTypeMcpServer)
+	if err != nil || auth == nil {
+		return 0
+	}
+	return auth.ID
+}
+
+// SearchDocs returns the chunks of the answerable documents related to the query
+func (u *MCPUsecase) SearchDocs(ctx context.Context, kbID, query string) (string, error) {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return "", fmt.Errorf("get kb failed: %w", err)
+	}
+	groupIDs, err := u.authRepo.GetAuthGroupIdsWithParentsByAuthId(ctx, u.getAuthID(ctx))
+	if err != nil {
+		return "", fmt.Errorf("get auth groupIds failed: %w", err)
+	}
+	_, rankedNodes, err := u.llmUsecase.GetRankNodes(ctx, GetRankNodesRequest{
+		KBID:                kb.ID,
+		DatasetID:           kb.DatasetID,
+		Question:            query,
+		GroupIDs:            groupIDs,
+		SimilarityThreshold: 0.2,
+	})
+	if err != nil {
+		return "", fmt.Errorf("get rank nodes failed: %w", err)
+	}
+	if len(rankedNodes) == 0 {
+		return "未检索到相关文档", nil
+	}
+	return domain.FormatNodeChunks(rankedNodes, kb.AccessSettings.BaseURL), nil
+}
+
+// GetDoc returns the released content of the visitable document in markdown,
+// the children of a folder are listed instead
+func (u *MCPUsecase) GetDoc(ctx context.Context, kbID, id string) (string, error) {
+	authID := u.getAuthID(ctx)
+	if errCode := u.nodeUsecase.ValidateNodePerm(ctx, kbID, id, authID); errCode != nil {
+		if errCode.Code == domain.ErrCodeNotFound.Code {
+			return "", errors.New("文档不存在")
+		}
+		return "", errors.New("无权访问该文档")
+	}
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return "", fmt.Errorf("get kb failed: %w", err)
+	}
+	node, err := u.nodeUsecase.GetNodeReleaseDetailByKBIDAndID(ctx, kbID, id, "raw")
+	if err != nil {
+		return "", fmt.Errorf("get node release failed: %w", err)
+	}
+
+	doc := strings.Builder{}
+	doc.WriteString(fmt.Sprintf("# %s\n\n", node.Name))
+	if kb.AccessSettings.BaseURL != "" {
+		doc.WriteString(fmt.Sprintf("URL: %s/node/%s\n", kb.AccessSettings.BaseURL, node.ID))
+	}
+	doc.WriteString(fmt.Sprintf("更新时间: %s\n\n", node.UpdatedAt.Format("2006-01-02 15:04:05")))
+	if node.Type == domain.NodeTypeFolder {
+		children, err := u.nodeUsecase.GetNodeReleaseListByParentID(ctx, kbID, id, authID)
+		if err != nil {
+			return "", fmt.Errorf("get child nodes failed: %w", err)
+		}
+		doc.WriteString("该节点为文件夹，包含以下文档:\n")
+		for _, child := range children {
+			doc.WriteString(fmt.Sprintf("- %s (id: %s)\n", child.Name, child.ID))
+		}
+		return doc.String(), nil
+	}
+	doc.WriteString(node.Content)
+	return doc.String(), nil
+}
+
+// GetDocTree returns the tree of the visible documents under the parent, the whole tree if parentID is empty
+func (u *MCPUsecase) GetDocTree(ctx context.Context, kbID, parentID string) ([]*domain.MCPDocTreeItem, error) {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, fmt.Errorf("get kb failed: %w", err)
+	}
+	nodes, err := u.nodeUsecase.GetNodeReleaseListByParentID(ctx, kbID, parentID, u.getAuthID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("get node release list failed: %w", err)
+	}
+	return newMCPDocTree(nodes, kb.AccessSettings.BaseURL), nil
+}
+
+func newMCPDocTree(nodes []*domain.ShareNodeDetailItem, baseURL string) []*domain.MCPDocTreeItem {
+	items := make([]*domain.MCPDocTreeItem, 0, len(nodes))
+	for _, node := range nodes {
+		item := &domain.MCPDocTreeItem{
+			ID:       node.ID,
+			Name:     node.Name,
+			Type:     node.Type,
+			Children: newMCPDocTree(node.Children, baseURL),
+		}
+		if node.Type == domain.NodeTypeDocument && baseURL != "" {
+			item.URL = fmt.Sprintf("%s/node/%s", baseURL, node.ID)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// RecordCall saves the call, failures are only logged not to break the MCP session
+func (u *MCPUsecase) RecordCall(ctx context.Context, call *domain.MCPCall) {
+	if err := u.mcpRepo.CreateMCPCall(ctx, call); err != nil {
+		u.logger.Error("create mcp call failed", log.Error(err), log.String("kb_id", call.KBID), log.String("session_id", call.MCPSessionID))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/cloudwego/eino/schema"
+	"gorm.io/gorm"
 
 	modelkitDomain "github.com/chaitin/ModelKit/v2/domain"
 	modelkit "github.com/chaitin/ModelKit/v2/usecase"
@@ -144,33 +145,88 @@ func (u *ModelUsecase) Update(ctx context.Context, req *domain.UpdateModelReq) e
 }
 
 func (u *ModelUsecase) GetChatModel(ctx context.Context) (*domain.Model, error) {
-	var model *domain.Model
+	if model := u.getAutoModeChatModel(ctx); model != nil {
+		return model, nil
+	}
+	return u.modelRepo.GetChatModel(ctx)
+}
+
+// GetChatModels returns the chat models to try in order: the model chosen by the app if any,
+// then the active chat models by priority, or the BaiZhiCloud model in auto mode
+func (u *ModelUsecase) GetChatModels(ctx context.Context, appModelID string) ([]*domain.Model, error) {
+	models := make([]*domain.Model, 0)
+	if appModelID != "" {
+		model, err := u.modelRepo.GetChatModelByID(ctx, appModelID)
+		switch {
+		case err != nil:
+			u.logger.Warn("get chat model of app failed, use default chat models", log.String("model_id", appModelID), log.Error(err))
+		case !model.IsActive:
+			u.logger.Warn("chat model of app is inactive, use default chat models", log.String("model_id", appModelID))
+		default:
+			models = append(models, model)
+		}
+	}
+	if model := u.getAutoModeChatModel(ctx); model != nil {
+		return append(models, model), nil
+	}
+	activeModels, err := u.modelRepo.GetActiveChatModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, model := range activeModels {
+		if len(models) > 0 && models[0].ID == model.ID {
+			continue
+		}
+		models = append(models, model)
+	}
+	if len(models) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return models, nil
+}
+
+// getAutoModeChatModel returns the BaiZhiCloud chat model in auto mode, nil in manual mode
+func (u *ModelUsecase) getAutoModeChatModel(ctx context.Context) *domain.Model {
 	modelModeSetting, err := u.GetModelModeSetting(ctx)
 	// 获取不到模型模式时，使用手动模式, 不返回错误
+	if err != nil {
+		u.logger.Error("get model mode setting failed, use manual mode", log.Error(err))
+		return nil
+	}
+	if modelModeSetting.Mode != consts.ModelSettingModeAuto || modelModeSetting.AutoModeAPIKey == "" {
+		return nil
+	}
+	modelName := modelModeSetting.ChatModel
+	if modelName == "" {
+		modelName = string(consts.AutoModeDefaultChatModel)
+	}
+	return &domain.Model{
+		Model:    modelName,
+		Type:     domain.ModelTypeChat,
+		IsActive: true,
+		BaseURL:  consts.AutoModeBaseURL,
+		APIKey:   modelModeSetting.AutoModeAPIKey,
+		Provider: domain.ModelProviderBrandBaiZhiCloud,
+	}
+}
+
+// GetEmbeddingModel returns the embedding model in use, following the model mode like GetChatModel
+func (u *ModelUsecase) GetEmbeddingModel(ctx context.Context) (*domain.Model, error) {
+	modelModeSetting, err := u.GetModelModeSetting(ctx)
 	if err != nil {
 		u.logger.Error("get model mode setting failed, use manual mode", log.Error(err))
 	}
 	if err == nil && modelModeSetting.Mode == consts.ModelSettingModeAuto && modelModeSetting.AutoModeAPIKey != "" {
-		modelName := modelModeSetting.ChatModel
-		if modelName == "" {
-			modelName = string(consts.AutoModeDefaultChatModel)
-		}
-		model = &domain.Model{
-			Model:    modelName,
-			Type:     domain.ModelTypeChat,
+		return &domain.Model{
+			Model:    consts.GetAutoModeDefaultModel(string(domain.ModelTypeEmbedding)),
+			Type:     domain.ModelTypeEmbedding,
 			IsActive: true,
 			BaseURL:  consts.AutoModeBaseURL,
 			APIKey:   modelModeSetting.AutoModeAPIKey,
 			Provider: domain.ModelProviderBrandBaiZhiCloud,
-		}
-		return model, nil
+		}, nil
 	}
-	model, err = u.modelRepo.GetChatModel(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	return model, nil
+	return u.modelRepo.GetModelByType(ctx, domain.ModelTypeEmbedding)
 }
 
 func (u *ModelUsecase) GetModelByType(ctx context.Context, modelType domain.ModelType) (*domain.Model, error) {
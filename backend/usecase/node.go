@@ -29,6 +29,7 @@ type NodeUsecase struct {
 	nodeRepo     *pg.NodeRepository
 	appRepo      *pg.AppRepository
 	ragRepo      *mq.RAGRepository
+	webhookRepo  *mq.WebhookRepository
 	kbRepo       *pg.KnowledgeBaseRepository
 	modelRepo    *pg.ModelRepository
 	userRepo     *pg.UserRepository
@@ -53,6 +54,7 @@ func NewNodeUsecase(
 	modelRepo *pg.ModelRepository,
 	authRepo *pg.AuthRepo,
 	modelUsecase *ModelUsecase,
+	webhookRepo *mq.WebhookRepository,
 ) *NodeUsecase {
 	return &NodeUsecase{
 		nodeRepo:     nodeRepo,
@@ -67,6 +69,7 @@ func NewNodeUsecase(
 		logger:       logger.WithModule("usecase.node"),
 		s3Client:     s3Client,
 		modelUsecase: modelUsecase,
+		webhookRepo:  webhookRepo,
 	}
 }
 
@@ -77,6 +80,15 @@ func (u *NodeUsecase) Create(ctx context.Context, req *domain.CreateNodeReq, use
 	if err != nil {
 		return "", err
 	}
+	if err := u.webhookRepo.AsyncTriggerEvent(ctx, req.KBID, domain.WebhookEventNodeCreated, &domain.WebhookNodeEventData{
+		NodeID:   nodeID,
+		Name:     req.Name,
+		Type:     req.Type,
+		ParentID: req.ParentID,
+		UserID:   userId,
+	}); err != nil {
+		u.logger.Error("trigger node created webhook failed", log.String("node_id", nodeID), log.Error(err))
+	}
 	return nodeID, nil
 }
 
@@ -129,7 +141,7 @@ func (u *NodeUsecase) GetNodeByKBID(ctx context.Context, id, kbId, format string
 	}
 	if format != "raw" {
 		if !utils.IsLikelyHTML(node.Content) {
-			node.Content = u.convertMDToHTML(node.Content)
+			node.Content = convertMDToHTML(node.Content)
 		}
 	}
 	return node, nil
@@ -153,6 +165,11 @@ func (u *NodeUsecase) NodeAction(ctx context.Context, req *domain.NodeActionReq)
 		if err := u.ragRepo.AsyncUpdateNodeReleaseVector(ctx, nodeVectorContentRequests); err != nil {
 			return err
 		}
+		if err := u.webhookRepo.AsyncTriggerEvent(ctx, req.KBID, domain.WebhookEventNodeDeleted, &domain.WebhookNodeDeletedEventData{
+			NodeIDs: req.IDs,
+		}); err != nil {
+			u.logger.Error("trigger node deleted webhook failed", log.Error(err))
+		}
 	}
 	return nil
 }
@@ -162,6 +179,13 @@ func (u *NodeUsecase) Update(ctx context.Context, req *domain.UpdateNodeReq, use
 	if err != nil {
 		return err
 	}
+	if err := u.webhookRepo.AsyncTriggerEvent(ctx, req.KBID, domain.WebhookEventNodeUpdated, &domain.WebhookNodeEventData{
+		NodeID: req.ID,
+		Name:   lo.FromPtr(req.Name),
+		UserID: userId,
+	}); err != nil {
+		u.logger.Error("trigger node updated webhook failed", log.String("node_id", req.ID), log.Error(err))
+	}
 	return nil
 }
 
@@ -247,7 +271,7 @@ func (u *NodeUsecase) GetNodeReleaseDetailByKBIDAndID(ctx context.Context, kbID,
 	// just for info
 	if format != "raw" {
 		if !utils.IsLikelyHTML(node.Content) {
-			node.Content = u.convertMDToHTML(node.Content)
+			node.Content = convertMDToHTML(node.Content)
 		}
 	}
 	return node, nil
@@ -342,7 +366,7 @@ func (u *NodeUsecase) BatchMoveNode(ctx context.Context, req *domain.BatchMoveRe
 	return u.nodeRepo.BatchMove(ctx, req)
 }
 
-func (u *NodeUsecase) convertMDToHTML(mdStr string) string {
+func convertMDToHTML(mdStr string) string {
 	extensions := parser.CommonExtensions & ^parser.Autolink & ^parser.MathJax
 	p := parser.NewWithExtensions(extensions)
 	doc := p.Parse([]byte(mdStr))
@@ -705,3 +729,104 @@ func (u *NodeUsecase) NodeRestudy(ctx context.Context, req *v1.NodeRestudyReq) e
 
 	return nil
 }
+
+func (u *NodeUsecase) GetNodeHistoryList(ctx context.Context, req *v1.NodeHistoryListReq) (*v1.NodeHistoryListResp, error) {
+	items, total, err := u.nodeRepo.GetNodeReleaseHistory(ctx, req.KbId, req.ID, req.Offset(), req.Limit())
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewPaginatedResult(items, uint64(total)), nil
+}
+
+func (u *NodeUsecase) GetNodeHistoryDiff(ctx context.Context, req *v1.NodeHistoryDiffReq) (*v1.NodeHistoryDiffResp, error) {
+	var (
+		toRelease *domain.NodeRelease
+		to        *v1.NodeHistoryVersion
+		toContent string
+		err       error
+	)
+	if req.ToReleaseID == "" {
+		node, err := u.nodeRepo.GetByID(ctx, req.ID, req.KbId)
+		if err != nil {
+			return nil, err
+		}
+		to = &v1.NodeHistoryVersion{Name: node.Name, Meta: node.Meta, UpdatedAt: node.UpdatedAt}
+		toContent = node.Content
+	} else {
+		toRelease, err = u.nodeRepo.GetNodeReleaseByNodeIDAndID(ctx, req.KbId, req.ID, req.ToReleaseID)
+		if err != nil {
+			return nil, fmt.Errorf("get to release failed: %w", err)
+		}
+		to = newNodeHistoryVersion(toRelease)
+		toContent = toRelease.Content
+	}
+
+	var fromRelease *domain.NodeRelease
+	switch {
+	case req.FromReleaseID != "":
+		fromRelease, err = u.nodeRepo.GetNodeReleaseByNodeIDAndID(ctx, req.KbId, req.ID, req.FromReleaseID)
+		if err != nil {
+			return nil, fmt.Errorf("get from release failed: %w", err)
+		}
+	case toRelease == nil:
+		fromRelease, err = u.nodeRepo.GetLatestNodeReleaseByNodeID(ctx, req.ID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	default:
+		fromRelease, err = u.nodeRepo.GetPrevNodeRelease(ctx, toRelease)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &v1.NodeHistoryDiffResp{To: to}
+	fromContent := ""
+	if fromRelease != nil {
+		resp.From = newNodeHistoryVersion(fromRelease)
+		fromContent = fromRelease.Content
+	}
+
+	resp.Lines, resp.Stats = utils.DiffLines(u.diffableContent(fromContent), u.diffableContent(toContent))
+	resp.HTML = utils.RenderDiffHTML(resp.Lines)
+	resp.Unified = utils.RenderUnifiedDiff(resp.Lines)
+	return resp, nil
+}
+
+func (u *NodeUsecase) RestoreNodeHistory(ctx context.Context, req *v1.NodeHistoryRestoreReq, userId string) error {
+	nodeRelease, err := u.nodeRepo.GetNodeReleaseByNodeIDAndID(ctx, req.KbId, req.ID, req.ReleaseID)
+	if err != nil {
+		return fmt.Errorf("get node release failed: %w", err)
+	}
+	if err := u.nodeRepo.RestoreNodeFromRelease(ctx, nodeRelease, userId); err != nil {
+		return err
+	}
+	u.logger.Info("node restored from release",
+		log.String("kb_id", req.KbId),
+		log.String("node_id", req.ID),
+		log.String("node_release_id", req.ReleaseID),
+		log.String("user_id", userId))
+	return nil
+}
+
+// diffableContent converts html content to markdown so that diffs are line based
+func (u *NodeUsecase) diffableContent(content string) string {
+	if !utils.IsLikelyHTML(content) {
+		return content
+	}
+	md, err := rag.NewHTML2MDConverter().ConvertString(content)
+	if err != nil {
+		u.logger.Warn("convert html to markdown for diff failed", log.Error(err))
+		return content
+	}
+	return md
+}
+
+func newNodeHistoryVersion(nodeRelease *domain.NodeRelease) *v1.NodeHistoryVersion {
+	return &v1.NodeHistoryVersion{
+		ReleaseID: nodeRelease.ID,
+		Name:      nodeRelease.Name,
+		Meta:      nodeRelease.Meta,
+		UpdatedAt: nodeRelease.UpdatedAt,
+	}
+}
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/store/rag"
+)
+
+const openAIAPIModelOwner = "panda-wiki"
+
+// OpenAIAPIUsecase serves the OpenAI compatible endpoints of the OpenAI API app besides chat completions
+type OpenAIAPIUsecase struct {
+	kbRepo       *pg.KnowledgeBaseRepository
+	modelUsecase *ModelUsecase
+	client       *http.Client
+	logger       *log.Logger
+}
+
+func NewOpenAIAPIUsecase(kbRepo *pg.KnowledgeBaseRepository, modelUsecase *ModelUsecase, logger *log.Logger) *OpenAIAPIUsecase {
+	return &OpenAIAPIUsecase{
+		kbRepo:       kbRepo,
+		modelUsecase: modelUsecase,
+		client:       &http.Client{Timeout: 60 * time.Second},
+		logger:       logger.WithModule("usecase.openai_api"),
+	}
+}
+
+// GetModel returns the knowledge base as the virtual model answering chat completions, its id is the knowledge base id
+func (u *OpenAIAPIUsecase) GetModel(ctx context.Context, kbID string) (*domain.OpenAIModel, error) {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.OpenAIModel{
+		ID:      kb.ID,
+		Object:  "model",
+		Created: kb.CreatedAt.Unix(),
+		OwnedBy: openAIAPIModelOwner,
+	}, nil
+}
+
+func (u *OpenAIAPIUsecase) ListModels(ctx context.Context, kbID string) (*domain.OpenAIModelList, error) {
+	model, err := u.GetModel(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.OpenAIModelList{
+		Object: "list",
+		Data:   []domain.OpenAIModel{*model},
+	}, nil
+}
+
+// CreateEmbeddings embeds the input with the embedding model in use, whatever model is requested
+func (u *OpenAIAPIUsecase) CreateEmbeddings(ctx context.Context, req *domain.OpenAIEmbeddingsRequest) (*domain.OpenAIEmbeddingsResponse, error) {
+	model, err := u.modelUsecase.GetEmbeddingModel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get embedding model failed: %w", err)
+	}
+	resp, err := rag.CreateEmbeddings(ctx, u.client, model, req)
+	if err != nil {
+		return nil, fmt.Errorf("create embeddings failed: %w", err)
+	}
+	resp.Model = req.Model
+	if resp.Usage != nil && model.ID != "" {
+		if err := u.modelUsecase.UpdateUsage(ctx, model.ID, &schema.TokenUsage{
+			PromptTokens: resp.Usage.PromptTokens,
+			TotalTokens:  resp.Usage.TotalTokens,
+		}); err != nil {
+			u.logger.Error("failed to update model usage", log.Error(err))
+		}
+	}
+	return resp, nil
+}
@@ -35,4 +35,16 @@ var ProviderSet = wire.NewSet(
 	NewWecomUsecase,
 	NewWechatAppUsecase,
 	NewAuthUsecase,
+	NewImportSubscriptionUsecase,
+	NewKBArchiveUsecase,
+	NewStaticExportUsecase,
+	NewWebhookUsecase,
+	NewContributeUsecase,
+	NewKBReleaseScheduleUsecase,
+	NewEvalUsecase,
+	NewQuestionGapUsecase,
+	NewTokenBudgetUsecase,
+	NewChatRateLimitUsecase,
+	NewOpenAIAPIUsecase,
+	NewMCPUsecase,
 )
@@ -0,0 +1,324 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	v1 "github.com/chaitin/panda-wiki/api/conversation/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/store/rag"
+	"github.com/chaitin/panda-wiki/utils"
+)
+
+const (
+	questionGapDefaultDays = 30
+	// questionGapMaxCandidates caps the questions embedded in one analysis
+	questionGapMaxCandidates = 2000
+	// questionGapSimilarity is the cosine similarity for two questions to fall in one topic
+	questionGapSimilarity = 0.8
+	// questionGapRunningTimeout is the time after which a running analysis is considered dead
+	questionGapRunningTimeout = time.Hour
+	// questionGapReportRetention is how long the reports are kept, the latest one of each knowledge base is always kept
+	questionGapReportRetention = 90 * 24 * time.Hour
+)
+
+type QuestionGapUsecase struct {
+	repo         *pg.QuestionGapRepository
+	kbRepo       *pg.KnowledgeBaseRepository
+	nodeRepo     *pg.NodeRepository
+	modelUsecase *ModelUsecase
+	nodeUsecase  *NodeUsecase
+	logger       *log.Logger
+	client       *http.Client
+}
+
+func NewQuestionGapUsecase(
+	repo *pg.QuestionGapRepository,
+	kbRepo *pg.KnowledgeBaseRepository,
+	nodeRepo *pg.NodeRepository,
+	modelUsecase *ModelUsecase,
+	nodeUsecase *NodeUsecase,
+	logger *log.Logger,
+) *QuestionGapUsecase {
+	return &QuestionGapUsecase{
+		repo:         repo,
+		kbRepo:       kbRepo,
+		nodeRepo:     nodeRepo,
+		modelUsecase: modelUsecase,
+		nodeUsecase:  nodeUsecase,
+		logger:       logger.WithModule("usecase.question_gap"),
+		client:       &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Analyze starts an analysis of the recent conversations in background
+func (u *QuestionGapUsecase) Analyze(ctx context.Context, req *v1.QuestionGapAnalyzeReq, userID string) (string, error) {
+	running, err := u.repo.HasRunningReport(ctx, req.KbID, time.Now().Add(-questionGapRunningTimeout))
+	if err != nil {
+		return "", err
+	}
+	if running {
+		return "", errors.New("an analysis is running")
+	}
+	report, err := u.createReport(ctx, req.KbID, lo.Ternary(req.Days > 0, req.Days, questionGapDefaultDays), userID)
+	if err != nil {
+		return "", err
+	}
+	go func() {
+		bgCtx, cancel := context.WithTimeout(context.Background(), questionGapRunningTimeout)
+		defer cancel()
+		u.run(bgCtx, report)
+	}()
+	return report.ID, nil
+}
+
+// AnalyzeAll removes the expired reports and analyzes the conversations of the last days of every
+// knowledge base one by one, a knowledge base that fails or already has a running analysis is skipped
+func (u *QuestionGapUsecase) AnalyzeAll(ctx context.Context) error {
+	if deleted, err := u.repo.DeleteReportsBefore(ctx, time.Now().Add(-questionGapReportRetention)); err != nil {
+		u.logger.Error("delete expired question gap reports failed", log.Error(err))
+	} else if deleted > 0 {
+		u.logger.Info("deleted expired question gap reports", log.Int("count", deleted))
+	}
+
+	kbs, err := u.kbRepo.GetKnowledgeBaseList(ctx)
+	if err != nil {
+		return err
+	}
+	for _, kb := range kbs {
+		running, err := u.repo.HasRunningReport(ctx, kb.ID, time.Now().Add(-questionGapRunningTimeout))
+		if err != nil {
+			u.logger.Error("check running question gap report failed", log.String("kb_id", kb.ID), log.Error(err))
+			continue
+		}
+		if running {
+			u.logger.Info("skip question gap analysis as one is running", log.String("kb_id", kb.ID))
+			continue
+		}
+		report, err := u.createReport(ctx, kb.ID, questionGapDefaultDays, "")
+		if err != nil {
+			u.logger.Error("create question gap report failed", log.String("kb_id", kb.ID), log.Error(err))
+			continue
+		}
+		u.run(ctx, report)
+	}
+	return nil
+}
+
+func (u *QuestionGapUsecase) createReport(ctx context.Context, kbID string, days int, userID string) (*domain.QuestionGapReport, error) {
+	now := time.Now()
+	report := &domain.QuestionGapReport{
+		ID:        uuid.New().String(),
+		KBID:      kbID,
+		Since:     now.AddDate(0, 0, -days),
+		Status:    domain.QuestionGapReportStatusRunning,
+		CreatorID: userID,
+		CreatedAt: now,
+	}
+	if err := u.repo.CreateReport(ctx, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (u *QuestionGapUsecase) run(ctx context.Context, report *domain.QuestionGapReport) {
+	if err := u.analyze(ctx, report); err != nil {
+		u.logger.Error("analyze question gaps failed", log.String("kb_id", report.KBID), log.String("report_id", report.ID), log.Error(err))
+		if err := u.repo.UpdateReport(context.Background(), report.ID, map[string]any{
+			"status":      domain.QuestionGapReportStatusFailed,
+			"error":       err.Error(),
+			"finished_at": time.Now(),
+		}); err != nil {
+			u.logger.Error("update question gap report failed", log.String("report_id", report.ID), log.Error(err))
+		}
+	}
+}
+
+// analyze embeds the unanswered and disliked questions and clusters them into topics
+func (u *QuestionGapUsecase) analyze(ctx context.Context, report *domain.QuestionGapReport) error {
+	candidates, err := u.repo.GetCandidates(ctx, report.KBID, report.Since, questionGapMaxCandidates)
+	if err != nil {
+		return fmt.Errorf("get candidates failed: %w", err)
+	}
+	if len(candidates) == 0 {
+		return u.repo.FinishReport(ctx, report.ID, 0, nil, nil)
+	}
+
+	for _, c := range candidates {
+		c.Question = strings.TrimSpace(c.Question)
+	}
+	questions := lo.Uniq(lo.Map(candidates, func(c *domain.QuestionGapCandidate, _ int) string {
+		return c.Question
+	}))
+	model, err := u.modelUsecase.GetEmbeddingModel(ctx)
+	if err != nil {
+		return fmt.Errorf("get embedding model failed: %w", err)
+	}
+	vectors, err := rag.EmbedTexts(ctx, u.client, model, questions)
+	if err != nil {
+		return err
+	}
+	questionVectors := make(map[string][]float32, len(questions))
+	for i, question := range questions {
+		questionVectors[question] = vectors[i]
+	}
+
+	groups := utils.ClusterByCosine(lo.Map(candidates, func(c *domain.QuestionGapCandidate, _ int) []float32 {
+		return questionVectors[c.Question]
+	}), questionGapSimilarity)
+
+	now := time.Now()
+	clusters := make([]*domain.QuestionGapCluster, 0, len(groups))
+	items := make([]*domain.QuestionGapItem, 0, len(candidates))
+	for rank, group := range groups {
+		cluster := &domain.QuestionGapCluster{
+			ID:            uuid.New().String(),
+			ReportID:      report.ID,
+			KBID:          report.KBID,
+			Rank:          rank + 1,
+			Topic:         candidates[group[0]].Question,
+			QuestionCount: len(group),
+			ReasonCounts:  domain.MapStrInt64{},
+			AppTypeCounts: domain.MapStrInt64{},
+			CreatedAt:     now,
+		}
+		for _, index := range group {
+			candidate := candidates[index]
+			reasons := candidate.Reasons()
+			for _, reason := range reasons {
+				cluster.ReasonCounts[reason]++
+			}
+			cluster.AppTypeCounts[strconv.Itoa(int(candidate.AppType))]++
+			items = append(items, &domain.QuestionGapItem{
+				ID:             uuid.New().String(),
+				ClusterID:      cluster.ID,
+				MessageID:      candidate.MessageID,
+				ConversationID: candidate.ConversationID,
+				Question:       candidate.Question,
+				AppType:        candidate.AppType,
+				Reasons:        reasons,
+				AskedAt:        candidate.CreatedAt,
+			})
+		}
+		clusters = append(clusters, cluster)
+	}
+	if err := u.repo.FinishReport(ctx, report.ID, len(candidates), clusters, items); err != nil {
+		return fmt.Errorf("save clusters failed: %w", err)
+	}
+	u.logger.Info("question gaps analyzed",
+		log.String("kb_id", report.KBID),
+		log.String("report_id", report.ID),
+		log.Int("questions", len(candidates)),
+		log.Int("clusters", len(clusters)))
+	return nil
+}
+
+func (u *QuestionGapUsecase) GetReportList(ctx context.Context, req *v1.QuestionGapReportListReq) (*v1.QuestionGapReportListResp, error) {
+	total, reports, err := u.repo.GetReportList(ctx, req.KbID, &req.Pager)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewPaginatedResult(reports, uint64(total)), nil
+}
+
+// GetReport returns the report with its clusters ranked by frequency, the latest one if id is empty
+func (u *QuestionGapUsecase) GetReport(ctx context.Context, kbID, id string) (*v1.QuestionGapReportResp, error) {
+	var report *domain.QuestionGapReport
+	var err error
+	if id == "" {
+		report, err = u.repo.GetLatestReport(ctx, kbID)
+	} else {
+		report, err = u.repo.GetReport(ctx, kbID, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := u.repo.GetClusters(ctx, report.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.QuestionGapReportResp{Report: report, Clusters: clusters}, nil
+}
+
+func (u *QuestionGapUsecase) GetClusterDetail(ctx context.Context, kbID, id string) (*v1.QuestionGapClusterDetailResp, error) {
+	cluster, err := u.repo.GetCluster(ctx, kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	items, err := u.repo.GetItems(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.QuestionGapClusterDetailResp{QuestionGapCluster: *cluster, Items: items}, nil
+}
+
+// CreateDraft creates an unpublished document listing the questions of the cluster for editors to answer
+func (u *QuestionGapUsecase) CreateDraft(ctx context.Context, req *v1.QuestionGapDraftReq, userID string, maxNode int) (string, error) {
+	cluster, err := u.repo.GetCluster(ctx, req.KbID, req.ID)
+	if err != nil {
+		return "", err
+	}
+	if cluster.NodeID != "" {
+		return "", errors.New("draft node has been created for the cluster")
+	}
+	if req.ParentID != "" {
+		parent, err := u.nodeRepo.GetByID(ctx, req.ParentID, req.KbID)
+		if err != nil {
+			return "", fmt.Errorf("get parent node failed: %w", err)
+		}
+		if parent.Type != domain.NodeTypeFolder {
+			return "", errors.New("parent node is not a folder")
+		}
+	}
+	items, err := u.repo.GetItems(ctx, cluster.ID)
+	if err != nil {
+		return "", err
+	}
+
+	content := strings.Builder{}
+	content.WriteString("## 用户提问\n\n")
+	for _, question := range lo.Uniq(lo.Map(items, func(item *domain.QuestionGapItem, _ int) string {
+		return strings.Join(strings.Fields(item.Question), " ")
+	})) {
+		content.WriteString(fmt.Sprintf("- %s\n", question))
+	}
+	content.WriteString("\n## 解答\n\n")
+
+	name := req.Name
+	if name == "" {
+		name = cluster.Topic
+		if runes := []rune(name); len(runes) > 100 {
+			name = string(runes[:100])
+		}
+	}
+	nodeID, err := u.nodeUsecase.Create(ctx, &domain.CreateNodeReq{
+		KBID:        req.KbID,
+		ParentID:    req.ParentID,
+		Type:        domain.NodeTypeDocument,
+		Name:        name,
+		Content:     content.String(),
+		ContentType: lo.ToPtr(domain.ContentTypeMD),
+		MaxNode:     maxNode,
+	}, userID)
+	if err != nil {
+		return "", err
+	}
+	claimed, err := u.repo.SetClusterNode(ctx, cluster.ID, nodeID)
+	if err != nil {
+		return "", err
+	}
+	if !claimed {
+		u.logger.Warn("draft node has been created for the cluster concurrently", log.String("cluster_id", cluster.ID), log.String("node_id", nodeID))
+	}
+	return nodeID, nil
+}
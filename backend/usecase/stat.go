@@ -221,6 +221,12 @@ func (u *StatUseCase) GetStatCount(ctx context.Context, kbID string, day consts.
 	}
 	count.ConversationCount = conversationCount
 
+	chatRateLimitedCount, err := u.repo.GetChatRateLimitedCount(ctx, kbID, 24)
+	if err != nil {
+		return nil, err
+	}
+	count.ChatRateLimitedCount = chatRateLimitedCount
+
 	if day > consts.StatDay1 {
 		countHour, err := u.repo.GetStatPageCountByHour(ctx, kbID, int64(day)*24)
 		if err != nil {
@@ -230,6 +236,7 @@ func (u *StatUseCase) GetStatCount(ctx context.Context, kbID string, day consts.
 		count.ConversationCount += countHour.ConversationCount
 		count.SessionCount += countHour.SessionCount
 		count.PageVisitCount += countHour.PageVisitCount
+		count.ChatRateLimitedCount += countHour.ChatRateLimitedCount
 	}
 
 	return count, nil
@@ -413,6 +420,11 @@ func (u *StatUseCase) AggregateHourlyStats(ctx context.Context) error {
 			return err
 		}
 
+		chatRateLimitedCount, err := u.repo.GetChatRateLimitedCountOneHour(ctx, kbId)
+		if err != nil {
+			return err
+		}
+
 		distributions, err := u.repo.GetConversationDistributionOneHour(ctx, kbId)
 		if err != nil {
 			return err
@@ -441,6 +453,7 @@ func (u *StatUseCase) AggregateHourlyStats(ctx context.Context) error {
 		statPageHour.KbID = kbId
 		statPageHour.Hour = lastHour
 		statPageHour.ConversationCount = conversationCount
+		statPageHour.ChatRateLimitedCount = chatRateLimitedCount
 
 		statPageHour.GeoCount = geoCount
 		statPageHour.ConversationDistribution = distributions
@@ -0,0 +1,265 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+	"gorm.io/gorm"
+
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/store/s3"
+	"github.com/chaitin/panda-wiki/utils"
+)
+
+type StaticExportUsecase struct {
+	nodeRepo       *pg.NodeRepository
+	kbRepo         *pg.KnowledgeBaseRepository
+	sitemapUsecase *SitemapUsecase
+	s3Client       *s3.MinioClient
+	logger         *log.Logger
+}
+
+func NewStaticExportUsecase(
+	nodeRepo *pg.NodeRepository,
+	kbRepo *pg.KnowledgeBaseRepository,
+	sitemapUsecase *SitemapUsecase,
+	s3Client *s3.MinioClient,
+	logger *log.Logger,
+) *StaticExportUsecase {
+	return &StaticExportUsecase{
+		nodeRepo:       nodeRepo,
+		kbRepo:         kbRepo,
+		sitemapUsecase: sitemapUsecase,
+		s3Client:       s3Client,
+		logger:         logger.WithModule("usecase.static_export"),
+	}
+}
+
+type staticNavItem struct {
+	node     *domain.ShareNodeListItemResp
+	children []*staticNavItem
+}
+
+type staticPage struct {
+	SiteName  string
+	Title     string
+	Root      string
+	Nav       template.HTML
+	Content   template.HTML
+	UpdatedAt string
+}
+
+type staticSearchItem struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Summary string `json:"summary"`
+	Content string `json:"content"`
+}
+
+// Export renders the latest release of a knowledge base as a read-only static site in a zip archive.
+// Only nodes visible and visitable without login are included, together with the files they reference,
+// a search index for the bundled search script and the sitemap.
+func (u *StaticExportUsecase) Export(ctx context.Context, kbID string, w io.Writer) error {
+	kb, err := u.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return fmt.Errorf("get knowledge base failed: %w", err)
+	}
+	release, err := u.kbRepo.GetLatestRelease(ctx, kbID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("knowledge base has not been released yet")
+		}
+		return fmt.Errorf("get latest release failed: %w", err)
+	}
+	listItems, err := u.nodeRepo.GetNodeReleaseListByKBID(ctx, kbID)
+	if err != nil {
+		return fmt.Errorf("get node release list failed: %w", err)
+	}
+	nodeReleases, err := u.nodeRepo.GetNodeReleasesByKBReleaseID(ctx, kbID, release.ID)
+	if err != nil {
+		return fmt.Errorf("get node releases failed: %w", err)
+	}
+	contents := make(map[string]string, len(nodeReleases))
+	for _, nodeRelease := range nodeReleases {
+		contents[nodeRelease.NodeID] = nodeRelease.Content
+	}
+
+	// the mirror has no login, partially open nodes are left out as well
+	items := make(map[string]*staticNavItem)
+	for _, node := range listItems {
+		if node.Permissions.Visible != consts.NodeAccessPermOpen {
+			continue
+		}
+		if node.Type == domain.NodeTypeDocument && node.Permissions.Visitable != consts.NodeAccessPermOpen {
+			continue
+		}
+		items[node.ID] = &staticNavItem{node: node}
+	}
+	roots := make([]*staticNavItem, 0)
+	for _, item := range items {
+		if parent, ok := items[item.node.ParentID]; ok {
+			parent.children = append(parent.children, item)
+		} else if item.node.ParentID == "" {
+			roots = append(roots, item)
+		}
+	}
+	sortStaticNavItems(roots)
+
+	zw := zip.NewWriter(w)
+	sanitizer := bluemonday.UGCPolicy()
+	assetKeys := make(map[string]bool)
+	searchItems := make([]staticSearchItem, 0)
+
+	var writePages func(navItems []*staticNavItem) error
+	writePages = func(navItems []*staticNavItem) error {
+		for _, item := range navItems {
+			node := item.node
+			if node.Type == domain.NodeTypeDocument {
+				content := contents[node.ID]
+				if utils.IsLikelyHTML(content) {
+					content = sanitizer.Sanitize(content)
+				} else {
+					content = convertMDToHTML(content)
+				}
+				content = replaceStaticFileRefs(content, func(key string) (string, bool) {
+					assetKeys[key] = true
+					return "../static-file/" + key, true
+				})
+				page := &staticPage{
+					SiteName:  kb.Name,
+					Title:     node.Name,
+					Root:      "../",
+					Nav:       renderStaticNav(roots, node.ID, "../"),
+					Content:   template.HTML(content),
+					UpdatedAt: node.UpdatedAt.Format(time.DateOnly),
+				}
+				if err := writeStaticPage(zw, "node/"+node.ID+".html", page); err != nil {
+					return err
+				}
+				searchItems = append(searchItems, staticSearchItem{
+					ID:      node.ID,
+					Title:   node.Name,
+					URL:     "node/" + node.ID + ".html",
+					Summary: node.Meta.Summary,
+					Content: utils.HTMLToText(content),
+				})
+			}
+			if err := writePages(item.children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := writePages(roots); err != nil {
+		return err
+	}
+
+	if err := writeStaticPage(zw, "index.html", &staticPage{
+		SiteName: kb.Name,
+		Title:    kb.Name,
+		Nav:      renderStaticNav(roots, "", ""),
+		Content: template.HTML(fmt.Sprintf("<p>%s</p>", html.EscapeString(
+			fmt.Sprintf("本站点为知识库只读镜像，发布版本 %s，发布于 %s", release.Tag, release.CreatedAt.Format(time.DateTime)),
+		))),
+	}); err != nil {
+		return err
+	}
+
+	searchIndex, err := json.Marshal(searchItems)
+	if err != nil {
+		return fmt.Errorf("marshal search index failed: %w", err)
+	}
+	if err := writeZipFile(zw, "search-index.json", searchIndex); err != nil {
+		return err
+	}
+	sitemap, err := u.sitemapUsecase.GetSitemap(ctx, kbID)
+	if err != nil {
+		return fmt.Errorf("generate sitemap failed: %w", err)
+	}
+	if err := writeZipFile(zw, "sitemap.xml", []byte(sitemap)); err != nil {
+		return err
+	}
+	if err := writeZipFile(zw, "assets/style.css", []byte(staticSiteCSS)); err != nil {
+		return err
+	}
+	if err := writeZipFile(zw, "assets/search.js", []byte(staticSiteSearchJS)); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(assetKeys))
+	for key := range assetKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		found, err := writeStaticFileToZip(ctx, u.s3Client, zw, "static-file/"+key, key)
+		if err != nil {
+			return err
+		}
+		if !found {
+			u.logger.Warn("referenced file not found, skip it", log.String("kb_id", kbID), log.String("key", key))
+		}
+	}
+
+	return zw.Close()
+}
+
+func sortStaticNavItems(items []*staticNavItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].node.Position < items[j].node.Position
+	})
+	for _, item := range items {
+		sortStaticNavItems(item.children)
+	}
+}
+
+// renderStaticNav renders the node tree as nested lists, root is the relative path to the site root
+func renderStaticNav(items []*staticNavItem, currentID, root string) template.HTML {
+	var sb strings.Builder
+	var render func(items []*staticNavItem)
+	render = func(items []*staticNavItem) {
+		sb.WriteString("<ul>")
+		for _, item := range items {
+			node := item.node
+			name := html.EscapeString(strings.TrimSpace(node.Emoji + " " + node.Name))
+			switch {
+			case node.Type == domain.NodeTypeFolder:
+				sb.WriteString(`<li class="folder"><span>` + name + `</span>`)
+			case node.ID == currentID:
+				sb.WriteString(`<li class="active"><a href="` + root + "node/" + html.EscapeString(node.ID) + `.html">` + name + `</a>`)
+			default:
+				sb.WriteString(`<li><a href="` + root + "node/" + html.EscapeString(node.ID) + `.html">` + name + `</a>`)
+			}
+			if len(item.children) > 0 {
+				render(item.children)
+			}
+			sb.WriteString("</li>")
+		}
+		sb.WriteString("</ul>")
+	}
+	render(items)
+	return template.HTML(sb.String())
+}
+
+func writeStaticPage(zw *zip.Writer, name string, page *staticPage) error {
+	var buf bytes.Buffer
+	if err := staticPageTemplate.Execute(&buf, page); err != nil {
+		return fmt.Errorf("render %s failed: %w", name, err)
+	}
+	return writeZipFile(zw, name, buf.Bytes())
+}
@@ -0,0 +1,90 @@
+package usecase
+
+import "html/template"
+
+var staticPageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}{{if ne .Title .SiteName}} - {{.SiteName}}{{end}}</title>
+<link rel="stylesheet" href="{{.Root}}assets/style.css">
+</head>
+<body>
+<nav class="sidebar">
+<a class="site-name" href="{{.Root}}index.html">{{.SiteName}}</a>
+<input id="search" type="search" placeholder="搜索文档" autocomplete="off">
+<ul id="search-results"></ul>
+<div class="tree">{{.Nav}}</div>
+</nav>
+<main class="content">
+<h1>{{.Title}}</h1>
+{{if .UpdatedAt}}<p class="meta">更新于 {{.UpdatedAt}}</p>{{end}}
+<article>{{.Content}}</article>
+</main>
+<script src="{{.Root}}assets/search.js" data-root="{{.Root}}"></script>
+</body>
+</html>
+`))
+
+const staticSiteCSS = `* { box-sizing: border-box; }
+body { margin: 0; display: flex; font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; color: #21222d; line-height: 1.7; }
+.sidebar { width: 280px; flex-shrink: 0; height: 100vh; position: sticky; top: 0; overflow-y: auto; padding: 24px 16px; border-right: 1px solid #ececec; background: #fafafa; }
+.site-name { display: block; font-size: 18px; font-weight: 600; color: inherit; text-decoration: none; margin-bottom: 16px; }
+#search { width: 100%; padding: 6px 10px; border: 1px solid #ddd; border-radius: 6px; }
+#search-results { list-style: none; padding: 0; margin: 8px 0; }
+#search-results li { padding: 4px 0; border-bottom: 1px dashed #eee; }
+.tree ul { list-style: none; padding-left: 14px; margin: 0; }
+.tree > ul { padding-left: 0; }
+.tree li { padding: 2px 0; }
+.tree a { color: #3a3b45; text-decoration: none; }
+.tree li.active > a { color: #3248f2; font-weight: 600; }
+.tree li.folder > span { color: #888; }
+.content { flex: 1; min-width: 0; max-width: 960px; padding: 32px 48px; }
+.content .meta { color: #888; font-size: 13px; }
+.content img { max-width: 100%; }
+.content pre { background: #f6f8fa; padding: 12px; overflow-x: auto; border-radius: 6px; }
+.content table { border-collapse: collapse; }
+.content th, .content td { border: 1px solid #ddd; padding: 6px 12px; }
+@media (max-width: 768px) { body { display: block; } .sidebar { width: auto; height: auto; position: static; } .content { padding: 16px; } }
+`
+
+const staticSiteSearchJS = `(function () {
+  var root = document.currentScript.getAttribute('data-root') || '';
+  var input = document.getElementById('search');
+  var results = document.getElementById('search-results');
+  var index = null;
+
+  function load(callback) {
+    if (index) { callback(); return; }
+    fetch(root + 'search-index.json').then(function (resp) { return resp.json(); }).then(function (data) {
+      index = data || [];
+      callback();
+    });
+  }
+
+  function search() {
+    var query = input.value.trim().toLowerCase();
+    results.innerHTML = '';
+    if (!query) { return; }
+    var hits = index.filter(function (item) {
+      return item.title.toLowerCase().indexOf(query) >= 0 || item.content.toLowerCase().indexOf(query) >= 0;
+    }).slice(0, 20);
+    hits.forEach(function (item) {
+      var li = document.createElement('li');
+      var a = document.createElement('a');
+      a.href = root + item.url;
+      a.textContent = item.title;
+      li.appendChild(a);
+      results.appendChild(li);
+    });
+    if (hits.length === 0) {
+      var empty = document.createElement('li');
+      empty.textContent = '没有找到相关文档';
+      results.appendChild(empty);
+    }
+  }
+
+  input.addEventListener('input', function () { load(search); });
+})();
+`
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/pg"
+)
+
+const tokenUsageDefaultDays = 30
+
+type TokenBudgetUsecase struct {
+	repo   *pg.TokenBudgetRepository
+	logger *log.Logger
+}
+
+func NewTokenBudgetUsecase(repo *pg.TokenBudgetRepository, logger *log.Logger) *TokenBudgetUsecase {
+	return &TokenBudgetUsecase{
+		repo:   repo,
+		logger: logger.WithModule("usecase.token_budget"),
+	}
+}
+
+// Set creates the budget of the app type and period, or updates its limit
+func (u *TokenBudgetUsecase) Set(ctx context.Context, req *v1.TokenBudgetSetReq) error {
+	if req.AppType != 0 && !lo.Contains(domain.AppTypes, req.AppType) {
+		return errors.New("invalid app type")
+	}
+	now := time.Now()
+	return u.repo.Upsert(ctx, &domain.TokenBudget{
+		ID:         uuid.New().String(),
+		KBID:       req.KbID,
+		AppType:    req.AppType,
+		Period:     req.Period,
+		TokenLimit: req.TokenLimit,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+}
+
+// GetList returns the budgets with the tokens used in the current periods
+func (u *TokenBudgetUsecase) GetList(ctx context.Context, kbID string) ([]*v1.TokenBudgetListItem, error) {
+	budgets, err := u.repo.GetList(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	items := make([]*v1.TokenBudgetListItem, 0, len(budgets))
+	for _, budget := range budgets {
+		used, err := u.repo.GetUsedTokens(ctx, kbID, budget.AppType, budget.Period.Start(now))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &v1.TokenBudgetListItem{TokenBudget: *budget, UsedTokens: used})
+	}
+	return items, nil
+}
+
+func (u *TokenBudgetUsecase) Delete(ctx context.Context, kbID, id string) error {
+	return u.repo.Delete(ctx, kbID, id)
+}
+
+// CheckBudget returns the first budget of the app type used up in its current period, nil if there is none
+func (u *TokenBudgetUsecase) CheckBudget(ctx context.Context, kbID string, appType domain.AppType) (*domain.TokenBudget, error) {
+	budgets, err := u.repo.GetAppBudgets(ctx, kbID, appType)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, budget := range budgets {
+		used, err := u.repo.GetUsedTokens(ctx, kbID, budget.AppType, budget.Period.Start(now))
+		if err != nil {
+			return nil, err
+		}
+		if used >= budget.TokenLimit {
+			return budget, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetUsage returns the tokens used by knowledge base, app and day
+func (u *TokenBudgetUsecase) GetUsage(ctx context.Context, req *v1.TokenUsageReq) (*v1.TokenUsageResp, error) {
+	end := time.Now()
+	if req.EndAt > 0 {
+		end = time.Unix(req.EndAt, 0)
+	}
+	start := end.AddDate(0, 0, -tokenUsageDefaultDays)
+	if req.StartAt > 0 {
+		start = time.Unix(req.StartAt, 0)
+	}
+	if !start.Before(end) {
+		return nil, errors.New("start_at must be before end_at")
+	}
+	stats, err := u.repo.GetUsageStats(ctx, req.KbID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	resp := &v1.TokenUsageResp{Stats: stats}
+	for _, stat := range stats {
+		resp.PromptTokens += stat.PromptTokens
+		resp.CompletionTokens += stat.CompletionTokens
+		resp.TotalTokens += stat.TotalTokens
+	}
+	return resp, nil
+}
@@ -14,15 +14,19 @@ import (
 	"github.com/chaitin/panda-wiki/domain"
 	"github.com/chaitin/panda-wiki/log"
 	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/store/cache"
 )
 
 type UserUsecase struct {
-	repo   *pg.UserRepository
-	logger *log.Logger
-	config *config.Config
+	repo              *pg.UserRepository
+	kbRepo            *pg.KnowledgeBaseRepository
+	systemSettingRepo *pg.SystemSettingRepo
+	cache             *cache.Cache
+	logger            *log.Logger
+	config            *config.Config
 }
 
-func NewUserUsecase(repo *pg.UserRepository, logger *log.Logger, config *config.Config) (*UserUsecase, error) {
+func NewUserUsecase(repo *pg.UserRepository, kbRepo *pg.KnowledgeBaseRepository, systemSettingRepo *pg.SystemSettingRepo, cache *cache.Cache, logger *log.Logger, config *config.Config) (*UserUsecase, error) {
 	if config.AdminPassword != "" {
 		if err := repo.UpsertDefaultUser(context.Background(), &domain.User{
 			ID:       uuid.New().String(),
@@ -34,9 +38,12 @@ func NewUserUsecase(repo *pg.UserRepository, logger *log.Logger, config *config.
 		}
 	}
 	return &UserUsecase{
-		repo:   repo,
-		logger: logger.WithModule("usecase.user"),
-		config: config,
+		repo:              repo,
+		kbRepo:            kbRepo,
+		systemSettingRepo: systemSettingRepo,
+		cache:             cache,
+		logger:            logger.WithModule("usecase.user"),
+		config:            config,
 	}, nil
 }
 
@@ -49,10 +56,17 @@ func (u *UserUsecase) VerifyUser(ctx context.Context, account string, password s
 }
 
 func (u *UserUsecase) VerifyUserAndGenerateToken(ctx context.Context, req v1.LoginReq) (string, error) {
+	if err := u.checkPasswordLoginAllowed(ctx, req.Account); err != nil {
+		return "", err
+	}
 	user, err := u.VerifyUser(ctx, req.Account, req.Password)
 	if err != nil {
 		return "", err
 	}
+	return u.generateToken(user)
+}
+
+func (u *UserUsecase) generateToken(user *domain.User) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"id":  user.ID,
 		"exp": time.Now().Add(time.Hour * 24).Unix(),
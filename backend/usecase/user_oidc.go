@@ -0,0 +1,351 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+
+	v1 "github.com/chaitin/panda-wiki/api/user/v1"
+	"github.com/chaitin/panda-wiki/consts"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/pkg/oidc"
+)
+
+const (
+	adminOIDCStatePrefix = "admin_oidc_state:"
+	adminOIDCStateExpire = 15 * time.Minute
+
+	defaultOIDCAccountClaim = "preferred_username"
+	defaultOIDCGroupsClaim  = "groups"
+
+	// builtinAdminAccount 启动时根据配置创建的管理员账号，始终可以使用密码登录，不能关联到 IdP 用户
+	builtinAdminAccount = "admin"
+)
+
+var (
+	ErrPasswordLoginDisabled = errors.New("password login is disabled")
+	ErrOIDCUserNotAllowed    = errors.New("oidc user is not allowed to login")
+)
+
+// adminOIDCState 授权请求的 nonce 和 PKCE verifier，回调时校验
+type adminOIDCState struct {
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// kbPermissionRank 多个组映射到同一知识库时取权限最高的
+var kbPermissionRank = map[consts.UserKBPermission]int{
+	consts.UserKBPermissionDataOperate: 1,
+	consts.UserKBPermissionDocManage:   2,
+	consts.UserKBPermissionFullControl: 3,
+}
+
+func (u *UserUsecase) GetAdminOIDCSetting(ctx context.Context) (*domain.AdminOIDCSetting, error) {
+	setting, err := u.systemSettingRepo.GetSystemSetting(ctx, consts.SystemSettingAdminOIDC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get admin oidc setting: %w", err)
+	}
+	var config domain.AdminOIDCSetting
+	if err := json.Unmarshal(setting.Value, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse admin oidc setting: %w", err)
+	}
+	return &config, nil
+}
+
+func (u *UserUsecase) UpdateAdminOIDCSetting(ctx context.Context, setting *domain.AdminOIDCSetting) error {
+	if setting.Enabled && (setting.Issuer == "" || setting.ClientID == "" || setting.RedirectURL == "") {
+		return fmt.Errorf("issuer, client_id and redirect_url are required")
+	}
+	for _, p := range setting.KBPermissions {
+		if p.Group == "" || p.KBID == "" {
+			return fmt.Errorf("group and kb_id of kb permission are required")
+		}
+		if _, ok := kbPermissionRank[p.Perm]; !ok {
+			return fmt.Errorf("invalid kb permission: %s", p.Perm)
+		}
+	}
+	value, err := json.Marshal(setting)
+	if err != nil {
+		return fmt.Errorf("failed to marshal admin oidc setting: %w", err)
+	}
+	if err := u.systemSettingRepo.UpdateSystemSetting(ctx, string(consts.SystemSettingAdminOIDC), string(value)); err != nil {
+		return fmt.Errorf("failed to update admin oidc setting: %w", err)
+	}
+	return nil
+}
+
+// GetAdminOIDCLoginConfig 登录页展示用的配置，不包含敏感信息
+func (u *UserUsecase) GetAdminOIDCLoginConfig(ctx context.Context) (*v1.OIDCLoginConfigResp, error) {
+	setting, err := u.getAdminOIDCSettingOrEmpty(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.OIDCLoginConfigResp{
+		Enabled:              setting.Enabled,
+		DisablePasswordLogin: setting.Enabled && setting.DisablePasswordLogin,
+	}, nil
+}
+
+func (u *UserUsecase) getAdminOIDCSettingOrEmpty(ctx context.Context) (*domain.AdminOIDCSetting, error) {
+	setting, err := u.GetAdminOIDCSetting(ctx)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &domain.AdminOIDCSetting{}, nil
+		}
+		return nil, err
+	}
+	return setting, nil
+}
+
+// checkPasswordLoginAllowed 开启 OIDC 并禁用密码登录后，只有启动配置的 admin 账号可以使用密码登录，
+// 防止 IdP 不可用时无法登录
+func (u *UserUsecase) checkPasswordLoginAllowed(ctx context.Context, account string) error {
+	setting, err := u.getAdminOIDCSettingOrEmpty(ctx)
+	if err != nil {
+		return err
+	}
+	if !setting.Enabled || !setting.DisablePasswordLogin {
+		return nil
+	}
+	if u.config.AdminPassword != "" && account == builtinAdminAccount {
+		return nil
+	}
+	return ErrPasswordLoginDisabled
+}
+
+func (u *UserUsecase) getOIDCClient(ctx context.Context, setting *domain.AdminOIDCSetting) (*oidc.Client, error) {
+	if !setting.Enabled {
+		return nil, fmt.Errorf("oidc login is not enabled")
+	}
+	return oidc.NewClient(ctx, u.logger, oidc.Config{
+		Issuer:       setting.Issuer,
+		ClientID:     setting.ClientID,
+		ClientSecret: setting.ClientSecret,
+		RedirectURL:  setting.RedirectURL,
+		Scopes:       setting.Scopes,
+	})
+}
+
+func (u *UserUsecase) GenerateAdminOIDCAuthUrl(ctx context.Context) (string, error) {
+	setting, err := u.GetAdminOIDCSetting(ctx)
+	if err != nil {
+		return "", err
+	}
+	client, err := u.getOIDCClient(ctx, setting)
+	if err != nil {
+		return "", err
+	}
+
+	stateInfo := adminOIDCState{
+		Nonce:    uuid.New().String(),
+		Verifier: oauth2.GenerateVerifier(),
+	}
+	stateInfoBytes, err := json.Marshal(stateInfo)
+	if err != nil {
+		return "", err
+	}
+	state := uuid.New().String()
+	if err := u.cache.SetNX(ctx, adminOIDCStatePrefix+state, stateInfoBytes, adminOIDCStateExpire).Err(); err != nil {
+		return "", fmt.Errorf("gen state failed: %w", err)
+	}
+
+	return client.GetAuthorizeURL(state, stateInfo.Nonce, stateInfo.Verifier), nil
+}
+
+// AdminOIDCCallback 使用授权码完成登录，首次登录时创建用户，每次登录时根据 IdP 的组同步角色和知识库权限
+func (u *UserUsecase) AdminOIDCCallback(ctx context.Context, req v1.OIDCCallbackReq, edition consts.LicenseEdition) (string, error) {
+	// state 只能使用一次
+	stateInfoBytes, err := u.cache.GetDel(ctx, adminOIDCStatePrefix+req.State).Bytes()
+	if err != nil {
+		return "", fmt.Errorf("invalid state: %w", err)
+	}
+	var stateInfo adminOIDCState
+	if err := json.Unmarshal(stateInfoBytes, &stateInfo); err != nil {
+		return "", err
+	}
+
+	setting, err := u.GetAdminOIDCSetting(ctx)
+	if err != nil {
+		return "", err
+	}
+	client, err := u.getOIDCClient(ctx, setting)
+	if err != nil {
+		return "", err
+	}
+	userInfo, err := client.GetUserInfo(req.Code, stateInfo.Nonce, stateInfo.Verifier)
+	if err != nil {
+		return "", err
+	}
+
+	groupsClaim := lo.CoalesceOrEmpty(setting.GroupsClaim, defaultOIDCGroupsClaim)
+	groups := userInfo.Strings(groupsClaim)
+	role, err := mapOIDCRole(setting, groups)
+	if err != nil {
+		u.logger.Warn("oidc user is not in the allowed groups", log.String("subject", userInfo.Subject), log.Any("groups", groups))
+		return "", err
+	}
+
+	user, err := u.getOrCreateOIDCUser(ctx, setting, userInfo, role, edition)
+	if err != nil {
+		return "", err
+	}
+	if err := u.syncOIDCKBPermissions(ctx, setting, user, groups); err != nil {
+		return "", fmt.Errorf("sync kb permissions failed: %w", err)
+	}
+
+	return u.generateToken(user)
+}
+
+// mapOIDCRole 属于管理员组的为管理员，属于允许登录的组（未配置时为所有用户）的为普通用户
+func mapOIDCRole(setting *domain.AdminOIDCSetting, groups []string) (consts.UserRole, error) {
+	if lo.Some(groups, setting.AdminGroups) {
+		return consts.UserRoleAdmin, nil
+	}
+	if len(setting.UserGroups) == 0 || lo.Some(groups, setting.UserGroups) {
+		return consts.UserRoleUser, nil
+	}
+	return "", ErrOIDCUserNotAllowed
+}
+
+// getOrCreateOIDCUser 按 IdP 的唯一标识获取用户，首次登录时关联邮箱已验证且与账号相同的本地用户，或者创建新用户。
+// 关联的本地用户保留原有的角色，其他用户的角色每次登录时根据组同步
+func (u *UserUsecase) getOrCreateOIDCUser(ctx context.Context, setting *domain.AdminOIDCSetting, userInfo *oidc.UserInfo, role consts.UserRole, edition consts.LicenseEdition) (*domain.User, error) {
+	user, err := u.repo.GetUserByOIDCSubject(ctx, userInfo.Issuer, userInfo.Subject)
+	if err == nil {
+		if !user.OIDCLinked && user.Role != role {
+			if err := u.repo.UpdateUserOIDC(ctx, user.ID, userInfo.Issuer, userInfo.Subject, role); err != nil {
+				return nil, err
+			}
+			user.Role = role
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if setting.LinkExistingUsers {
+		user, err := u.linkOIDCUser(ctx, userInfo)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			return user, nil
+		}
+	}
+
+	account := userInfo.String(lo.CoalesceOrEmpty(setting.AccountClaim, defaultOIDCAccountClaim))
+	if account == "" {
+		account = userInfo.String("email")
+	}
+	if account == "" {
+		return nil, fmt.Errorf("account claim is missing in the oidc user info")
+	}
+
+	if _, err := u.repo.GetUserByAccount(ctx, account); err == nil {
+		return nil, fmt.Errorf("account %s already exists", account)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// OIDC 用户不使用密码登录，设置随机密码
+	user = &domain.User{
+		ID:          uuid.New().String(),
+		Account:     account,
+		Password:    oauth2.GenerateVerifier(),
+		Role:        role,
+		OIDCIssuer:  userInfo.Issuer,
+		OIDCSubject: &userInfo.Subject,
+	}
+	if err := u.repo.CreateUser(ctx, user, edition); err != nil {
+		return nil, fmt.Errorf("create user failed: %w", err)
+	}
+	u.logger.Info("created user from oidc login", log.String("account", account), log.String("role", string(role)))
+	return user, nil
+}
+
+// linkOIDCUser 关联账号与已验证邮箱相同的本地用户，没有可关联的用户时返回 nil。
+// 用户名等 claim 可以由 IdP 用户自行修改，所以只按已验证的邮箱关联；
+// 内置的 admin 账号、已关联过 IdP 的用户和访客用户不会被关联
+func (u *UserUsecase) linkOIDCUser(ctx context.Context, userInfo *oidc.UserInfo) (*domain.User, error) {
+	email := userInfo.String("email")
+	if email == "" || !userInfo.Bool("email_verified") {
+		return nil, nil
+	}
+	user, err := u.repo.GetUserByAccount(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if user.Account == builtinAdminAccount || user.OIDCSubject != nil || user.Role == consts.UserRoleGuest {
+		return nil, fmt.Errorf("account %s already exists", email)
+	}
+	if err := u.repo.LinkUserOIDC(ctx, user.ID, userInfo.Issuer, userInfo.Subject); err != nil {
+		return nil, err
+	}
+	user.OIDCIssuer = userInfo.Issuer
+	user.OIDCSubject = &userInfo.Subject
+	user.OIDCLinked = true
+	u.logger.Info("linked user to oidc subject", log.String("account", user.Account))
+	return user, nil
+}
+
+// syncOIDCKBPermissions 同步配置了映射的知识库的权限，未映射的知识库的权限保持不变。
+// 关联的本地用户原有的权限由管理员手动分配，只添加或提升权限，不删除也不降低
+func (u *UserUsecase) syncOIDCKBPermissions(ctx context.Context, setting *domain.AdminOIDCSetting, user *domain.User, groups []string) error {
+	// 管理员拥有所有知识库的权限
+	if user.Role == consts.UserRoleAdmin {
+		return nil
+	}
+
+	perms := make(map[string]consts.UserKBPermission)
+	for _, p := range setting.KBPermissions {
+		if _, ok := perms[p.KBID]; !ok {
+			perms[p.KBID] = consts.UserKBPermissionNull
+		}
+		if lo.Contains(groups, p.Group) && kbPermissionRank[p.Perm] > kbPermissionRank[perms[p.KBID]] {
+			perms[p.KBID] = p.Perm
+		}
+	}
+
+	for kbID, perm := range perms {
+		kbUser, err := u.kbRepo.GetKBUser(ctx, kbID, user.ID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		switch {
+		case perm == consts.UserKBPermissionNull:
+			if kbUser != nil && !user.OIDCLinked {
+				if err := u.kbRepo.DeleteKBUser(ctx, kbID, user.ID); err != nil {
+					return err
+				}
+			}
+		case kbUser == nil:
+			if err := u.kbRepo.CreateKBUser(ctx, &domain.KBUsers{
+				KBId:   kbID,
+				UserId: user.ID,
+				Perm:   perm,
+			}); err != nil {
+				return err
+			}
+		case kbUser.Perm != perm:
+			if user.OIDCLinked && kbPermissionRank[perm] < kbPermissionRank[kbUser.Perm] {
+				continue
+			}
+			if err := u.kbRepo.UpdateKBUserPerm(ctx, kbID, user.ID, perm); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,473 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	v1 "github.com/chaitin/panda-wiki/api/kb/v1"
+	"github.com/chaitin/panda-wiki/config"
+	"github.com/chaitin/panda-wiki/domain"
+	"github.com/chaitin/panda-wiki/log"
+	"github.com/chaitin/panda-wiki/repo/mq"
+	"github.com/chaitin/panda-wiki/repo/pg"
+	"github.com/chaitin/panda-wiki/utils"
+)
+
+const (
+	webhookTimeout         = 10 * time.Second
+	webhookResponseMaxSize = 1024
+)
+
+// webhookRetryBackoff is the wait before each retry, a delivery is failed after all retries are used up
+var webhookRetryBackoff = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	6 * time.Hour,
+}
+
+type WebhookUsecase struct {
+	repo       *pg.WebhookRepository
+	mqRepo     *mq.WebhookRepository
+	httpClient *http.Client
+	logger     *log.Logger
+}
+
+func NewWebhookUsecase(repo *pg.WebhookRepository, mqRepo *mq.WebhookRepository, config *config.Config, logger *log.Logger) *WebhookUsecase {
+	logger = logger.WithModule("usecase.webhook")
+	allowlist := newWebhookAllowlist(config.Webhook.AllowedNetworks, logger)
+	return &WebhookUsecase{
+		repo:   repo,
+		mqRepo: mqRepo,
+		httpClient: &http.Client{
+			Timeout: webhookTimeout,
+			// 不使用环境变量中的代理，否则连接的是代理的地址，无法检查 webhook 的地址
+			Transport: &http.Transport{
+				DialContext:         allowlist.dialContext,
+				ForceAttemptHTTP2:   true,
+				TLSHandshakeTimeout: webhookTimeout,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		logger: logger,
+	}
+}
+
+func (u *WebhookUsecase) Create(ctx context.Context, req *v1.WebhookCreateReq) (*v1.WebhookCreateResp, error) {
+	if err := validateWebhookEvents(req.Events); err != nil {
+		return nil, err
+	}
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	webhook := &domain.Webhook{
+		ID:        uuid.New().String(),
+		KBID:      req.KbID,
+		Name:      req.Name,
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    lo.Uniq(lo.Map(req.Events, func(e domain.WebhookEventType, _ int) string { return string(e) })),
+		Enabled:   req.Enabled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := u.repo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return &v1.WebhookCreateResp{ID: webhook.ID, Secret: webhook.Secret}, nil
+}
+
+func (u *WebhookUsecase) GetList(ctx context.Context, kbID string) ([]*v1.WebhookListItem, error) {
+	webhooks, err := u.repo.GetList(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(webhooks, func(webhook *domain.Webhook, _ int) *v1.WebhookListItem {
+		return &v1.WebhookListItem{
+			ID:        webhook.ID,
+			Name:      webhook.Name,
+			URL:       webhook.URL,
+			Events:    lo.Map(webhook.Events, func(e string, _ int) domain.WebhookEventType { return domain.WebhookEventType(e) }),
+			Enabled:   webhook.Enabled,
+			CreatedAt: webhook.CreatedAt,
+			UpdatedAt: webhook.UpdatedAt,
+		}
+	}), nil
+}
+
+func (u *WebhookUsecase) Update(ctx context.Context, req *v1.WebhookUpdateReq) error {
+	updateMap := make(map[string]any)
+	if req.Name != nil {
+		updateMap["name"] = *req.Name
+	}
+	if req.URL != nil {
+		updateMap["url"] = *req.URL
+	}
+	if req.Secret != nil {
+		if *req.Secret == "" {
+			return errors.New("secret can not be empty")
+		}
+		updateMap["secret"] = *req.Secret
+	}
+	if req.Events != nil {
+		if len(req.Events) == 0 {
+			return errors.New("events can not be empty")
+		}
+		if err := validateWebhookEvents(req.Events); err != nil {
+			return err
+		}
+		updateMap["events"] = lo.Uniq(lo.Map(req.Events, func(e domain.WebhookEventType, _ int) string { return string(e) }))
+	}
+	if req.Enabled != nil {
+		updateMap["enabled"] = *req.Enabled
+	}
+	if len(updateMap) == 0 {
+		return nil
+	}
+	return u.repo.Update(ctx, req.KbID, req.ID, updateMap)
+}
+
+func (u *WebhookUsecase) Delete(ctx context.Context, kbID, id string) error {
+	return u.repo.Delete(ctx, kbID, id)
+}
+
+func (u *WebhookUsecase) GetDeliveryList(ctx context.Context, req *v1.WebhookDeliveryListReq) (*v1.WebhookDeliveryListResp, error) {
+	total, deliveries, err := u.repo.GetDeliveryList(ctx, req.KbID, req.WebhookID, &req.Pager)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewPaginatedResult(deliveries, uint64(total)), nil
+}
+
+// GetDelivery returns a delivery with its payload and the response body of the receiver
+func (u *WebhookUsecase) GetDelivery(ctx context.Context, kbID, id string) (*domain.WebhookDelivery, error) {
+	delivery, err := u.repo.GetDeliveryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.KBID != kbID {
+		return nil, errors.New("delivery not found")
+	}
+	return delivery, nil
+}
+
+// Test sends a ping event to the webhook right away and returns the result,
+// the response body is not returned to avoid exposing the content of the receiver
+func (u *WebhookUsecase) Test(ctx context.Context, kbID, id string) (*v1.WebhookTestResp, error) {
+	webhook, err := u.repo.GetByID(ctx, kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := u.newDelivery(ctx, webhook, &domain.WebhookEvent{
+		ID:         uuid.New().String(),
+		KBID:       kbID,
+		Event:      domain.WebhookEventPing,
+		Data:       map[string]string{"webhook_id": webhook.ID},
+		OccurredAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := u.Deliver(ctx, delivery.ID); err != nil {
+		return nil, err
+	}
+	delivery, err = u.repo.GetDeliveryByID(ctx, delivery.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.WebhookTestResp{
+		DeliveryID:     delivery.ID,
+		Status:         delivery.Status,
+		ResponseStatus: delivery.ResponseStatus,
+		Error:          delivery.Error,
+	}, nil
+}
+
+// RetryDelivery sends a finished delivery again, e.g. after the receiver is fixed
+func (u *WebhookUsecase) RetryDelivery(ctx context.Context, kbID, id string) error {
+	delivery, err := u.repo.GetDeliveryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if delivery.KBID != kbID {
+		return errors.New("delivery not found")
+	}
+	if err := u.repo.UpdateDelivery(ctx, id, map[string]any{
+		"status":        domain.WebhookDeliveryStatusPending,
+		"attempts":      0,
+		"next_retry_at": nil,
+	}); err != nil {
+		return err
+	}
+	return u.mqRepo.AsyncDeliver(ctx, id)
+}
+
+// HandleEvent creates a delivery for every enabled webhook subscribed to the event and queues it.
+// Failures of one webhook are only logged, the event must not be handled again once any delivery
+// is created, otherwise the webhooks already notified would receive it twice.
+func (u *WebhookUsecase) HandleEvent(ctx context.Context, event *domain.WebhookEvent) error {
+	webhooks, err := u.repo.GetSubscribedList(ctx, event.KBID, event.Event)
+	if err != nil {
+		return fmt.Errorf("get subscribed webhooks failed: %w", err)
+	}
+	for _, webhook := range webhooks {
+		delivery, err := u.newDelivery(ctx, webhook, event)
+		if err != nil {
+			u.logger.Error("create webhook delivery failed", log.String("webhook_id", webhook.ID), log.String("event_id", event.ID), log.Error(err))
+			continue
+		}
+		if err := u.mqRepo.AsyncDeliver(ctx, delivery.ID); err != nil {
+			// left as retrying, it will be picked up by the retry job
+			u.logger.Error("queue webhook delivery failed", log.String("delivery_id", delivery.ID), log.Error(err))
+			if err := u.repo.UpdateDelivery(ctx, delivery.ID, map[string]any{
+				"status":        domain.WebhookDeliveryStatusRetrying,
+				"next_retry_at": time.Now(),
+			}); err != nil {
+				u.logger.Error("update webhook delivery failed", log.String("delivery_id", delivery.ID), log.Error(err))
+			}
+		}
+	}
+	return nil
+}
+
+func (u *WebhookUsecase) newDelivery(ctx context.Context, webhook *domain.Webhook, event *domain.WebhookEvent) (*domain.WebhookDelivery, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("marshal webhook payload failed: %w", err)
+	}
+	now := time.Now()
+	delivery := &domain.WebhookDelivery{
+		ID:        uuid.New().String(),
+		WebhookID: webhook.ID,
+		KBID:      webhook.KBID,
+		Event:     event.Event,
+		Payload:   string(payload),
+		Status:    domain.WebhookDeliveryStatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := u.repo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("create webhook delivery failed: %w", err)
+	}
+	return delivery, nil
+}
+
+// Deliver posts a pending delivery to its webhook. Failed attempts are scheduled for retry
+// with backoff, the error returned is only about the delivery log itself.
+func (u *WebhookUsecase) Deliver(ctx context.Context, deliveryID string) error {
+	delivery, err := u.repo.GetDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		return fmt.Errorf("get webhook delivery failed: %w", err)
+	}
+	if delivery.Status != domain.WebhookDeliveryStatusPending {
+		return nil
+	}
+	webhook, err := u.repo.GetByID(ctx, delivery.KBID, delivery.WebhookID)
+	if err != nil {
+		return u.repo.UpdateDelivery(ctx, delivery.ID, map[string]any{
+			"status": domain.WebhookDeliveryStatusFailed,
+			"error":  fmt.Sprintf("get webhook failed: %v", err),
+		})
+	}
+
+	attempts := delivery.Attempts + 1
+	statusCode, body, sendErr := u.send(ctx, webhook, delivery)
+	updateMap := map[string]any{
+		"attempts":        attempts,
+		"response_status": statusCode,
+		"response_body":   body,
+		"error":           "",
+		"next_retry_at":   nil,
+	}
+	switch {
+	case sendErr == nil:
+		updateMap["status"] = domain.WebhookDeliveryStatusSuccess
+	case attempts > len(webhookRetryBackoff), delivery.Event == domain.WebhookEventPing:
+		updateMap["status"] = domain.WebhookDeliveryStatusFailed
+		updateMap["error"] = sendErr.Error()
+	default:
+		updateMap["status"] = domain.WebhookDeliveryStatusRetrying
+		updateMap["error"] = sendErr.Error()
+		updateMap["next_retry_at"] = time.Now().Add(webhookRetryBackoff[attempts-1])
+	}
+	if sendErr != nil {
+		u.logger.Warn("deliver webhook failed",
+			log.String("delivery_id", delivery.ID),
+			log.String("webhook_id", webhook.ID),
+			log.Int("attempts", attempts),
+			log.Error(sendErr))
+	}
+	return u.repo.UpdateDelivery(ctx, delivery.ID, updateMap)
+}
+
+func (u *WebhookUsecase) send(ctx context.Context, webhook *domain.Webhook, delivery *domain.WebhookDelivery) (int, string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "PandaWiki-Webhook")
+	req.Header.Set("X-PandaWiki-Event", string(delivery.Event))
+	req.Header.Set("X-PandaWiki-Delivery", delivery.ID)
+	req.Header.Set("X-PandaWiki-Timestamp", timestamp)
+	req.Header.Set("X-PandaWiki-Signature", "sha256="+signWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseMaxSize))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(body), fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// RetryDueDeliveries queues the deliveries whose retry time has come
+func (u *WebhookUsecase) RetryDueDeliveries(ctx context.Context) error {
+	deliveries, err := u.repo.GetDueRetryDeliveries(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		claimed, err := u.repo.ClaimRetry(ctx, delivery.ID)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		if err := u.mqRepo.AsyncDeliver(ctx, delivery.ID); err != nil {
+			u.logger.Error("queue webhook retry failed", log.String("delivery_id", delivery.ID), log.Error(err))
+			if err := u.repo.UpdateDelivery(ctx, delivery.ID, map[string]any{
+				"status":        domain.WebhookDeliveryStatusRetrying,
+				"next_retry_at": time.Now().Add(time.Minute),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var errWebhookAddressNotAllowed = errors.New("webhook address is not allowed")
+
+// webhookAllowlist holds the admin configured networks and hosts that webhooks may deliver to,
+// everything else in loopback, private, link-local and other reserved ranges is rejected
+type webhookAllowlist struct {
+	networks []*net.IPNet
+	hosts    map[string]struct{}
+}
+
+func newWebhookAllowlist(entries []string, logger *log.Logger) *webhookAllowlist {
+	a := &webhookAllowlist{hosts: make(map[string]struct{})}
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			a.networks = append(a.networks, network)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			a.networks = append(a.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		if strings.ContainsAny(entry, "/:") {
+			logger.Warn("ignore invalid webhook allowed network", log.String("entry", entry))
+			continue
+		}
+		a.hosts[strings.TrimSuffix(entry, ".")] = struct{}{}
+	}
+	return a
+}
+
+// dialContext connects to allowed hosts directly, other addresses are checked by dialControl after
+// the host is resolved so that a domain resolving to an internal address is rejected too
+func (a *webhookAllowlist) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: webhookTimeout, Control: a.dialControl}
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		if _, ok := a.hosts[strings.TrimSuffix(strings.ToLower(host), ".")]; ok {
+			dialer.Control = nil
+		}
+	}
+	return dialer.DialContext(ctx, network, address)
+}
+
+func (a *webhookAllowlist) dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: %s", errWebhookAddressNotAllowed, host)
+	}
+	for _, network := range a.networks {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+	if ip.IsUnspecified() || ip.IsMulticast() || utils.IsPrivateOrReservedIP(host) {
+		return fmt.Errorf("%w: %s", errWebhookAddressNotAllowed, host)
+	}
+	return nil
+}
+
+// signWebhookPayload signs "<timestamp>.<payload>" with HMAC-SHA256, receivers should verify it
+// and reject old timestamps to prevent replay
+func signWebhookPayload(secret, timestamp, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate webhook secret failed: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func validateWebhookEvents(events []domain.WebhookEventType) error {
+	for _, event := range events {
+		if !slices.Contains(domain.WebhookEventTypes, event) {
+			return fmt.Errorf("unsupported webhook event: %s", event)
+		}
+	}
+	return nil
+}
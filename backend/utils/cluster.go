@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"math"
+	"sort"
+)
+
+// ClusterByCosine greedily groups vectors whose cosine similarity to a cluster centroid reaches threshold.
+// Clusters are ordered by size, each lists member indexes with the one closest to the centroid first.
+func ClusterByCosine(vectors [][]float32, threshold float64) [][]int {
+	type cluster struct {
+		sum      []float64
+		centroid []float64 // normalized sum, updated when a member is added
+		members  []int
+	}
+	normalized := make([][]float64, len(vectors))
+	for i, vector := range vectors {
+		normalized[i] = normalizeVector(vector)
+	}
+
+	clusters := make([]*cluster, 0)
+	for i, vector := range normalized {
+		best, bestSim := -1, threshold
+		for j, c := range clusters {
+			if sim := dotProduct(vector, c.centroid); sim >= bestSim {
+				best, bestSim = j, sim
+			}
+		}
+		if best == -1 {
+			clusters = append(clusters, &cluster{sum: append([]float64(nil), vector...), centroid: vector, members: []int{i}})
+			continue
+		}
+		c := clusters[best]
+		for k := range c.sum {
+			if k < len(vector) {
+				c.sum[k] += vector[k]
+			}
+		}
+		c.centroid = normalizeVector64(c.sum)
+		c.members = append(c.members, i)
+	}
+
+	result := make([][]int, 0, len(clusters))
+	for _, c := range clusters {
+		sims := make(map[int]float64, len(c.members))
+		for _, member := range c.members {
+			sims[member] = dotProduct(normalized[member], c.centroid)
+		}
+		sort.SliceStable(c.members, func(a, b int) bool {
+			return sims[c.members[a]] > sims[c.members[b]]
+		})
+		result = append(result, c.members)
+	}
+	sort.SliceStable(result, func(a, b int) bool {
+		return len(result[a]) > len(result[b])
+	})
+	return result
+}
+
+func normalizeVector(vector []float32) []float64 {
+	result := make([]float64, len(vector))
+	for i, v := range vector {
+		result[i] = float64(v)
+	}
+	return normalizeVector64(result)
+}
+
+func normalizeVector64(vector []float64) []float64 {
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	result := make([]float64, len(vector))
+	if norm == 0 {
+		return result
+	}
+	for i, v := range vector {
+		result[i] = v / norm
+	}
+	return result
+}
+
+func dotProduct(a, b []float64) float64 {
+	var sum float64
+	for i := 0; i < len(a) && i < len(b); i++ {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterByCosine(t *testing.T) {
+	vectors := [][]float32{
+		{1, 0, 0},
+		{0, 1, 0},
+		{0.9, 0.1, 0},
+		{1, 0.05, 0},
+		{0, 0, 1},
+	}
+	assert.Equal(t, [][]int{{3, 0, 2}, {1}, {4}}, ClusterByCosine(vectors, 0.9))
+	assert.Equal(t, [][]int{{0}, {1}, {2}, {3}, {4}}, ClusterByCosine(vectors, 1.01))
+}
@@ -0,0 +1,333 @@
+package utils
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+// maxDiffEdits caps the edit distance explored by the myers diff,
+// larger changes fall back to replacing the whole block
+const maxDiffEdits = 2000
+
+type diffEdit struct {
+	op   domain.DiffOp
+	text string
+}
+
+// DiffLines computes a line level diff between oldText and newText,
+// paired deleted/inserted lines carry word level segments
+func DiffLines(oldText, newText string) ([]*domain.DiffLine, domain.DiffStats) {
+	edits := diffTokens(splitLines(oldText), splitLines(newText))
+
+	lines := make([]*domain.DiffLine, 0, len(edits))
+	stats := domain.DiffStats{}
+	oldLine, newLine := 0, 0
+	for i := 0; i < len(edits); {
+		if edits[i].op == domain.DiffOpEqual {
+			oldLine++
+			newLine++
+			lines = append(lines, &domain.DiffLine{
+				Op:      domain.DiffOpEqual,
+				OldLine: oldLine,
+				NewLine: newLine,
+				Text:    edits[i].text,
+			})
+			i++
+			continue
+		}
+
+		// collect a block of changes between two equal lines
+		var deleted, inserted []string
+		for ; i < len(edits) && edits[i].op != domain.DiffOpEqual; i++ {
+			if edits[i].op == domain.DiffOpDelete {
+				deleted = append(deleted, edits[i].text)
+			} else {
+				inserted = append(inserted, edits[i].text)
+			}
+		}
+		stats.Deleted += len(deleted)
+		stats.Added += len(inserted)
+
+		pairs := min(len(deleted), len(inserted))
+		deletedLines := make([]*domain.DiffLine, len(deleted))
+		insertedLines := make([]*domain.DiffLine, len(inserted))
+		for j, text := range deleted {
+			oldLine++
+			deletedLines[j] = &domain.DiffLine{Op: domain.DiffOpDelete, OldLine: oldLine, Text: text}
+		}
+		for j, text := range inserted {
+			newLine++
+			insertedLines[j] = &domain.DiffLine{Op: domain.DiffOpInsert, NewLine: newLine, Text: text}
+		}
+		for j := 0; j < pairs; j++ {
+			words := DiffWords(deleted[j], inserted[j])
+			for _, w := range words {
+				if w.Op != domain.DiffOpInsert {
+					deletedLines[j].Segments = appendSegment(deletedLines[j].Segments, w)
+				}
+				if w.Op != domain.DiffOpDelete {
+					insertedLines[j].Segments = appendSegment(insertedLines[j].Segments, w)
+				}
+			}
+		}
+		lines = append(lines, deletedLines...)
+		lines = append(lines, insertedLines...)
+	}
+	return lines, stats
+}
+
+// DiffWords computes a word level diff, CJK characters are compared one by one
+func DiffWords(oldText, newText string) []domain.DiffSegment {
+	edits := diffTokens(splitWords(oldText), splitWords(newText))
+	segments := make([]domain.DiffSegment, 0, len(edits))
+	for _, e := range edits {
+		segments = appendSegment(segments, domain.DiffSegment{Op: e.op, Text: e.text})
+	}
+	return segments
+}
+
+// RenderDiffHTML renders diff lines as html, changed words are wrapped by <ins>/<del>
+func RenderDiffHTML(lines []*domain.DiffLine) string {
+	var sb strings.Builder
+	sb.WriteString(`<div class="diff">`)
+	for _, line := range lines {
+		sb.WriteString(`<div class="diff-line diff-`)
+		sb.WriteString(string(line.Op))
+		sb.WriteString(`">`)
+		if len(line.Segments) > 0 {
+			for _, seg := range line.Segments {
+				writeDiffText(&sb, seg.Op, seg.Text)
+			}
+		} else {
+			writeDiffText(&sb, line.Op, line.Text)
+		}
+		sb.WriteString(`</div>`)
+	}
+	sb.WriteString(`</div>`)
+	return sb.String()
+}
+
+// SideBySideDiff aligns diff lines into rows, paired deleted/inserted lines share a row
+func SideBySideDiff(lines []*domain.DiffLine) []*domain.DiffRow {
+	rows := make([]*domain.DiffRow, 0, len(lines))
+	for i := 0; i < len(lines); {
+		if lines[i].Op == domain.DiffOpEqual {
+			rows = append(rows, &domain.DiffRow{Old: lines[i], New: lines[i]})
+			i++
+			continue
+		}
+		var deleted, inserted []*domain.DiffLine
+		for ; i < len(lines) && lines[i].Op == domain.DiffOpDelete; i++ {
+			deleted = append(deleted, lines[i])
+		}
+		for ; i < len(lines) && lines[i].Op == domain.DiffOpInsert; i++ {
+			inserted = append(inserted, lines[i])
+		}
+		for j := 0; j < max(len(deleted), len(inserted)); j++ {
+			row := &domain.DiffRow{}
+			if j < len(deleted) {
+				row.Old = deleted[j]
+			}
+			if j < len(inserted) {
+				row.New = inserted[j]
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// RenderUnifiedDiff renders diff lines in unified format without hunk headers
+func RenderUnifiedDiff(lines []*domain.DiffLine) string {
+	var sb strings.Builder
+	for _, line := range lines {
+		switch line.Op {
+		case domain.DiffOpInsert:
+			sb.WriteString("+")
+		case domain.DiffOpDelete:
+			sb.WriteString("-")
+		default:
+			sb.WriteString(" ")
+		}
+		sb.WriteString(line.Text)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func writeDiffText(sb *strings.Builder, op domain.DiffOp, text string) {
+	escaped := html.EscapeString(text)
+	switch op {
+	case domain.DiffOpInsert:
+		sb.WriteString("<ins>" + escaped + "</ins>")
+	case domain.DiffOpDelete:
+		sb.WriteString("<del>" + escaped + "</del>")
+	default:
+		sb.WriteString(escaped)
+	}
+}
+
+func appendSegment(segments []domain.DiffSegment, seg domain.DiffSegment) []domain.DiffSegment {
+	if n := len(segments); n > 0 && segments[n-1].Op == seg.Op {
+		segments[n-1].Text += seg.Text
+		return segments
+	}
+	return append(segments, seg)
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// splitWords splits text into words, whitespace runs and single CJK or punctuation characters
+func splitWords(text string) []string {
+	var tokens []string
+	var cur []rune
+	curSpace := false
+	flush := func() {
+		if len(cur) > 0 {
+			tokens = append(tokens, string(cur))
+			cur = cur[:0]
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			if !curSpace {
+				flush()
+			}
+			curSpace = true
+			cur = append(cur, r)
+		case (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') && !isCJK(r):
+			if curSpace {
+				flush()
+			}
+			curSpace = false
+			cur = append(cur, r)
+		default:
+			flush()
+			curSpace = false
+			tokens = append(tokens, string(r))
+		}
+	}
+	flush()
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// diffTokens returns the shortest edit script between a and b using the myers algorithm
+func diffTokens(a, b []string) []diffEdit {
+	// strip common prefix and suffix
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]diffEdit, 0, len(a)+len(b))
+	for _, t := range a[:prefix] {
+		edits = append(edits, diffEdit{op: domain.DiffOpEqual, text: t})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, t := range a[len(a)-suffix:] {
+		edits = append(edits, diffEdit{op: domain.DiffOpEqual, text: t})
+	}
+	return edits
+}
+
+func myers(a, b []string) []diffEdit {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+	maxD := min(n+m, maxDiffEdits)
+	offset := maxD + 1
+	v := make([]int, 2*offset+1)
+	// trace[d] holds v[-d..d] before round d
+	trace := make([][]int, 0)
+	found := false
+	for d := 0; d <= maxD && !found; d++ {
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		edits := make([]diffEdit, 0, n+m)
+		for _, t := range a {
+			edits = append(edits, diffEdit{op: domain.DiffOpDelete, text: t})
+		}
+		for _, t := range b {
+			edits = append(edits, diffEdit{op: domain.DiffOpInsert, text: t})
+		}
+		return edits
+	}
+
+	// backtrack from (n, m) to (0, 0)
+	reversed := make([]diffEdit, 0, n+m)
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		snapshot := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && snapshot[k-1+d] < snapshot[k+1+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := snapshot[prevK+d]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, diffEdit{op: domain.DiffOpEqual, text: a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, diffEdit{op: domain.DiffOpInsert, text: b[y-1]})
+		} else {
+			reversed = append(reversed, diffEdit{op: domain.DiffOpDelete, text: a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, diffEdit{op: domain.DiffOpEqual, text: a[x-1]})
+		x--
+		y--
+	}
+
+	edits := make([]diffEdit, len(reversed))
+	for i, e := range reversed {
+		edits[len(reversed)-1-i] = e
+	}
+	return edits
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/chaitin/panda-wiki/domain"
+)
+
+func TestDiffLines(t *testing.T) {
+	oldText := "# Title\nkeep this line\nthe quick brown fox\nremoved line\n"
+	newText := "# Title\nkeep this line\nthe quick red fox\nadded line\nanother line\n"
+
+	lines, stats := DiffLines(oldText, newText)
+	assert.Equal(t, domain.DiffStats{Added: 3, Deleted: 2}, stats)
+
+	ops := make([]domain.DiffOp, len(lines))
+	for i, line := range lines {
+		ops[i] = line.Op
+	}
+	assert.Equal(t, []domain.DiffOp{
+		domain.DiffOpEqual,
+		domain.DiffOpEqual,
+		domain.DiffOpDelete,
+		domain.DiffOpDelete,
+		domain.DiffOpInsert,
+		domain.DiffOpInsert,
+		domain.DiffOpInsert,
+	}, ops)
+
+	// the first deleted line is paired with the first inserted line
+	assert.Equal(t, []domain.DiffSegment{
+		{Op: domain.DiffOpEqual, Text: "the quick "},
+		{Op: domain.DiffOpDelete, Text: "brown"},
+		{Op: domain.DiffOpEqual, Text: " fox"},
+	}, lines[2].Segments)
+	assert.Equal(t, []domain.DiffSegment{
+		{Op: domain.DiffOpEqual, Text: "the quick "},
+		{Op: domain.DiffOpInsert, Text: "red"},
+		{Op: domain.DiffOpEqual, Text: " fox"},
+	}, lines[4].Segments)
+	assert.Empty(t, lines[6].Segments)
+
+	assert.Equal(t, 3, lines[2].OldLine)
+	assert.Equal(t, 5, lines[6].NewLine)
+}
+
+func TestDiffWordsCJK(t *testing.T) {
+	segments := DiffWords("知识库问答", "知识库搜索")
+	assert.Equal(t, []domain.DiffSegment{
+		{Op: domain.DiffOpEqual, Text: "知识库"},
+		{Op: domain.DiffOpDelete, Text: "问答"},
+		{Op: domain.DiffOpInsert, Text: "搜索"},
+	}, segments)
+}
+
+func TestRenderDiffHTML(t *testing.T) {
+	lines, _ := DiffLines("a <b>", "a <i>")
+	assert.Equal(t,
+		`<div class="diff"><div class="diff-line diff-delete">a &lt;<del>b</del>&gt;</div><div class="diff-line diff-insert">a &lt;<ins>i</ins>&gt;</div></div>`,
+		RenderDiffHTML(lines))
+}
+
+func TestSideBySideDiff(t *testing.T) {
+	lines, _ := DiffLines("a\nb\nc\n", "a\nB\nc\nd\n")
+	rows := SideBySideDiff(lines)
+	assert.Len(t, rows, 4)
+
+	assert.Same(t, rows[0].Old, rows[0].New)
+	assert.Equal(t, "b", rows[1].Old.Text)
+	assert.Equal(t, "B", rows[1].New.Text)
+	assert.Nil(t, rows[3].Old)
+	assert.Equal(t, 4, rows[3].New.NewLine)
+}
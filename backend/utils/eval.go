@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// citedNodeURLRegex matches the node urls the answer cites, see domain.RankedNodeChunks.GetURL
+var citedNodeURLRegex = regexp.MustCompile(`/node/([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})`)
+
+// RecallAtK is the fraction of expected ids found in the first k retrieved ids,
+// ok is false when nothing is expected
+func RecallAtK(expected, retrieved []string, k int) (recall float64, ok bool) {
+	if len(expected) == 0 {
+		return 0, false
+	}
+	if k > 0 && len(retrieved) > k {
+		retrieved = retrieved[:k]
+	}
+	return overlapRatio(expected, retrieved), true
+}
+
+// CitedNodeIDs returns the distinct node ids cited by an answer in order of appearance
+func CitedNodeIDs(answer string) []string {
+	ids := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, match := range citedNodeURLRegex.FindAllStringSubmatch(answer, -1) {
+		id := strings.ToLower(match[1])
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// CitationAccuracy is the fraction of cited ids that are expected, citing nothing scores 0,
+// ok is false when nothing is expected
+func CitationAccuracy(expected, cited []string) (accuracy float64, ok bool) {
+	if len(expected) == 0 {
+		return 0, false
+	}
+	if len(cited) == 0 {
+		return 0, true
+	}
+	return overlapRatio(cited, expected), true
+}
+
+// overlapRatio is the fraction of ids in base also found in other
+func overlapRatio(base, other []string) float64 {
+	set := make(map[string]bool, len(other))
+	for _, id := range other {
+		set[strings.ToLower(id)] = true
+	}
+	hit := 0
+	for _, id := range base {
+		if set[strings.ToLower(id)] {
+			hit++
+		}
+	}
+	return float64(hit) / float64(len(base))
+}
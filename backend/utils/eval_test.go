@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecallAtK(t *testing.T) {
+	recall, ok := RecallAtK([]string{"a", "b"}, []string{"c", "a", "b"}, 2)
+	assert.True(t, ok)
+	assert.Equal(t, 0.5, recall)
+
+	_, ok = RecallAtK(nil, []string{"a"}, 10)
+	assert.False(t, ok)
+}
+
+func TestCitedNodeIDs(t *testing.T) {
+	answer := "重启服务即可[[1](https://wiki.example.com/node/0198A0B1-1111-7000-8000-000000000001)]。" +
+		"也可以修改配置[[2](https://wiki.example.com/node/0198a0b1-2222-7000-8000-000000000002)],[[1](https://wiki.example.com/node/0198a0b1-1111-7000-8000-000000000001)]。"
+	assert.Equal(t,
+		[]string{"0198a0b1-1111-7000-8000-000000000001", "0198a0b1-2222-7000-8000-000000000002"},
+		CitedNodeIDs(answer))
+}
+
+func TestCitationAccuracy(t *testing.T) {
+	accuracy, ok := CitationAccuracy([]string{"a"}, []string{"a", "b"})
+	assert.True(t, ok)
+	assert.Equal(t, 0.5, accuracy)
+
+	accuracy, ok = CitationAccuracy([]string{"a"}, nil)
+	assert.True(t, ok)
+	assert.Equal(t, 0.0, accuracy)
+}
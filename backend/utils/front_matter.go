@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+
+	"gopkg.in/yaml.v3"
+)
+
+var frontMatterDelimiter = []byte("---\n")
+
+// MarshalFrontMatter renders meta as a yaml front-matter block followed by body
+func MarshalFrontMatter(meta any, body string) ([]byte, error) {
+	header, err := yaml.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(frontMatterDelimiter)
+	buf.Write(header)
+	buf.Write(frontMatterDelimiter)
+	buf.WriteString(body)
+	return buf.Bytes(), nil
+}
+
+// ParseFrontMatter decodes the yaml front-matter of data into meta and returns the rest as body
+func ParseFrontMatter(data []byte, meta any) (string, error) {
+	if !bytes.HasPrefix(data, frontMatterDelimiter) {
+		return "", errors.New("front-matter not found")
+	}
+	data = data[len(frontMatterDelimiter):]
+	end := bytes.Index(data, append([]byte("\n"), frontMatterDelimiter...))
+	if end < 0 {
+		return "", errors.New("front-matter is not closed")
+	}
+	if err := yaml.Unmarshal(data[:end+1], meta); err != nil {
+		return "", err
+	}
+	return string(data[end+1+len(frontMatterDelimiter):]), nil
+}
@@ -0,0 +1,208 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// maxSearchTokenLen caps the length of a single token, longer tokens are dropped
+const maxSearchTokenLen = 64
+
+// inlineHTMLTags are rendered without line breaks by HTMLToText
+var inlineHTMLTags = map[string]bool{
+	"a": true, "abbr": true, "b": true, "code": true, "em": true, "i": true, "kbd": true,
+	"mark": true, "s": true, "small": true, "span": true, "strong": true, "sub": true,
+	"sup": true, "u": true,
+}
+
+// SearchTokens splits text into lower case tokens for keyword search.
+// Latin words, numbers and identifiers like `ERR-1001`, `v1.2.3` or `--force` are kept as a whole
+// together with their parts, CJK text is split into overlapping bigrams.
+func SearchTokens(text string) []string {
+	tokens := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(token string) {
+		if token == "" || seen[token] || len([]rune(token)) > maxSearchTokenLen {
+			return
+		}
+		seen[token] = true
+		tokens = append(tokens, token)
+	}
+	addWord := func(word []rune) {
+		compound := strings.TrimRight(string(word), "-._/")
+		compound = strings.TrimLeft(compound, "._/")
+		if strings.Trim(compound, "-") == "" {
+			return
+		}
+		parts := strings.FieldsFunc(compound, isSearchConnector)
+		if len(parts) > 1 || strings.HasPrefix(compound, "-") {
+			add(compound)
+		}
+		for _, part := range parts {
+			// single letters are too common to be useful
+			if len([]rune(part)) == 1 && !unicode.IsDigit([]rune(part)[0]) {
+				continue
+			}
+			add(part)
+		}
+	}
+	addCJK := func(run []rune) {
+		if len(run) == 1 {
+			add(string(run))
+			return
+		}
+		for i := 0; i+1 < len(run); i++ {
+			add(string(run[i : i+2]))
+		}
+	}
+
+	var word, cjk []rune
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			if len(word) > 0 {
+				addWord(word)
+				word = word[:0]
+			}
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || isSearchConnector(r):
+			if len(cjk) > 0 {
+				addCJK(cjk)
+				cjk = cjk[:0]
+			}
+			word = append(word, r)
+		default:
+			if len(word) > 0 {
+				addWord(word)
+				word = word[:0]
+			}
+			if len(cjk) > 0 {
+				addCJK(cjk)
+				cjk = cjk[:0]
+			}
+		}
+	}
+	if len(word) > 0 {
+		addWord(word)
+	}
+	if len(cjk) > 0 {
+		addCJK(cjk)
+	}
+	return tokens
+}
+
+// DocumentSearchTokens returns the search tokens of a document with html or markdown content
+func DocumentSearchTokens(name, content string) []string {
+	if IsLikelyHTML(content) {
+		content = HTMLToText(content)
+	}
+	return SearchTokens(name + "\n" + content)
+}
+
+func isSearchConnector(r rune) bool {
+	return r == '-' || r == '.' || r == '/'
+}
+
+// HTMLToText extracts the text of html content, block elements are separated by line breaks
+func HTMLToText(content string) string {
+	var sb strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(content))
+	skip := 0
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			return strings.TrimSpace(sb.String())
+		case html.TextToken:
+			if skip == 0 {
+				sb.Write(tokenizer.Text())
+			}
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			if tag == "script" || tag == "style" {
+				if tokenType == html.StartTagToken {
+					skip++
+				} else if skip > 0 {
+					skip--
+				}
+				continue
+			}
+			if !inlineHTMLTags[tag] {
+				sb.WriteString("\n")
+			}
+		}
+	}
+}
+
+// ExtractSearchSnippet returns the lines of text around the line with the most token hits,
+// at most maxLen characters
+func ExtractSearchSnippet(text string, tokens []string, maxLen int) string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	best, bestHits := 0, 0
+	for i, line := range lines {
+		lower := strings.ToLower(line)
+		hits := 0
+		for _, token := range tokens {
+			hits += strings.Count(lower, token)
+		}
+		if hits > bestHits {
+			best, bestHits = i, hits
+		}
+	}
+
+	// expand around the best line while the snippet fits
+	start, end := best, best+1
+	size := len([]rune(lines[best]))
+	for {
+		expanded := false
+		if end < len(lines) && size+len([]rune(lines[end]))+1 <= maxLen {
+			size += len([]rune(lines[end])) + 1
+			end++
+			expanded = true
+		}
+		if start > 0 && size+len([]rune(lines[start-1]))+1 <= maxLen {
+			size += len([]rune(lines[start-1])) + 1
+			start--
+			expanded = true
+		}
+		if !expanded {
+			break
+		}
+	}
+	snippet := []rune(strings.Join(lines[start:end], "\n"))
+	if len(snippet) > maxLen {
+		snippet = snippet[:maxLen]
+	}
+	return string(snippet)
+}
+
+// ReciprocalRankFusion merges ranked id lists, an id scores 1/(k+rank) in each list it appears.
+// Ids with equal scores keep the order they first appear in.
+func ReciprocalRankFusion(k int, rankings ...[]string) []string {
+	scores := make(map[string]float64)
+	ids := make([]string, 0)
+	for _, ranking := range rankings {
+		for rank, id := range ranking {
+			if _, ok := scores[id]; !ok {
+				ids = append(ids, id)
+			}
+			scores[id] += 1 / float64(k+rank+1)
+		}
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		return scores[ids[i]] > scores[ids[j]]
+	})
+	return ids
+}
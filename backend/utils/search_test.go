@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchTokens(t *testing.T) {
+	assert.Equal(t,
+		[]string{"run", "--force", "force", "to", "fix", "err-1001", "err", "1001", "in", "v1.2.3", "v1", "2", "3"},
+		SearchTokens("Run --force to fix ERR-1001 in v1.2.3."))
+	assert.Equal(t,
+		[]string{"知识", "识库", "库配", "配置", "api", "密钥"},
+		SearchTokens("知识库配置 API 密钥"))
+}
+
+func TestHTMLToText(t *testing.T) {
+	assert.Equal(t,
+		"Title\n\nrun <code>--force</code> &",
+		HTMLToText("<h1>Title</h1><p>run <code>&lt;code&gt;--force&lt;/code&gt;</code> &amp;</p><script>alert(1)</script>"))
+}
+
+func TestExtractSearchSnippet(t *testing.T) {
+	text := "intro\nfirst line\nERR-1001 means timeout\nlast line"
+	assert.Equal(t, "ERR-1001 means timeout", ExtractSearchSnippet(text, []string{"err-1001"}, 25))
+	assert.Equal(t, "first line\nERR-1001 means timeout\nlast line", ExtractSearchSnippet(text, []string{"err-1001"}, 45))
+}
+
+func TestReciprocalRankFusion(t *testing.T) {
+	assert.Equal(t,
+		[]string{"b", "a", "d", "c"},
+		ReciprocalRankFusion(60, []string{"a", "b", "c"}, []string{"d", "b"}))
+}